@@ -0,0 +1,179 @@
+package coolpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/analyze"
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	analyzeJSON   bool
+	analyzeFormat string
+	analyzePlan   string
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze [image|plan|path]",
+	Short: "Report what makes an image large and how to shrink it",
+	Long: `Analyze a built image, a plan file, or an application directory.
+
+For an image, reports the total size and largest layers, and checks the
+runtime filesystem for caches and dev dependencies that shouldn't be there.
+For a plan file (e.g., coolpack.json) or an application directory, reports
+the plan settings that make the image larger than it needs to be.
+
+Each finding comes with a suggested change (standalone output, pruning,
+alpine base image, .dockerignore entries).
+
+Without an argument, the application at --path (or the current directory)
+is analyzed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAnalyze,
+}
+
+func init() {
+	analyzeCmd.Flags().BoolVar(&analyzeJSON, "json", false, "Output as JSON (same as --format json)")
+	analyzeCmd.Flags().StringVar(&analyzeFormat, "format", "", "Output format: text (default) or json")
+	analyzeCmd.Flags().StringVar(&analyzePlan, "plan", "", "Plan file to include plan findings when analyzing an image")
+}
+
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(analyzeFormat, analyzeJSON)
+	if err != nil {
+		return err
+	}
+
+	target := rootPath
+	if len(args) > 0 {
+		target = args[0]
+	} else if target == "" {
+		target = "."
+	}
+	report := &analyze.Report{}
+
+	var plan *app.Plan
+	if info, statErr := os.Stat(target); statErr == nil {
+		// A plan file or an application directory
+		if info.IsDir() {
+			absPath, err := filepath.Abs(target)
+			if err != nil {
+				return fmt.Errorf("failed to resolve path: %w", err)
+			}
+			plan, err = newDetector(absPath).Detect()
+			if err != nil {
+				return errDetection(cmd, err)
+			}
+			if plan == nil {
+				return errNoProvider(cmd)
+			}
+		} else if plan, err = loadPlanFromFile(target); err != nil {
+			return errPlanInvalid(cmd, fmt.Errorf("failed to load plan file: %w", err))
+		}
+	} else {
+		if analyzePlan != "" {
+			if plan, err = loadPlanFromFile(analyzePlan); err != nil {
+				return errPlanInvalid(cmd, fmt.Errorf("failed to load plan file: %w", err))
+			}
+		}
+		if err := analyzeImage(report, target, plan); err != nil {
+			return err
+		}
+	}
+
+	if plan != nil {
+		report.Findings = append(report.Findings, analyze.AnalyzePlan(plan)...)
+	}
+
+	if format == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	printReport(report)
+	return nil
+}
+
+// analyzeImage reads the image size, layers and runtime filesystem findings with docker
+func analyzeImage(report *analyze.Report, image string, plan *app.Plan) error {
+	report.Image = image
+
+	if _, err := exec.Command("docker", "image", "inspect", image).Output(); err != nil {
+		return fmt.Errorf("image %s not found locally (pull or build it first, or pass a plan file or directory)", image)
+	}
+
+	var err error
+	report.Size, report.Layers, err = imageLayers(image)
+	if err != nil {
+		return err
+	}
+
+	// Probe paths as root, so caches in /root are visible
+	out, err := exec.Command("docker", "run", "--rm", "--user", "0", "--entrypoint", "sh", image, "-c", analyze.ProbeScript()).Output()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not inspect the image filesystem: %v\n", err)
+		return nil
+	}
+	report.Findings = append(report.Findings, analyze.ParseProbeOutput(string(out), plan)...)
+
+	return nil
+}
+
+// imageLayers returns the size and layers of a local image
+func imageLayers(image string) (int64, []analyze.Layer, error) {
+	out, err := exec.Command("docker", "image", "inspect", image, "--format", "{{.Size}}").Output()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to inspect image: %w", err)
+	}
+	size, _ := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+
+	out, err = exec.Command("docker", "history", "--human=false", "--no-trunc", "--format", "{{.Size}}\t{{.CreatedBy}}", image).Output()
+	if err != nil {
+		return size, nil, fmt.Errorf("failed to read image history: %w", err)
+	}
+	return size, analyze.ParseHistory(string(out)), nil
+}
+
+func printReport(report *analyze.Report) {
+	if report.Image != "" {
+		printField("Image", report.Image)
+		printField("Size", analyze.FormatSize(report.Size))
+		fmt.Println()
+
+		fmt.Println("Largest layers:")
+		for _, layer := range analyze.LargestLayers(report.Layers, 5) {
+			createdBy := layer.CreatedBy
+			if len(createdBy) > 80 {
+				createdBy = createdBy[:77] + "..."
+			}
+			fmt.Printf("  %10s  %s\n", analyze.FormatSize(layer.Size), createdBy)
+		}
+		fmt.Println()
+	}
+
+	if len(report.Findings) == 0 {
+		fmt.Println("No size issues found.")
+		return
+	}
+
+	fmt.Println("Findings:")
+	for _, f := range report.Findings {
+		line := fmt.Sprintf("  - [%s] %s", f.Code, f.Message)
+		if f.Size > 0 {
+			line += fmt.Sprintf(" (%s)", analyze.FormatSize(f.Size))
+		}
+		fmt.Println(line)
+		fmt.Printf("    Suggestion: %s\n", f.Suggestion)
+	}
+}
@@ -1,21 +1,30 @@
 package coolpack
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/assemble"
 	"github.com/coollabsio/coolpack/pkg/detector"
 	"github.com/coollabsio/coolpack/pkg/generator"
+	"github.com/coollabsio/coolpack/pkg/nixpacks"
+	"github.com/coollabsio/coolpack/pkg/provenance"
+	"github.com/coollabsio/coolpack/pkg/report"
+	"github.com/coollabsio/coolpack/pkg/sbom"
 	"github.com/spf13/cobra"
 )
 
 var (
-	buildPath         string
 	buildImageName    string
 	buildTag          string
 	buildNoCache      bool
@@ -27,8 +36,20 @@ var (
 	buildOutputDir    string
 	buildSPA          bool
 	buildNoSPA        bool
+	buildPrecompress  bool
+	buildRunTests     bool
 	buildPackages     []string
 	buildPlanFile     string
+	buildSecrets      []string
+	buildPlatforms    []string
+	buildPush         bool
+	buildSBOM         string
+	buildSBOMFormat   string
+	buildSBOMAttest   bool
+	buildProvenance   string
+	buildProvAttest   bool
+	buildReport       string
+	buildDaemonless   bool
 )
 
 var buildCmd = &cobra.Command{
@@ -47,10 +68,17 @@ Environment Variables:
   COOLPACK_START_CMD       Override start command
   COOLPACK_BASE_IMAGE      Override base Docker image (e.g., node:20)
   COOLPACK_NODE_VERSION    Override Node.js version
+  COOLPACK_NODE_DEFAULT    Default Node.js version policy: lts, ecosystem, current or a version
   COOLPACK_STATIC_SERVER   Static file server: caddy (default), nginx
   COOLPACK_SPA_OUTPUT_DIR  Override static output directory (e.g., dist, build)
   COOLPACK_SPA             Enable SPA mode (serves index.html for all routes)
+  COOLPACK_PRECOMPRESS     Precompress static output with brotli/gzip
+  COOLPACK_RUN_TESTS       Run the test command during the build
+  COOLPACK_BUILD_HEAP_SIZE V8 heap limit for the build in MB (0 turns it off)
   COOLPACK_PACKAGES        Additional APT packages (comma-separated)
+  COOLPACK_PLATFORMS       Target platforms (e.g., linux/amd64,linux/arm64)
+  COOLPACK_REGISTRY_USERNAME, COOLPACK_REGISTRY_PASSWORD
+                           Registry credentials for --daemonless (default: Docker config)
 
 Build-time env vars (--build-env) are available during build (e.g., for
 Next.js NEXT_PUBLIC_*, Vite VITE_*, SvelteKit $env/static/*).
@@ -62,10 +90,9 @@ config that changes per environment.`,
 }
 
 func init() {
-	buildCmd.Flags().StringVarP(&buildPath, "path", "p", "", "Path to the application (defaults to current directory)")
 	buildCmd.Flags().StringVarP(&buildImageName, "name", "n", "", "Image name (defaults to directory name)")
 	buildCmd.Flags().StringVarP(&buildTag, "tag", "t", "latest", "Image tag")
-	buildCmd.Flags().BoolVar(&buildNoCache, "no-cache", false, "Build without cache")
+	buildCmd.Flags().BoolVar(&buildNoCache, "no-cache", false, "Build without cache (the image build cache and cached plans)")
 	buildCmd.Flags().StringArrayVar(&buildBuildEnvs, "build-env", nil, "Build-time environment variables (KEY=value or KEY to use current env)")
 	buildCmd.Flags().StringVarP(&buildInstallCmd, "install-cmd", "i", "", "Override install command")
 	buildCmd.Flags().StringVarP(&buildBuildCmd, "build-cmd", "b", "", "Override build command")
@@ -74,29 +101,31 @@ func init() {
 	buildCmd.Flags().StringVar(&buildOutputDir, "output-dir", "", "Override static output directory (e.g., dist, build, out)")
 	buildCmd.Flags().BoolVar(&buildSPA, "spa", false, "Enable SPA mode (serves index.html for all routes)")
 	buildCmd.Flags().BoolVar(&buildNoSPA, "no-spa", false, "Disable SPA mode (overrides auto-detection)")
+	buildCmd.Flags().BoolVar(&buildPrecompress, "precompress", false, "Precompress static output with brotli/gzip during build")
+	buildCmd.Flags().BoolVar(&buildRunTests, "run-tests", false, "Run the detected test command after the build (a failing test fails the build)")
 	buildCmd.Flags().StringArrayVar(&buildPackages, "packages", nil, "Additional APT packages to install (e.g., curl, wget)")
 	buildCmd.Flags().StringVar(&buildPlanFile, "plan", "", "Use plan file instead of detection (e.g., coolpack.json)")
+	buildCmd.Flags().StringArrayVar(&buildSecrets, "secret", nil, "Build secret (ID to use current env, or ID=path to read from a file)")
+	buildCmd.Flags().StringSliceVar(&buildPlatforms, "platform", nil, "Target platforms, built with buildx (e.g., linux/amd64,linux/arm64)")
+	buildCmd.Flags().BoolVar(&buildPush, "push", false, "Push the image after building (needed for multi-platform images without the containerd image store)")
+	buildCmd.Flags().StringVar(&buildSBOM, "sbom", "", "Write an SBOM of the image to a file (e.g., sbom.json)")
+	buildCmd.Flags().StringVar(&buildSBOMFormat, "sbom-format", sbom.FormatCycloneDX, "SBOM format: cyclonedx, spdx")
+	buildCmd.Flags().BoolVar(&buildSBOMAttest, "sbom-attest", false, "Attach the SBOM to the pushed image as an attestation (requires cosign and --push)")
+	buildCmd.Flags().StringVar(&buildProvenance, "provenance", "", "Write a SLSA provenance statement for the image to a file (e.g., provenance.json)")
+	buildCmd.Flags().StringVar(&buildReport, "report", "", "Write a build report (stage durations, cache hits, image and layer sizes) to a file (e.g., build-report.json)")
+	buildCmd.Flags().BoolVar(&buildDaemonless, "daemonless", false, "Assemble and push the image without Docker: prebuilt static sites served by Caddy (requires --push)")
+	buildCmd.Flags().BoolVar(&buildProvAttest, "provenance-attest", false, "Sign and attach the provenance to the pushed image with cosign keyless (requires --push)")
 }
 
 func runBuild(cmd *cobra.Command, args []string) error {
-	// Determine the path to analyze
-	path := "."
-	if len(args) > 0 {
-		path = args[0]
-	}
-	if buildPath != "" {
-		path = buildPath
-	}
-
-	// Convert to absolute path
-	absPath, err := filepath.Abs(path)
+	absPath, err := appPath(args)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
+		return err
 	}
 
-	// Check if path exists
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		return fmt.Errorf("path does not exist: %s", absPath)
+	// Project settings from coolpack.toml (CLI > env > coolpack.toml > detected)
+	if err := applyProjectConfig(absPath); err != nil {
+		return err
 	}
 
 	// Determine image name
@@ -110,7 +139,7 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 	fullImageName := fmt.Sprintf("%s:%s", imageName, buildTag)
 
-	fmt.Printf("Building image: %s\n", fullImageName)
+	progressf("Building image: %s\n", fullImageName)
 
 	var plan *app.Plan
 
@@ -125,22 +154,23 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 	if planFile != "" {
 		// Load plan from file
-		fmt.Printf("Using plan file: %s\n", planFile)
+		progressln(msg.T("using_plan_file", planFile))
 		plan, err = loadPlanFromFile(planFile)
 		if err != nil {
-			return fmt.Errorf("failed to load plan file: %w", err)
+			return errPlanInvalid(cmd, fmt.Errorf("failed to load plan file: %w", err))
 		}
 	} else {
 		// Run detection
-		fmt.Println("Detecting application...")
-		d := detector.New(absPath)
+		progressln("Detecting application...")
+		noPlanCache = buildNoCache
+		d := newDetector(absPath)
 		plan, err = d.Detect()
 		if err != nil {
-			return fmt.Errorf("detection failed: %w", err)
+			return errDetection(cmd, err)
 		}
 
 		if plan == nil {
-			return fmt.Errorf("no supported application detected")
+			return errNoProvider(cmd)
 		}
 	}
 
@@ -153,39 +183,61 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	// Apply SPA setting (CLI > env > auto-detected)
 	applySPASetting(plan, buildSPA, buildNoSPA)
 
+	// Apply precompression setting (CLI > env > default off)
+	applyPrecompressSetting(plan, buildPrecompress)
+
+	// Apply the test phase setting (CLI > env > default off)
+	applyRunTestsSetting(plan, buildRunTests)
+
 	// Apply output directory override (CLI > env > framework default)
 	applyOutputDirSetting(plan, buildOutputDir)
 
 	// Apply custom packages (CLI > env > detected)
 	applyCustomPackagesBuild(plan, buildPackages)
 
-	// Print detection summary
-	framework := plan.Framework
-	if framework == "" {
-		framework = "generic"
+	// Apply target platforms (CLI > env > plan)
+	applyPlatforms(plan, buildPlatforms)
+
+	if buildSBOMAttest && !buildPush {
+		return fmt.Errorf("--sbom-attest requires --push (attestations are stored in the registry)")
 	}
-	fmt.Printf("Detected: %s %s", plan.Language, framework)
-	if plan.PackageManager != "" {
-		pmVersion := ""
-		if plan.PackageManagerVersion != "" {
-			pmVersion = "@" + plan.PackageManagerVersion
-		}
-		fmt.Printf(" (%s%s)", plan.PackageManager, pmVersion)
+	if buildProvAttest && !buildPush {
+		return fmt.Errorf("--provenance-attest requires --push (attestations are stored in the registry)")
 	}
-	// Print output type and SPA mode
-	if ot, ok := plan.Metadata["output_type"].(string); ok {
-		fmt.Printf(" [%s", ot)
-		if isSPA, ok := plan.Metadata["is_spa"].(bool); ok && isSPA {
-			fmt.Printf("/spa")
+	if buildDaemonless {
+		if !buildPush {
+			return fmt.Errorf("--daemonless requires --push (the image is assembled in the registry)")
+		}
+		if buildReport != "" {
+			return fmt.Errorf("--report needs a BuildKit build and can't be used with --daemonless")
+		}
+		if err := assemble.Supported(plan); err != nil {
+			return fmt.Errorf("--daemonless can't assemble this plan: %w", err)
 		}
-		fmt.Printf("]")
 	}
-	fmt.Println()
+	if buildSBOMFormat != sbom.FormatCycloneDX && buildSBOMFormat != sbom.FormatSPDX {
+		return fmt.Errorf("unknown SBOM format %q (use %s or %s)", buildSBOMFormat, sbom.FormatCycloneDX, sbom.FormatSPDX)
+	}
+
+	// Print detection summary
+	progressf("Detected: %s\n", detectSummary(plan))
 
 	// Parse build environment variables
 	envMap := parseEnvVars(buildBuildEnvs)
-	if len(envMap) > 0 {
-		plan.BuildEnv = envMap
+	applyBuildEnv(plan, envMap)
+	if planFile == "" {
+		applyNixpacksVariables(plan, absPath)
+	}
+
+	// Pass required build-time variables set in the environment, and warn about the others
+	passthrough := passthroughEnv(plan, app.PhaseBuild, plan.BuildEnv)
+	for _, req := range plan.RequiredEnv {
+		if req.Phase != app.PhaseBuild || slices.Contains(passthrough, req.Name) {
+			continue
+		}
+		if _, ok := plan.BuildEnv[req.Name]; !ok {
+			fmt.Printf("Warning: required build-time variable %s is not set (%s). Pass it with --build-env %s=...\n", req.Name, req.Description, req.Name)
+		}
 	}
 
 	// Create .coolpack directory
@@ -194,8 +246,16 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create .coolpack directory: %w", err)
 	}
 
+	// Assemble the image in the registry instead of building it with Docker
+	if buildDaemonless {
+		if err := runDaemonlessBuild(absPath, coolpackDir, plan, imageName, fullImageName); err != nil {
+			return errBuildFailed(cmd, err)
+		}
+		return nil
+	}
+
 	// Generate Dockerfile
-	fmt.Println("Generating Dockerfile...")
+	progressln(msg.T("build.generating_dockerfile"))
 	gen := generator.New(plan)
 	dockerfile, err := gen.GenerateDockerfile()
 	if err != nil {
@@ -209,56 +269,337 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build Docker image
-	fmt.Println("Building Docker image...")
+	progressln(msg.T("build.building_image"))
 	dockerArgs := []string{
 		"build",
 		"-t", fullImageName,
 		"-f", dockerfilePath,
 	}
 
+	// Explicit target platforms are built with buildx, one builder stage per platform
+	if len(plan.Platforms) > 0 {
+		dockerArgs = append([]string{"buildx"}, dockerArgs...)
+		dockerArgs = append(dockerArgs, "--platform", strings.Join(plan.Platforms, ","))
+		if buildPush {
+			dockerArgs = append(dockerArgs, "--push")
+		} else {
+			dockerArgs = append(dockerArgs, "--load")
+		}
+	} else if buildPush {
+		dockerArgs = append(dockerArgs, "--push")
+	}
+
 	if buildNoCache {
 		dockerArgs = append(dockerArgs, "--no-cache")
 	}
+	if rootQuiet && buildReport == "" {
+		// Print only the image ID
+		dockerArgs = append(dockerArgs, "--quiet")
+	}
 
 	// Add build args for environment variables
 	for key, value := range envMap {
 		dockerArgs = append(dockerArgs, "--build-arg", fmt.Sprintf("%s=%s", key, value))
 	}
+	for _, name := range passthrough {
+		dockerArgs = append(dockerArgs, "--build-arg", name)
+	}
+
+	// Add build secrets (never passed as build args, so they stay out of image history)
+	dockerArgs = append(dockerArgs, secretArgs(plan, buildSecrets)...)
+
+	// Add OCI labels that change on every build (plan labels are in the Dockerfile)
+	for key, value := range buildLabels(absPath) {
+		dockerArgs = append(dockerArgs, "--label", fmt.Sprintf("%s=%s", key, value))
+	}
+
+	// Plain progress output is parsed for the build report
+	var progress *report.Parser
+	if buildReport != "" {
+		dockerArgs = append(dockerArgs, "--progress=plain")
+		progress = report.NewParser()
+	}
 
 	dockerArgs = append(dockerArgs, absPath)
 
+	startedOn := time.Now()
 	dockerCmd := exec.Command("docker", dockerArgs...)
 	dockerCmd.Stdout = os.Stdout
 	dockerCmd.Stderr = os.Stderr
+	if progress != nil {
+		dockerCmd.Stderr = io.MultiWriter(os.Stderr, progress)
+	}
 	dockerCmd.Dir = absPath
 
-	if err := dockerCmd.Run(); err != nil {
-		return fmt.Errorf("docker build failed: %w", err)
+	buildErr := dockerCmd.Run()
+	if progress != nil {
+		remote := len(plan.Platforms) > 1 || buildPush
+		if err := writeBuildReport(progress, fullImageName, startedOn, buildErr == nil, remote); err != nil {
+			return err
+		}
+	}
+	if buildErr != nil {
+		return errBuildFailed(cmd, fmt.Errorf("docker build failed: %w", buildErr))
 	}
 
-	fmt.Printf("\nSuccessfully built image: %s\n", fullImageName)
+	progressln()
+	fmt.Println(msg.T("build.success", fullImageName))
 
-	// Show correct port based on output type
-	port := "3000"
-	outputType := "server"
-	if ot, ok := plan.Metadata["output_type"].(string); ok && ot == "static" {
-		port = "80"
-		outputType = "static"
+	// Generate SBOM from the lockfile and the image's system packages
+	if buildSBOM != "" || buildSBOMAttest {
+		if err := writeSBOM(absPath, coolpackDir, plan, imageName, buildTag, len(plan.Platforms) > 1 || buildPush); err != nil {
+			return err
+		}
 	}
 
+	// Generate provenance describing the source, plan and builder
+	if buildProvenance != "" || buildProvAttest {
+		if err := writeProvenance(absPath, coolpackDir, plan, dockerfile, fullImageName, "", startedOn, len(plan.Platforms) > 1 || buildPush); err != nil {
+			return err
+		}
+	}
+
+	// Show correct port based on detected ports and output type
+	port := planPort(plan)
+	outputType := plan.OutputType()
+
 	// Show output type and SPA mode
-	if isSPA, ok := plan.Metadata["is_spa"].(bool); ok && isSPA {
-		fmt.Printf("Output: %s (SPA mode enabled)\n", outputType)
+	if plan.IsSPA() {
+		progressf("Output: %s (SPA mode enabled)\n", outputType)
 	} else {
-		fmt.Printf("Output: %s\n", outputType)
+		progressf("Output: %s\n", outputType)
 	}
 
-	fmt.Printf("Run with: docker run -p %s:%s %s\n", port, port, fullImageName)
-	fmt.Printf("Run (development only): docker run --rm -it -p %s:%s %s\n", port, port, fullImageName)
+	progressln(msg.T("build.run_with", fmt.Sprintf("docker run -p %s:%s %s", port, port, fullImageName)))
+	progressln(msg.T("build.run_dev", fmt.Sprintf("docker run --rm -it -p %s:%s %s", port, port, fullImageName)))
 
 	return nil
 }
 
+// runDaemonlessBuild assembles the image from the prebuilt static output and pushes
+// it with the registry API, so no Docker daemon or BuildKit is needed
+func runDaemonlessBuild(absPath, coolpackDir string, plan *detector.Plan, imageName, fullImageName string) error {
+	progressln("Assembling image without Docker...")
+	startedOn := time.Now()
+
+	result, err := assemble.Assemble(context.Background(), assemble.Options{
+		AppPath:  absPath,
+		Image:    fullImageName,
+		Plan:     plan,
+		Labels:   buildLabels(absPath),
+		TempDir:  coolpackDir,
+		Progress: progressWriter(),
+	})
+	if err != nil {
+		return fmt.Errorf("daemonless build failed: %w", err)
+	}
+
+	progressln()
+	fmt.Printf("Pushed %s (%s)\n", fullImageName, strings.Join(result.Platforms, ", "))
+	fmt.Printf("Digest: %s\n", result.Digest)
+
+	if buildSBOM != "" || buildSBOMAttest {
+		if err := writeSBOM(absPath, coolpackDir, plan, imageName, buildTag, true); err != nil {
+			return err
+		}
+	}
+	if buildProvenance != "" || buildProvAttest {
+		if err := writeProvenance(absPath, coolpackDir, plan, "", fullImageName, result.Digest, startedOn, true); err != nil {
+			return err
+		}
+	}
+
+	port := planPort(plan)
+	progressln(msg.T("build.run_with", fmt.Sprintf("docker run -p %s:%s %s", port, port, fullImageName)))
+	return nil
+}
+
+// writeBuildReport writes the JSON build report to --report and prints its summary.
+// Failed builds get a report too, so platforms can show where the build stopped.
+func writeBuildReport(progress *report.Parser, fullImageName string, startedOn time.Time, success, remote bool) error {
+	rep := report.New(fullImageName, progress.Steps(), startedOn, time.Now(), success)
+	if success && !remote {
+		if size, layers, err := imageLayers(fullImageName); err == nil {
+			rep.ImageSize = size
+			rep.Layers = layers
+		}
+	}
+
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal build report: %w", err)
+	}
+	if err := os.WriteFile(buildReport, data, 0644); err != nil {
+		return fmt.Errorf("failed to write build report: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Print(rep.Summary())
+	fmt.Printf("Build report written to %s\n", buildReport)
+	return nil
+}
+
+// writeSBOM writes the image's SBOM to --sbom (default .coolpack/sbom.json) and
+// attaches it with cosign for --sbom-attest. System packages are read from the
+// local image with dpkg-query; images that weren't loaded locally (pushed or
+// multi-platform) or aren't Debian-based fall back to the plan's APT packages.
+func writeSBOM(absPath, coolpackDir string, plan *detector.Plan, imageName, tag string, remote bool) error {
+	fullImageName := fmt.Sprintf("%s:%s", imageName, tag)
+	doc := sbom.New(imageName, tag)
+
+	components, lockfile, err := sbom.ReadLockfile(absPath)
+	if err != nil {
+		fmt.Printf("Warning: SBOM has no npm packages: %v\n", err)
+	} else {
+		fmt.Printf("SBOM: %d packages from %s\n", len(components), lockfile)
+		doc.Add(components...)
+	}
+
+	var debs []sbom.Component
+	if !remote {
+		out, err := exec.Command("docker", "run", "--rm", "--entrypoint", "dpkg-query", fullImageName,
+			"-W", "-f", "${Package}\t${Version}\t${Architecture}\n").Output()
+		if err == nil {
+			debs = sbom.ParseDpkgQuery(string(out))
+		}
+	}
+	if len(debs) == 0 {
+		for _, name := range planAptPackages(plan) {
+			debs = append(debs, sbom.Component{Kind: sbom.KindDeb, Name: name})
+		}
+	}
+	doc.Add(debs...)
+
+	data, err := doc.Encode(buildSBOMFormat)
+	if err != nil {
+		return fmt.Errorf("failed to generate SBOM: %w", err)
+	}
+
+	sbomPath := buildSBOM
+	if sbomPath == "" {
+		sbomPath = filepath.Join(coolpackDir, "sbom.json")
+	}
+	if err := os.WriteFile(sbomPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write SBOM: %w", err)
+	}
+	fmt.Printf("SBOM written to %s (%s)\n", sbomPath, buildSBOMFormat)
+
+	if buildSBOMAttest {
+		predicateType := "cyclonedx"
+		if buildSBOMFormat == sbom.FormatSPDX {
+			predicateType = "spdxjson"
+		}
+		if err := cosignAttest(predicateType, sbomPath, fullImageName); err != nil {
+			return fmt.Errorf("failed to attach SBOM attestation: %w", err)
+		}
+		fmt.Printf("SBOM attested to %s\n", fullImageName)
+	}
+
+	return nil
+}
+
+// writeProvenance writes a SLSA provenance statement to --provenance (default
+// .coolpack/provenance.json) and attaches it with cosign for --provenance-attest.
+// The image digest is looked up when it isn't known.
+func writeProvenance(absPath, coolpackDir string, plan *detector.Plan, dockerfile, fullImageName, digest string, startedOn time.Time, remote bool) error {
+	if digest == "" {
+		var err error
+		if digest, err = imageDigest(fullImageName, remote); err != nil {
+			return fmt.Errorf("failed to read image digest for provenance: %w", err)
+		}
+	}
+
+	source := provenance.Source{
+		Path:       absPath,
+		Repository: sourceURL(gitOutput(absPath, "remote", "get-url", "origin")),
+		Commit:     gitOutput(absPath, "rev-parse", "HEAD"),
+	}
+	if source.Commit == "" {
+		source.Commit = os.Getenv("SOURCE_COMMIT")
+	}
+
+	statement := provenance.New(provenance.Options{
+		Image:      fullImageName,
+		Digest:     digest,
+		Source:     source,
+		Plan:       plan,
+		Dockerfile: dockerfile,
+		StartedOn:  startedOn,
+		FinishedOn: time.Now(),
+	})
+
+	data, err := statement.Encode()
+	if err != nil {
+		return fmt.Errorf("failed to generate provenance: %w", err)
+	}
+	provenancePath := buildProvenance
+	if provenancePath == "" {
+		provenancePath = filepath.Join(coolpackDir, "provenance.json")
+	}
+	if err := os.WriteFile(provenancePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write provenance: %w", err)
+	}
+	fmt.Printf("Provenance written to %s\n", provenancePath)
+
+	if buildProvAttest {
+		// cosign wraps the predicate in its own statement for the pushed digest
+		predicate, err := statement.EncodePredicate()
+		if err != nil {
+			return fmt.Errorf("failed to generate provenance: %w", err)
+		}
+		predicatePath := filepath.Join(coolpackDir, "provenance.predicate.json")
+		if err := os.WriteFile(predicatePath, predicate, 0644); err != nil {
+			return fmt.Errorf("failed to write provenance: %w", err)
+		}
+		if err := cosignAttest("slsaprovenance1", predicatePath, fullImageName); err != nil {
+			return fmt.Errorf("failed to attach provenance attestation: %w", err)
+		}
+		fmt.Printf("Provenance attested to %s\n", fullImageName)
+	}
+
+	return nil
+}
+
+// imageDigest returns the digest of a built image: the registry digest for
+// pushed images, the local image ID otherwise
+func imageDigest(image string, remote bool) (string, error) {
+	var out []byte
+	var err error
+	if remote {
+		out, err = exec.Command("docker", "buildx", "imagetools", "inspect", image, "--format", "{{.Manifest.Digest}}").Output()
+	} else {
+		out, err = exec.Command("docker", "image", "inspect", image, "--format", "{{.Id}}").Output()
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// cosignAttest signs a predicate and attaches it to a pushed image. Without
+// COSIGN_KEY, cosign signs keyless (OIDC identity, recorded in Rekor).
+func cosignAttest(predicateType, predicatePath, image string) error {
+	args := []string{"attest", "--yes", "--type", predicateType, "--predicate", predicatePath}
+	if key := os.Getenv("COSIGN_KEY"); key != "" {
+		args = append(args, "--key", key)
+	}
+	args = append(args, image)
+
+	cosignCmd := exec.Command("cosign", args...)
+	cosignCmd.Stdout = os.Stdout
+	cosignCmd.Stderr = os.Stderr
+	return cosignCmd.Run()
+}
+
+// planAptPackages returns the APT packages the plan installs in the runtime image
+func planAptPackages(plan *detector.Plan) []string {
+	var packages []string
+	if plan.NativeDeps != nil {
+		packages = append(packages, plan.NativeDeps.RuntimeAptPackages...)
+	}
+	return append(packages, plan.Metadata.CustomPackages...)
+}
+
 // parseEnvVars parses environment variable arguments
 // Supports KEY=value format or KEY (pulls from current environment)
 func parseEnvVars(envArgs []string) map[string]string {
@@ -279,102 +620,308 @@ func parseEnvVars(envArgs []string) map[string]string {
 	return result
 }
 
-// applyCommandOverrides applies command overrides from CLI flags or env vars
-// Priority: CLI flags > Environment variables > Auto-detected
-func applyCommandOverrides(plan *detector.Plan, installCmd, buildCmd, startCmd string) {
-	// Install command: CLI > env > detected
-	if installCmd != "" {
-		plan.InstallCommand = installCmd
-	} else if env := os.Getenv("COOLPACK_INSTALL_CMD"); env != "" {
-		plan.InstallCommand = env
+// secretArgs returns docker build --secret arguments for the plan's build secrets.
+// --secret flags are ID (read from the current env) or ID=path (read from a file);
+// declared secrets not passed with a flag are read from the current env when set.
+func secretArgs(plan *detector.Plan, flags []string) []string {
+	var args []string
+	passed := make(map[string]bool)
+
+	for _, flag := range flags {
+		if idx := strings.Index(flag, "="); idx != -1 {
+			id := flag[:idx]
+			args = append(args, "--secret", fmt.Sprintf("id=%s,src=%s", id, flag[idx+1:]))
+			passed[id] = true
+		} else {
+			args = append(args, "--secret", fmt.Sprintf("id=%s,env=%s", flag, flag))
+			passed[flag] = true
+		}
+	}
+
+	for _, secret := range plan.Secrets {
+		if passed[secret.ID] {
+			continue
+		}
+		env := secret.Env
+		if env == "" {
+			env = secret.ID
+		}
+		if _, ok := os.LookupEnv(env); ok {
+			args = append(args, "--secret", fmt.Sprintf("id=%s,env=%s", secret.ID, env))
+			continue
+		}
+		fmt.Printf("Warning: build secret %s is not set (%s). Export %s or pass --secret %s=<file>\n", secret.ID, secret.Description, env, secret.ID)
 	}
 
-	// Build command: CLI > env > detected
-	if buildCmd != "" {
-		plan.BuildCommand = buildCmd
-	} else if env := os.Getenv("COOLPACK_BUILD_CMD"); env != "" {
-		plan.BuildCommand = env
+	return args
+}
+
+// buildLabels returns the created, revision and source OCI labels for an image built from path.
+// Revision and source come from git, falling back to SOURCE_COMMIT (set by Coolify) for the revision.
+func buildLabels(path string) map[string]string {
+	labels := map[string]string{
+		generator.LabelCreated: time.Now().UTC().Format(time.RFC3339),
 	}
 
-	// Start command: CLI > env > detected
-	if startCmd != "" {
-		plan.StartCommand = startCmd
-	} else if env := os.Getenv("COOLPACK_START_CMD"); env != "" {
-		plan.StartCommand = env
+	if revision := gitOutput(path, "rev-parse", "HEAD"); revision != "" {
+		labels[generator.LabelRevision] = revision
+	} else if revision := os.Getenv("SOURCE_COMMIT"); revision != "" {
+		labels[generator.LabelRevision] = revision
+	}
+	if source := sourceURL(gitOutput(path, "remote", "get-url", "origin")); source != "" {
+		labels[generator.LabelSource] = source
 	}
+
+	return labels
+}
+
+// gitOutput runs a git command in path and returns its trimmed output, or "" on failure
+func gitOutput(path string, args ...string) string {
+	out, err := exec.Command("git", append([]string{"-C", path}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// sourceURL turns a git remote into a browsable https URL without credentials
+// (git@github.com:org/repo.git -> https://github.com/org/repo)
+func sourceURL(remote string) string {
+	if remote == "" {
+		return ""
+	}
+	if strings.HasPrefix(remote, "git@") {
+		if host, repo, ok := strings.Cut(strings.TrimPrefix(remote, "git@"), ":"); ok {
+			remote = "https://" + host + "/" + repo
+		}
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return ""
+	}
+	u.User = nil
+	u.Path = strings.TrimSuffix(u.Path, ".git")
+	return u.String()
+}
+
+// projectConfigEnv maps the variables applyProjectConfig set to the file they
+// came from, so --explain can name the file instead of the variable
+var projectConfigEnv = make(map[string]string)
+
+// applyProjectConfig exports the settings in coolpack.toml, then those of a
+// nixpacks.toml/nixpacks.json, as the COOLPACK_* variables they stand for,
+// unless already set, so environment variables and CLI flags take precedence
+// over the files
+func applyProjectConfig(path string) error {
+	config, err := detector.LoadProjectConfig(path)
+	if err != nil {
+		return err
+	}
+	if config != nil {
+		_, spaSet := os.LookupEnv("COOLPACK_SPA")
+		_, noSPASet := os.LookupEnv("COOLPACK_NO_SPA")
+		for key, value := range config.Env() {
+			// spa = false means COOLPACK_NO_SPA, which must not override COOLPACK_SPA from the environment
+			if (key == "COOLPACK_SPA" || key == "COOLPACK_NO_SPA") && (spaSet || noSPASet) {
+				continue
+			}
+			if _, ok := os.LookupEnv(key); !ok {
+				os.Setenv(key, value)
+				projectConfigEnv[key] = detector.ConfigFile
+			}
+		}
+	}
+
+	nixConfig, err := nixpacks.LoadConfig(path)
+	if err != nil || nixConfig == nil {
+		return err
+	}
+	env, notes := nixConfig.Env()
+	for key, value := range env {
+		if _, ok := os.LookupEnv(key); !ok {
+			os.Setenv(key, value)
+			projectConfigEnv[key] = nixConfig.File
+		}
+	}
+	for _, note := range notes {
+		fmt.Fprintf(os.Stderr, "Note: %s: %s\n", nixConfig.File, note)
+	}
+	return nil
+}
+
+// applyNixpacksVariables adds the application variables of a nixpacks.toml/
+// nixpacks.json to the plan's build and runtime environment, like nixpacks
+// does. They replace the provider's defaults (e.g., CI); --build-env values take precedence.
+func applyNixpacksVariables(plan *detector.Plan, path string) {
+	config, err := nixpacks.LoadConfig(path)
+	if err != nil || config == nil {
+		return
+	}
+	vars := config.AppVariables()
+	for _, name := range sortedKeys(vars) {
+		value := vars[name]
+		if plan.BuildEnv == nil {
+			plan.BuildEnv = make(map[string]string)
+		}
+		if overridable(plan, plan.BuildEnv, "build_env.", name) {
+			plan.BuildEnv[name] = value
+			plan.Explain(app.Decision{Field: "build_env." + name, Value: value, Source: app.SourceConfig, File: config.File, Reason: "variables in " + config.File})
+		}
+		if plan.Env == nil {
+			plan.Env = make(map[string]string)
+		}
+		if overridable(plan, plan.Env, "env.", name) {
+			plan.Env[name] = value
+			plan.Explain(app.Decision{Field: "env." + name, Value: value, Source: app.SourceConfig, File: config.File, Reason: "variables in " + config.File})
+		}
+	}
+}
+
+// overridable reports whether a configured variable may replace the value in env:
+// unset variables and provider defaults can be replaced, --build-env values can't
+func overridable(plan *detector.Plan, env map[string]string, prefix, name string) bool {
+	if _, ok := env[name]; !ok {
+		return true
+	}
+	d, ok := plan.Decision(prefix + name)
+	return ok && d.Source == app.SourceDefault
+}
+
+// applyBuildEnv adds --build-env variables to the plan's build environment,
+// replacing the values the provider set (e.g., CI, NITRO_PRESET)
+func applyBuildEnv(plan *detector.Plan, envMap map[string]string) {
+	if len(envMap) == 0 {
+		return
+	}
+	if plan.BuildEnv == nil {
+		plan.BuildEnv = make(map[string]string, len(envMap))
+	}
+	for name, value := range envMap {
+		plan.BuildEnv[name] = value
+		plan.Explain(app.Decision{Field: "build_env." + name, Value: value, Source: app.SourceFlag, Reason: "--build-env"})
+	}
+}
+
+// passthroughEnv returns the required variables of a phase that aren't in provided
+// but are set in the current environment, so they can be passed by name
+// (docker build --build-arg NAME, docker run -e NAME) without copying their values
+func passthroughEnv(plan *detector.Plan, phase string, provided map[string]string) []string {
+	var names []string
+	for _, req := range plan.RequiredEnv {
+		if req.Phase != phase {
+			continue
+		}
+		if _, ok := provided[req.Name]; ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(req.Name); ok {
+			names = append(names, req.Name)
+		}
+	}
+	return names
+}
+
+// applyPlatforms sets the target platforms from CLI or env var
+// Priority: --platform > COOLPACK_PLATFORMS > plan
+func applyPlatforms(plan *detector.Plan, platforms []string) {
+	if len(platforms) > 0 {
+		plan.Platforms = app.ParsePlatforms(strings.Join(platforms, ","))
+		plan.Explain(app.Decision{Field: "platforms", Value: strings.Join(plan.Platforms, ","), Source: app.SourceFlag, Reason: "--platform"})
+	} else if env := os.Getenv("COOLPACK_PLATFORMS"); env != "" {
+		plan.Platforms = app.ParsePlatforms(env)
+	}
+}
+
+// applyCommandOverrides applies command overrides from CLI flags or env vars
+// Priority: CLI flags > Environment variables > Auto-detected
+func applyCommandOverrides(plan *detector.Plan, installCmd, buildCmd, startCmd string) {
+	override := func(command *string, field, value, flag, env string) {
+		if value != "" {
+			*command = value
+			plan.Explain(app.Decision{Field: field, Value: value, Source: app.SourceFlag, Reason: flag})
+		} else if value := os.Getenv(env); value != "" {
+			*command = value
+			plan.Explain(app.Decision{Field: field, Value: value, Source: app.SourceEnv, Env: env, Reason: env})
+		}
+	}
+
+	override(&plan.InstallCommand, "install_command", installCmd, "--install-cmd", "COOLPACK_INSTALL_CMD")
+	override(&plan.BuildCommand, "build_command", buildCmd, "--build-cmd", "COOLPACK_BUILD_CMD")
+	override(&plan.StartCommand, "start_command", startCmd, "--start-cmd", "COOLPACK_START_CMD")
 }
 
 // applyStaticServerSetting applies static server setting from CLI or env var
 // Priority: CLI flag > Environment variable > default (caddy)
 func applyStaticServerSetting(plan *detector.Plan, staticServer string) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
-	}
-
 	if staticServer != "" {
-		plan.Metadata["static_server"] = staticServer
+		plan.Metadata.StaticServer = staticServer
 	} else if env := os.Getenv("COOLPACK_STATIC_SERVER"); env != "" {
-		plan.Metadata["static_server"] = env
+		plan.Metadata.StaticServer = env
 	}
 	// Default is "caddy" which is handled in generator
 }
 
+// applyPrecompressSetting enables brotli/gzip precompression of static output from CLI or env var
+// Priority: --precompress > COOLPACK_PRECOMPRESS > detected
+func applyPrecompressSetting(plan *detector.Plan, precompress bool) {
+	if precompress {
+		plan.Metadata.Precompress = true
+	} else if env := os.Getenv("COOLPACK_PRECOMPRESS"); env == "true" || env == "1" {
+		plan.Metadata.Precompress = true
+	}
+}
+
+// applyRunTestsSetting runs the detected test command during the build from CLI or env var
+// Priority: --run-tests > COOLPACK_RUN_TESTS > default off
+func applyRunTestsSetting(plan *detector.Plan, runTests bool) {
+	if runTests {
+		plan.Metadata.RunTests = true
+	} else if env := os.Getenv("COOLPACK_RUN_TESTS"); env == "true" || env == "1" {
+		plan.Metadata.RunTests = true
+	}
+}
+
 // applySPASetting applies SPA setting from CLI or env var
 // Priority: --no-spa/COOLPACK_NO_SPA > --spa/COOLPACK_SPA > auto-detected
 func applySPASetting(plan *detector.Plan, spa bool, noSPA bool) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
-	}
-
 	// --no-spa and COOLPACK_NO_SPA take highest priority
 	if noSPA {
-		delete(plan.Metadata, "is_spa")
+		plan.SPA = nil
 		return
 	}
 	if env := os.Getenv("COOLPACK_NO_SPA"); env == "true" || env == "1" {
-		delete(plan.Metadata, "is_spa")
+		plan.SPA = nil
 		return
 	}
 
 	if spa {
-		plan.Metadata["is_spa"] = true
+		plan.SPA = &app.SPAInfo{Enabled: true, Reason: "--spa"}
 	} else if env := os.Getenv("COOLPACK_SPA"); env == "true" || env == "1" {
-		plan.Metadata["is_spa"] = true
+		plan.SPA = &app.SPAInfo{Enabled: true, Reason: "COOLPACK_SPA"}
 	}
-	// Auto-detected value is already in metadata from provider
+	// Auto-detected value is already in the plan from provider
 }
 
 // applyOutputDirSetting applies output directory override from CLI or env var
 // Priority: CLI flag > Environment variable > framework default (handled in generator)
 func applyOutputDirSetting(plan *detector.Plan, outputDir string) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
+	if outputDir == "" {
+		outputDir = os.Getenv("COOLPACK_SPA_OUTPUT_DIR")
 	}
-
-	if outputDir != "" {
-		plan.Metadata["output_dir_override"] = outputDir
-	} else if env := os.Getenv("COOLPACK_SPA_OUTPUT_DIR"); env != "" {
-		plan.Metadata["output_dir_override"] = env
+	if outputDir == "" {
+		return
 	}
+	if plan.Output == nil {
+		plan.Output = &app.OutputInfo{}
+	}
+	plan.Output.DirOverride = outputDir
 }
 
 // applyCustomPackagesBuild adds custom APT packages to the plan (merges with existing)
 func applyCustomPackagesBuild(plan *detector.Plan, packages []string) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
-	}
-
 	// Start with existing custom packages from plan file
-	var customPackages []string
-	if existing, ok := plan.Metadata["custom_packages"].([]interface{}); ok {
-		for _, pkg := range existing {
-			if s, ok := pkg.(string); ok {
-				customPackages = append(customPackages, s)
-			}
-		}
-	} else if existing, ok := plan.Metadata["custom_packages"].([]string); ok {
-		customPackages = append(customPackages, existing...)
-	}
+	customPackages := append([]string(nil), plan.Metadata.CustomPackages...)
 
 	// Add CLI packages
 	if len(packages) > 0 {
@@ -405,20 +952,30 @@ func applyCustomPackagesBuild(plan *detector.Plan, packages []string) {
 		}
 	}
 
-	plan.Metadata["custom_packages"] = unique
+	plan.Metadata.CustomPackages = unique
 }
 
-// loadPlanFromFile loads a build plan from a JSON file
+// loadPlanFromFile loads a build plan from a JSON, YAML or TOML file (by extension)
 func loadPlanFromFile(path string) (*app.Plan, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var plan app.Plan
-	if err := json.Unmarshal(data, &plan); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	plan, err := app.UnmarshalPlan(data, app.FormatFromPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	// Extensions registered by an embedding platform must match their types
+	if err := plan.ValidateExtensions(); err != nil {
+		return nil, err
+	}
+
+	// Plans from a newer coolpack may rely on features this version can't build
+	if _, err := plan.NegotiateFeatures(app.KnownFeatures()); err != nil {
+		return nil, err
 	}
 
-	return &plan, nil
+	return plan, nil
 }
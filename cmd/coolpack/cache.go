@@ -0,0 +1,194 @@
+package coolpack
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/analyze"
+	"github.com/coollabsio/coolpack/pkg/cache"
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cachePruneMaxAge  string
+	cachePruneMaxSize string
+	cachePruneAll     bool
+	cachePruneDryRun  bool
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Show coolpack's on-disk caches",
+	Long: `Show coolpack's on-disk caches and their sizes:
+
+  data       Refreshed version data ('coolpack data update')
+  plans      Cached detection results
+  releases   GitHub responses of the update check, with their ETags
+  artifacts  Build artifacts of the application (.coolpack/: Dockerfile, SBOM, provenance)
+
+Environment Variables:
+  COOLPACK_CACHE_DIR       Cache directory (default: user cache dir)
+  COOLPACK_DATA_DIR        Directory for refreshed data (default: data in the cache dir)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		caches, err := coolpackCaches()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Cache directory: %s\n\n", cache.Dir())
+		for _, c := range caches {
+			entries, err := c.Entries()
+			if err != nil {
+				return fmt.Errorf("failed to read %s cache: %w", c.Name, err)
+			}
+			var size int64
+			var files int
+			var used time.Time
+			for _, e := range entries {
+				size += e.Size
+				files += e.Files
+				if e.Used.After(used) {
+					used = e.Used
+				}
+			}
+			lastUsed := "-"
+			if !used.IsZero() {
+				lastUsed = used.Format("2006-01-02")
+			}
+			fmt.Printf("  %-10s %10s %6d files  last used %-10s  %s\n", c.Name, analyze.FormatSize(size), files, lastUsed, c.Dir)
+		}
+		return nil
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune [cache...]",
+	Short: "Remove old cache entries",
+	Long: `Remove cache entries not used within --max-age (default 30d), then the
+least recently used entries until the caches fit in --max-size.
+
+Caches are plans and releases by default. Refreshed data and artifacts are
+only pruned when named: data is only rewritten by 'coolpack data update',
+and artifacts are the application's own .coolpack/ directory. Removed
+entries are recreated when needed: plans by detection, releases by the next
+update check, artifacts by prepare and build, and data falls back to the
+version database embedded in coolpack.`,
+	Example: `  coolpack cache prune                    # Entries unused for 30 days
+  coolpack cache prune --max-size 200MB   # Also cap the total size
+  coolpack cache prune plans --all        # Empty the plan cache
+  coolpack cache prune data --all         # Go back to the embedded version data
+  coolpack cache prune artifacts          # Old build artifacts of the application
+  coolpack cache prune --dry-run          # Show what would be removed`,
+	RunE: runCachePrune,
+}
+
+func init() {
+	cachePruneCmd.Flags().StringVar(&cachePruneMaxAge, "max-age", "30d", "Remove entries not used for longer (e.g., 7d, 12h; 0 to disable)")
+	cachePruneCmd.Flags().StringVar(&cachePruneMaxSize, "max-size", "", "Remove the least recently used entries until the caches fit (e.g., 500MB, 2G)")
+	cachePruneCmd.Flags().BoolVar(&cachePruneAll, "all", false, "Remove every entry")
+	cachePruneCmd.Flags().BoolVar(&cachePruneDryRun, "dry-run", false, "Show what would be removed without removing it")
+	cacheCmd.AddCommand(cachePruneCmd)
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	policy := cache.Policy{All: cachePruneAll}
+	var err error
+	if cachePruneMaxAge != "" && cachePruneMaxAge != "0" {
+		if policy.MaxAge, err = cache.ParseAge(cachePruneMaxAge); err != nil {
+			return err
+		}
+	}
+	if cachePruneMaxSize != "" {
+		if policy.MaxSize, err = cache.ParseSize(cachePruneMaxSize); err != nil {
+			return err
+		}
+	}
+
+	caches, err := coolpackCaches()
+	if err != nil {
+		return err
+	}
+	// Refreshed data and the application's .coolpack/ are only pruned when named
+	if len(args) == 0 {
+		args = []string{"plans", "releases"}
+	}
+	caches, err = selectCaches(caches, args)
+	if err != nil {
+		return err
+	}
+
+	var entries []cache.Entry
+	for _, c := range caches {
+		found, err := c.Entries()
+		if err != nil {
+			return fmt.Errorf("failed to read %s cache: %w", c.Name, err)
+		}
+		entries = append(entries, found...)
+	}
+
+	selected := policy.Select(entries, time.Now())
+	if len(selected) == 0 {
+		fmt.Println("Nothing to prune")
+		return nil
+	}
+
+	var size int64
+	for _, e := range selected {
+		size += e.Size
+		fmt.Printf("  %-10s %10s  %s\n", e.Cache, analyze.FormatSize(e.Size), e.Path)
+	}
+
+	if cachePruneDryRun {
+		fmt.Printf("Would remove %d entries (%s)\n", len(selected), analyze.FormatSize(size))
+		return nil
+	}
+	freed, err := cache.Remove(selected)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d entries (%s)\n", len(selected), analyze.FormatSize(freed))
+	return nil
+}
+
+// coolpackCaches returns the caches: the global ones and the build artifacts of
+// the application (--path)
+func coolpackCaches() ([]cache.Cache, error) {
+	absPath, err := appPath(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return []cache.Cache{
+		{Name: "data", Dir: data.Dir()},
+		{Name: "plans", Dir: cache.Path("plans")},
+		{Name: "releases", Dir: cache.Path("releases")},
+		{Name: "artifacts", Dir: filepath.Join(absPath, ".coolpack")},
+	}, nil
+}
+
+// selectCaches returns the named caches
+func selectCaches(caches []cache.Cache, names []string) ([]cache.Cache, error) {
+	var selected []cache.Cache
+	for _, name := range names {
+		found := false
+		for _, c := range caches {
+			if c.Name == name {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			known := make([]string, len(caches))
+			for i, c := range caches {
+				known[i] = c.Name
+			}
+			return nil, fmt.Errorf("unknown cache %q (use %s)", name, strings.Join(known, ", "))
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,68 @@
+package coolpack
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/spf13/cobra"
+)
+
+var dataUpdateSource string
+
+var dataCmd = &cobra.Command{
+	Use:   "data",
+	Short: "Show the version database used for detection",
+	Long: `Show the version database used for detection: the Node.js release table,
+the base image catalog, native dependency mappings and the package manager
+index.
+
+The database is embedded in coolpack, so detection works offline. Use
+'coolpack data update' to refresh it without upgrading coolpack.
+
+Environment Variables:
+  COOLPACK_DATA_DIR        Directory for refreshed data (default: user cache dir)
+  COOLPACK_DATA_SOURCE     URL or directory 'data update' reads from (default: the coolpack repository)`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db := data.Load()
+		fmt.Printf("Data directory: %s\n\n", data.Dir())
+		for _, asset := range db.Assets {
+			fmt.Printf("  %-20s %-12s %s\n", asset.Name, asset.Version, asset.Source)
+		}
+	},
+}
+
+var dataUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Refresh the version database",
+	Long: `Refresh the version database into the data directory. Refreshed assets
+are used instead of the embedded ones when they are newer.
+
+For air-gapped environments, copy the assets (pkg/data/assets/*.json) to the
+machine and pass the directory with --from. Behind a firewall, point --from
+(or COOLPACK_DATA_SOURCE) at an internal mirror of the assets; HTTPS_PROXY and
+NO_PROXY are honored.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// COOLPACK_DATA_SOURCE applies unless --from is given (an env file may set it)
+		if env := os.Getenv("COOLPACK_DATA_SOURCE"); env != "" && !cmd.Flags().Changed("from") {
+			dataUpdateSource = env
+		}
+		fmt.Printf("Updating data from %s...\n", dataUpdateSource)
+		infos, err := data.Update(context.Background(), dataUpdateSource, data.Dir())
+		if err != nil {
+			return fmt.Errorf("data update failed: %w", err)
+		}
+		for _, info := range infos {
+			fmt.Printf("  %-20s %-12s %s\n", info.Name, info.Version, info.Source)
+		}
+		return nil
+	},
+}
+
+func init() {
+	dataUpdateCmd.Flags().StringVar(&dataUpdateSource, "from", data.DefaultSource, "URL or local directory to read the data assets from")
+	dataCmd.AddCommand(dataUpdateCmd)
+}
@@ -0,0 +1,121 @@
+package coolpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	detectJSON   bool
+	detectFormat string
+)
+
+var detectCmd = &cobra.Command{
+	Use:   "detect [path]",
+	Short: "Print the detected language, framework and package manager",
+	Long: `Detect the application at the given path (or current directory) and print
+a one-line summary: language, framework, package manager and output type.
+It leaves out the commands, images and environment plan shows, which makes it
+the quick check for scripts:
+
+  coolpack detect --format json | jq -r .framework
+
+Exits with status 2 when no supported application is found (see coolpack
+--help for the exit codes).`,
+	Example: `  coolpack detect
+  coolpack detect ./app --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDetect,
+}
+
+func init() {
+	detectCmd.Flags().BoolVar(&detectJSON, "json", false, "Output as JSON (same as --format json)")
+	detectCmd.Flags().BoolVar(&noPlanCache, "no-cache", false, "Detect again instead of using a cached plan")
+	detectCmd.Flags().StringVar(&detectFormat, "format", "", "Output format: text (default) or json")
+}
+
+// detection is the JSON output of detect
+type detection struct {
+	Provider              string `json:"provider"`
+	Language              string `json:"language"`
+	LanguageVersion       string `json:"language_version,omitempty"`
+	Framework             string `json:"framework,omitempty"`
+	FrameworkVersion      string `json:"framework_version,omitempty"`
+	PackageManager        string `json:"package_manager,omitempty"`
+	PackageManagerVersion string `json:"package_manager_version,omitempty"`
+	OutputType            string `json:"output_type,omitempty"`
+	SPA                   bool   `json:"spa,omitempty"`
+}
+
+func runDetect(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(detectFormat, detectJSON)
+	if err != nil {
+		return err
+	}
+
+	absPath, err := appPath(args)
+	if err != nil {
+		return err
+	}
+
+	// Project settings from coolpack.toml (CLI > env > coolpack.toml > detected)
+	if err := applyProjectConfig(absPath); err != nil {
+		return err
+	}
+
+	plan, err := newDetector(absPath).Detect()
+	if err != nil {
+		return errDetection(cmd, err)
+	}
+	if plan == nil {
+		return errNoProvider(cmd)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(detection{
+			Provider:              plan.Provider,
+			Language:              plan.Language,
+			LanguageVersion:       plan.LanguageVersion,
+			Framework:             plan.Framework,
+			FrameworkVersion:      plan.FrameworkVersion,
+			PackageManager:        plan.PackageManager,
+			PackageManagerVersion: plan.PackageManagerVersion,
+			OutputType:            plan.OutputType(),
+			SPA:                   plan.IsSPA(),
+		})
+	}
+
+	fmt.Println(detectSummary(plan))
+	return nil
+}
+
+// detectSummary describes a plan in one line, e.g. "nodejs nextjs (pnpm@9.1.0) [server]"
+func detectSummary(plan *app.Plan) string {
+	framework := plan.Framework
+	if framework == "" {
+		framework = "generic"
+	}
+	summary := fmt.Sprintf("%s %s", plan.Language, framework)
+	if plan.PackageManager != "" {
+		summary += " (" + plan.PackageManager
+		if plan.PackageManagerVersion != "" {
+			summary += "@" + plan.PackageManagerVersion
+		}
+		summary += ")"
+	}
+	// Output type and SPA mode
+	if plan.Output != nil && plan.Output.Type != "" {
+		summary += " [" + plan.Output.Type
+		if plan.IsSPA() {
+			summary += "/spa"
+		}
+		summary += "]"
+	}
+	return summary
+}
@@ -0,0 +1,31 @@
+package coolpack
+
+import (
+	"os"
+
+	"github.com/coollabsio/coolpack/pkg/cache"
+	"github.com/coollabsio/coolpack/pkg/detector"
+)
+
+// noPlanCache disables the plan cache (--no-cache on plan, detect, prepare and build)
+var noPlanCache bool
+
+// newDetector returns a detector for the application that sees the COOLPACK_*
+// variables of the environment and every variable of the --env-file files,
+// limited by --detect-timeout. Detection results are cached in the plans
+// cache unless --no-cache or COOLPACK_NO_CACHE is set.
+func newDetector(absPath string) *detector.Detector {
+	d := detector.New(absPath)
+	if len(envFileVars) > 0 {
+		env := detector.LoadEnv()
+		for key := range envFileVars {
+			env[key] = os.Getenv(key)
+		}
+		d = detector.NewWithEnv(absPath, env)
+	}
+	d.SetTimeout(rootDetectTimeout)
+	if env := os.Getenv("COOLPACK_NO_CACHE"); !noPlanCache && env != "true" && env != "1" {
+		d.SetCache(cache.Path("plans"))
+	}
+	return d
+}
@@ -0,0 +1,39 @@
+package coolpack
+
+import (
+	"os"
+
+	"github.com/coollabsio/coolpack/pkg/detector"
+)
+
+// envFileVars are the variables the --env-file files exported
+var envFileVars = make(map[string]string)
+
+// applyEnvFiles exports the variables of the --env-file files that aren't set
+// in the environment, so detection, command overrides and build arguments see
+// them like the variables a platform injects. Later files override earlier
+// ones; the environment overrides them all, and they override coolpack.toml.
+func applyEnvFiles(files []string) error {
+	vars := make(map[string]string)
+	source := make(map[string]string)
+	for _, file := range files {
+		fileVars, err := detector.LoadEnvFile(file)
+		if err != nil {
+			return err
+		}
+		for key, value := range fileVars {
+			vars[key] = value
+			source[key] = file
+		}
+	}
+
+	for key, value := range vars {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		os.Setenv(key, value)
+		envFileVars[key] = value
+		projectConfigEnv[key] = source[key]
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package coolpack
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes, so scripts and platforms can branch on the failure mode instead
+// of parsing stderr. They are part of the CLI's interface: don't renumber them.
+const (
+	// exitError is any other failure: invalid flags or arguments, I/O errors,
+	// lint findings
+	exitError = 1
+	// exitNoProvider means no provider recognized the application
+	exitNoProvider = 2
+	// exitDetectionError means a provider failed while analyzing the application
+	exitDetectionError = 3
+	// exitPlanInvalid means a plan file couldn't be loaded or failed validation
+	exitPlanInvalid = 4
+	// exitBuildFailed means the image build failed
+	exitBuildFailed = 5
+)
+
+// exitCodeError is an error that ends the CLI with a specific exit code
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code for an error returned by a command
+func exitCode(err error) int {
+	var e *exitCodeError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitError
+}
+
+// errNoProvider is returned when no provider detects the application
+func errNoProvider(cmd *cobra.Command) error {
+	cmd.SilenceUsage = true
+	return &exitCodeError{code: exitNoProvider, err: errors.New(msg.T("no_app_detected"))}
+}
+
+// errDetection is returned when detection fails (e.g., an unreadable package.json)
+func errDetection(cmd *cobra.Command, err error) error {
+	cmd.SilenceUsage = true
+	return &exitCodeError{code: exitDetectionError, err: fmt.Errorf("detection failed: %w", err)}
+}
+
+// errPlanInvalid is returned for a plan file that can't be loaded or doesn't validate
+func errPlanInvalid(cmd *cobra.Command, err error) error {
+	cmd.SilenceUsage = true
+	return &exitCodeError{code: exitPlanInvalid, err: err}
+}
+
+// errBuildFailed is returned when the image build fails
+func errBuildFailed(cmd *cobra.Command, err error) error {
+	cmd.SilenceUsage = true
+	return &exitCodeError{code: exitBuildFailed, err: err}
+}
@@ -0,0 +1,78 @@
+package coolpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/detector"
+	"github.com/spf13/cobra"
+)
+
+var (
+	frameworksJSON     bool
+	frameworksFormat   string
+	frameworksProvider string
+)
+
+var frameworksCmd = &cobra.Command{
+	Use:   "frameworks",
+	Short: "List the frameworks coolpack detects",
+	Long: `List every framework coolpack detects, in detection order: the provider,
+the version of its detection logic, the output types it can build (the default
+first), the default port and the packages or config files that identify it.
+With --json, the output directories are included as well.
+
+A framework's detection version increases when a release plans an existing
+application with it differently (output type, directories, port).`,
+	Example: `  coolpack frameworks
+  coolpack frameworks --json | jq -r '.[].name'`,
+	Args: cobra.NoArgs,
+	RunE: runFrameworks,
+}
+
+func init() {
+	frameworksCmd.Flags().BoolVar(&frameworksJSON, "json", false, "Output as JSON (same as --format json)")
+	frameworksCmd.Flags().StringVar(&frameworksFormat, "format", "", "Output format: text (default) or json")
+	frameworksCmd.Flags().StringVar(&frameworksProvider, "provider", "", "Only list the frameworks of this provider (e.g., node)")
+}
+
+func runFrameworks(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(frameworksFormat, frameworksJSON)
+	if err != nil {
+		return err
+	}
+
+	frameworks := []app.FrameworkDescription{}
+	found := false
+	for _, p := range detector.New(".").Providers() {
+		if frameworksProvider != "" && p.Name != frameworksProvider {
+			continue
+		}
+		found = true
+		frameworks = append(frameworks, p.Frameworks...)
+	}
+	if !found {
+		return fmt.Errorf("unknown provider %q (see coolpack providers)", frameworksProvider)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(frameworks)
+	}
+
+	fmt.Printf("%-17s %-9s %-10s %-16s %-6s %s\n", "FRAMEWORK", "PROVIDER", "DETECTION", "OUTPUT", "PORT", "DETECTED BY")
+	for _, fw := range frameworks {
+		port := "-"
+		if fw.DefaultPort != 0 {
+			port = fmt.Sprint(fw.DefaultPort)
+		}
+		detectedBy := append(append([]string{}, fw.Packages...), fw.ConfigFiles...)
+		fmt.Printf("%-17s %-9s %-10s %-16s %-6s %s\n",
+			fw.Name, fw.Provider, fmt.Sprintf("v%d", fw.DetectionVersion), strings.Join(fw.OutputTypes, ", "), port, strings.Join(detectedBy, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package coolpack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/coollabsio/coolpack/pkg/detector"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initForce bool
+	initPrint bool
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init [path]",
+	Short: "Write a coolpack.toml with the detected settings",
+	Long: `Detect the application at the given path (or current directory) and write a
+commented coolpack.toml pre-filled with the detected install, build and start
+commands, Node.js version and static output settings. Each value comes with
+the reason it was chosen (see plan --explain).
+
+Committing the file locks in today's behavior: a new framework default or
+Node.js release no longer changes the build until the file is edited.
+Settings that weren't detected are included as comments.
+
+An existing coolpack.toml is only replaced with --force; its settings are
+applied before detection, so they carry over into the new file.`,
+	Example: `  coolpack init
+  coolpack init ./app --print`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInit,
+}
+
+func init() {
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing coolpack.toml")
+	initCmd.Flags().BoolVar(&initPrint, "print", false, "Print the file instead of writing it")
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	absPath, err := appPath(args)
+	if err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(absPath, detector.ConfigFile)
+	if _, err := os.Stat(configPath); err == nil && !initForce && !initPrint {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", configPath)
+	}
+
+	// Project settings from coolpack.toml (CLI > env > coolpack.toml > detected)
+	if err := applyProjectConfig(absPath); err != nil {
+		return err
+	}
+
+	plan, err := newDetector(absPath).Detect()
+	if err != nil {
+		return errDetection(cmd, err)
+	}
+	if plan == nil {
+		return errNoProvider(cmd)
+	}
+
+	// Carry over the settings from the environment and an existing coolpack.toml
+	applyCommandOverrides(plan, "", "", "")
+	applyStaticServerSetting(plan, "")
+	applySPASetting(plan, false, false)
+	applyOutputDirSetting(plan, "")
+	applyPrecompressSetting(plan, false)
+	applyCustomPackages(plan, nil)
+	applyPlatforms(plan, nil)
+	explainProjectConfig(plan)
+
+	config := detector.RenderProjectConfig(plan)
+	if initPrint {
+		fmt.Print(config)
+		return nil
+	}
+
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", detector.ConfigFile, err)
+	}
+	// The file must load, or every later command would fail on it
+	if _, err := detector.LoadProjectConfig(absPath); err != nil {
+		return fmt.Errorf("generated an invalid %s, please report this: %w", detector.ConfigFile, err)
+	}
+
+	fmt.Printf("Wrote %s (%s)\n", configPath, detectSummary(plan))
+	progressln("Edit the commands and versions to change them; remove a setting to go back to detection.")
+	return nil
+}
@@ -0,0 +1,373 @@
+package coolpack
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/coollabsio/coolpack/pkg/detector"
+	"github.com/coollabsio/coolpack/pkg/generator"
+	"github.com/coollabsio/coolpack/pkg/providers/node"
+)
+
+// Keys read from the terminal in menus
+const (
+	keyUp = iota
+	keyDown
+	keyEnter
+	keyQuit
+	keyOther
+)
+
+// terminal is the controlling terminal, switched to unbuffered input without
+// echo for the arrow-key menus. The settings are changed with stty, which
+// keeps coolpack free of terminal libraries.
+type terminal struct {
+	tty    *os.File
+	reader *bufio.Reader
+	saved  string // stty -g settings to restore
+}
+
+// openTerminal switches the controlling terminal to menu mode
+func openTerminal() (*terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := &terminal{tty: tty, reader: bufio.NewReader(tty)}
+	if t.saved, err = t.stty("-g"); err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("stty: %w", err)
+	}
+	if err := t.menuMode(); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// stty runs stty on the terminal and returns its output
+func (t *terminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.tty
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// menuMode reads single keys without echo; Ctrl-C arrives as a key, so the
+// settings are always restored
+func (t *terminal) menuMode() error {
+	_, err := t.stty("-icanon", "-echo", "-isig", "min", "1")
+	return err
+}
+
+// restore puts the terminal back the way it was and shows the cursor
+func (t *terminal) restore() {
+	fmt.Print("\x1b[?25h")
+	t.stty(t.saved)
+	t.tty.Close()
+}
+
+// readKey reads a key press
+func (t *terminal) readKey() (int, error) {
+	b, err := t.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch b {
+	case '\r', '\n':
+		return keyEnter, nil
+	case 'k':
+		return keyUp, nil
+	case 'j':
+		return keyDown, nil
+	case 'q', 3: // Ctrl-C
+		return keyQuit, nil
+	case 0x1b:
+		// Arrow keys are ESC [ A and ESC [ B (ESC O A in application mode);
+		// ESC on its own cancels
+		if t.reader.Buffered() == 0 {
+			return keyQuit, nil
+		}
+		if next, _ := t.reader.ReadByte(); next != '[' && next != 'O' {
+			return keyOther, nil
+		}
+		switch code, _ := t.reader.ReadByte(); code {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		}
+	}
+	return keyOther, nil
+}
+
+// choose shows options as a menu under title and returns the index chosen
+// with enter, or -1 when the menu is left with q or escape
+func (t *terminal) choose(title string, options []string, selected int) (int, error) {
+	selected = max(0, min(selected, len(options)-1))
+	fmt.Print("\x1b[?25l")
+	defer fmt.Print("\x1b[?25h")
+
+	fmt.Println(title)
+	for {
+		for i, option := range options {
+			if i == selected {
+				fmt.Printf("\r\x1b[2K  \x1b[7m> %s\x1b[0m\n", option)
+			} else {
+				fmt.Printf("\r\x1b[2K    %s\n", option)
+			}
+		}
+
+		key, err := t.readKey()
+		if err != nil {
+			return -1, err
+		}
+		switch key {
+		case keyUp:
+			selected = (selected + len(options) - 1) % len(options)
+		case keyDown:
+			selected = (selected + 1) % len(options)
+		case keyEnter:
+			return selected, nil
+		case keyQuit:
+			return -1, nil
+		}
+		fmt.Printf("\x1b[%dA", len(options))
+	}
+}
+
+// readLine prompts for a line of text with the terminal's own line editing;
+// an empty answer keeps current
+func (t *terminal) readLine(label, current string) (string, error) {
+	if _, err := t.stty(t.saved); err != nil {
+		return "", err
+	}
+	defer t.menuMode()
+
+	fmt.Printf("%s [%s]: ", label, current)
+	line, err := t.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if line = strings.TrimSpace(line); line == "" {
+		return current, nil
+	}
+	return line, nil
+}
+
+// clear clears the screen
+func (t *terminal) clear() {
+	fmt.Print("\x1b[H\x1b[2J")
+}
+
+// reviewPlan shows the plan and lets the user change the framework, the
+// Node.js version and the commands, then write the plan to planFile or the
+// Dockerfile to .coolpack/. detect re-runs detection (and the plan flags);
+// the framework and version are passed to it as COOLPACK_FRAMEWORK and
+// COOLPACK_NODE_VERSION, so detection follows them like any override.
+func reviewPlan(absPath, planFile string, plan *detector.Plan, detect func() (*detector.Plan, error)) error {
+	t, err := openTerminal()
+	if err != nil {
+		return fmt.Errorf("plan -i needs an interactive terminal: %w", err)
+	}
+	defer t.restore()
+
+	// Command edits are applied again after each detection
+	commands := map[string]string{}
+	applyEdits := func(p *detector.Plan) {
+		for _, field := range []string{"install_command", "build_command", "start_command"} {
+			value, ok := commands[field]
+			if !ok {
+				continue
+			}
+			switch field {
+			case "install_command":
+				p.InstallCommand = value
+			case "build_command":
+				p.BuildCommand = value
+			case "start_command":
+				p.StartCommand = value
+			}
+			p.Explain(app.Decision{Field: field, Value: value, Source: app.SourceFlag, Reason: "set in plan -i"})
+		}
+	}
+	redetect := func() (string, error) {
+		p, err := detect()
+		if err != nil {
+			return "", err
+		}
+		if p == nil {
+			return msg.T("no_app_detected"), nil
+		}
+		applyEdits(p)
+		plan = p
+		return "", nil
+	}
+	editCommand := func(field, label string, current *string) func() (string, error) {
+		return func() (string, error) {
+			value, err := t.readLine(label, *current)
+			if err != nil || value == *current {
+				return "", err
+			}
+			commands[field] = value
+			applyEdits(plan)
+			return "", nil
+		}
+	}
+
+	type menuItem struct {
+		label string
+		run   func() (string, error)
+	}
+	selected := 0
+	status := ""
+	for {
+		t.clear()
+		printPlan(plan)
+		fmt.Println()
+		if status != "" {
+			fmt.Println(status)
+			fmt.Println()
+		}
+
+		framework := plan.Framework
+		if framework == "" {
+			framework = "none"
+		}
+		items := []menuItem{
+			{fmt.Sprintf("%s: %s", msg.T("plan.framework"), framework), func() (string, error) {
+				options := []string{"none"}
+				for _, fw := range node.Frameworks {
+					options = append(options, string(fw))
+				}
+				i, err := t.choose("Framework (detection picks the output type and commands for it):", options, slices.Index(options, framework))
+				if err != nil || i < 0 || options[i] == framework {
+					return "", err
+				}
+				os.Setenv("COOLPACK_FRAMEWORK", options[i])
+				return redetect()
+			}},
+		}
+		if plan.Language == "nodejs" {
+			items = append(items, menuItem{fmt.Sprintf("%s: %s", msg.T("plan.language_version"), plan.LanguageVersion), func() (string, error) {
+				versions, labels := nodeVersionChoices(plan.LanguageVersion)
+				labels = append(labels, "Other...")
+				i, err := t.choose("Node.js version:", labels, slices.Index(versions, plan.LanguageVersion))
+				if err != nil || i < 0 {
+					return "", err
+				}
+				version := plan.LanguageVersion
+				if i < len(versions) {
+					version = versions[i]
+				} else if version, err = t.readLine("Node.js version", version); err != nil {
+					return "", err
+				}
+				if version == plan.LanguageVersion {
+					return "", nil
+				}
+				os.Setenv("COOLPACK_NODE_VERSION", version)
+				return redetect()
+			}})
+		}
+		items = append(items,
+			menuItem{fmt.Sprintf("%s: %s", msg.T("plan.install_command"), plan.InstallCommand), editCommand("install_command", msg.T("plan.install_command"), &plan.InstallCommand)},
+			menuItem{fmt.Sprintf("%s: %s", msg.T("plan.build_command"), plan.BuildCommand), editCommand("build_command", msg.T("plan.build_command"), &plan.BuildCommand)},
+			menuItem{fmt.Sprintf("%s: %s", msg.T("plan.start_command"), plan.StartCommand), editCommand("start_command", msg.T("plan.start_command"), &plan.StartCommand)},
+			menuItem{"Write plan to " + planFile, func() (string, error) {
+				return writeReviewedPlan(plan, absPath, planFile)
+			}},
+			menuItem{"Write .coolpack/Dockerfile", func() (string, error) {
+				return writeReviewedDockerfile(plan, absPath)
+			}},
+			menuItem{"Quit", nil},
+		)
+
+		labels := make([]string, len(items))
+		for i, item := range items {
+			labels[i] = item.label
+		}
+		choice, err := t.choose("Review the plan (up/down to move, enter to change, q to quit):", labels, selected)
+		if err != nil {
+			return err
+		}
+		if choice < 0 || items[choice].run == nil {
+			return nil
+		}
+		selected = choice
+		if status, err = items[choice].run(); err != nil {
+			status = "Error: " + err.Error()
+		}
+	}
+}
+
+// nodeVersionChoices returns the Node.js majors that are released and not
+// end-of-life, newest first, with menu labels, plus current if it isn't one of them
+func nodeVersionChoices(current string) (versions, labels []string) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	releases := slices.Clone(data.Load().NodeReleases)
+	slices.SortFunc(releases, func(a, b data.NodeRelease) int { return b.Major - a.Major })
+	for _, r := range releases {
+		if r.Released > today || r.IsEOL(now) {
+			continue
+		}
+		label := strconv.Itoa(r.Major)
+		if r.IsLTS() && r.LTS <= today {
+			label += " (LTS " + r.Codename + ")"
+		} else {
+			label += " (Current)"
+		}
+		versions = append(versions, strconv.Itoa(r.Major))
+		labels = append(labels, label)
+	}
+	if current != "" && !slices.Contains(versions, current) {
+		versions = append([]string{current}, versions...)
+		labels = append([]string{current + " (detected)"}, labels...)
+	}
+	return versions, labels
+}
+
+// writeReviewedPlan writes the plan to planFile, encoded by its extension
+func writeReviewedPlan(plan *detector.Plan, absPath, planFile string) (string, error) {
+	outPath := planFile
+	if !filepath.IsAbs(outPath) {
+		outPath = filepath.Join(absPath, outPath)
+	}
+	if filepath.Base(outPath) == detector.ConfigFile {
+		return "", fmt.Errorf("%s holds the project settings; write the plan to another file (e.g., coolpack.plan.toml)", detector.ConfigFile)
+	}
+	out, err := encodePlan(plan, app.FormatFromPath(outPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(outPath, out, 0644); err != nil {
+		return "", fmt.Errorf("failed to write plan: %w", err)
+	}
+	return msg.T("plan.written", outPath), nil
+}
+
+// writeReviewedDockerfile writes the plan's Dockerfile to .coolpack/, like prepare
+func writeReviewedDockerfile(plan *detector.Plan, absPath string) (string, error) {
+	coolpackDir := filepath.Join(absPath, ".coolpack")
+	if err := os.MkdirAll(coolpackDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create .coolpack directory: %w", err)
+	}
+	dockerfile, err := generator.New(plan).GenerateDockerfile()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate Dockerfile: %w", err)
+	}
+	dockerfilePath := filepath.Join(coolpackDir, "Dockerfile")
+	if err := os.WriteFile(dockerfilePath, []byte(dockerfile), 0644); err != nil {
+		return "", fmt.Errorf("failed to write Dockerfile: %w", err)
+	}
+	return "Wrote " + dockerfilePath, nil
+}
@@ -0,0 +1,109 @@
+package coolpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintJSON   bool
+	lintFormat string
+	lintStrict bool
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [path]",
+	Short: "Check an application for deployability issues",
+	Long: `Check the application at the given path (or current directory) for issues
+that break or weaken a deployment, without generating a plan:
+
+  missing_lockfile       No lockfile for the frozen install
+  missing_start_script   Server app without a start script or derivable start command
+  dev_command            start/build script runs a dev server or watcher (next dev, nodemon)
+  hardcoded_port         Server listens on a fixed port instead of PORT
+  localhost_url          Source connects to localhost, which is the container in production
+  committed_secret       .env with values or key files not excluded by .gitignore
+
+Exits with status 1 when there are errors (or warnings, with --strict), so it
+can gate CI. --json prints the findings for automation.`,
+	Example: `  coolpack lint
+  coolpack lint ./app --strict
+  coolpack lint --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLint,
+}
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintJSON, "json", false, "Output findings as JSON (same as --format json)")
+	lintCmd.Flags().StringVar(&lintFormat, "format", "", "Output format: text (default) or json")
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Fail on warnings as well as errors")
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(lintFormat, lintJSON)
+	if err != nil {
+		return err
+	}
+
+	absPath, err := appPath(args)
+	if err != nil {
+		return err
+	}
+
+	// Project settings from coolpack.toml (CLI > env > coolpack.toml > detected)
+	if err := applyProjectConfig(absPath); err != nil {
+		return err
+	}
+
+	report, err := newDetector(absPath).Lint()
+	if err != nil {
+		return errDetection(cmd, err)
+	}
+	if report == nil {
+		return errNoProvider(cmd)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printLintReport(report)
+	}
+
+	errors, warnings := report.Count(app.SeverityError), report.Count(app.SeverityWarning)
+	if errors > 0 || (lintStrict && warnings > 0) {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("lint failed: %d errors, %d warnings", errors, warnings)
+	}
+	return nil
+}
+
+func printLintReport(report *app.LintReport) {
+	if len(report.Findings) == 0 {
+		fmt.Println("No deployability issues found.")
+		return
+	}
+
+	for _, f := range report.Findings {
+		location := ""
+		if f.File != "" {
+			location = " " + f.File
+			if f.Line > 0 {
+				location += fmt.Sprintf(":%d", f.Line)
+			}
+		}
+		fmt.Printf("%-7s [%s]%s\n", f.Severity, f.Code, location)
+		fmt.Printf("    %s\n", f.Message)
+		if f.Suggestion != "" {
+			fmt.Printf("    Suggestion: %s\n", f.Suggestion)
+		}
+	}
+	fmt.Printf("\n%d errors, %d warnings\n", report.Count(app.SeverityError), report.Count(app.SeverityWarning))
+}
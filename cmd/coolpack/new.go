@@ -0,0 +1,121 @@
+package coolpack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/providers/node"
+	"github.com/coollabsio/coolpack/pkg/starter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newName  string
+	newForce bool
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new <framework> [directory]",
+	Short: "Create a starter application",
+	Long: `Create a minimal application that coolpack detects and deploys without
+overrides: package.json with the framework's scripts, .nvmrc, coolpack.toml,
+.gitignore and a small amount of source code.
+
+The directory defaults to the framework name. The Node.js version in .nvmrc
+follows COOLPACK_NODE_DEFAULT (default: the newest LTS line).
+
+Frameworks:
+` + starterList(),
+	Example: `  coolpack new express
+  coolpack new nextjs my-app
+  coolpack new vite . --force`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return starter.Names(), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: runNew,
+}
+
+func init() {
+	newCmd.Flags().StringVar(&newName, "name", "", "Package name (defaults to the directory name)")
+	newCmd.Flags().BoolVar(&newForce, "force", false, "Overwrite existing files")
+}
+
+func runNew(cmd *cobra.Command, args []string) error {
+	s, ok := starter.Get(args[0])
+	if !ok {
+		return fmt.Errorf("no starter for %q (use %s)", args[0], strings.Join(starter.Names(), ", "))
+	}
+
+	dir := args[0]
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	absPath, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	name := newName
+	if name == "" {
+		name = packageName(filepath.Base(absPath))
+	}
+
+	if err := os.MkdirAll(absPath, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	files, err := s.Write(absPath, starter.Options{
+		Name:        name,
+		NodeVersion: node.DefaultNodeVersionFor(os.Getenv("COOLPACK_NODE_DEFAULT"), time.Now()),
+		Force:       newForce,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s starter in %s\n", s.Framework, absPath)
+	for _, f := range files {
+		fmt.Printf("  %s\n", f)
+	}
+	fmt.Println()
+	fmt.Println("Next steps:")
+	if rel, err := filepath.Rel(".", absPath); err != nil || rel != "." {
+		fmt.Printf("  cd %s\n", dir)
+	}
+	fmt.Println("  npm install      # Creates package-lock.json, which the build installs from")
+	fmt.Println("  coolpack plan    # Check what coolpack detects")
+	return nil
+}
+
+// starterList returns the frameworks with a starter for the help text
+func starterList() string {
+	var sb strings.Builder
+	for _, s := range starter.List() {
+		sb.WriteString(fmt.Sprintf("  %-16s %s\n", s.Framework, s.Description))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// packageName turns a directory name into a valid npm package name
+func packageName(dir string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(dir) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('-')
+		}
+	}
+	name := strings.Trim(sb.String(), "-._")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
@@ -1,23 +1,33 @@
 package coolpack
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/coollabsio/coolpack/pkg/app"
 	"github.com/coollabsio/coolpack/pkg/detector"
+	"github.com/coollabsio/coolpack/pkg/generator"
+	"github.com/coollabsio/coolpack/pkg/nixpacks"
+	"github.com/coollabsio/coolpack/pkg/registry"
 	"github.com/spf13/cobra"
 )
 
 var (
 	planOutputJSON bool
-	planPath       string
 	planOutFile    string
 	planPackages   []string
 	planBuildEnvs  []string
+	planPinImages  bool
+	planPlatforms  []string
+	planFormat     string
+	planExplain    bool
+	planInteract   bool
 )
 
 var planCmd = &cobra.Command{
@@ -26,64 +36,110 @@ var planCmd = &cobra.Command{
 	Long: `Analyze the application at the given path (or current directory),
 detect the language, framework, and package manager, then output a build plan.
 
+With -i, the plan is shown in an interactive review: change the framework,
+Node.js version and commands with arrow-key menus, then write the plan
+(coolpack.json, or the --out file) or the Dockerfile (.coolpack/Dockerfile).
+
 Environment Variables:
   COOLPACK_BASE_IMAGE      Override base Docker image
-  COOLPACK_NODE_VERSION    Override Node.js version`,
+  COOLPACK_NODE_VERSION    Override Node.js version
+  COOLPACK_NODE_DEFAULT    Default Node.js version policy: lts, ecosystem, current or a version`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runPlan,
 }
 
 func init() {
 	planCmd.Flags().BoolVar(&planOutputJSON, "json", false, "Output plan as JSON")
-	planCmd.Flags().StringVarP(&planPath, "path", "p", "", "Path to the application (defaults to current directory)")
 	planCmd.Flags().StringVarP(&planOutFile, "out", "o", "", "Write plan to file (default: coolpack.json if flag used without value)")
 	planCmd.Flags().Lookup("out").NoOptDefVal = "coolpack.json"
 	planCmd.Flags().StringArrayVar(&planPackages, "packages", nil, "Additional APT packages to install (e.g., curl, wget)")
 	planCmd.Flags().StringArrayVar(&planBuildEnvs, "build-env", nil, "Build-time environment variables (KEY=value or KEY to use current env)")
+	planCmd.Flags().BoolVar(&planPinImages, "pin-images", false, "Resolve base image tags to digests and record them in the plan")
+	planCmd.Flags().StringSliceVar(&planPlatforms, "platform", nil, "Target platforms (e.g., linux/amd64,linux/arm64)")
+	planCmd.Flags().StringVar(&planFormat, "format", "", "Output format: text (default), json, yaml, toml, or nixpacks (nixpacks build plan JSON)")
+	planCmd.Flags().BoolVar(&planExplain, "explain", false, "Show why each value was chosen (file and line, env var, flag or default)")
+	planCmd.Flags().BoolVar(&noPlanCache, "no-cache", false, "Detect again instead of using a cached plan")
+	planCmd.Flags().BoolVarP(&planInteract, "interactive", "i", false, "Review and adjust the plan with arrow-key menus before writing it")
 }
 
 func runPlan(cmd *cobra.Command, args []string) error {
-	// Determine the path to analyze
-	path := "."
-	if len(args) > 0 {
-		path = args[0]
+	format := planFormat
+	if planOutputJSON {
+		format = "json"
+	}
+	switch format {
+	case "", "text", app.FormatJSON, app.FormatYAML, app.FormatTOML, "nixpacks":
+	default:
+		return fmt.Errorf("unknown format %q (use text, json, yaml, toml or nixpacks)", format)
+	}
+	if planExplain && (planOutFile != "" || (format != "" && format != "text" && format != app.FormatJSON)) {
+		return fmt.Errorf("--explain prints to the terminal as text or JSON; it can't be combined with --out or --format %s", format)
 	}
-	if planPath != "" {
-		path = planPath
+
+	if planInteract && planExplain {
+		return fmt.Errorf("-i reviews the plan in the terminal; it can't be combined with --explain")
+	}
+	if planInteract && format != "" && format != "text" {
+		return fmt.Errorf("-i reviews the plan in the terminal; it can't be combined with --format %s", format)
 	}
 
-	// Convert to absolute path
-	absPath, err := filepath.Abs(path)
+	absPath, err := appPath(args)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
+		return err
 	}
 
-	// Check if path exists
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		return fmt.Errorf("path does not exist: %s", absPath)
+	// Project settings from coolpack.toml (CLI > env > coolpack.toml > detected)
+	if err := applyProjectConfig(absPath); err != nil {
+		return err
 	}
 
-	// Run detection
-	d := detector.New(absPath)
-	plan, err := d.Detect()
+	// Run detection and apply the flags; the interactive review runs it again
+	// after each change
+	detectPlan := func() (*detector.Plan, error) {
+		plan, err := newDetector(absPath).Detect()
+		if err != nil {
+			return nil, err
+		}
+		if plan == nil {
+			return nil, nil
+		}
+
+		// Apply custom packages (CLI > env > detected)
+		applyCustomPackages(plan, planPackages)
+
+		// Parse and apply build environment variables
+		if len(planBuildEnvs) > 0 {
+			envMap := planParseEnvVars(planBuildEnvs)
+			applyBuildEnv(plan, envMap)
+		}
+		applyNixpacksVariables(plan, absPath)
+
+		// Apply target platforms (CLI > env > detected)
+		applyPlatforms(plan, planPlatforms)
+
+		// Pin base images to the digests their tags currently point to
+		if planPinImages {
+			pinImages(cmd.Context(), plan)
+		}
+		return plan, nil
+	}
+
+	plan, err := detectPlan()
 	if err != nil {
-		return fmt.Errorf("detection failed: %w", err)
+		return errDetection(cmd, err)
 	}
 
 	if plan == nil {
-		fmt.Println("No supported application detected")
-		return nil
+		return errNoProvider(cmd)
 	}
 
-	// Apply custom packages (CLI > env > detected)
-	applyCustomPackages(plan, planPackages)
-
-	// Parse and apply build environment variables
-	if len(planBuildEnvs) > 0 {
-		envMap := planParseEnvVars(planBuildEnvs)
-		if len(envMap) > 0 {
-			plan.BuildEnv = envMap
+	if planInteract {
+		cmd.SilenceUsage = true
+		outFile := planOutFile
+		if outFile == "" {
+			outFile = "coolpack.json"
 		}
+		return reviewPlan(absPath, outFile, plan, detectPlan)
 	}
 
 	// Write to file if --out is specified
@@ -92,26 +148,48 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		if !filepath.IsAbs(outPath) {
 			outPath = filepath.Join(absPath, outPath)
 		}
-		file, err := os.Create(outPath)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
+		if filepath.Base(outPath) == detector.ConfigFile {
+			return fmt.Errorf("%s holds the project settings; write the plan to another file (e.g., coolpack.plan.toml)", detector.ConfigFile)
 		}
-		defer file.Close()
 
-		enc := json.NewEncoder(file)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(plan); err != nil {
+		// Without --format, the file extension picks the encoding (coolpack.yaml)
+		if format == "" || format == "text" {
+			format = app.FormatFromPath(outPath)
+		}
+		out, err := encodePlan(plan, format)
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		if err := os.WriteFile(outPath, out, 0644); err != nil {
 			return fmt.Errorf("failed to write plan: %w", err)
 		}
-		fmt.Printf("Plan written to %s\n", outPath)
+		fmt.Println(msg.T("plan.written", outPath))
+		return nil
+	}
+
+	if planExplain {
+		explainProjectConfig(plan)
+		if format == app.FormatJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Plan    *detector.Plan `json:"plan"`
+				Explain []app.Decision `json:"explain"`
+			}{plan, plan.Decisions})
+		}
+		printPlan(plan)
+		printExplanation(plan)
 		return nil
 	}
 
 	// Output the plan
-	if planOutputJSON {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(plan)
+	if format != "" && format != "text" {
+		out, err := encodePlan(plan, format)
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		_, err = os.Stdout.Write(out)
+		return err
 	}
 
 	// Pretty print the plan
@@ -141,10 +219,6 @@ func planParseEnvVars(envArgs []string) map[string]string {
 
 // applyCustomPackages adds custom APT packages to the plan
 func applyCustomPackages(plan *detector.Plan, packages []string) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
-	}
-
 	// Collect packages from CLI and env
 	var customPackages []string
 
@@ -177,48 +251,136 @@ func applyCustomPackages(plan *detector.Plan, packages []string) {
 		}
 	}
 
-	plan.Metadata["custom_packages"] = unique
+	plan.Metadata.CustomPackages = unique
+	d := app.Decision{Field: "metadata.custom_packages", Value: strings.Join(unique, " "), Source: app.SourceEnv, Env: "COOLPACK_PACKAGES", Reason: "COOLPACK_PACKAGES"}
+	if len(packages) > 0 {
+		d = app.Decision{Field: d.Field, Value: d.Value, Source: app.SourceFlag, Reason: "--packages"}
+	}
+	plan.Explain(d)
+}
+
+// explainProjectConfig attributes decisions taken from variables that
+// applyProjectConfig set to the file they came from
+func explainProjectConfig(plan *detector.Plan) {
+	for i, d := range plan.Decisions {
+		if file, ok := projectConfigEnv[d.Env]; ok && d.Source == app.SourceEnv {
+			plan.Decisions[i].Source = app.SourceConfig
+			plan.Decisions[i].File = file
+			plan.Decisions[i].Reason = fmt.Sprintf("%s in %s", d.Env, file)
+		}
+	}
+}
+
+// printExplanation prints why each plan value was chosen
+func printExplanation(plan *detector.Plan) {
+	if len(plan.Decisions) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println(msg.T("plan.explain") + ":")
+	for _, d := range plan.Decisions {
+		fmt.Printf("  %s: %s\n", d.Field, d.Value)
+		fmt.Printf("      %s: %s\n", d.Source, d.Describe())
+	}
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func printPlan(plan *detector.Plan) {
-	fmt.Println("=== Coolpack Build Plan ===")
+	fmt.Println(msg.T("plan.title"))
 	fmt.Println()
-	fmt.Printf("Provider:                %s\n", plan.Provider)
-	fmt.Printf("Language:                %s\n", plan.Language)
+	printField(msg.T("plan.provider"), plan.Provider)
+	printField(msg.T("plan.language"), plan.Language)
 	if plan.LanguageVersion != "" {
-		fmt.Printf("Language Version:        %s\n", plan.LanguageVersion)
+		printField(msg.T("plan.language_version"), plan.LanguageVersion)
 	}
 	if plan.Framework != "" {
-		fmt.Printf("Framework:               %s\n", plan.Framework)
+		printField(msg.T("plan.framework"), plan.Framework)
 	}
 	if plan.FrameworkVersion != "" {
-		fmt.Printf("Framework Version:       %s\n", plan.FrameworkVersion)
+		printField(msg.T("plan.framework_version"), plan.FrameworkVersion)
 	}
 	if plan.PackageManager != "" {
-		fmt.Printf("Package Manager:         %s\n", plan.PackageManager)
+		printField(msg.T("plan.package_manager"), plan.PackageManager)
 	}
 	if plan.PackageManagerVersion != "" {
-		fmt.Printf("Package Manager Version: %s\n", plan.PackageManagerVersion)
+		printField(msg.T("plan.package_manager_version"), plan.PackageManagerVersion)
 	}
 	if plan.InstallCommand != "" {
-		fmt.Printf("Install Command:         %s\n", plan.InstallCommand)
+		printField(msg.T("plan.install_command"), plan.InstallCommand)
 	}
 	if plan.BuildCommand != "" {
-		fmt.Printf("Build Command:           %s\n", plan.BuildCommand)
+		printField(msg.T("plan.build_command"), plan.BuildCommand)
 	}
 	if plan.StartCommand != "" {
-		fmt.Printf("Start Command:           %s\n", plan.StartCommand)
+		printField(msg.T("plan.start_command"), plan.StartCommand)
+	}
+	if plan.TestCommand != "" {
+		printField(msg.T("plan.test_command"), plan.TestCommand)
+	}
+	if len(plan.Ports) > 0 {
+		ports := make([]string, len(plan.Ports))
+		for i, p := range plan.Ports {
+			ports[i] = fmt.Sprintf("%d", p)
+		}
+		printField(msg.T("plan.ports"), strings.Join(ports, ", "))
+	}
+	if plan.Output != nil {
+		output := plan.Output.Type
+		var details []string
+		if dir := plan.Output.Dir; plan.Output.DirOverride != "" {
+			details = append(details, msg.T("plan.output_override", plan.Output.DirOverride, dir))
+		} else if dir != "" {
+			details = append(details, dir)
+		}
+		if plan.Output.BasePath != "" {
+			details = append(details, msg.T("plan.output_base_path", plan.Output.BasePath))
+		}
+		if len(details) > 0 {
+			output += " (" + strings.Join(details, ", ") + ")"
+		}
+		printField(msg.T("plan.output"), strings.TrimSpace(output))
+	}
+	if plan.SPA != nil && plan.SPA.Enabled {
+		printField(msg.T("plan.spa"), msg.T("plan.spa_enabled", plan.SPA.Reason))
+	}
+	if plan.Monorepo != nil {
+		printField(msg.T("plan.workspaces"), strings.Join(plan.Monorepo.Workspaces, ", "))
+	}
+	if plan.NativeDeps != nil {
+		if len(plan.NativeDeps.Packages) > 0 {
+			printField(msg.T("plan.native_deps"), strings.Join(plan.NativeDeps.Packages, ", "))
+			printField(msg.T("plan.apt_packages"), strings.Join(plan.NativeDeps.AptPackages, ", "))
+		}
+		if len(plan.NativeDeps.PrebuiltBinaries) > 0 {
+			prebuilt := make([]string, len(plan.NativeDeps.PrebuiltBinaries))
+			for i, bin := range plan.NativeDeps.PrebuiltBinaries {
+				prebuilt[i] = bin.Package
+			}
+			printField(msg.T("plan.prebuilt_binaries"), strings.Join(prebuilt, ", "))
+		}
+	}
+	if len(plan.Platforms) > 0 {
+		printField(msg.T("plan.platforms"), strings.Join(plan.Platforms, ", "))
 	}
 	if len(plan.DetectedFiles) > 0 {
 		fmt.Println()
-		fmt.Println("Detected Files:")
+		fmt.Println(msg.T("plan.detected_files") + ":")
 		for _, f := range plan.DetectedFiles {
 			fmt.Printf("  - %s\n", f)
 		}
 	}
 	if len(plan.BuildEnv) > 0 {
 		fmt.Println()
-		fmt.Println("Build Environment:")
+		fmt.Println(msg.T("plan.build_env") + ":")
 		// Sort keys for consistent output
 		keys := make([]string, 0, len(plan.BuildEnv))
 		for k := range plan.BuildEnv {
@@ -229,17 +391,159 @@ func printPlan(plan *detector.Plan) {
 			fmt.Printf("  %s=%s\n", k, plan.BuildEnv[k])
 		}
 	}
-	if len(plan.Metadata) > 0 {
+	if len(plan.Env) > 0 {
 		fmt.Println()
-		fmt.Println("Metadata:")
+		fmt.Println(msg.T("plan.runtime_env") + ":")
+		keys := make([]string, 0, len(plan.Env))
+		for k := range plan.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %s=%s\n", k, plan.Env[k])
+		}
+	}
+	if metadata := plan.Metadata.Map(); len(metadata) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.metadata") + ":")
 		// Sort keys for consistent output
-		keys := make([]string, 0, len(plan.Metadata))
-		for k := range plan.Metadata {
+		keys := make([]string, 0, len(metadata))
+		for k := range metadata {
 			keys = append(keys, k)
 		}
 		sort.Strings(keys)
 		for _, k := range keys {
-			fmt.Printf("  %s: %v\n", k, plan.Metadata[k])
+			fmt.Printf("  %s: %v\n", k, metadata[k])
 		}
 	}
+	if len(plan.Services) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.services") + ":")
+		for _, svc := range plan.Services {
+			fmt.Printf("  %s (%s)", svc.Name, strings.Join(svc.Packages, ", "))
+			if len(svc.Env) > 0 {
+				fmt.Printf(" - env: %s", strings.Join(svc.Env, ", "))
+			}
+			if svc.Optional {
+				fmt.Printf(" [%s]", msg.T("plan.optional"))
+			}
+			fmt.Println()
+		}
+	}
+	if len(plan.Volumes) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.volumes") + ":")
+		for _, v := range plan.Volumes {
+			fmt.Printf("  %s", v.Path)
+			if v.Description != "" {
+				fmt.Printf(" - %s", v.Description)
+			}
+			fmt.Println()
+		}
+	}
+	if len(plan.RequiredEnv) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.required_env") + ":")
+		for _, v := range plan.RequiredEnv {
+			secret := ""
+			if v.Secret {
+				secret = ", " + msg.T("plan.secret")
+			}
+			fmt.Printf("  %s (%s%s) - %s\n", v.Name, v.Phase, secret, v.Description)
+		}
+	}
+	if len(plan.Secrets) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.build_secrets") + ":")
+		for _, secret := range plan.Secrets {
+			fmt.Printf("  %s - %s", secret.ID, secret.Description)
+			if secret.Source != "" {
+				fmt.Printf(" (%s)", secret.Source)
+			}
+			fmt.Println()
+		}
+	}
+	if len(plan.Features) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.features") + ":")
+		for _, f := range plan.Features {
+			if f.Required {
+				fmt.Printf("  - %s (%s)\n", f.Name, msg.T("plan.required"))
+			} else {
+				fmt.Printf("  - %s\n", f.Name)
+			}
+		}
+	}
+	if len(plan.ImageDigests) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.image_digests") + ":")
+		images := make([]string, 0, len(plan.ImageDigests))
+		for image := range plan.ImageDigests {
+			images = append(images, image)
+		}
+		sort.Strings(images)
+		for _, image := range images {
+			fmt.Printf("  %s@%s\n", image, plan.ImageDigests[image])
+		}
+	}
+	if len(plan.Extensions) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.extensions") + ":")
+		names := make([]string, 0, len(plan.Extensions))
+		for name := range plan.Extensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %s: %s\n", name, plan.Extensions[name])
+		}
+	}
+	if len(plan.Warnings) > 0 {
+		fmt.Println()
+		fmt.Println(msg.T("plan.warnings") + ":")
+		for _, w := range plan.Warnings {
+			// Codes stay untranslated; a localized title is shown when the locale has one
+			if key := "warning." + w.Code; msg.Has(key) {
+				fmt.Printf("  - [%s] %s\n    %s\n", w.Code, msg.T(key), w.Message)
+			} else {
+				fmt.Printf("  - [%s] %s\n", w.Code, w.Message)
+			}
+		}
+	}
+}
+
+// encodePlan encodes the plan as JSON, YAML or TOML, or as a nixpacks build plan
+func encodePlan(plan *detector.Plan, format string) ([]byte, error) {
+	if format == "nixpacks" {
+		out, err := json.MarshalIndent(nixpacks.FromPlan(plan), "", "  ")
+		return append(out, '\n'), err
+	}
+	return app.MarshalPlan(plan, format)
+}
+
+// pinImages resolves the images the Dockerfile uses to digests and records them in the plan.
+// Images that can't be resolved (e.g., no network, private registry) are left unpinned with a warning.
+func pinImages(ctx context.Context, plan *detector.Plan) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	resolver := registry.NewResolver()
+
+	for _, image := range generator.New(plan).Images() {
+		digest, err := resolver.ResolveDigest(ctx, image)
+		if err != nil {
+			plan.AddWarning("image_digest_unresolved", fmt.Sprintf("Could not resolve %s to a digest: %v", image, err), "")
+			continue
+		}
+		if plan.ImageDigests == nil {
+			plan.ImageDigests = make(map[string]string)
+		}
+		plan.ImageDigests[image] = digest
+		plan.AddFeature(app.FeatureImageDigests, false)
+	}
+}
+
+// printField prints an aligned "Label: value" line of the plan summary
+func printField(label, value string) {
+	fmt.Printf("%-*s%s\n", max(25, utf8.RuneCountInString(label)+2), label+":", value)
 }
@@ -1,7 +1,6 @@
 package coolpack
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,7 +13,6 @@ import (
 )
 
 var (
-	preparePath         string
 	prepareBuildEnvs    []string
 	prepareInstallCmd   string
 	prepareBuildCmd     string
@@ -23,8 +21,11 @@ var (
 	prepareOutputDir    string
 	prepareSPA          bool
 	prepareNoSPA        bool
+	preparePrecompress  bool
+	prepareRunTests     bool
 	preparePackages     []string
 	preparePlanFile     string
+	preparePlatforms    []string
 )
 
 var prepareCmd = &cobra.Command{
@@ -43,16 +44,19 @@ Environment Variables:
   COOLPACK_START_CMD       Override start command
   COOLPACK_BASE_IMAGE      Override base Docker image (e.g., node:20)
   COOLPACK_NODE_VERSION    Override Node.js version
+  COOLPACK_NODE_DEFAULT    Default Node.js version policy: lts, ecosystem, current or a version
   COOLPACK_STATIC_SERVER   Static file server: caddy (default), nginx
   COOLPACK_SPA_OUTPUT_DIR  Override static output directory (e.g., dist, build)
   COOLPACK_SPA             Enable SPA mode (serves index.html for all routes)
+  COOLPACK_PRECOMPRESS     Precompress static output with brotli/gzip
+  COOLPACK_RUN_TESTS       Run the test command during the build
+  COOLPACK_BUILD_HEAP_SIZE V8 heap limit for the build in MB (0 turns it off)
   COOLPACK_PACKAGES        Additional APT packages (comma-separated)`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runPrepare,
 }
 
 func init() {
-	prepareCmd.Flags().StringVarP(&preparePath, "path", "p", "", "Path to the application (defaults to current directory)")
 	prepareCmd.Flags().StringArrayVar(&prepareBuildEnvs, "build-env", nil, "Build-time environment variables (KEY=value or KEY to use current env)")
 	prepareCmd.Flags().StringVarP(&prepareInstallCmd, "install-cmd", "i", "", "Override install command")
 	prepareCmd.Flags().StringVarP(&prepareBuildCmd, "build-cmd", "b", "", "Override build command")
@@ -61,29 +65,23 @@ func init() {
 	prepareCmd.Flags().StringVar(&prepareOutputDir, "output-dir", "", "Override static output directory (e.g., dist, build, out)")
 	prepareCmd.Flags().BoolVar(&prepareSPA, "spa", false, "Enable SPA mode (serves index.html for all routes)")
 	prepareCmd.Flags().BoolVar(&prepareNoSPA, "no-spa", false, "Disable SPA mode (overrides auto-detection)")
+	prepareCmd.Flags().BoolVar(&preparePrecompress, "precompress", false, "Precompress static output with brotli/gzip during build")
+	prepareCmd.Flags().BoolVar(&prepareRunTests, "run-tests", false, "Run the detected test command after the build (a failing test fails the build)")
+	prepareCmd.Flags().BoolVar(&noPlanCache, "no-cache", false, "Detect again instead of using a cached plan")
 	prepareCmd.Flags().StringArrayVar(&preparePackages, "packages", nil, "Additional APT packages to install (e.g., curl, wget)")
 	prepareCmd.Flags().StringVar(&preparePlanFile, "plan", "", "Use plan file instead of detection (e.g., coolpack.json)")
+	prepareCmd.Flags().StringSliceVar(&preparePlatforms, "platform", nil, "Target platforms (e.g., linux/amd64,linux/arm64)")
 }
 
 func runPrepare(cmd *cobra.Command, args []string) error {
-	// Determine the path to analyze
-	path := "."
-	if len(args) > 0 {
-		path = args[0]
-	}
-	if preparePath != "" {
-		path = preparePath
-	}
-
-	// Convert to absolute path
-	absPath, err := filepath.Abs(path)
+	absPath, err := appPath(args)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
+		return err
 	}
 
-	// Check if path exists
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		return fmt.Errorf("path does not exist: %s", absPath)
+	// Project settings from coolpack.toml (CLI > env > coolpack.toml > detected)
+	if err := applyProjectConfig(absPath); err != nil {
+		return err
 	}
 
 	var plan *app.Plan
@@ -99,23 +97,23 @@ func runPrepare(cmd *cobra.Command, args []string) error {
 
 	if planFile != "" {
 		// Load plan from file
-		fmt.Printf("Using plan file: %s\n", planFile)
+		progressln(msg.T("using_plan_file", planFile))
 		var err error
 		plan, err = prepareLoadPlanFromFile(planFile)
 		if err != nil {
-			return fmt.Errorf("failed to load plan file: %w", err)
+			return errPlanInvalid(cmd, fmt.Errorf("failed to load plan file: %w", err))
 		}
 	} else {
 		// Run detection
-		d := detector.New(absPath)
+		d := newDetector(absPath)
 		var err error
 		plan, err = d.Detect()
 		if err != nil {
-			return fmt.Errorf("detection failed: %w", err)
+			return errDetection(cmd, err)
 		}
 
 		if plan == nil {
-			return fmt.Errorf("no supported application detected")
+			return errNoProvider(cmd)
 		}
 	}
 
@@ -128,16 +126,26 @@ func runPrepare(cmd *cobra.Command, args []string) error {
 	// Apply SPA setting (CLI > env > auto-detected)
 	prepareApplySPASetting(plan, prepareSPA, prepareNoSPA)
 
+	// Apply precompression setting (CLI > env > default off)
+	prepareApplyPrecompressSetting(plan, preparePrecompress)
+
+	// Apply the test phase setting (CLI > env > default off)
+	prepareApplyRunTestsSetting(plan, prepareRunTests)
+
 	// Apply output directory override (CLI > env > framework default)
 	prepareApplyOutputDirSetting(plan, prepareOutputDir)
 
 	// Apply custom packages (CLI > env > detected)
 	prepareApplyCustomPackages(plan, preparePackages)
 
+	// Apply target platforms (CLI > env > plan)
+	prepareApplyPlatforms(plan, preparePlatforms)
+
 	// Parse build environment variables
 	envMap := prepareParseEnvVars(prepareBuildEnvs)
-	if len(envMap) > 0 {
-		plan.BuildEnv = envMap
+	applyBuildEnv(plan, envMap)
+	if planFile == "" {
+		applyNixpacksVariables(plan, absPath)
 	}
 
 	// Create .coolpack directory
@@ -159,7 +167,7 @@ func runPrepare(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to write Dockerfile: %w", err)
 	}
 
-	fmt.Printf("Generated files in %s:\n", coolpackDir)
+	fmt.Println(msg.T("prepare.generated", coolpackDir))
 	fmt.Printf("  - Dockerfile\n")
 
 	return nil
@@ -210,74 +218,84 @@ func prepareApplyCommandOverrides(plan *detector.Plan, installCmd, buildCmd, sta
 // prepareApplyStaticServerSetting applies static server setting from CLI or env var
 // Priority: CLI flag > Environment variable > default (caddy)
 func prepareApplyStaticServerSetting(plan *detector.Plan, staticServer string) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
-	}
-
 	if staticServer != "" {
-		plan.Metadata["static_server"] = staticServer
+		plan.Metadata.StaticServer = staticServer
 	} else if env := os.Getenv("COOLPACK_STATIC_SERVER"); env != "" {
-		plan.Metadata["static_server"] = env
+		plan.Metadata.StaticServer = env
 	}
 	// Default is "caddy" which is handled in generator
 }
 
+// prepareApplyPlatforms sets the target platforms from CLI or env var
+// Priority: --platform > COOLPACK_PLATFORMS > plan
+func prepareApplyPlatforms(plan *detector.Plan, platforms []string) {
+	if len(platforms) > 0 {
+		plan.Platforms = app.ParsePlatforms(strings.Join(platforms, ","))
+	} else if env := os.Getenv("COOLPACK_PLATFORMS"); env != "" {
+		plan.Platforms = app.ParsePlatforms(env)
+	}
+}
+
+// prepareApplyPrecompressSetting enables brotli/gzip precompression of static output from CLI or env var
+// Priority: --precompress > COOLPACK_PRECOMPRESS > detected
+func prepareApplyPrecompressSetting(plan *detector.Plan, precompress bool) {
+	if precompress {
+		plan.Metadata.Precompress = true
+	} else if env := os.Getenv("COOLPACK_PRECOMPRESS"); env == "true" || env == "1" {
+		plan.Metadata.Precompress = true
+	}
+}
+
+// prepareApplyRunTestsSetting runs the detected test command during the build from CLI or env var
+// Priority: --run-tests > COOLPACK_RUN_TESTS > default off
+func prepareApplyRunTestsSetting(plan *detector.Plan, runTests bool) {
+	if runTests {
+		plan.Metadata.RunTests = true
+	} else if env := os.Getenv("COOLPACK_RUN_TESTS"); env == "true" || env == "1" {
+		plan.Metadata.RunTests = true
+	}
+}
+
 // prepareApplySPASetting applies SPA setting from CLI or env var
 // Priority: --no-spa/COOLPACK_NO_SPA > --spa/COOLPACK_SPA > auto-detected
 func prepareApplySPASetting(plan *detector.Plan, spa bool, noSPA bool) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
-	}
-
 	// --no-spa and COOLPACK_NO_SPA take highest priority
 	if noSPA {
-		delete(plan.Metadata, "is_spa")
+		plan.SPA = nil
 		return
 	}
 	if env := os.Getenv("COOLPACK_NO_SPA"); env == "true" || env == "1" {
-		delete(plan.Metadata, "is_spa")
+		plan.SPA = nil
 		return
 	}
 
 	if spa {
-		plan.Metadata["is_spa"] = true
+		plan.SPA = &app.SPAInfo{Enabled: true, Reason: "--spa"}
 	} else if env := os.Getenv("COOLPACK_SPA"); env == "true" || env == "1" {
-		plan.Metadata["is_spa"] = true
+		plan.SPA = &app.SPAInfo{Enabled: true, Reason: "COOLPACK_SPA"}
 	}
-	// Auto-detected value is already in metadata from provider
+	// Auto-detected value is already in the plan from provider
 }
 
 // prepareApplyOutputDirSetting applies output directory override from CLI or env var
 // Priority: CLI flag > Environment variable > framework default (handled in generator)
 func prepareApplyOutputDirSetting(plan *detector.Plan, outputDir string) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
+	if outputDir == "" {
+		outputDir = os.Getenv("COOLPACK_SPA_OUTPUT_DIR")
 	}
-
-	if outputDir != "" {
-		plan.Metadata["output_dir_override"] = outputDir
-	} else if env := os.Getenv("COOLPACK_SPA_OUTPUT_DIR"); env != "" {
-		plan.Metadata["output_dir_override"] = env
+	if outputDir == "" {
+		return
+	}
+	if plan.Output == nil {
+		plan.Output = &app.OutputInfo{}
 	}
+	plan.Output.DirOverride = outputDir
 }
 
 // prepareApplyCustomPackages adds custom APT packages to the plan (merges with existing)
 func prepareApplyCustomPackages(plan *detector.Plan, packages []string) {
-	if plan.Metadata == nil {
-		plan.Metadata = make(map[string]interface{})
-	}
-
 	// Start with existing custom packages from plan file
-	var customPackages []string
-	if existing, ok := plan.Metadata["custom_packages"].([]interface{}); ok {
-		for _, pkg := range existing {
-			if s, ok := pkg.(string); ok {
-				customPackages = append(customPackages, s)
-			}
-		}
-	} else if existing, ok := plan.Metadata["custom_packages"].([]string); ok {
-		customPackages = append(customPackages, existing...)
-	}
+	customPackages := append([]string(nil), plan.Metadata.CustomPackages...)
 
 	// Add CLI packages
 	if len(packages) > 0 {
@@ -308,20 +326,30 @@ func prepareApplyCustomPackages(plan *detector.Plan, packages []string) {
 		}
 	}
 
-	plan.Metadata["custom_packages"] = unique
+	plan.Metadata.CustomPackages = unique
 }
 
-// prepareLoadPlanFromFile loads a build plan from a JSON file
+// prepareLoadPlanFromFile loads a build plan from a JSON, YAML or TOML file (by extension)
 func prepareLoadPlanFromFile(path string) (*app.Plan, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var plan app.Plan
-	if err := json.Unmarshal(data, &plan); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	plan, err := app.UnmarshalPlan(data, app.FormatFromPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	// Extensions registered by an embedding platform must match their types
+	if err := plan.ValidateExtensions(); err != nil {
+		return nil, err
+	}
+
+	// Plans from a newer coolpack may rely on features this version can't build
+	if _, err := plan.NegotiateFeatures(app.KnownFeatures()); err != nil {
+		return nil, err
 	}
 
-	return &plan, nil
+	return plan, nil
 }
@@ -0,0 +1,64 @@
+package coolpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/detector"
+	"github.com/spf13/cobra"
+)
+
+var (
+	providersJSON   bool
+	providersFormat string
+)
+
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "List the providers coolpack detects applications with",
+	Long: `List the registered providers in detection order: the languages they plan,
+the files that select them, the package managers they detect and the version of
+their detection logic. The detection version increases when a release plans an
+existing application differently, so platforms can tell when to re-plan.
+
+See coolpack frameworks for the frameworks each provider detects.`,
+	Example: `  coolpack providers
+  coolpack providers --json`,
+	Args: cobra.NoArgs,
+	RunE: runProviders,
+}
+
+func init() {
+	providersCmd.Flags().BoolVar(&providersJSON, "json", false, "Output as JSON (same as --format json)")
+	providersCmd.Flags().StringVar(&providersFormat, "format", "", "Output format: text (default) or json")
+}
+
+func runProviders(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(providersFormat, providersJSON)
+	if err != nil {
+		return err
+	}
+
+	providers := detector.New(".").Providers()
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(providers)
+	}
+
+	for i, p := range providers {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (detection v%d)\n", p.Name, p.DetectionVersion)
+		fmt.Printf("  %-18s %s\n", "Languages:", strings.Join(p.Languages, ", "))
+		fmt.Printf("  %-18s %s\n", "Detected by:", strings.Join(p.DetectFiles, ", "))
+		if len(p.PackageManagers) > 0 {
+			fmt.Printf("  %-18s %s\n", "Package managers:", strings.Join(p.PackageManagers, ", "))
+		}
+		fmt.Printf("  %-18s %d\n", "Frameworks:", len(p.Frameworks))
+	}
+	return nil
+}
@@ -2,11 +2,32 @@ package coolpack
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/coollabsio/coolpack/pkg/detector"
+	"github.com/coollabsio/coolpack/pkg/i18n"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// msg translates user-facing messages (COOLPACK_LANG/LANG, or --lang)
+	msg = i18n.New(i18n.DetectLocale())
+
+	rootLang      string
+	rootPath      string
+	rootQuiet     bool
+	rootVerbose   bool
+	rootDebug     bool
+	rootLogFormat string
+	rootEnvFiles  []string
+
+	rootDetectTimeout time.Duration
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "coolpack",
 	Short: "A general purpose build pack for applications",
@@ -16,26 +37,175 @@ generates Dockerfiles, and builds container images.
 Currently supports:
   - Node.js (npm, yarn, pnpm, bun)
 
+Global flags (--path, --quiet, --lang, --verbose, --debug, --log-format,
+--env-file, --detect-timeout) work with every command; commands that print a
+result take --format text|json (--json is short for --format json). Logs go
+to stderr.
+
+Exit codes:
+  0  Success
+  1  Other errors (invalid flags or arguments, I/O, lint findings)
+  2  No provider matched the application
+  3  Detection error (a provider failed to analyze the application)
+  4  Plan invalid (a plan file can't be loaded or fails validation)
+  5  Build failed
+
 Environment Variables:
   COOLPACK_INSTALL_CMD     Override install command
   COOLPACK_BUILD_CMD       Override build command
   COOLPACK_START_CMD       Override start command
+  COOLPACK_FRAMEWORK       Override the detected framework (e.g., vite, or none)
+  COOLPACK_PROVIDERS       Only try these providers, in order (e.g., node)
+  COOLPACK_DISABLE_PROVIDERS  Never try these providers
+  COOLPACK_NO_CACHE        Don't use cached plans (true or 1)
+  COOLPACK_DETECT_TIMEOUT  Detection time limit (e.g., 30s; default 1m, 0 for none)
+  COOLPACK_CASE_INSENSITIVE  Match detection files regardless of case (default on macOS and Windows)
   COOLPACK_BASE_IMAGE      Override base Docker image (e.g., node:20-alpine)
   COOLPACK_NODE_VERSION    Override Node.js version
-  COOLPACK_STATIC_SERVER   Static file server: caddy (default), nginx`,
+  COOLPACK_NODE_DEFAULT    Default Node.js version policy: lts, ecosystem, current or a version
+  COOLPACK_BUILD_HEAP_SIZE V8 heap limit for the build in MB (0 turns it off)
+  COOLPACK_STATIC_SERVER   Static file server: caddy (default), nginx
+  COOLPACK_LANG            Language of CLI messages (en, de, es, fr)`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if rootLang != "" {
+			msg = i18n.New(rootLang)
+		}
+		if err := setupLogging(); err != nil {
+			return err
+		}
+		if err := applyEnvFiles(rootEnvFiles); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		// COOLPACK_DETECT_TIMEOUT applies unless the flag is given (an env file may set it)
+		if env := os.Getenv("COOLPACK_DETECT_TIMEOUT"); env != "" && !cmd.Flags().Changed("detect-timeout") {
+			timeout, err := time.ParseDuration(env)
+			if err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("invalid COOLPACK_DETECT_TIMEOUT %q (use a duration such as 30s or 2m): %w", env, err)
+			}
+			rootDetectTimeout = timeout
+		}
+		return nil
+	},
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&rootLang, "lang", "", "Language of CLI messages (e.g., de, es, fr); defaults to COOLPACK_LANG or LANG")
+	rootCmd.PersistentFlags().StringVarP(&rootPath, "path", "p", "", "Path to the application (defaults to current directory)")
+	rootCmd.PersistentFlags().BoolVarP(&rootQuiet, "quiet", "q", false, "Only print results, warnings and errors")
+	rootCmd.PersistentFlags().BoolVarP(&rootVerbose, "verbose", "v", false, "Log detection steps and skipped files to stderr")
+	rootCmd.PersistentFlags().BoolVar(&rootDebug, "debug", false, "Log every detection decision to stderr (implies --verbose)")
+	rootCmd.PersistentFlags().StringVar(&rootLogFormat, "log-format", "text", "Log format: text or json")
+	rootCmd.PersistentFlags().DurationVar(&rootDetectTimeout, "detect-timeout", detector.DefaultTimeout, "Give up on detection after this long (0 for no limit)")
+	rootCmd.PersistentFlags().StringArrayVar(&rootEnvFiles, "env-file", nil, "Read variables from an env file for detection and build arguments (repeatable; the environment takes precedence)")
+
+	rootCmd.AddCommand(detectCmd)
+	rootCmd.AddCommand(providersCmd)
+	rootCmd.AddCommand(frameworksCmd)
+
 	rootCmd.AddCommand(planCmd)
 	rootCmd.AddCommand(prepareCmd)
 	rootCmd.AddCommand(buildCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(dataCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(newCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(initCmd)
+}
+
+// setupLogging sends the log to stderr: warnings (e.g., a failing provider) by
+// default, detection steps and skipped files with --verbose, every decision
+// with --debug
+func setupLogging() error {
+	level := slog.LevelWarn
+	switch {
+	case rootDebug:
+		level = slog.LevelDebug
+	case rootVerbose:
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch rootLogFormat {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q (use text or json)", rootLogFormat)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// appPath returns the absolute path of the application: --path, the path
+// argument, or the current directory
+func appPath(args []string) (string, error) {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if rootPath != "" {
+		path = rootPath
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("path does not exist: %s", absPath)
+	}
+	return absPath, nil
+}
+
+// outputFormat returns the output format of a command that prints text or JSON,
+// from --format and the --json shorthand
+func outputFormat(format string, asJSON bool) (string, error) {
+	if asJSON {
+		return "json", nil
+	}
+	switch format {
+	case "", "text":
+		return "text", nil
+	case "json":
+		return "json", nil
+	default:
+		return "", fmt.Errorf("unknown format %q (use text or json)", format)
+	}
+}
+
+// progressf prints a progress message, unless --quiet is set
+func progressf(format string, a ...interface{}) {
+	if !rootQuiet {
+		fmt.Printf(format, a...)
+	}
+}
+
+// progressln prints a progress line, unless --quiet is set
+func progressln(a ...interface{}) {
+	if !rootQuiet {
+		fmt.Println(a...)
+	}
+}
+
+// progressWriter returns where detailed progress goes: stdout, or nowhere with --quiet
+func progressWriter() io.Writer {
+	if rootQuiet {
+		return io.Discard
+	}
+	return os.Stdout
 }
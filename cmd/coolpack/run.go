@@ -7,12 +7,12 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/coollabsio/coolpack/pkg/app"
 	"github.com/coollabsio/coolpack/pkg/detector"
 	"github.com/spf13/cobra"
 )
 
 var (
-	runPath      string
 	runImageName string
 	runTag       string
 	runEnvVars   []string
@@ -42,7 +42,6 @@ Run the built container image in development mode with:
 }
 
 func init() {
-	runCmd.Flags().StringVarP(&runPath, "path", "p", "", "Path to the application (defaults to current directory)")
 	runCmd.Flags().StringVarP(&runImageName, "name", "n", "", "Image name (defaults to directory name)")
 	runCmd.Flags().StringVarP(&runTag, "tag", "t", "latest", "Image tag")
 	runCmd.Flags().StringArrayVarP(&runEnvVars, "env", "e", nil, "Environment variables (KEY=value)")
@@ -50,31 +49,16 @@ func init() {
 
 func runRun(cmd *cobra.Command, args []string) error {
 	// Print warning
-	fmt.Println()
-	fmt.Println("╔══════════════════════════════════════════════════════════════════════════════╗")
-	fmt.Println("║                              ⚠️  WARNING ⚠️                                   ║")
-	fmt.Println("║         This is for DEVELOPMENT ONLY - Do NOT use in production!            ║")
-	fmt.Println("╚══════════════════════════════════════════════════════════════════════════════╝")
-	fmt.Println()
-
-	// Determine the path to analyze
-	path := "."
-	if len(args) > 0 {
-		path = args[0]
-	}
-	if runPath != "" {
-		path = runPath
-	}
-
-	// Convert to absolute path
-	absPath, err := filepath.Abs(path)
+	progressln()
+	progressln("╔══════════════════════════════════════════════════════════════════════════════╗")
+	progressln("║                              ⚠️  WARNING ⚠️                                   ║")
+	progressln("║         This is for DEVELOPMENT ONLY - Do NOT use in production!            ║")
+	progressln("╚══════════════════════════════════════════════════════════════════════════════╝")
+	progressln()
+
+	absPath, err := appPath(args)
 	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
-	}
-
-	// Check if path exists
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		return fmt.Errorf("path does not exist: %s", absPath)
+		return err
 	}
 
 	// Determine image name
@@ -88,41 +72,45 @@ func runRun(cmd *cobra.Command, args []string) error {
 	fullImageName := fmt.Sprintf("%s:%s", imageName, runTag)
 
 	// Run detection to get output type for port
-	d := detector.New(absPath)
+	d := newDetector(absPath)
 	plan, err := d.Detect()
 	if err != nil {
-		return fmt.Errorf("detection failed: %w", err)
+		return errDetection(cmd, err)
 	}
 
 	if plan == nil {
-		return fmt.Errorf("no supported application detected")
+		return errNoProvider(cmd)
 	}
 
-	// Determine port based on output type
-	port := "3000"
-	if ot, ok := plan.Metadata["output_type"].(string); ok && ot == "static" {
-		port = "80"
-	}
+	// Determine port based on detected ports and output type
+	port := planPort(plan)
 
 	// Build docker run arguments
 	dockerArgs := []string{"run", "--rm", "-it", "-p", fmt.Sprintf("%s:%s", port, port)}
 
-	// Add environment variables
+	// Add environment variables, and pass required runtime variables set in the environment
+	provided := make(map[string]string, len(runEnvVars))
 	for _, env := range runEnvVars {
 		dockerArgs = append(dockerArgs, "-e", env)
+		name, _, _ := strings.Cut(env, "=")
+		provided[name] = ""
+	}
+	for _, name := range passthroughEnv(plan, app.PhaseRuntime, provided) {
+		runEnvVars = append(runEnvVars, name)
+		dockerArgs = append(dockerArgs, "-e", name)
 	}
 
 	dockerArgs = append(dockerArgs, fullImageName)
 
 	// Run docker
 	if len(runEnvVars) > 0 {
-		fmt.Printf("Running: docker run --rm -it -p %s:%s", port, port)
+		progressf("Running: docker run --rm -it -p %s:%s", port, port)
 		for _, env := range runEnvVars {
-			fmt.Printf(" -e %s", env)
+			progressf(" -e %s", env)
 		}
-		fmt.Printf(" %s\n\n", fullImageName)
+		progressf(" %s\n\n", fullImageName)
 	} else {
-		fmt.Printf("Running: docker run --rm -it -p %s:%s %s\n\n", port, port, fullImageName)
+		progressf("Running: docker run --rm -it -p %s:%s %s\n\n", port, port, fullImageName)
 	}
 
 	dockerCmd := exec.Command("docker", dockerArgs...)
@@ -136,3 +124,14 @@ func runRun(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// planPort returns the primary port of the application described by the plan
+func planPort(plan *detector.Plan) string {
+	if plan.OutputType() == app.OutputTypeStatic {
+		return "80"
+	}
+	if len(plan.Ports) > 0 && plan.Ports[0] > 0 {
+		return fmt.Sprintf("%d", plan.Ports[0])
+	}
+	return "3000"
+}
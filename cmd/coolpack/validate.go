@@ -0,0 +1,109 @@
+package coolpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateJSON   bool
+	validateFormat string
+	validateSchema bool
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [plan-file]",
+	Short: "Check a plan file for structural errors",
+	Long: `Check a plan file (JSON, YAML or TOML, by extension; defaults to coolpack.json in --path)
+against the plan JSON Schema before building: unknown fields, wrong types and
+missing required fields are reported with their JSON pointer. Plans that pass are
+also checked for invalid extensions and required features this version of coolpack
+doesn't support.
+
+--schema prints the JSON Schema instead, for editors and external tools. It is
+also published with every release as plan.schema.json.`,
+	Example: `  coolpack validate
+  coolpack validate plan.yaml
+  coolpack validate --schema > plan.schema.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runValidate,
+}
+
+func init() {
+	validateCmd.Flags().BoolVar(&validateJSON, "json", false, "Output the result as JSON (same as --format json)")
+	validateCmd.Flags().StringVar(&validateFormat, "format", "", "Output format: text (default) or json")
+	validateCmd.Flags().BoolVar(&validateSchema, "schema", false, "Print the plan JSON Schema")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	format, err := outputFormat(validateFormat, validateJSON)
+	if err != nil {
+		return err
+	}
+
+	if validateSchema {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(app.PlanSchema())
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		absPath, err := appPath(nil)
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(absPath, "coolpack.json")
+	}
+
+	// A missing plan file is an invalid plan, as for build --plan
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errPlanInvalid(cmd, fmt.Errorf("failed to read file: %w", err))
+	}
+
+	var errs []app.SchemaError
+	doc, err := app.DecodePlanDocument(data, app.FormatFromPath(path))
+	if err != nil {
+		errs = append(errs, app.SchemaError{Message: err.Error()})
+	} else if errs = app.ValidatePlanDocument(doc); len(errs) == 0 {
+		// Structurally valid; check extensions and features as build does
+		if _, err := loadPlanFromFile(path); err != nil {
+			errs = append(errs, app.SchemaError{Message: err.Error()})
+		}
+	}
+
+	if format == "json" {
+		result := struct {
+			File   string            `json:"file"`
+			Valid  bool              `json:"valid"`
+			Errors []app.SchemaError `json:"errors"`
+		}{path, len(errs) == 0, errs}
+		if result.Errors == nil {
+			result.Errors = []app.SchemaError{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else if len(errs) == 0 {
+		progressf("%s is a valid plan.\n", path)
+	} else {
+		for _, e := range errs {
+			fmt.Printf("%s: %s\n", path, e.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errPlanInvalid(cmd, fmt.Errorf("validation failed: %d errors", len(errs)))
+	}
+	return nil
+}
@@ -1,16 +1,91 @@
 package coolpack
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
 
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/detector"
 	"github.com/coollabsio/coolpack/pkg/version"
 	"github.com/spf13/cobra"
 )
 
+var (
+	versionReleaseNotes bool
+	versionChannel      string
+	versionJSON         bool
+	versionFormat       string
+)
+
+// versionInfo is the build metadata printed by version --json, to record
+// which coolpack and detection logic produced a plan
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	// PlanSchema is the $id of the plan schema and PlanSchemaVersion the
+	// version of the plan format
+	PlanSchema        string `json:"plan_schema"`
+	PlanSchemaVersion int    `json:"plan_schema_version"`
+	// Providers are the registered providers in detection order
+	Providers []providerVersion `json:"providers"`
+}
+
+// providerVersion is a provider's detection version and those of its frameworks
+type providerVersion struct {
+	Name             string         `json:"name"`
+	DetectionVersion int            `json:"detection_version"`
+	Frameworks       map[string]int `json:"frameworks,omitempty"`
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Print version information and check GitHub for a newer release.
+
+The stable channel only offers full releases; the beta channel also offers
+pre-releases, which ship framework detection fixes early.
+
+With --json, the build metadata is printed as JSON (version, commit, build
+date, Go version, plan schema version and the detection versions of providers
+and frameworks) without checking for updates.
+
+Environment Variables:
+  COOLPACK_CHANNEL         Release channel to check: stable (default), beta
+  GITHUB_TOKEN             Token for the GitHub API (raises the rate limit)
+  COOLPACK_RELEASES_URL    Releases API to check instead of GitHub's (GitHub
+                           Enterprise or an internal mirror serving
+                           <url>/releases/latest and <url>/releases)
+  HTTPS_PROXY, NO_PROXY    Proxy settings for the check`,
+	Example: `  coolpack version
+  coolpack version --channel beta
+  coolpack version --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(versionFormat, versionJSON)
+		if err != nil {
+			return err
+		}
+		if format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(buildVersionInfo())
+		}
+
+		// COOLPACK_CHANNEL applies unless --channel is given (an env file may set it)
+		if env := os.Getenv("COOLPACK_CHANNEL"); env != "" && !cmd.Flags().Changed("channel") {
+			versionChannel = env
+		}
+		if !slices.Contains(version.Channels, versionChannel) {
+			return fmt.Errorf("unknown release channel %q (use %s)", versionChannel, strings.Join(version.Channels, " or "))
+		}
+
 		fmt.Printf("coolpack %s\n", version.Version)
 		if version.Commit != "none" {
 			fmt.Printf("  commit: %s\n", version.Commit)
@@ -20,6 +95,39 @@ var versionCmd = &cobra.Command{
 		}
 
 		// Check for updates
-		version.CheckForUpdate()
+		version.CheckForUpdate(versionChannel, versionReleaseNotes)
+		return nil
 	},
 }
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Output build metadata as JSON (same as --format json)")
+	versionCmd.Flags().StringVar(&versionFormat, "format", "", "Output format: text (default) or json")
+	versionCmd.Flags().StringVar(&versionChannel, "channel", version.ChannelStable, "Release channel to check: stable or beta (pre-releases)")
+	versionCmd.Flags().BoolVar(&versionReleaseNotes, "release-notes", false, "Show the release notes of the releases since the current version")
+}
+
+// buildVersionInfo collects the build metadata of this binary
+func buildVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:           version.Version,
+		Commit:            version.Commit,
+		Date:              version.Date,
+		GoVersion:         runtime.Version(),
+		Platform:          runtime.GOOS + "/" + runtime.GOARCH,
+		PlanSchema:        app.SchemaID,
+		PlanSchemaVersion: app.SchemaVersion,
+		Providers:         []providerVersion{},
+	}
+	for _, p := range detector.New(".").Providers() {
+		pv := providerVersion{Name: p.Name, DetectionVersion: p.DetectionVersion}
+		for _, f := range p.Frameworks {
+			if pv.Frameworks == nil {
+				pv.Frameworks = make(map[string]int)
+			}
+			pv.Frameworks[f.Name] = f.DetectionVersion
+		}
+		info.Providers = append(info.Providers, pv)
+	}
+	return info
+}
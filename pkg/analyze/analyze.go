@@ -0,0 +1,250 @@
+// Package analyze reports what makes a built image large and suggests plan
+// changes to shrink it. Plans are analyzed statically; images are analyzed
+// from their layer history and the paths found in the runtime filesystem.
+package analyze
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/coollabsio/coolpack/pkg/generator"
+)
+
+// Finding codes
+const (
+	CodeDevDependencies = "dev_dependencies"
+	CodeCache           = "cache"
+	CodeSourceCopied    = "source_copied"
+	CodeStandalone      = "nextjs_standalone"
+	CodeAlpine          = "alpine_base"
+	CodeBrowser         = "browser_runtime"
+)
+
+// Finding is something that makes the image larger than it needs to be
+type Finding struct {
+	// Code identifies the kind of finding (e.g., "cache")
+	Code string `json:"code"`
+
+	// Message describes what was found
+	Message string `json:"message"`
+
+	// Suggestion is the change that shrinks the image
+	Suggestion string `json:"suggestion"`
+
+	// Path is the path in the image, for image findings
+	Path string `json:"path,omitempty"`
+
+	// Size is the size in bytes, when known
+	Size int64 `json:"size,omitempty"`
+}
+
+// Layer is an image layer from `docker history`
+type Layer struct {
+	Size      int64  `json:"size"`
+	CreatedBy string `json:"created_by"`
+}
+
+// Report is the result of an analysis
+type Report struct {
+	// Image is the analyzed image, empty for plan-only analysis
+	Image string `json:"image,omitempty"`
+
+	// Size is the total image size in bytes
+	Size int64 `json:"size,omitempty"`
+
+	Layers   []Layer   `json:"layers,omitempty"`
+	Findings []Finding `json:"findings"`
+}
+
+// Probe is a path checked in the runtime image
+type Probe struct {
+	Path string
+	Code string
+	What string
+}
+
+// Probes are the paths that shouldn't be in a runtime image. Dev-only packages
+// indicate devDependencies weren't pruned; caches are build leftovers.
+var Probes = []Probe{
+	{Path: "/app/.next/cache", Code: CodeCache, What: "Next.js build cache"},
+	{Path: "/app/node_modules/.cache", Code: CodeCache, What: "tool cache in node_modules (babel, eslint, terser)"},
+	{Path: "/app/.turbo", Code: CodeCache, What: "Turborepo cache"},
+	{Path: "/app/.svelte-kit", Code: CodeCache, What: "SvelteKit build intermediates"},
+	{Path: "/root/.npm", Code: CodeCache, What: "npm cache"},
+	{Path: "/root/.cache", Code: CodeCache, What: "user cache directory"},
+	{Path: "/usr/local/share/.cache/yarn", Code: CodeCache, What: "yarn cache"},
+	{Path: "/root/.local/share/pnpm", Code: CodeCache, What: "pnpm store"},
+	{Path: "/app/node_modules/typescript", Code: CodeDevDependencies, What: "typescript"},
+	{Path: "/app/node_modules/eslint", Code: CodeDevDependencies, What: "eslint"},
+	{Path: "/app/node_modules/prettier", Code: CodeDevDependencies, What: "prettier"},
+	{Path: "/app/node_modules/jest", Code: CodeDevDependencies, What: "jest"},
+	{Path: "/app/node_modules/vitest", Code: CodeDevDependencies, What: "vitest"},
+	{Path: "/app/node_modules/@types", Code: CodeDevDependencies, What: "@types packages"},
+	{Path: "/app/node_modules/@testing-library", Code: CodeDevDependencies, What: "@testing-library packages"},
+	{Path: "/app/node_modules/cypress", Code: CodeDevDependencies, What: "cypress"},
+}
+
+// ProbeScript returns a shell script that prints "<kilobytes>\t<path>" for each probe that exists
+func ProbeScript() string {
+	paths := make([]string, len(Probes))
+	for i, p := range Probes {
+		paths[i] = p.Path
+	}
+	return fmt.Sprintf(`for p in %s; do [ -e "$p" ] && du -sk "$p"; done; true`, strings.Join(paths, " "))
+}
+
+// ParseProbeOutput turns ProbeScript output into findings
+func ParseProbeOutput(output string, plan *app.Plan) []Finding {
+	byPath := make(map[string]Probe, len(Probes))
+	for _, p := range Probes {
+		byPath[p.Path] = p
+	}
+
+	var findings []Finding
+	for _, line := range strings.Split(output, "\n") {
+		size, path, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		probe, known := byPath[path]
+		kb, err := strconv.ParseInt(size, 10, 64)
+		if !known || err != nil {
+			continue
+		}
+
+		finding := Finding{Code: probe.Code, Path: path, Size: kb * 1024}
+		switch probe.Code {
+		case CodeCache:
+			finding.Message = fmt.Sprintf("%s is in the runtime image", probe.What)
+			// Coolpack builds mount known caches, so these usually come from the build context
+			finding.Suggestion = fmt.Sprintf("Add %s to .dockerignore; if the build writes it, list it in cacheDirectories in package.json so it's a cache mount", path)
+		case CodeDevDependencies:
+			finding.Message = fmt.Sprintf("%s (usually a devDependency) is in the runtime image", probe.What)
+			finding.Suggestion = devDependencySuggestion(plan)
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+// ParseHistory parses `docker history --human=false --no-trunc --format '{{.Size}}\t{{.CreatedBy}}'` output
+func ParseHistory(output string) []Layer {
+	var layers []Layer
+	for _, line := range strings.Split(output, "\n") {
+		size, createdBy, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		if err != nil {
+			continue
+		}
+		layers = append(layers, Layer{Size: n, CreatedBy: strings.TrimSpace(createdBy)})
+	}
+	return layers
+}
+
+// LargestLayers returns the n largest layers
+func LargestLayers(layers []Layer, n int) []Layer {
+	sorted := append([]Layer(nil), layers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Size > sorted[j].Size
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// AnalyzePlan returns the size findings that follow from a plan alone
+func AnalyzePlan(plan *app.Plan) []Finding {
+	var findings []Finding
+	server := plan.OutputType() == "server"
+
+	if reason := plan.Metadata.PruneSkippedReason; reason != "" {
+		findings = append(findings, Finding{
+			Code:       CodeDevDependencies,
+			Message:    fmt.Sprintf("devDependencies aren't pruned: %s", reason),
+			Suggestion: devDependencySuggestion(plan),
+		})
+	}
+
+	// Apps without a build step run from their sources, so copying them is expected
+	if server && plan.BuildCommand != "" && (plan.Output == nil || plan.Output.Dir == "") {
+		findings = append(findings, Finding{
+			Code:       CodeSourceCopied,
+			Message:    "The build output directory isn't known, so the whole app directory (sources, tests, configs) is copied into the runtime image",
+			Suggestion: "Add sources and files the server doesn't need at runtime (tests, docs, src/ for compiled apps) to .dockerignore",
+		})
+	}
+
+	if server && plan.Framework == "nextjs" {
+		findings = append(findings, Finding{
+			Code:       CodeStandalone,
+			Message:    "Next.js runs from .next with the full node_modules",
+			Suggestion: "Set output: 'standalone' in next.config, so Next.js traces the files the server needs into .next/standalone",
+		})
+	}
+
+	if base := generator.New(plan).Images(); server && len(base) > 0 && !strings.Contains(base[0], "alpine") {
+		hasNative := plan.NativeDeps != nil && (len(plan.NativeDeps.Packages) > 0 || len(plan.NativeDeps.PrebuiltBinaries) > 0)
+		if plan.Provider == "node" && plan.PackageManager != "bun" && !hasNative {
+			findings = append(findings, Finding{
+				Code:       CodeAlpine,
+				Message:    fmt.Sprintf("The runtime uses %s", base[0]),
+				Suggestion: fmt.Sprintf("No native dependencies were detected; COOLPACK_BASE_IMAGE=node:%s-alpine is smaller (musl libc, no APT packages)", nodeVersion(plan)),
+			})
+		}
+	}
+
+	if plan.NativeDeps != nil {
+		for _, pkg := range plan.NativeDeps.RuntimeAptPackages {
+			if pkg == "chromium" {
+				findings = append(findings, Finding{
+					Code:       CodeBrowser,
+					Message:    "Chromium and its libraries are installed in the runtime image",
+					Suggestion: "Connect to a remote browser service (e.g., puppeteer.connect with browserWSEndpoint) instead of bundling Chromium",
+				})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// devDependencySuggestion explains how to keep devDependencies out of the image
+func devDependencySuggestion(plan *app.Plan) string {
+	if plan != nil {
+		if plan.Metadata.PruneSkippedReason != "" {
+			return "Move the package the start command needs from devDependencies to dependencies, so coolpack can prune the rest"
+		}
+	}
+	return "Keep build and test tools in devDependencies, so they're pruned from the runtime stage"
+}
+
+// nodeVersion returns the plan's Node version, or the catalog default
+func nodeVersion(plan *app.Plan) string {
+	if plan.LanguageVersion != "" {
+		return plan.LanguageVersion
+	}
+	return data.Load().BaseImages["node"].DefaultVersion
+}
+
+// FormatSize formats bytes for display (e.g., "12.3 MB")
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f kB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,86 @@
+package app
+
+import "encoding/json"
+
+// Plans written before the typed Output, NativeDeps, Monorepo and SPA sections
+// kept these values in Metadata. Plans are still written with the legacy keys so
+// existing consumers keep working, and plan files using only the legacy keys are
+// read into the typed sections.
+
+// planJSON has the same fields as Plan without its JSON methods
+type planJSON Plan
+
+// MarshalJSON writes the plan, mirroring typed sections into their legacy metadata keys
+func (p Plan) MarshalJSON() ([]byte, error) {
+	out := planJSON(p)
+	out.Metadata.setLegacy(&p)
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a plan, filling typed sections from legacy metadata keys
+// when the plan doesn't have them
+func (p *Plan) UnmarshalJSON(data []byte) error {
+	var in planJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*p = Plan(in)
+
+	p.migrateLegacyMetadata()
+	return nil
+}
+
+// setLegacy sets the metadata keys that used to hold the typed sections
+func (m *Metadata) setLegacy(p *Plan) {
+	if p.Output != nil {
+		m.OutputType = p.Output.Type
+		m.OutputDir = p.Output.Dir
+		m.OutputDirOverride = p.Output.DirOverride
+	}
+	if p.NativeDeps != nil {
+		m.NativePackages = p.NativeDeps.Packages
+		m.AptPackages = p.NativeDeps.AptPackages
+		m.RuntimeAptPackages = p.NativeDeps.RuntimeAptPackages
+	}
+	if p.Monorepo != nil {
+		m.IsMonorepo = true
+		m.Workspaces = p.Monorepo.Workspaces
+	}
+	m.IsSPA = p.IsSPA()
+}
+
+// migrateLegacyMetadata moves legacy metadata keys into the typed sections.
+// Typed sections present in the plan take precedence over legacy keys.
+func (p *Plan) migrateLegacyMetadata() {
+	md := &p.Metadata
+
+	if p.Output == nil {
+		out := OutputInfo{
+			Type:        md.OutputType,
+			Dir:         md.OutputDir,
+			DirOverride: md.OutputDirOverride,
+		}
+		if out != (OutputInfo{}) {
+			p.Output = &out
+		}
+	}
+	if p.NativeDeps == nil {
+		if len(md.NativePackages) > 0 || len(md.AptPackages) > 0 || len(md.RuntimeAptPackages) > 0 {
+			p.NativeDeps = &NativeDeps{
+				Packages:           md.NativePackages,
+				AptPackages:        md.AptPackages,
+				RuntimeAptPackages: md.RuntimeAptPackages,
+			}
+		}
+	}
+	if p.Monorepo == nil && md.IsMonorepo {
+		p.Monorepo = &MonorepoInfo{Workspaces: md.Workspaces}
+	}
+	if p.SPA == nil && md.IsSPA {
+		p.SPA = &SPAInfo{Enabled: true}
+	}
+
+	md.OutputType, md.OutputDir, md.OutputDirOverride = "", "", ""
+	md.NativePackages, md.AptPackages, md.RuntimeAptPackages = nil, nil, nil
+	md.IsMonorepo, md.Workspaces, md.IsSPA = false, nil, false
+}
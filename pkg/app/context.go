@@ -1,10 +1,53 @@
 package app
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 )
 
+var (
+	// ErrLinkTargetMissing is returned for symlinks whose target doesn't exist
+	ErrLinkTargetMissing = errors.New("symlink target does not exist")
+
+	// ErrLinkOutsideRoot is returned for symlinks that resolve outside the application path
+	ErrLinkOutsideRoot = errors.New("symlink points outside the application directory")
+
+	// ErrPathOutsideRoot is returned for names that leave the application path,
+	// such as "../secrets" or "/etc/passwd"
+	ErrPathOutsideRoot = errors.New("path is outside the application directory")
+)
+
+// LinkError reports a symlinked file that can't be followed
+type LinkError struct {
+	// Name is the file path relative to the application root
+	Name string
+	// Target is the symlink target as written in the link
+	Target string
+	// Err is ErrLinkTargetMissing or ErrLinkOutsideRoot
+	Err error
+}
+
+func (e *LinkError) Error() string {
+	msg := fmt.Sprintf("%s is a symlink to %s: %v", e.Name, e.Target, e.Err)
+	if errors.Is(e.Err, ErrLinkTargetMissing) {
+		msg += " (if it is generated at install time, commit it or generate it before running coolpack)"
+	}
+	return msg
+}
+
+func (e *LinkError) Unwrap() error {
+	return e.Err
+}
+
 // Context provides information about the application being analyzed
 type Context struct {
 	// Path is the absolute path to the application root
@@ -12,46 +55,255 @@ type Context struct {
 
 	// Env contains environment variables that may influence detection
 	Env map[string]string
+
+	// ProcessEnv makes LookupEnv read the process environment, of which Env
+	// holds only the detection settings. NewContext sets it; callers that
+	// supply the environment themselves (detector.NewWithEnv) clear it, so
+	// LookupEnv sees Env alone.
+	ProcessEnv bool
+
+	// CaseInsensitive makes file names match regardless of case, so a
+	// Package.json is read as package.json, as macOS and Windows filesystems
+	// do. It defaults to true on those systems. Either way, files found only
+	// with different casing are listed by MiscasedFiles.
+	CaseInsensitive bool
+
+	// Logger receives the detection log: decisions at info and debug level,
+	// files that couldn't be read or parsed at info level
+	Logger *slog.Logger
+
+	// c is canceled when detection times out or is abandoned; file operations
+	// fail once it is done
+	c context.Context
+
+	// recorder collects the files and variables detection consults (see TrackInputs)
+	recorder *inputRecorder
+
+	// files caches reads and stats for the detection run: providers check the
+	// same files (package.json, next.config.ts) many times, and each access is
+	// a round trip on a network mount
+	files *fileCache
+}
+
+// fileCache holds the results of file operations on the application path
+type fileCache struct {
+	mu       sync.Mutex
+	reads    map[string]fileRead
+	exists   map[string]bool
+	links    map[string]bool
+	dirs     map[string]dirIndex
+	miscased map[string]string
+
+	// diagnostics are the problems found in project files (AddDiagnostic)
+	diagnostics []Diagnostic
+}
+
+// fileRead is the result of reading a file
+type fileRead struct {
+	data []byte
+	err  error
+}
+
+// newFileCache creates an empty file cache
+func newFileCache() *fileCache {
+	return &fileCache{
+		reads:    make(map[string]fileRead),
+		exists:   make(map[string]bool),
+		links:    make(map[string]bool),
+		dirs:     make(map[string]dirIndex),
+		miscased: make(map[string]string),
+	}
+}
+
+// cached returns the value cached for name in m, or computes and caches it.
+// Without a cache (a Context not made by NewContext), it always computes.
+func cached[T any](c *fileCache, m func(*fileCache) map[string]T, name string, compute func() T) T {
+	if c == nil {
+		return compute()
+	}
+	c.mu.Lock()
+	value, ok := m(c)[name]
+	c.mu.Unlock()
+	if ok {
+		return value
+	}
+	value = compute()
+	c.mu.Lock()
+	m(c)[name] = value
+	c.mu.Unlock()
+	return value
 }
 
 // NewContext creates a new Context for the given path
 func NewContext(path string) *Context {
 	return &Context{
-		Path: path,
-		Env:  make(map[string]string),
+		Path:            path,
+		Env:             make(map[string]string),
+		ProcessEnv:      true,
+		CaseInsensitive: runtime.GOOS == "darwin" || runtime.GOOS == "windows",
+		Logger:          slog.Default(),
+		files:           newFileCache(),
 	}
 }
 
-// HasFile checks if a file exists in the application path
+// SetContext sets the context detection runs under
+func (ctx *Context) SetContext(c context.Context) {
+	ctx.c = c
+}
+
+// Context returns the context detection runs under, for work that takes a
+// context.Context (e.g., network requests)
+func (ctx *Context) Context() context.Context {
+	if ctx.c == nil {
+		return context.Background()
+	}
+	return ctx.c
+}
+
+// Err returns the context's error once detection has timed out or was
+// canceled. Long loops (directory walks, source scans) should stop on it.
+func (ctx *Context) Err() error {
+	return ctx.Context().Err()
+}
+
+// Log returns the context's logger, or the default logger when none is set
+func (ctx *Context) Log() *slog.Logger {
+	if ctx.Logger == nil {
+		return slog.Default()
+	}
+	return ctx.Logger
+}
+
+// HasFile checks if a file exists in the application path.
+// Symlinks count only if their target exists inside the application path.
 func (ctx *Context) HasFile(name string) bool {
-	path := filepath.Join(ctx.Path, name)
-	_, err := os.Stat(path)
-	return err == nil
+	if ctx.Err() != nil {
+		return false
+	}
+	exists := ctx.hasFile(name)
+	ctx.record(InputExists, name, boolDigest(exists))
+	return exists
+}
+
+func (ctx *Context) hasFile(name string) bool {
+	return cached(ctx.files, func(c *fileCache) map[string]bool { return c.exists }, name, func() bool {
+		if _, entry, ok := ctx.lookup(name); ok && (entry == nil || !isSymlink(entry)) {
+			return entry != nil
+		}
+		path, err := ctx.resolve(name)
+		if err != nil {
+			return false
+		}
+		_, err = os.Stat(path)
+		return err == nil
+	})
+}
+
+// IsLink checks if a file in the application path is a symlink, whether or not its target exists
+func (ctx *Context) IsLink(name string) bool {
+	isLink := ctx.isLink(name)
+	ctx.record(InputLink, name, boolDigest(isLink))
+	return isLink
 }
 
-// ReadFile reads a file from the application path
+func (ctx *Context) isLink(name string) bool {
+	return cached(ctx.files, func(c *fileCache) map[string]bool { return c.links }, name, func() bool {
+		if _, entry, ok := ctx.lookup(name); ok {
+			return entry != nil && isSymlink(entry)
+		}
+		if !filepath.IsLocal(name) {
+			return false
+		}
+		// The directory must be inside the root too, or this would tell
+		// whether a file elsewhere is a symlink
+		path := filepath.Join(ctx.Path, name)
+		if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err != nil || !ctx.inRoot(dir) {
+			return false
+		}
+		info, err := os.Lstat(path)
+		return err == nil && info.Mode()&os.ModeSymlink != 0
+	})
+}
+
+// ReadFile reads a text file from the application path, normalized to UTF-8 (see NormalizeText).
+// Symlinks are followed within the application path; broken links and links leaving
+// it return a *LinkError. After the context is done it returns the context's error.
+// Files are read once per context; callers get their own copy of the data.
 func (ctx *Context) ReadFile(name string) ([]byte, error) {
-	path := filepath.Join(ctx.Path, name)
-	return os.ReadFile(path)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	read := cached(ctx.files, func(c *fileCache) map[string]fileRead { return c.reads }, name, func() fileRead {
+		path, err := ctx.resolve(name)
+		if err == nil {
+			if _, entry, ok := ctx.lookup(name); ok && entry == nil {
+				err = &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+			}
+		}
+		if err != nil {
+			ctx.record(InputFile, name, fileDigest(nil, err))
+			return fileRead{err: err}
+		}
+		data, err := os.ReadFile(path)
+		ctx.record(InputFile, name, fileDigest(data, err))
+		if err != nil {
+			return fileRead{err: err}
+		}
+		return fileRead{data: NormalizeText(data)}
+	})
+	if read.err != nil {
+		return nil, read.err
+	}
+	return bytes.Clone(read.data), nil
 }
 
-// ListFiles lists files matching a pattern in the application path
-func (ctx *Context) ListFiles(pattern string) ([]string, error) {
-	fullPattern := filepath.Join(ctx.Path, pattern)
-	matches, err := filepath.Glob(fullPattern)
+// resolve returns the real path of a file in the application path, following symlinks
+// as long as they stay inside it. Names must be relative and stay inside the
+// application path: repositories (and the values read from them, such as a
+// "main" in package.json) are untrusted, so "../" and absolute names are
+// refused rather than read from the host.
+func (ctx *Context) resolve(name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", &fs.PathError{Op: "open", Path: name, Err: ErrPathOutsideRoot}
+	}
+	path := filepath.Join(ctx.Path, name)
+
+	// Indexed files are reached without symlinks; missing ones are reported
+	// by the caller's read or stat
+	if found, entry, ok := ctx.lookup(name); ok && (entry == nil || !isSymlink(entry)) {
+		return filepath.Join(ctx.Path, found), nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		return nil, err
+		if os.IsNotExist(err) && ctx.isLink(name) {
+			target, _ := os.Readlink(path)
+			return "", &LinkError{Name: name, Target: target, Err: ErrLinkTargetMissing}
+		}
+		// Let the caller's read or stat report the error
+		return path, nil
+	}
+	if resolved == path {
+		return path, nil
 	}
 
-	// Convert to relative paths
-	result := make([]string, 0, len(matches))
-	for _, m := range matches {
-		rel, err := filepath.Rel(ctx.Path, m)
-		if err != nil {
-			continue
+	if !ctx.inRoot(resolved) {
+		target, _ := os.Readlink(path)
+		if target == "" {
+			target = resolved
 		}
-		result = append(result, rel)
+		return "", &LinkError{Name: name, Target: target, Err: ErrLinkOutsideRoot}
 	}
 
-	return result, nil
+	return resolved, nil
+}
+
+// inRoot checks if a path with its symlinks resolved is inside the application path
+func (ctx *Context) inRoot(resolved string) bool {
+	root, err := filepath.EvalSymlinks(ctx.Path)
+	if err != nil {
+		root = ctx.Path
+	}
+	rel, err := filepath.Rel(root, resolved)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
@@ -0,0 +1,53 @@
+package app
+
+// ProviderDescription describes what a provider detects, for `coolpack providers`
+// and documentation generated from the binary
+type ProviderDescription struct {
+	// Name is the provider name used in plans (e.g., "node")
+	Name string `json:"name"`
+
+	// DetectionVersion is increased when the provider's own detection changes
+	// the plan of an existing application (package manager, version, commands)
+	DetectionVersion int `json:"detection_version"`
+
+	// Languages are the plan languages the provider produces (e.g., "nodejs", "bun")
+	Languages []string `json:"languages"`
+
+	// DetectFiles are the files whose presence selects the provider
+	DetectFiles []string `json:"detect_files"`
+
+	// PackageManagers are the package managers the provider detects
+	PackageManagers []string `json:"package_managers,omitempty"`
+
+	// Frameworks are the frameworks the provider detects, in detection order
+	Frameworks []FrameworkDescription `json:"frameworks"`
+}
+
+// FrameworkDescription describes how a framework is detected and built
+type FrameworkDescription struct {
+	// Name is the framework name used in plans (e.g., "nextjs")
+	Name string `json:"name"`
+
+	// Provider is the provider that detects the framework
+	Provider string `json:"provider"`
+
+	// DetectionVersion is increased when the framework's detection changes the
+	// plan of an existing application (output type, directories, commands, port)
+	DetectionVersion int `json:"detection_version"`
+
+	// Packages are the dependencies that identify the framework
+	Packages []string `json:"packages,omitempty"`
+
+	// ConfigFiles identify the framework without its dependency
+	ConfigFiles []string `json:"config_files,omitempty"`
+
+	// OutputTypes are the output types the framework can build ("static", "server"),
+	// the first being the default
+	OutputTypes []string `json:"output_types"`
+
+	// OutputDirs are the build output directories by output type
+	OutputDirs map[string]string `json:"output_dirs,omitempty"`
+
+	// DefaultPort is the port the framework's server listens on by default
+	DefaultPort int `json:"default_port,omitempty"`
+}
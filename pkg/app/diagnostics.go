@@ -0,0 +1,58 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Diagnostic is a problem in a project file that kept detection from reading
+// a setting, such as a config file that doesn't parse or a value only known
+// when the config runs. Detection goes on with a default; the diagnostic
+// becomes a plan warning so the default doesn't go unnoticed.
+type Diagnostic struct {
+	// Code is the plan warning code (e.g., "config_value_dynamic")
+	Code string
+	// File is the file path relative to the application root
+	File string
+	// Line and Column locate the problem (1-based), or are 0 when unknown
+	Line   int
+	Column int
+	// Message describes the problem and the default used instead
+	Message string
+}
+
+// Location returns "file:line:column", or as much of it as is known
+func (d Diagnostic) Location() string {
+	switch {
+	case d.Line > 0 && d.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+	case d.Line > 0:
+		return fmt.Sprintf("%s:%d", d.File, d.Line)
+	default:
+		return d.File
+	}
+}
+
+// AddDiagnostic records a diagnostic for the plan, once however often the
+// file is checked. It is also logged at info level.
+func (ctx *Context) AddDiagnostic(d Diagnostic) {
+	if ctx.files != nil {
+		ctx.files.mu.Lock()
+		defer ctx.files.mu.Unlock()
+		if slices.Contains(ctx.files.diagnostics, d) {
+			return
+		}
+		ctx.files.diagnostics = append(ctx.files.diagnostics, d)
+	}
+	ctx.Log().Info("config problem", "code", d.Code, "location", d.Location(), "message", d.Message)
+}
+
+// Diagnostics returns the recorded diagnostics, in the order they were found
+func (ctx *Context) Diagnostics() []Diagnostic {
+	if ctx.files == nil {
+		return nil
+	}
+	ctx.files.mu.Lock()
+	defer ctx.files.mu.Unlock()
+	return slices.Clone(ctx.files.diagnostics)
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// NormalizeText converts text files to plain UTF-8 before parsing. Files saved by
+// Windows editors often start with a byte order mark or are UTF-16 encoded, which
+// JSON and version-file parsers reject.
+//
+//   - UTF-8 BOMs are stripped
+//   - UTF-16 (LE/BE, with a BOM or detected from NUL bytes) is decoded
+//   - Invalid UTF-8 is decoded as Windows-1252/Latin-1
+//
+// Valid UTF-8 without a BOM is returned unchanged.
+func NormalizeText(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeUTF16(data[2:], binary.LittleEndian)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeUTF16(data[2:], binary.BigEndian)
+	}
+
+	// UTF-16 without a BOM: ASCII text has a NUL in every other byte
+	if len(data) >= 4 && len(data)%2 == 0 {
+		if data[0] != 0 && data[1] == 0 && data[2] != 0 && data[3] == 0 {
+			return decodeUTF16(data, binary.LittleEndian)
+		}
+		if data[0] == 0 && data[1] != 0 && data[2] == 0 && data[3] != 0 {
+			return decodeUTF16(data, binary.BigEndian)
+		}
+	}
+
+	if utf8.Valid(data) {
+		return data
+	}
+	return decodeLatin1(data)
+}
+
+// decodeUTF16 decodes UTF-16 text to UTF-8, dropping a trailing odd byte
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(units))
+	for _, r := range utf16.Decode(units) {
+		buf.WriteRune(r)
+	}
+	return buf.Bytes()
+}
+
+// decodeLatin1 decodes single-byte text, keeping valid UTF-8 sequences as-is.
+// Windows-1252 and Latin-1 agree on every printable character that appears in
+// config files, so bytes are mapped to the code point of the same value.
+func decodeLatin1(data []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(data) + len(data)/8)
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 {
+			buf.WriteRune(rune(data[0]))
+			data = data[1:]
+			continue
+		}
+		buf.Write(data[:size])
+		data = data[size:]
+	}
+	return buf.Bytes()
+}
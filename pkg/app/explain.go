@@ -0,0 +1,104 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Decision sources
+const (
+	// SourceFile is a value read from a project file
+	SourceFile = "file"
+	// SourceEnv is a value from an environment variable
+	SourceEnv = "env"
+	// SourceConfig is a value from a project settings file (coolpack.toml, nixpacks.toml)
+	SourceConfig = "config"
+	// SourceFlag is a value from a CLI flag
+	SourceFlag = "flag"
+	// SourceDefault is a default, used when nothing else decided
+	SourceDefault = "default"
+)
+
+// Decision records why a plan field has its value, for `plan --explain`
+type Decision struct {
+	// Field is the JSON name of the plan field (e.g., "language_version", "output.dir")
+	Field string `json:"field"`
+
+	// Value is the chosen value as text
+	Value string `json:"value"`
+
+	// Source is where the value came from (SourceFile, SourceEnv, ...)
+	Source string `json:"source"`
+
+	// File and Line locate the value in the project, for SourceFile and SourceConfig
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	// Env is the environment variable, for SourceEnv
+	Env string `json:"env,omitempty"`
+
+	// Reason says why the value was chosen (e.g., "engines.node in package.json")
+	Reason string `json:"reason"`
+}
+
+// Location returns "file:line", the file, or the environment variable the value came from
+func (d Decision) Location() string {
+	switch {
+	case d.File != "" && d.Line > 0:
+		return fmt.Sprintf("%s:%d", d.File, d.Line)
+	case d.File != "":
+		return d.File
+	default:
+		return d.Env
+	}
+}
+
+// Describe returns the reason with the location appended, unless the reason
+// already names it (e.g., "engines.node in package.json (package.json:5)")
+func (d Decision) Describe() string {
+	if loc := d.Location(); loc != "" && !strings.Contains(d.Reason, loc) {
+		return d.Reason + " (" + loc + ")"
+	}
+	return d.Reason
+}
+
+// Explain records the decision for a plan field, replacing an earlier decision
+// for the same field (e.g., a CLI flag overriding the detected value)
+func (p *Plan) Explain(d Decision) {
+	for i := range p.Decisions {
+		if p.Decisions[i].Field == d.Field {
+			p.Decisions[i] = d
+			return
+		}
+	}
+	p.Decisions = append(p.Decisions, d)
+}
+
+// Decision returns the decision recorded for a plan field
+func (p *Plan) Decision(field string) (Decision, bool) {
+	for _, d := range p.Decisions {
+		if d.Field == field {
+			return d, true
+		}
+	}
+	return Decision{}, false
+}
+
+// LineOf returns the 1-based line of the last of keys in data, each searched
+// after the previous one, so LineOf(pkg, `"engines"`, `"node"`) finds engines.node.
+// It returns 0 when a key isn't found.
+func LineOf(data []byte, keys ...string) int {
+	offset := 0
+	for _, key := range keys {
+		i := bytes.Index(data[offset:], []byte(key))
+		if i < 0 {
+			return 0
+		}
+		offset += i
+	}
+	if len(keys) == 0 {
+		return 0
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
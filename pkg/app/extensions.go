@@ -0,0 +1,130 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Extensions let downstream platforms (e.g., Coolify) attach their own structured
+// data to a plan. Each extension is stored as raw JSON under a namespaced name
+// (e.g., "coolify.deployment"), so coolpack round-trips it without knowing its type.
+
+var (
+	extensionsMu sync.RWMutex
+	extensions   = make(map[string]func() interface{})
+)
+
+// RegisterExtension registers the type stored under an extension name.
+// newValue returns a pointer to a zero value, used to validate plans with
+// ValidateExtensions. Registering the same name twice panics.
+func RegisterExtension(name string, newValue func() interface{}) {
+	if err := validateExtensionName(name); err != nil {
+		panic(err)
+	}
+
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+
+	if _, exists := extensions[name]; exists {
+		panic(fmt.Sprintf("extension %q is already registered", name))
+	}
+	extensions[name] = newValue
+}
+
+// RegisteredExtensions returns the names of registered extensions in sorted order
+func RegisteredExtensions() []string {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+
+	names := make([]string, 0, len(extensions))
+	for name := range extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetExtension stores v as JSON under the extension name, replacing any existing value
+func (p *Plan) SetExtension(name string, v interface{}) error {
+	if err := validateExtensionName(name); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode extension %q: %w", name, err)
+	}
+
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]json.RawMessage)
+	}
+	p.Extensions[name] = data
+	p.AddFeature(FeatureExtensions, false)
+	return nil
+}
+
+// GetExtension decodes the extension stored under name into v.
+// Returns false if the plan has no such extension.
+func (p *Plan) GetExtension(name string, v interface{}) (bool, error) {
+	data, ok := p.Extensions[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return true, fmt.Errorf("failed to decode extension %q: %w", name, err)
+	}
+	return true, nil
+}
+
+// RemoveExtension deletes the extension stored under name
+func (p *Plan) RemoveExtension(name string) {
+	delete(p.Extensions, name)
+	if len(p.Extensions) == 0 {
+		p.Extensions = nil
+	}
+}
+
+// ValidateExtensions checks that registered extensions decode into their
+// registered type. Unregistered extensions are left as-is.
+func (p *Plan) ValidateExtensions() error {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		newValue, ok := extensions[name]
+		if !ok {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(string(p.Extensions[name])))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(newValue()); err != nil {
+			return fmt.Errorf("invalid extension %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateExtensionName checks that an extension name is namespaced (e.g., "coolify.deployment")
+func validateExtensionName(name string) error {
+	if name == "" {
+		return fmt.Errorf("extension name is empty")
+	}
+	if !strings.Contains(name, ".") || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return fmt.Errorf("extension name %q must be namespaced (e.g., \"coolify.deployment\")", name)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' || r == '/') {
+			return fmt.Errorf("extension name %q may only contain lowercase letters, digits, '.', '-', '_' and '/'", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Features declare the capabilities a plan relies on, so consumers built against
+// an older coolpack can tell which parts of a plan they don't understand.
+// Optional features can be ignored; required ones change how the image must be
+// built, and a consumer that doesn't support them should refuse the plan.
+const (
+	// FeatureCacheMounts means the build uses BuildKit cache mounts (RUN --mount=type=cache)
+	FeatureCacheMounts = "cache-mounts"
+
+	// FeatureBuildSecrets means install/build steps need the secrets in Plan.Secrets
+	FeatureBuildSecrets = "build-secrets"
+
+	// FeatureServices means Plan.Services lists backing services to provision
+	FeatureServices = "services"
+
+	// FeatureVolumes means Plan.Volumes lists paths that need persistent storage
+	FeatureVolumes = "volumes"
+
+	// FeatureRouting means Plan.Routing has redirects, rewrites or headers for static output
+	FeatureRouting = "routing"
+
+	// FeatureImageDigests means Plan.ImageDigests pins base images to digests
+	FeatureImageDigests = "image-digests"
+
+	// FeatureExtensions means Plan.Extensions holds platform-specific data
+	FeatureExtensions = "extensions"
+)
+
+// knownFeatures lists the features this version of coolpack understands
+var knownFeatures = []string{
+	FeatureCacheMounts,
+	FeatureBuildSecrets,
+	FeatureServices,
+	FeatureVolumes,
+	FeatureRouting,
+	FeatureImageDigests,
+	FeatureExtensions,
+}
+
+// Feature is a capability a plan relies on
+type Feature struct {
+	// Name identifies the feature (e.g., "cache-mounts")
+	Name string `json:"name"`
+
+	// Required is true if the plan can't be built correctly without the feature
+	Required bool `json:"required,omitempty"`
+}
+
+// UnsupportedFeaturesError is returned by NegotiateFeatures when a plan requires
+// features the consumer doesn't support
+type UnsupportedFeaturesError struct {
+	Features []string
+}
+
+func (e *UnsupportedFeaturesError) Error() string {
+	return fmt.Sprintf("plan requires unsupported features: %s (was it created by a newer coolpack?)", strings.Join(e.Features, ", "))
+}
+
+// KnownFeatures returns the features this version of coolpack supports
+func KnownFeatures() []string {
+	return append([]string(nil), knownFeatures...)
+}
+
+// AddFeature declares a feature. Declaring an existing feature as required upgrades it.
+func (p *Plan) AddFeature(name string, required bool) {
+	for i, existing := range p.Features {
+		if existing.Name == name {
+			p.Features[i].Required = existing.Required || required
+			return
+		}
+	}
+	p.Features = append(p.Features, Feature{Name: name, Required: required})
+}
+
+// HasFeature checks if the plan declares a feature
+func (p *Plan) HasFeature(name string) bool {
+	for _, f := range p.Features {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateFeatures compares the plan's features with those a consumer supports.
+// It returns the optional features the consumer should ignore, or an
+// *UnsupportedFeaturesError if any required feature is unsupported.
+func (p *Plan) NegotiateFeatures(supported []string) (ignored []string, err error) {
+	supports := make(map[string]bool, len(supported))
+	for _, name := range supported {
+		supports[name] = true
+	}
+
+	var missing []string
+	for _, f := range p.Features {
+		if supports[f.Name] {
+			continue
+		}
+		if f.Required {
+			missing = append(missing, f.Name)
+		} else {
+			ignored = append(ignored, f.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return ignored, &UnsupportedFeaturesError{Features: missing}
+	}
+	return ignored, nil
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Plan encodings
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// PlanFormats lists the encodings MarshalPlan and UnmarshalPlan support
+var PlanFormats = []string{FormatJSON, FormatYAML, FormatTOML}
+
+// FormatFromPath returns the plan encoding for a file extension (".yml" and
+// ".yaml" are YAML, ".toml" is TOML), defaulting to JSON
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// MarshalPlan encodes a plan as JSON, YAML or TOML. Every encoding uses the
+// JSON field names, so plans convert between them without renaming keys. YAML
+// keeps the JSON field order; TOML lists plain keys before tables, as it
+// requires.
+func MarshalPlan(plan *Plan, format string) ([]byte, error) {
+	raw, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return append(raw, '\n'), nil
+	case FormatYAML:
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		node, err := yamlNode(dec)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(node); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), enc.Close()
+	case FormatTOML:
+		var generic map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&generic); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(tomlValue(generic)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown plan format %q (use %s)", format, strings.Join(PlanFormats, ", "))
+	}
+}
+
+// UnmarshalPlan decodes a plan encoded by MarshalPlan (or written by hand in
+// the same shape)
+func UnmarshalPlan(data []byte, format string) (*Plan, error) {
+	data = NormalizeText(data)
+
+	if format == FormatJSON {
+		var plan Plan
+		if err := json.Unmarshal(data, &plan); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return &plan, nil
+	}
+
+	// Decode through JSON, so the field names and types are the plan's JSON ones
+	raw, err := toJSON(data, format)
+	if err != nil {
+		return nil, err
+	}
+	var plan Plan
+	if err := json.Unmarshal(raw, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", strings.ToUpper(format), err)
+	}
+	return &plan, nil
+}
+
+// toJSON re-encodes a YAML or TOML document as JSON
+func toJSON(data []byte, format string) ([]byte, error) {
+	var generic interface{}
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	case FormatTOML:
+		if _, err := toml.Decode(string(data), &generic); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown plan format %q (use %s)", format, strings.Join(PlanFormats, ", "))
+	}
+
+	raw, err := json.Marshal(generic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", strings.ToUpper(format), err)
+	}
+	return raw, nil
+}
+
+// yamlNode converts the next JSON value into a YAML node, keeping key order
+func yamlNode(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := yamlNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)}, value)
+			}
+			_, err := dec.Token()
+			return node, err
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for dec.More() {
+			value, err := yamlNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		_, err := dec.Token()
+		return node, err
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		value := "false"
+		if v {
+			value = "true"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: value}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// tomlValue prepares a decoded JSON value for the TOML encoder: numbers become
+// integers or floats, and nulls, which TOML can't express, are dropped
+func tomlValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if value != nil {
+				out[key] = tomlValue(value)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, value := range v {
+			if value != nil {
+				out = append(out, tomlValue(value))
+			}
+		}
+		return out
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// globSkippedDirs are never descended into by wildcards and "**": dependency
+// trees and VCS metadata can hold more files than the rest of the repository
+var globSkippedDirs = []string{".git", "node_modules"}
+
+// ListFiles lists the files and directories matching a pattern in the
+// application path, as sorted paths relative to it. Patterns use "/" as the
+// separator and filepath.Match syntax within a segment; a "**" segment matches
+// any number of directories, including none:
+//
+//	ctx.ListFiles("apps/*/package.json")
+//	ctx.ListFiles("src/**/*.csproj", "bin", "obj")
+//
+// Wildcards and "**" don't descend into .git, node_modules or the excluded
+// directories, given as names ("dist") or as paths relative to the root
+// ("apps/legacy", which may contain wildcards). Symlinked directories are
+// only followed by literal segments, so "**" can't loop, and only when they
+// stay inside the application path; ".." segments are an error.
+func (ctx *Context) ListFiles(pattern string, exclude ...string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	matches, err := ctx.listFiles(pattern, exclude)
+	if err == nil {
+		ctx.record(InputGlob, globInput(pattern, exclude), hashString(strings.Join(matches, "\n")))
+	}
+	return matches, err
+}
+
+// listFiles expands the pattern
+func (ctx *Context) listFiles(pattern string, exclude []string) ([]string, error) {
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	segments := strings.Split(pattern, "/")
+	for _, segment := range segments {
+		if segment == ".." {
+			return nil, &fs.PathError{Op: "glob", Path: pattern, Err: ErrPathOutsideRoot}
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range exclude {
+		if _, err := path.Match(filepath.ToSlash(e), ""); err != nil {
+			return nil, err
+		}
+	}
+
+	g := &glob{ctx: ctx, exclude: exclude, seen: make(map[string]bool)}
+	if err := g.expand(".", segments); err != nil {
+		return nil, err
+	}
+	slices.Sort(g.matches)
+	return g.matches, nil
+}
+
+// glob is the state of a pattern expansion
+type glob struct {
+	ctx     *Context
+	exclude []string
+	seen    map[string]bool
+	matches []string
+}
+
+// expand matches the segments against the entries of dir (relative to the root)
+func (g *glob) expand(dir string, segments []string) error {
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		if dir != "." && !g.seen[dir] {
+			g.seen[dir] = true
+			g.matches = append(g.matches, filepath.FromSlash(dir))
+		}
+		return nil
+	}
+
+	segment, rest := segments[0], segments[1:]
+	switch {
+	case segment == "**":
+		// No directory, then each subdirectory with the same segments
+		if err := g.expand(dir, rest); err != nil {
+			return err
+		}
+		entries, err := g.readDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !g.excluded(path.Join(dir, entry.Name())) {
+				if err := g.expand(path.Join(dir, entry.Name()), segments); err != nil {
+					return err
+				}
+			}
+		}
+	case !hasMeta(segment):
+		name := path.Join(dir, segment)
+		if found, entry, ok := g.ctx.lookup(filepath.FromSlash(name)); ok && (entry == nil || !isSymlink(entry)) {
+			if entry != nil && (len(rest) == 0 || entry.IsDir()) {
+				return g.expand(filepath.ToSlash(found), rest)
+			}
+			return nil
+		}
+		resolved, err := g.ctx.resolve(filepath.FromSlash(name))
+		if err != nil {
+			return nil
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return nil
+		}
+		if len(rest) == 0 || info.IsDir() {
+			return g.expand(name, rest)
+		}
+	default:
+		entries, err := g.readDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			name := path.Join(dir, entry.Name())
+			if ok, _ := path.Match(segment, entry.Name()); !ok || g.excluded(name) {
+				continue
+			}
+			if len(rest) == 0 || entry.IsDir() {
+				if err := g.expand(name, rest); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readDir lists a directory, from the directory index when it has it; a
+// missing or unreadable one has no entries
+func (g *glob) readDir(dir string) ([]fs.DirEntry, error) {
+	if g.ctx.files != nil {
+		if index := g.ctx.listDir(filepath.FromSlash(dir)); index.ok {
+			return index.entries, nil
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(g.ctx.Path, filepath.FromSlash(dir)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		g.ctx.Log().Info("skipped unreadable directory", "dir", dir, "error", err)
+	}
+	return entries, nil
+}
+
+// excluded checks if a path reached by a wildcard is skipped
+func (g *glob) excluded(name string) bool {
+	base := path.Base(name)
+	if slices.Contains(globSkippedDirs, base) {
+		return true
+	}
+	for _, e := range g.exclude {
+		e = strings.Trim(filepath.ToSlash(e), "/")
+		if strings.Contains(e, "/") {
+			if ok, _ := path.Match(e, name); ok {
+				return true
+			}
+		} else if ok, _ := path.Match(e, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMeta checks if a pattern segment has wildcards
+func hasMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[\`)
+}
+
+// globInput names a ListFiles call as an input: the pattern, then the exclusions
+func globInput(pattern string, exclude []string) string {
+	return strings.Join(append([]string{pattern}, exclude...), "\n")
+}
+
+// splitGlobInput splits an input name made by globInput
+func splitGlobInput(name string) (string, []string) {
+	parts := strings.Split(name, "\n")
+	return parts[0], parts[1:]
+}
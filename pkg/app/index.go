@@ -0,0 +1,157 @@
+package app
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxIndexedEntries is the size above which a directory isn't indexed: listing
+// a directory with more entries costs more than the stats it saves
+const maxIndexedEntries = 10000
+
+// dirIndex is the listing of a directory in the application path. Detection
+// mostly asks about files that don't exist (next.config.mjs, bun.lockb, ...);
+// one readdir per directory answers all of those questions, where each stat
+// is a round trip on a network filesystem.
+type dirIndex struct {
+	// path is the directory relative to the root, as named on disk
+	path string
+	// entries are sorted by name, byName finds them and byFold finds them
+	// by lowercase name
+	entries []fs.DirEntry
+	byName  map[string]fs.DirEntry
+	byFold  map[string]fs.DirEntry
+	// ok is false when the directory isn't indexed (a symlink on the way, too
+	// many entries, unreadable), and the filesystem must be asked instead
+	ok bool
+}
+
+// listDir returns the index of a directory relative to the root, listing it on
+// first use. Directories are only indexed when reached without symlinks, so an
+// answer from the index never needs the root checks of resolve.
+func (ctx *Context) listDir(dir string) dirIndex {
+	return cached(ctx.files, func(c *fileCache) map[string]dirIndex { return c.dirs }, dir, func() dirIndex {
+		path := "."
+		if dir != "." {
+			parent := ctx.listDir(filepath.Dir(dir))
+			if !parent.ok {
+				return dirIndex{}
+			}
+			entry := ctx.find(parent, filepath.Base(dir))
+			switch {
+			case entry != nil && isSymlink(entry):
+				return dirIndex{}
+			case entry == nil || !entry.IsDir():
+				// Nothing can exist inside
+				return dirIndex{ok: true}
+			}
+			path = filepath.Join(parent.path, entry.Name())
+		}
+
+		f, err := os.Open(filepath.Join(ctx.Path, path))
+		if err != nil {
+			return dirIndex{}
+		}
+		defer f.Close()
+		entries, err := f.ReadDir(maxIndexedEntries + 1)
+		if (err != nil && err != io.EOF) || len(entries) > maxIndexedEntries {
+			return dirIndex{}
+		}
+		slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+		index := dirIndex{
+			path:    path,
+			entries: entries,
+			byName:  make(map[string]fs.DirEntry, len(entries)),
+			byFold:  make(map[string]fs.DirEntry, len(entries)),
+			ok:      true,
+		}
+		for _, entry := range entries {
+			index.byName[entry.Name()] = entry
+			if _, ok := index.byFold[strings.ToLower(entry.Name())]; !ok {
+				index.byFold[strings.ToLower(entry.Name())] = entry
+			}
+		}
+		return index
+	})
+}
+
+// MiscasedFile is a file detection looked for that exists only with
+// different casing, such as Package.json for package.json
+type MiscasedFile struct {
+	// Name is the name looked for, relative to the root
+	Name string
+	// Found is the file on disk
+	Found string
+}
+
+// MiscasedFiles returns the files detection looked for that exist only with
+// different casing, sorted by name. Builds run on Linux, where file names are
+// case-sensitive, so such files break the build even when detection found
+// them (see CaseInsensitive).
+func (ctx *Context) MiscasedFiles() []MiscasedFile {
+	if ctx.files == nil {
+		return nil
+	}
+	ctx.files.mu.Lock()
+	defer ctx.files.mu.Unlock()
+	files := make([]MiscasedFile, 0, len(ctx.files.miscased))
+	for found, name := range ctx.files.miscased {
+		files = append(files, MiscasedFile{Name: name, Found: found})
+	}
+	slices.SortFunc(files, func(a, b MiscasedFile) int { return strings.Compare(a.Name, b.Name) })
+	return files
+}
+
+// find returns the entry for name in a directory index, or nil. A file whose
+// name differs only in case is recorded (see MiscasedFiles), and returned
+// when the context matches names case-insensitively.
+func (ctx *Context) find(index dirIndex, name string) fs.DirEntry {
+	if entry, ok := index.byName[name]; ok {
+		return entry
+	}
+	entry, ok := index.byFold[strings.ToLower(name)]
+	if !ok {
+		return nil
+	}
+	found := filepath.Join(index.path, entry.Name())
+	cached(ctx.files, func(c *fileCache) map[string]string { return c.miscased }, found, func() string {
+		return filepath.Join(index.path, name)
+	})
+	if !ctx.CaseInsensitive {
+		return nil
+	}
+	return entry
+}
+
+// lookup finds a file in the directory index: entry is nil when the file
+// doesn't exist, and path is its name relative to the root as named on disk.
+// ok is false when the index can't tell, for names that aren't local or lead
+// through a directory that isn't indexed, and without a file cache (a Context
+// not made by NewContext), which would list directories again for every lookup.
+func (ctx *Context) lookup(name string) (path string, entry fs.DirEntry, ok bool) {
+	if ctx.files == nil || !filepath.IsLocal(name) {
+		return "", nil, false
+	}
+	name = filepath.Clean(name)
+	if name == "." {
+		return "", nil, false
+	}
+	index := ctx.listDir(filepath.Dir(name))
+	if !index.ok {
+		return "", nil, false
+	}
+	entry = ctx.find(index, filepath.Base(name))
+	if entry == nil {
+		return name, nil, true
+	}
+	return filepath.Join(index.path, entry.Name()), entry, true
+}
+
+// isSymlink checks if a directory entry is a symlink
+func isSymlink(entry fs.DirEntry) bool {
+	return entry.Type()&fs.ModeSymlink != 0
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Input kinds
+const (
+	// InputFile is a file's content (ReadFile)
+	InputFile = "file"
+	// InputExists is whether a file exists (HasFile)
+	InputExists = "exists"
+	// InputLink is whether a file is a symlink (IsLink)
+	InputLink = "link"
+	// InputGlob is the files matching a pattern (ListFiles)
+	InputGlob = "glob"
+	// InputDir is the entries of a directory walked by a provider
+	InputDir = "dir"
+	// InputEnv is a process environment variable (LookupEnv)
+	InputEnv = "env"
+)
+
+// Input is something detection consulted: a file, a directory listing or an
+// environment variable, with a digest of what it saw. A plan stays valid as
+// long as the digests of its inputs don't change (see InputsChanged).
+type Input struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+// inputRecorder collects the inputs of a detection
+type inputRecorder struct {
+	mu     sync.Mutex
+	inputs map[string]Input
+}
+
+// TrackInputs makes the context record the inputs detection consults,
+// returned by Inputs
+func (ctx *Context) TrackInputs() {
+	ctx.recorder = &inputRecorder{inputs: make(map[string]Input)}
+}
+
+// Inputs returns the recorded inputs, sorted by kind and name
+func (ctx *Context) Inputs() []Input {
+	if ctx.recorder == nil {
+		return nil
+	}
+	ctx.recorder.mu.Lock()
+	defer ctx.recorder.mu.Unlock()
+
+	inputs := make([]Input, 0, len(ctx.recorder.inputs))
+	for _, input := range ctx.recorder.inputs {
+		inputs = append(inputs, input)
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		if inputs[i].Kind != inputs[j].Kind {
+			return inputs[i].Kind < inputs[j].Kind
+		}
+		return inputs[i].Name < inputs[j].Name
+	})
+	return inputs
+}
+
+// RecordInput records an input that a provider read without the context's
+// file operations, such as the directories and files of a source scan
+// (InputDir, InputFile). Names are relative to the application root.
+func (ctx *Context) RecordInput(kind, name string) {
+	if ctx.recorder == nil {
+		return
+	}
+	ctx.record(kind, name, ctx.digest(kind, name))
+}
+
+// record adds an input; the first digest seen for an input is kept
+func (ctx *Context) record(kind, name, digest string) {
+	if ctx.recorder == nil {
+		return
+	}
+	ctx.recorder.mu.Lock()
+	defer ctx.recorder.mu.Unlock()
+	key := kind + ":" + name
+	if _, ok := ctx.recorder.inputs[key]; !ok {
+		ctx.recorder.inputs[key] = Input{Kind: kind, Name: name, Digest: digest}
+	}
+}
+
+// LookupEnv looks up a variable in the application's environment, recorded
+// as an input: Env when the caller supplied it, else the process environment
+// (see ProcessEnv). Detection settings are read from Env; this is for checks
+// such as whether a variable the application needs is already set.
+func (ctx *Context) LookupEnv(name string) (string, bool) {
+	value, ok := ctx.lookupEnv(name)
+	ctx.record(InputEnv, name, envDigest(value, ok))
+	return value, ok
+}
+
+// lookupEnv looks up a variable without recording it
+func (ctx *Context) lookupEnv(name string) (string, bool) {
+	if ctx.ProcessEnv {
+		return os.LookupEnv(name)
+	}
+	value, ok := ctx.Env[name]
+	return value, ok
+}
+
+// InputsChanged checks whether any of the inputs differs from what the
+// application path has now
+func (ctx *Context) InputsChanged(inputs []Input) bool {
+	for _, input := range inputs {
+		if ctx.digest(input.Kind, input.Name) != input.Digest {
+			return true
+		}
+	}
+	return false
+}
+
+// digest returns the current digest of an input
+func (ctx *Context) digest(kind, name string) string {
+	switch kind {
+	case InputFile:
+		path, err := ctx.resolve(name)
+		if err != nil {
+			return fileDigest(nil, err)
+		}
+		return fileDigest(os.ReadFile(path))
+	case InputExists:
+		return boolDigest(ctx.hasFile(name))
+	case InputLink:
+		return boolDigest(ctx.isLink(name))
+	case InputGlob:
+		pattern, exclude := splitGlobInput(name)
+		matches, err := ctx.listFiles(pattern, exclude)
+		if err != nil {
+			return "error"
+		}
+		return hashString(strings.Join(matches, "\n"))
+	case InputDir:
+		path, err := ctx.resolve(name)
+		if err != nil {
+			return fileDigest(nil, err)
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return fileDigest(nil, err)
+		}
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+			if entry.IsDir() {
+				names[i] += "/"
+			}
+		}
+		return hashString(strings.Join(names, "\n"))
+	case InputEnv:
+		return envDigest(ctx.lookupEnv(name))
+	}
+	return ""
+}
+
+// fileDigest returns the digest of a file's content or of the error reading it
+func fileDigest(data []byte, err error) string {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "missing"
+	case err != nil:
+		return "error: " + err.Error()
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// envDigest returns the digest of a variable, without keeping its value
+func envDigest(value string, ok bool) string {
+	if !ok {
+		return "unset"
+	}
+	return hashString(value)
+}
+
+func boolDigest(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package app
+
+// Severity is how serious a lint finding is
+type Severity string
+
+const (
+	// SeverityError blocks a working deployment (e.g., no start command)
+	SeverityError Severity = "error"
+
+	// SeverityWarning is likely to cause problems in production
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a deployability issue reported by `coolpack lint`
+type Finding struct {
+	// Code is a stable identifier for the finding (e.g., "missing_lockfile")
+	Code string `json:"code"`
+
+	Severity Severity `json:"severity"`
+
+	// Message describes the issue
+	Message string `json:"message"`
+
+	// File and Line locate the issue, when it relates to a file
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	// Suggestion is the change that fixes the issue
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// LintReport is the result of linting an application
+type LintReport struct {
+	// Provider is the provider that handled the application
+	Provider string `json:"provider"`
+
+	Findings []Finding `json:"findings"`
+}
+
+// Count returns the number of findings with the given severity
+func (r *LintReport) Count(severity Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Metadata contains additional provider-specific information about a plan.
+// Keys coolpack knows are typed fields; keys set by other providers or tools are
+// kept in Extra. In JSON, both are written as one flat object with sorted keys.
+type Metadata struct {
+	// Name and Version are the package's name and version (package.json)
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// ModuleType is the package.json "type" field (e.g., "module")
+	ModuleType string `json:"module_type,omitempty"`
+
+	// Runtime is the JavaScript runtime when it isn't Node.js (e.g., "bun")
+	Runtime     string `json:"runtime,omitempty"`
+	RuntimeNote string `json:"runtime_note,omitempty"`
+
+	// Deployable is false for applications that can't run in a container (e.g.,
+	// native-only Expo apps, Electron); nil means deployable (see IsDeployable)
+	Deployable          *bool  `json:"deployable,omitempty"`
+	NotDeployableReason string `json:"not_deployable_reason,omitempty"`
+
+	// BaseImage overrides the base Docker image (COOLPACK_BASE_IMAGE)
+	BaseImage string `json:"base_image,omitempty"`
+
+	// StaticServer is the static file server (caddy or nginx; empty is caddy)
+	StaticServer string `json:"static_server,omitempty"`
+
+	// Precompress writes brotli/gzip variants of the static output during the build
+	Precompress bool `json:"precompress,omitempty"`
+
+	// CustomPackages are extra APT packages (--packages, COOLPACK_PACKAGES)
+	CustomPackages []string `json:"custom_packages,omitempty"`
+
+	// CacheDirectories are extra build cache mounts (package.json cacheDirectories)
+	CacheDirectories []string `json:"cache_directories,omitempty"`
+
+	// RuntimeFiles are files outside the build output the runtime stage needs
+	RuntimeFiles []string `json:"runtime_files,omitempty"`
+
+	// PruneCommand removes devDependencies before node_modules is copied into the
+	// runtime stage; PruneSkippedReason explains why it isn't
+	PruneCommand       string `json:"prune_command,omitempty"`
+	PruneSkippedReason string `json:"prune_skipped_reason,omitempty"`
+
+	// TestRunner is the test framework the test command runs (e.g., "vitest",
+	// "jest", "node"); RunTests runs the test command during the build
+	// (--run-tests, COOLPACK_RUN_TESTS)
+	TestRunner string `json:"test_runner,omitempty"`
+	RunTests   bool   `json:"run_tests,omitempty"`
+
+	// LifecycleScripts are the root preinstall/install/postinstall/prepare
+	// scripts the install runs, and LifecycleTools the known tools they run
+	// (e.g., "husky", "patch-package")
+	LifecycleScripts map[string]string `json:"lifecycle_scripts,omitempty"`
+	LifecycleTools   []string          `json:"lifecycle_tools,omitempty"`
+	// Overrides are the dependency versions package.json forces, by field
+	// ("overrides", "resolutions", "pnpm.overrides") and package selector
+	Overrides map[string]map[string]string `json:"overrides,omitempty"`
+
+	// RebuildCommand runs install scripts after the source is copied, when
+	// they need more than package.json and the lockfile (the install then
+	// skips them)
+	RebuildCommand string `json:"rebuild_command,omitempty"`
+
+	// HasCypress and HasMoon skip the Cypress binary download and set up moon
+	HasCypress bool `json:"has_cypress,omitempty"`
+	HasMoon    bool `json:"has_moon,omitempty"`
+
+	// Legacy keys of the typed Output, NativeDeps, Monorepo and SPA sections,
+	// written for existing consumers (see compat.go)
+	OutputType         string   `json:"output_type,omitempty"`
+	OutputDir          string   `json:"output_dir,omitempty"`
+	OutputDirOverride  string   `json:"output_dir_override,omitempty"`
+	NativePackages     []string `json:"native_packages,omitempty"`
+	AptPackages        []string `json:"apt_packages,omitempty"`
+	RuntimeAptPackages []string `json:"runtime_apt_packages,omitempty"`
+	IsMonorepo         bool     `json:"is_monorepo,omitempty"`
+	Workspaces         []string `json:"workspaces,omitempty"`
+	IsSPA              bool     `json:"is_spa,omitempty"`
+
+	// Build-time content
+	SitemapGenerators []string         `json:"sitemap_generators,omitempty"`
+	ContentSources    []string         `json:"content_sources,omitempty"`
+	ContentDependent  bool             `json:"content_dependent,omitempty"`
+	RebuildHint       string           `json:"rebuild_hint,omitempty"`
+	RebuildSchedule   *RebuildSchedule `json:"rebuild_schedule,omitempty"`
+
+	// Environment variables referenced by the source and declared in .env files
+	ReferencedEnv      []string `json:"referenced_env,omitempty"`
+	ReferencedBuildEnv []string `json:"referenced_build_env,omitempty"`
+	EnvFiles           []string `json:"env_files,omitempty"`
+	EnvFileVars        []string `json:"env_file_vars,omitempty"`
+	EnvExampleVars     []string `json:"env_example_vars,omitempty"`
+
+	// NpmRegistries are the registries configured in .npmrc ("url", "file", ...)
+	NpmRegistries []map[string]string `json:"npm_registries,omitempty"`
+
+	// SQLite databases, which limit the app to one replica
+	SQLiteDatabases []string `json:"sqlite_databases,omitempty"`
+	SQLiteHints     []string `json:"sqlite_hints,omitempty"`
+	MaxReplicas     int      `json:"max_replicas,omitempty"`
+
+	// Detected SDKs and libraries
+	ObjectStorage             []string `json:"object_storage,omitempty"`
+	AISDKs                    []string `json:"ai_sdks,omitempty"`
+	StreamingResponses        bool     `json:"streaming_responses,omitempty"`
+	ProxyHints                []string `json:"proxy_hints,omitempty"`
+	RecommendedTimeoutSeconds int      `json:"recommended_timeout_seconds,omitempty"`
+	MailProviders             []string `json:"mail_providers,omitempty"`
+	OutboundPorts             []int    `json:"outbound_ports,omitempty"`
+	OutboundNotes             []string `json:"outbound_notes,omitempty"`
+	FeatureFlags              []string `json:"feature_flags,omitempty"`
+	AuthLibraries             []string `json:"auth_libraries,omitempty"`
+	AuthProviders             []string `json:"auth_providers,omitempty"`
+	AuthCallbackURLs          []string `json:"auth_callback_urls,omitempty"`
+	APMAgents                 []string `json:"apm_agents,omitempty"`
+	AgentPreloads             []string `json:"agent_preloads,omitempty"`
+	Loggers                   []string `json:"loggers,omitempty"`
+	I18nPackages              []string `json:"i18n_packages,omitempty"`
+
+	// Extra holds keys without a typed field, as decoded from JSON
+	Extra map[string]interface{} `json:"-"`
+}
+
+// RebuildSchedule suggests rebuilding a static site periodically
+type RebuildSchedule struct {
+	Cron   string `json:"cron"`
+	Reason string `json:"reason"`
+}
+
+// metadataJSON has the same fields as Metadata without its JSON methods
+type metadataJSON Metadata
+
+// metadataKeys are the JSON keys of the typed Metadata fields
+var metadataKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Metadata{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
+// IsDeployable returns false if the application can't run in a container
+func (m Metadata) IsDeployable() bool {
+	return m.Deployable == nil || *m.Deployable
+}
+
+// SetNotDeployable marks the application as not deployable
+func (m *Metadata) SetNotDeployable(reason string) {
+	deployable := false
+	m.Deployable = &deployable
+	m.NotDeployableReason = reason
+}
+
+// IsZero reports whether no metadata is set
+func (m Metadata) IsZero() bool {
+	extra := m.Extra
+	m.Extra = nil
+	return len(extra) == 0 && reflect.ValueOf(m).IsZero()
+}
+
+// Map returns the metadata as written to JSON, keyed by JSON name
+func (m Metadata) Map() map[string]interface{} {
+	out := make(map[string]interface{})
+	raw, err := json.Marshal(m)
+	if err == nil {
+		_ = json.Unmarshal(raw, &out)
+	}
+	return out
+}
+
+// MarshalJSON writes the typed fields and Extra as one object with sorted keys
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	raw, err := json.Marshal(metadataJSON(m))
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]interface{}, len(m.Extra))
+	for k, v := range m.Extra {
+		if !metadataKeys[k] {
+			merged[k] = v
+		}
+	}
+	var typed map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &typed); err != nil {
+		return nil, err
+	}
+	for k, v := range typed {
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON reads the typed fields and keeps the other keys in Extra
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	var typed metadataJSON
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+
+	*m = Metadata(typed)
+	for k, v := range all {
+		if metadataKeys[k] {
+			continue
+		}
+		if m.Extra == nil {
+			m.Extra = make(map[string]interface{})
+		}
+		m.Extra[k] = v
+	}
+	return nil
+}
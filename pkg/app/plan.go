@@ -1,5 +1,12 @@
 package app
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
 // Plan represents the detected build plan for an application
 type Plan struct {
 	// Provider is the name of the provider that detected this application (e.g., "node")
@@ -32,15 +39,417 @@ type Plan struct {
 	// StartCommand is the command to start the application
 	StartCommand string `json:"start_command,omitempty"`
 
+	// TestCommand runs the project's tests. It is optional: the build only
+	// runs it, after the build command, when Metadata.RunTests is set.
+	TestCommand string `json:"test_command,omitempty"`
+
+	// Ports lists the ports the application listens on (the first one is the primary port)
+	Ports []int `json:"ports,omitempty"`
+
+	// Platforms are the target platforms of the image (e.g., "linux/amd64", "linux/arm64").
+	// Empty builds for the platform of the Docker host.
+	Platforms []string `json:"platforms,omitempty"`
+
 	// DetectedFiles lists the files that were used for detection
 	DetectedFiles []string `json:"detected_files,omitempty"`
 
+	// Output describes what the build produces and where it is written
+	Output *OutputInfo `json:"output,omitempty"`
+
+	// NativeDeps lists packages that need system libraries and the APT packages installed for them
+	NativeDeps *NativeDeps `json:"native_deps,omitempty"`
+
+	// Monorepo describes the workspaces of a monorepo
+	Monorepo *MonorepoInfo `json:"monorepo,omitempty"`
+
+	// SPA describes Single Page Application mode for static output
+	SPA *SPAInfo `json:"spa,omitempty"`
+
 	// Metadata contains additional provider-specific information
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Metadata Metadata `json:"metadata,omitzero"`
 
 	// BuildEnv contains environment variables available during build (ARG in Dockerfile)
 	BuildEnv map[string]string `json:"build_env,omitempty"`
 
 	// Env contains environment variables available at runtime (ENV in Dockerfile)
 	Env map[string]string `json:"env,omitempty"`
+
+	// Services lists backing services the application needs alongside it (e.g., redis, rabbitmq)
+	Services []Service `json:"services,omitempty"`
+
+	// Routing contains redirects, rewrites and headers for static sites
+	Routing *Routing `json:"routing,omitempty"`
+
+	// Volumes lists paths that must be backed by persistent storage
+	Volumes []Volume `json:"volumes,omitempty"`
+
+	// RequiredEnv declares environment variables the application needs but coolpack cannot provide
+	RequiredEnv []EnvVar `json:"required_env,omitempty"`
+
+	// Secrets are credentials mounted into install and build steps without being
+	// written to image layers (e.g., private registry tokens)
+	Secrets []BuildSecret `json:"secrets,omitempty"`
+
+	// ImageDigests pins the images used in the Dockerfile to the digests their tags
+	// resolved to at plan time (image reference -> "sha256:..."), set by --pin-images
+	ImageDigests map[string]string `json:"image_digests,omitempty"`
+
+	// Warnings lists non-fatal issues found during detection
+	Warnings []Warning `json:"warnings,omitempty"`
+
+	// Features lists the capabilities the plan relies on (see NegotiateFeatures)
+	Features []Feature `json:"features,omitempty"`
+
+	// Extensions holds structured data attached by downstream platforms, keyed by
+	// a namespaced name (see SetExtension/GetExtension)
+	Extensions map[string]json.RawMessage `json:"extensions,omitempty"`
+
+	// Decisions record why fields have their values (see Explain). They are kept
+	// in memory for `plan --explain` and not written to plan files.
+	Decisions []Decision `json:"-"`
+}
+
+// Output types
+const (
+	OutputTypeStatic = "static"
+	OutputTypeServer = "server"
+)
+
+// OutputInfo describes the build output of an application
+type OutputInfo struct {
+	// Type is how the output is deployed: "static" (served by a static file server)
+	// or "server" (needs the language runtime)
+	Type string `json:"type,omitempty"`
+
+	// Dir is the framework's build output directory (e.g., "dist", ".next")
+	Dir string `json:"dir,omitempty"`
+
+	// DirOverride replaces Dir for static output (--output-dir, COOLPACK_SPA_OUTPUT_DIR)
+	DirOverride string `json:"dir_override,omitempty"`
+
+	// BasePath is the URL path static output is served under (e.g., "/app/"),
+	// empty for the site root
+	BasePath string `json:"base_path,omitempty"`
+}
+
+// NativeDeps describes dependencies that need system libraries
+type NativeDeps struct {
+	// Packages are the npm packages that need system libraries (e.g., "sharp")
+	Packages []string `json:"packages,omitempty"`
+
+	// AptPackages are installed in the build stage
+	AptPackages []string `json:"apt_packages,omitempty"`
+
+	// RuntimeAptPackages are also installed in the runtime stage (fonts, browsers, etc.)
+	RuntimeAptPackages []string `json:"runtime_apt_packages,omitempty"`
+
+	// PrebuiltBinaries are packages that ship a native binary per OS/architecture
+	PrebuiltBinaries []PrebuiltBinary `json:"prebuilt_binaries,omitempty"`
+}
+
+// PrebuiltBinary is a package with per-architecture binaries (e.g., sharp, esbuild)
+type PrebuiltBinary struct {
+	// Package is the npm package name
+	Package string `json:"package"`
+
+	// Check is a JavaScript snippet that throws if the binary for the current platform is missing
+	Check string `json:"check"`
+}
+
+// MonorepoInfo describes a monorepo
+type MonorepoInfo struct {
+	// Workspaces are the workspace globs (e.g., "packages/*")
+	Workspaces []string `json:"workspaces,omitempty"`
+}
+
+// SPAInfo describes Single Page Application mode
+type SPAInfo struct {
+	// Enabled serves the fallback document for unknown routes
+	Enabled bool `json:"enabled"`
+
+	// Reason explains why SPA mode was enabled (e.g., "vue-router dependency", "--spa")
+	Reason string `json:"reason,omitempty"`
+}
+
+// OutputType returns the plan's output type, defaulting to "server"
+func (p *Plan) OutputType() string {
+	if p.Output != nil && p.Output.Type != "" {
+		return p.Output.Type
+	}
+	return OutputTypeServer
+}
+
+// IsSPA returns true if SPA mode is enabled
+func (p *Plan) IsSPA() bool {
+	return p.SPA != nil && p.SPA.Enabled
+}
+
+// Hash returns a stable digest of the plan ("sha256:..."), used to tell which plan an image was built from
+func (p *Plan) Hash() (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Service describes a backing service the application connects to
+type Service struct {
+	// Name is the service type (e.g., "redis", "rabbitmq", "kafka")
+	Name string `json:"name"`
+
+	// Packages are the client libraries that indicated the service
+	Packages []string `json:"packages,omitempty"`
+
+	// Env are the variables conventionally used to connect to the service
+	Env []string `json:"env,omitempty"`
+
+	// Optional is true when the app can run without the service (e.g., a cache
+	// with an in-memory fallback)
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Routing contains the redirect, rewrite and header rules served by the static file server
+type Routing struct {
+	// Redirects send the client to another URL, in priority order
+	Redirects []RouteRule `json:"redirects,omitempty"`
+
+	// Rewrites serve another path without changing the URL, in priority order.
+	// They only apply when no file exists at the requested path.
+	Rewrites []RouteRule `json:"rewrites,omitempty"`
+
+	// Headers add response headers to matching paths
+	Headers []HeaderRule `json:"headers,omitempty"`
+
+	// Fallback is the SPA history fallback, applied when SPA mode is enabled
+	Fallback *Fallback `json:"fallback,omitempty"`
+
+	// Cache is the Cache-Control policy for static files
+	Cache *CachePolicy `json:"cache,omitempty"`
+
+	// ErrorPages are custom documents served for error statuses
+	ErrorPages []ErrorPage `json:"error_pages,omitempty"`
+}
+
+// ErrorPage serves a document for an error status
+type ErrorPage struct {
+	// Status is the HTTP status the page is served for (e.g., 404)
+	Status int `json:"status"`
+
+	// Document is the file served, with the status kept (e.g., "/404.html")
+	Document string `json:"document"`
+}
+
+// CachePolicy sets Cache-Control headers for static files
+type CachePolicy struct {
+	// ImmutablePrefixes hold content-hashed files that are cached for a year
+	// (e.g., "/assets/")
+	ImmutablePrefixes []string `json:"immutable_prefixes,omitempty"`
+
+	// Revalidate makes every other file (HTML documents, unhashed public files)
+	// revalidate on each request with "no-cache"
+	Revalidate bool `json:"revalidate,omitempty"`
+}
+
+// Fallback serves a document for unknown paths so a client-side router can handle them
+type Fallback struct {
+	// Document is the file served for unknown paths (e.g., "/index.html")
+	Document string `json:"document"`
+
+	// ExcludePrefixes are path prefixes that return 404 instead (e.g., "/assets/"),
+	// so missing bundles don't receive HTML
+	ExcludePrefixes []string `json:"exclude_prefixes,omitempty"`
+
+	// ExcludeExtensions are file extensions that return 404 instead (e.g., ".js")
+	ExcludeExtensions []string `json:"exclude_extensions,omitempty"`
+}
+
+// RouteRule is a redirect or rewrite rule
+type RouteRule struct {
+	// Source is the pattern as written in the source file (e.g., "/blog/*")
+	Source string `json:"source"`
+
+	// Match is an anchored regular expression matching the request path
+	Match string `json:"match"`
+
+	// Destination is the target path or URL, with $1..$n referencing Match groups
+	Destination string `json:"destination"`
+
+	// Status is the HTTP status for redirects (301, 302, 307, 308)
+	Status int `json:"status,omitempty"`
+}
+
+// HeaderRule adds response headers to paths matching a pattern
+type HeaderRule struct {
+	// Source is the pattern as written in the source file (e.g., "/assets/*")
+	Source string `json:"source"`
+
+	// Match is an anchored regular expression matching the request path
+	Match string `json:"match"`
+
+	// Headers are the headers to add, in source order
+	Headers []Header `json:"headers"`
+}
+
+// Header is a response header
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Volume describes a container path the application writes persistent data to
+type Volume struct {
+	// Path is the absolute path inside the container (e.g., "/app/uploads")
+	Path string `json:"path"`
+
+	// Description explains what is stored in the volume
+	Description string `json:"description,omitempty"`
+
+	// Source is the package that writes to the volume
+	Source string `json:"source,omitempty"`
+}
+
+// Env var phases
+const (
+	PhaseBuild   = "build"
+	PhaseRuntime = "runtime"
+)
+
+// EnvVar declares an environment variable the application depends on
+type EnvVar struct {
+	// Name is the variable name (e.g., "SITE_URL")
+	Name string `json:"name"`
+
+	// Phase is when the variable is needed: "build" or "runtime"
+	Phase string `json:"phase"`
+
+	// Secret marks credentials that must not be committed or baked into the image
+	Secret bool `json:"secret,omitempty"`
+
+	// Description explains why the variable is needed
+	Description string `json:"description,omitempty"`
+
+	// Source is the package or file that introduced the requirement
+	Source string `json:"source,omitempty"`
+}
+
+// BuildSecret declares a credential mounted with --mount=type=secret during the build
+type BuildSecret struct {
+	// ID is the secret id passed to docker build (--secret id=<ID>)
+	ID string `json:"id"`
+
+	// Env is the variable the secret is exposed as inside the build step (e.g., "NPM_TOKEN")
+	Env string `json:"env,omitempty"`
+
+	// Description explains what the secret is used for
+	Description string `json:"description,omitempty"`
+
+	// Source is the file or package that introduced the secret (e.g., ".npmrc")
+	Source string `json:"source,omitempty"`
+}
+
+// Warning describes a non-fatal issue found during detection
+type Warning struct {
+	// Code is a stable identifier for the warning (e.g., "expo_native_only")
+	Code string `json:"code"`
+
+	// Message is a human-readable description of the issue
+	Message string `json:"message"`
+
+	// File is the file the warning relates to, if any
+	File string `json:"file,omitempty"`
+
+	// Line is the line in File the warning points at (1-based), if known
+	Line int `json:"line,omitempty"`
+}
+
+// AddRequiredEnv declares a required environment variable, ignoring duplicates
+func (p *Plan) AddRequiredEnv(v EnvVar) {
+	for _, existing := range p.RequiredEnv {
+		if existing.Name == v.Name && existing.Phase == v.Phase {
+			return
+		}
+	}
+	p.RequiredEnv = append(p.RequiredEnv, v)
+}
+
+// AddSecret declares a build secret, ignoring duplicate ids
+func (p *Plan) AddSecret(secret BuildSecret) {
+	for _, existing := range p.Secrets {
+		if existing.ID == secret.ID {
+			return
+		}
+	}
+	p.Secrets = append(p.Secrets, secret)
+	p.AddFeature(FeatureBuildSecrets, true)
+}
+
+// AddService declares a backing service, merging packages and env vars into an existing entry.
+// A service stays optional only while every package indicating it is optional.
+func (p *Plan) AddService(name, pkg string, env []string, optional bool) {
+	for i := range p.Services {
+		if p.Services[i].Name == name {
+			p.Services[i].Packages = appendUnique(p.Services[i].Packages, pkg)
+			for _, e := range env {
+				p.Services[i].Env = appendUnique(p.Services[i].Env, e)
+			}
+			p.Services[i].Optional = p.Services[i].Optional && optional
+			return
+		}
+	}
+	svc := Service{Name: name, Packages: []string{pkg}, Optional: optional}
+	for _, e := range env {
+		svc.Env = appendUnique(svc.Env, e)
+	}
+	p.Services = append(p.Services, svc)
+	p.AddFeature(FeatureServices, false)
+}
+
+// AddVolume declares a persistent volume, ignoring paths that are already declared
+func (p *Plan) AddVolume(v Volume) {
+	for _, existing := range p.Volumes {
+		if existing.Path == v.Path {
+			return
+		}
+	}
+	p.Volumes = append(p.Volumes, v)
+	p.AddFeature(FeatureVolumes, false)
+}
+
+// AddWarning appends a warning to the plan
+func (p *Plan) AddWarning(code, message, file string) {
+	p.Warnings = append(p.Warnings, Warning{Code: code, Message: message, File: file})
+}
+
+// AddWarningAt adds a warning pointing at a line of a file
+func (p *Plan) AddWarningAt(code, message, file string, line int) {
+	p.Warnings = append(p.Warnings, Warning{Code: code, Message: message, File: file, Line: line})
+}
+
+// appendUnique appends a value to a slice if it is not already present
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// ParsePlatforms parses a comma-separated platform list ("linux/amd64,arm64").
+// Bare architectures get the "linux/" prefix; duplicates are dropped.
+func ParsePlatforms(list string) []string {
+	var platforms []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			p = "linux/" + p
+		}
+		platforms = appendUnique(platforms, p)
+	}
+	return platforms
 }
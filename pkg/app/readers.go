@@ -0,0 +1,236 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxConfigFileSize is the largest file ReadJSON, ReadJSONC, ReadYAML and
+// ReadTOML decode. Configuration files are small; a larger one is generated or
+// not a configuration file, and decoding it would only slow detection down.
+const MaxConfigFileSize = 16 << 20
+
+// ErrFileTooLarge is returned for files over MaxConfigFileSize
+var ErrFileTooLarge = fmt.Errorf("file is larger than %d MiB", MaxConfigFileSize>>20)
+
+// FileError reports a file that couldn't be decoded, with the position of the
+// error when the decoder gives one
+type FileError struct {
+	// Name is the file path relative to the application root
+	Name string
+	// Line and Column locate the error (1-based), or are 0 when unknown
+	Line   int
+	Column int
+	// Err is the decoder's error, or ErrFileTooLarge
+	Err error
+}
+
+func (e *FileError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %v", e.Name, e.Line, e.Column, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %v", e.Name, e.Line, e.Err)
+	default:
+		return fmt.Sprintf("%s: %v", e.Name, e.Err)
+	}
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ReadJSON reads a JSON file from the application path into v. Read errors are
+// returned as from ReadFile; files that don't decode return a *FileError.
+func (ctx *Context) ReadJSON(name string, v any) error {
+	data, err := ctx.readConfig(name)
+	if err != nil {
+		return err
+	}
+	return DecodeJSON(name, data, v)
+}
+
+// ReadJSONC reads a JSON file that may contain comments and trailing commas
+// (JSONC, as in tsconfig.json, nest-cli.json or Expo's app.json) into v, like
+// ReadJSON
+func (ctx *Context) ReadJSONC(name string, v any) error {
+	data, err := ctx.readConfig(name)
+	if err != nil {
+		return err
+	}
+	return DecodeJSONC(name, data, v)
+}
+
+// ReadYAML reads a YAML file from the application path into v, like ReadJSON
+func (ctx *Context) ReadYAML(name string, v any) error {
+	data, err := ctx.readConfig(name)
+	if err != nil {
+		return err
+	}
+	return DecodeYAML(name, data, v)
+}
+
+// ReadTOML reads a TOML file from the application path into v, like ReadJSON
+func (ctx *Context) ReadTOML(name string, v any) error {
+	data, err := ctx.readConfig(name)
+	if err != nil {
+		return err
+	}
+	return DecodeTOML(name, data, v)
+}
+
+// readConfig reads a file to decode, refusing files over MaxConfigFileSize
+// before reading them
+func (ctx *Context) readConfig(name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if path, err := ctx.resolve(name); err == nil {
+		if info, err := os.Stat(path); err == nil && info.Size() > MaxConfigFileSize {
+			return nil, &FileError{Name: name, Err: ErrFileTooLarge}
+		}
+	}
+	return ctx.ReadFile(name)
+}
+
+// DecodeJSON decodes JSON data read from the file name into v, returning a
+// *FileError with the line and column of syntax and type errors
+func DecodeJSON(name string, data []byte, v any) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := position(data, syntaxErr.Offset)
+		return &FileError{Name: name, Line: line, Column: col, Err: err}
+	case errors.As(err, &typeErr):
+		line, col := position(data, typeErr.Offset)
+		return &FileError{Name: name, Line: line, Column: col, Err: err}
+	}
+	return &FileError{Name: name, Err: err}
+}
+
+// DecodeJSONC decodes JSON with comments and trailing commas, like DecodeJSON.
+// Comments and trailing commas are blanked out rather than removed, so error
+// positions still point into the file as written.
+func DecodeJSONC(name string, data []byte, v any) error {
+	return DecodeJSON(name, StripJSONComments(data), v)
+}
+
+// StripJSONComments returns a copy of JSONC data with // and /* */ comments
+// and trailing commas replaced by spaces (newlines are kept), which makes it
+// JSON with the same byte offsets
+func StripJSONComments(data []byte) []byte {
+	out := bytes.Clone(data)
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(out); i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	comma := -1 // offset of a comma that may be trailing
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			comma = -1
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := bytes.IndexByte(out[i:], '\n')
+			if end < 0 {
+				end = len(out) - i
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(out) - i - 2
+			} else {
+				end += 2
+			}
+			blank(i, i+2+end)
+			i += 1 + end
+		case c == ',':
+			comma = i
+		case c == '}' || c == ']':
+			if comma >= 0 {
+				blank(comma, comma+1)
+			}
+			comma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			comma = -1
+		}
+	}
+	return out
+}
+
+// yamlLine matches the line yaml.v3 puts in its messages
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// DecodeYAML decodes YAML data read from the file name into v, returning a
+// *FileError with the line of the (first) error
+func DecodeYAML(name string, data []byte, v any) error {
+	err := yaml.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+	}
+	line := 0
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		msg = msg[len(m[0]):]
+	}
+	return &FileError{Name: name, Line: line, Err: errors.New(strings.TrimPrefix(msg, "yaml: "))}
+}
+
+// DecodeTOML decodes TOML data read from the file name into v, returning a
+// *FileError with the line and column of parse errors
+func DecodeTOML(name string, data []byte, v any) error {
+	_, err := toml.Decode(string(data), v)
+	if err == nil {
+		return nil
+	}
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		return &FileError{Name: name, Line: parseErr.Position.Line, Column: parseErr.Position.Col, Err: errors.New(parseErr.Message)}
+	}
+	return &FileError{Name: name, Err: err}
+}
+
+// position returns the 1-based line and column of a byte offset in data. JSON
+// decoders report the offset after the byte in error.
+func position(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset > 0 {
+		offset--
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
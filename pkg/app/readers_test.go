@@ -0,0 +1,58 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// FuzzDecodeJSONC decodes arbitrary JSON and JSONC files. Stripping comments
+// must keep every byte offset (error positions point into the file as
+// written) and leave plain JSON unchanged, and errors must locate a line and
+// column inside the file.
+func FuzzDecodeJSONC(f *testing.F) {
+	seeds := []string{
+		`{"a": 1}`,
+		"{\n  // comment\n  \"a\": [1, 2,],\n  /* block */ \"b\": \"//not a comment\",\n}",
+		`{"a": "\"/*", "b": "*/"}`,
+		"{\"a\": 1 /* unterminated",
+		"// only a comment",
+		`{"a": "\\"}`,
+		"\xef\xbb\xbf{}",
+		`[1, 2, , ]`,
+		`{"a": {"b": ]}`,
+		"{\r\n\"a\":\r\n tru\r\n}",
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		stripped := StripJSONComments(data)
+		if len(stripped) != len(data) {
+			t.Fatalf("stripping changed the length from %d to %d", len(data), len(stripped))
+		}
+		for i, c := range data {
+			if (c == '\n') != (stripped[i] == '\n') {
+				t.Fatalf("stripping moved a line break at offset %d", i)
+			}
+		}
+		if json.Valid(data) && !bytes.Equal(stripped, data) {
+			t.Errorf("stripping changed valid JSON %q to %q", data, stripped)
+		}
+
+		lines := bytes.Count(data, []byte("\n")) + 1
+		for _, decode := range []func(string, []byte, any) error{DecodeJSON, DecodeJSONC} {
+			var v any
+			err := decode("config.json", data, &v)
+			var fileErr *FileError
+			if err == nil || !errors.As(err, &fileErr) {
+				continue
+			}
+			if fileErr.Line < 0 || fileErr.Line > lines || fileErr.Column < 0 {
+				t.Errorf("error %v located outside the %d lines of %q", err, lines, data)
+			}
+		}
+	})
+}
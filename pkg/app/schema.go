@@ -0,0 +1,285 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaID is the $id of the plan schema, published with every release
+const SchemaID = "https://github.com/coollabsio/coolpack/releases/latest/download/plan.schema.json"
+
+// SchemaVersion is the version of the plan format. It increases when a field
+// is removed or changes meaning, so plans written by an older coolpack may no
+// longer load; new optional fields don't change it.
+const SchemaVersion = 1
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// PlanSchema returns the JSON Schema (draft 2020-12) of plan files, generated
+// from the Plan struct: properties are the JSON field names, fields without
+// omitempty are required, and unknown fields are errors, except in the env and
+// extensions maps and in metadata, whose Extra keeps keys without a field.
+func PlanSchema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	root := schemaFor(reflect.TypeOf(Plan{}), defs)
+
+	schema := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     SchemaID,
+		"title":   "Coolpack build plan",
+		"$defs":   defs,
+	}
+	for k, v := range root {
+		schema[k] = v
+	}
+	return schema
+}
+
+// schemaFor returns the schema of a Go type. Named structs other than Plan go
+// into defs and are referenced, so each is described once.
+func schemaFor(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		if t != reflect.TypeOf(Plan{}) {
+			if _, ok := defs[t.Name()]; !ok {
+				defs[t.Name()] = true // placeholder for recursive types
+				defs[t.Name()] = structSchema(t, defs)
+			}
+			return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+		}
+		return structSchema(t, defs)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	var additional interface{} = false
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			// Maps merged into the object by its MarshalJSON (Metadata.Extra)
+			if field.Type.Kind() == reflect.Map {
+				additional = schemaFor(field.Type.Elem(), defs)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaFor(field.Type, defs)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": additional,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// SchemaError is a structural error in a plan document
+type SchemaError struct {
+	// Path is the JSON pointer of the offending value (e.g., "/output/type")
+	Path string `json:"path"`
+
+	Message string `json:"message"`
+}
+
+func (e SchemaError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, e.Message)
+}
+
+// DecodePlanDocument decodes a plan file into generic JSON values (maps,
+// slices, json.Number), as ValidatePlanDocument expects
+func DecodePlanDocument(data []byte, format string) (interface{}, error) {
+	data = NormalizeText(data)
+	if format != FormatJSON {
+		raw, err := toJSON(data, format)
+		if err != nil {
+			return nil, err
+		}
+		data = raw
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return doc, nil
+}
+
+// ValidatePlanDocument checks a decoded plan document against PlanSchema and
+// returns every structural error, sorted by path
+func ValidatePlanDocument(doc interface{}) []SchemaError {
+	schema := PlanSchema()
+	defs, _ := schema["$defs"].(map[string]interface{})
+
+	var errs []SchemaError
+	validateValue(schema, defs, doc, "", &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+func validateValue(schema map[string]interface{}, defs map[string]interface{}, value interface{}, path string, errs *[]SchemaError) {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		validateValue(def, defs, value, path, errs)
+		return
+	}
+
+	typ, _ := schema["type"].(string)
+	if typ == "" {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch typ {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected object, got %s", jsonType(value))
+			return
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required field %q", name)
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+			if prop, ok := properties[k].(map[string]interface{}); ok {
+				validateValue(prop, defs, obj[k], child, errs)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					*errs = append(*errs, SchemaError{Path: child, Message: "unknown field"})
+				}
+			case map[string]interface{}:
+				validateValue(extra, defs, obj[k], child, errs)
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			fail("expected array, got %s", jsonType(value))
+			return
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range arr {
+			validateValue(items, defs, item, fmt.Sprintf("%s/%d", path, i), errs)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			fail("expected string, got %s", jsonType(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected boolean, got %s", jsonType(value))
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			fail("expected integer, got %s", jsonType(value))
+			return
+		}
+		i, err := n.Int64()
+		if err != nil {
+			fail("expected integer, got %s", n)
+			return
+		}
+		if min, ok := schema["minimum"].(int); ok && i < int64(min) {
+			fail("must be at least %d", min)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			fail("expected number, got %s", jsonType(value))
+		}
+	}
+}
+
+// jsonType names the JSON type of a decoded value for error messages
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
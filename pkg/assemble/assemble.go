@@ -0,0 +1,565 @@
+// Package assemble builds images for simple plans without Docker or BuildKit.
+// The plan's prebuilt output is added as a single layer on top of the base
+// image, and the image is pushed straight to the registry with the OCI
+// distribution API, for CI environments that can't run a container daemon.
+package assemble
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/analyze"
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/generator"
+	"github.com/coollabsio/coolpack/pkg/registry"
+)
+
+// Manifest and layer media types
+const (
+	mediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCILayer     = "application/vnd.oci.image.layer.v1.tar+gzip"
+	mediaTypeDockerList   = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerV2     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerLayer  = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	mediaTypeOCIConfig    = "application/vnd.oci.image.config.v1+json"
+	mediaTypeDockerConfig = "application/vnd.docker.container.image.v1+json"
+)
+
+// attestationAnnotation marks BuildKit attestation manifests in an index
+const attestationAnnotation = "vnd.docker.reference.type"
+
+// Options are the inputs of an assembly
+type Options struct {
+	// AppPath is the application directory, containing the prebuilt output
+	AppPath string
+
+	// Image is the target reference with a tag (e.g., "ghcr.io/org/site:latest")
+	Image string
+
+	Plan *app.Plan
+
+	// Labels are added to the plan's labels (e.g., created, revision, source)
+	Labels map[string]string
+
+	// TempDir holds the layer tarball and copied blobs while pushing
+	TempDir string
+
+	// Progress receives one line per step; nil discards them
+	Progress io.Writer
+}
+
+// Result describes the pushed image
+type Result struct {
+	// Digest is the digest of the pushed manifest, or index for several platforms
+	Digest string
+
+	Platforms []string
+
+	// LayerSize is the compressed size of the added layer
+	LayerSize int64
+}
+
+// Supported returns an error explaining why a plan can't be assembled without Docker
+func Supported(plan *app.Plan) error {
+	_, err := generator.New(plan).StaticRuntime()
+	return err
+}
+
+// descriptor is an OCI content descriptor
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// matches checks a platform against "os/arch[/variant]"
+func (p platform) matches(want string) bool {
+	parts := strings.Split(want, "/")
+	if len(parts) < 2 || parts[0] != p.OS || parts[1] != p.Architecture {
+		return false
+	}
+	return len(parts) < 3 || parts[2] == p.Variant
+}
+
+type manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        descriptor        `json:"config"`
+	Layers        []descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+type index struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Manifests     []descriptor `json:"manifests"`
+}
+
+// assembler holds the state of one assembly
+type assembler struct {
+	opts    Options
+	client  *registry.Client
+	base    registry.Reference
+	target  registry.Reference
+	rt      *generator.StaticRuntime
+	layer   *layer
+	labels  map[string]string
+	created time.Time
+
+	// pushed are the blobs already in the target repository
+	pushed map[string]bool
+}
+
+// Assemble builds the image for each target platform of the base image and pushes it
+func Assemble(ctx context.Context, opts Options) (*Result, error) {
+	rt, err := generator.New(opts.Plan).StaticRuntime()
+	if err != nil {
+		return nil, err
+	}
+	return assembleRuntime(ctx, opts, rt)
+}
+
+// assembleRuntime is Assemble for the plan's static runtime
+func assembleRuntime(ctx context.Context, opts Options, rt *generator.StaticRuntime) (*Result, error) {
+	outputDir := filepath.Join(opts.AppPath, rt.OutputDir)
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		hint := ""
+		if opts.Plan.BuildCommand != "" {
+			hint = fmt.Sprintf(" (run %q first)", opts.Plan.BuildCommand)
+		}
+		return nil, fmt.Errorf("static output directory %s not found; the build command isn't run without Docker, so build the site first%s", rt.OutputDir, hint)
+	}
+
+	target, err := registry.ParseReference(opts.Image)
+	if err != nil {
+		return nil, err
+	}
+	if target.Digest != "" {
+		return nil, fmt.Errorf("target image %s must be a tag, not a digest", opts.Image)
+	}
+	base, err := registry.ParseReference(rt.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &assembler{
+		opts:    opts,
+		client:  registry.NewClient(target.Registry),
+		base:    base,
+		target:  target,
+		rt:      rt,
+		labels:  generator.New(opts.Plan).Labels(),
+		created: time.Now().UTC(),
+		pushed:  make(map[string]bool),
+	}
+	for k, v := range opts.Labels {
+		a.labels[k] = v
+	}
+
+	a.logf("Adding %s to %s", rt.OutputDir, rt.Image)
+	a.layer, err = writeLayer(outputDir, rt, opts.TempDir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(a.layer.Path)
+
+	return a.run(ctx)
+}
+
+func (a *assembler) run(ctx context.Context) (*Result, error) {
+	baseRef := a.base.Digest
+	if baseRef == "" {
+		baseRef = a.base.Tag
+	}
+	top, err := a.client.GetManifest(ctx, a.base, baseRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull base image: %w", err)
+	}
+	if _, ok := a.labels[generator.LabelBaseDigest]; !ok {
+		a.labels[generator.LabelBaseDigest] = top.Digest
+	}
+
+	result := &Result{LayerSize: a.layer.Size}
+
+	if !isIndex(top) {
+		desc, body, err := a.assemblePlatform(ctx, top, nil)
+		if err != nil {
+			return nil, err
+		}
+		if result.Digest, err = a.client.PutManifest(ctx, a.target, a.target.Tag, desc.MediaType, body); err != nil {
+			return nil, fmt.Errorf("failed to push manifest: %w", err)
+		}
+		result.Platforms = []string{desc.Platform.String()}
+		return result, nil
+	}
+
+	var baseIndex index
+	if err := json.Unmarshal(top.Body, &baseIndex); err != nil {
+		return nil, fmt.Errorf("failed to parse base image index: %w", err)
+	}
+
+	entries, err := selectPlatforms(baseIndex.Manifests, a.opts.Plan.Platforms, a.rt.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	out := index{SchemaVersion: 2, MediaType: mediaTypeOCIIndex}
+	if top.MediaType == mediaTypeDockerList || baseIndex.MediaType == mediaTypeDockerList {
+		out.MediaType = mediaTypeDockerList
+	}
+	for _, entry := range entries {
+		m, err := a.client.GetManifest(ctx, a.base, entry.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pull base image for %s: %w", entry.Platform, err)
+		}
+		desc, _, err := a.assemblePlatform(ctx, m, entry.Platform)
+		if err != nil {
+			return nil, err
+		}
+		out.Manifests = append(out.Manifests, desc)
+		result.Platforms = append(result.Platforms, desc.Platform.String())
+	}
+
+	body, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	if result.Digest, err = a.client.PutManifest(ctx, a.target, a.target.Tag, out.MediaType, body); err != nil {
+		return nil, fmt.Errorf("failed to push index: %w", err)
+	}
+	return result, nil
+}
+
+// assemblePlatform adds the layer to one platform's base image, pushes its
+// blobs, config and manifest by digest, and returns the manifest descriptor and body.
+// plat is nil for single-platform base images; it's read from their config.
+func (a *assembler) assemblePlatform(ctx context.Context, base *registry.Manifest, plat *platform) (descriptor, []byte, error) {
+	var m manifest
+	if err := json.Unmarshal(base.Body, &m); err != nil {
+		return descriptor{}, nil, fmt.Errorf("failed to parse base manifest: %w", err)
+	}
+	mediaType := m.MediaType
+	if mediaType == "" {
+		mediaType = base.MediaType
+	}
+	if mediaType != mediaTypeDockerV2 {
+		mediaType = mediaTypeOCIManifest
+	}
+
+	rawConfig, err := a.readBlob(ctx, m.Config.Digest)
+	if err != nil {
+		return descriptor{}, nil, fmt.Errorf("failed to pull base image config: %w", err)
+	}
+	config, configPlatform, err := a.imageConfig(rawConfig)
+	if err != nil {
+		return descriptor{}, nil, err
+	}
+	if plat == nil {
+		if wanted := a.opts.Plan.Platforms; len(wanted) > 0 && !matchesAny(configPlatform, wanted) {
+			return descriptor{}, nil, fmt.Errorf("base image %s is %s only", a.rt.Image, configPlatform)
+		}
+		plat = &configPlatform
+	}
+
+	a.logf("Assembling %s", plat)
+	for _, l := range m.Layers {
+		if err := a.copyBlob(ctx, l); err != nil {
+			return descriptor{}, nil, err
+		}
+	}
+	if err := a.pushLayer(ctx); err != nil {
+		return descriptor{}, nil, err
+	}
+
+	configDigest := registry.Digest(config)
+	if err := a.pushBytes(ctx, configDigest, config); err != nil {
+		return descriptor{}, nil, fmt.Errorf("failed to push image config: %w", err)
+	}
+
+	layerType := mediaTypeOCILayer
+	configType := mediaTypeOCIConfig
+	if mediaType == mediaTypeDockerV2 {
+		layerType = mediaTypeDockerLayer
+		configType = mediaTypeDockerConfig
+	}
+
+	out := manifest{
+		SchemaVersion: 2,
+		MediaType:     mediaType,
+		Config:        descriptor{MediaType: configType, Digest: configDigest, Size: int64(len(config))},
+		Layers:        append(append([]descriptor(nil), m.Layers...), descriptor{MediaType: layerType, Digest: a.layer.Digest, Size: a.layer.Size}),
+	}
+	body, err := json.Marshal(out)
+	if err != nil {
+		return descriptor{}, nil, err
+	}
+	digest, err := a.client.PutManifest(ctx, a.target, registry.Digest(body), mediaType, body)
+	if err != nil {
+		return descriptor{}, nil, fmt.Errorf("failed to push manifest for %s: %w", plat, err)
+	}
+
+	return descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(body)), Platform: plat}, body, nil
+}
+
+// imageConfig returns the base config with the runtime settings, labels, the
+// added layer and its history entry. Unknown fields are kept as they are.
+func (a *assembler) imageConfig(raw []byte) ([]byte, platform, error) {
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, platform{}, fmt.Errorf("failed to parse base image config: %w", err)
+	}
+
+	var plat platform
+	_ = json.Unmarshal(config["architecture"], &plat.Architecture)
+	_ = json.Unmarshal(config["os"], &plat.OS)
+	_ = json.Unmarshal(config["variant"], &plat.Variant)
+
+	var runtime map[string]interface{}
+	if len(config["config"]) > 0 && string(config["config"]) != "null" {
+		if err := json.Unmarshal(config["config"], &runtime); err != nil {
+			return nil, plat, fmt.Errorf("failed to parse base image config: %w", err)
+		}
+	}
+	if runtime == nil {
+		runtime = make(map[string]interface{})
+	}
+
+	labels := make(map[string]interface{})
+	if existing, ok := runtime["Labels"].(map[string]interface{}); ok {
+		labels = existing
+	}
+	for k, v := range a.labels {
+		labels[k] = v
+	}
+	runtime["Labels"] = labels
+	runtime["User"] = a.rt.User
+	runtime["Cmd"] = a.rt.Cmd
+	ports := make(map[string]interface{})
+	if existing, ok := runtime["ExposedPorts"].(map[string]interface{}); ok {
+		ports = existing
+	}
+	ports[fmt.Sprintf("%d/tcp", a.rt.Port)] = struct{}{}
+	runtime["ExposedPorts"] = ports
+
+	var rootfs struct {
+		Type    string   `json:"type"`
+		DiffIDs []string `json:"diff_ids"`
+	}
+	if err := json.Unmarshal(config["rootfs"], &rootfs); err != nil {
+		return nil, plat, fmt.Errorf("failed to parse base image rootfs: %w", err)
+	}
+	rootfs.DiffIDs = append(rootfs.DiffIDs, a.layer.DiffID)
+
+	var history []interface{}
+	if len(config["history"]) > 0 {
+		_ = json.Unmarshal(config["history"], &history)
+	}
+	history = append(history, map[string]interface{}{
+		"created":    a.created.Format(time.RFC3339),
+		"created_by": fmt.Sprintf("COPY %s %s # coolpack (daemonless)", a.rt.OutputDir, a.rt.Root),
+	})
+
+	for key, value := range map[string]interface{}{
+		"config":  runtime,
+		"rootfs":  rootfs,
+		"history": history,
+		"created": a.created.Format(time.RFC3339),
+	} {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, plat, err
+		}
+		config[key] = encoded
+	}
+
+	out, err := json.Marshal(config)
+	return out, plat, err
+}
+
+// copyBlob makes a base image blob available in the target repository: it is
+// skipped if present, mounted from the base repository on the same registry,
+// or downloaded and uploaded otherwise
+func (a *assembler) copyBlob(ctx context.Context, d descriptor) error {
+	if a.pushed[d.Digest] {
+		return nil
+	}
+	if exists, err := a.client.BlobExists(ctx, a.target, d.Digest); err == nil && exists {
+		a.pushed[d.Digest] = true
+		return nil
+	}
+	if a.base.Registry == a.target.Registry {
+		if mounted, err := a.client.MountBlob(ctx, a.target, d.Digest, a.base.Repository); err == nil && mounted {
+			a.pushed[d.Digest] = true
+			return nil
+		}
+	}
+
+	a.logf("Copying base layer %s (%s)", shortDigest(d.Digest), analyze.FormatSize(d.Size))
+	tmp, err := os.CreateTemp(a.opts.TempDir, "blob-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	body, err := a.client.GetBlob(ctx, a.base, d.Digest)
+	if err != nil {
+		return fmt.Errorf("failed to pull base layer %s: %w", d.Digest, err)
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), body)
+	body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to pull base layer %s: %w", d.Digest, err)
+	}
+	if got := "sha256:" + hex.EncodeToString(hash.Sum(nil)); got != d.Digest {
+		return fmt.Errorf("base layer %s has digest %s", d.Digest, got)
+	}
+
+	if err := a.client.PushBlob(ctx, a.target, d.Digest, d.Size, openFile(tmp.Name())); err != nil {
+		return fmt.Errorf("failed to push base layer %s: %w", d.Digest, err)
+	}
+	a.pushed[d.Digest] = true
+	return nil
+}
+
+// pushLayer uploads the added layer once for all platforms
+func (a *assembler) pushLayer(ctx context.Context) error {
+	if a.pushed[a.layer.Digest] {
+		return nil
+	}
+	if exists, err := a.client.BlobExists(ctx, a.target, a.layer.Digest); err != nil || !exists {
+		a.logf("Pushing layer %s (%s)", shortDigest(a.layer.Digest), analyze.FormatSize(a.layer.Size))
+		if err := a.client.PushBlob(ctx, a.target, a.layer.Digest, a.layer.Size, openFile(a.layer.Path)); err != nil {
+			return fmt.Errorf("failed to push layer: %w", err)
+		}
+	}
+	a.pushed[a.layer.Digest] = true
+	return nil
+}
+
+func (a *assembler) pushBytes(ctx context.Context, digest string, content []byte) error {
+	if exists, err := a.client.BlobExists(ctx, a.target, digest); err == nil && exists {
+		return nil
+	}
+	return a.client.PushBlob(ctx, a.target, digest, int64(len(content)), func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	})
+}
+
+func (a *assembler) readBlob(ctx context.Context, digest string) ([]byte, error) {
+	body, err := a.client.GetBlob(ctx, a.base, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(io.LimitReader(body, 4<<20))
+}
+
+func (a *assembler) logf(format string, args ...interface{}) {
+	if a.opts.Progress != nil {
+		fmt.Fprintf(a.opts.Progress, format+"\n", args...)
+	}
+}
+
+// isIndex checks if a manifest is an image index (multi-platform)
+func isIndex(m *registry.Manifest) bool {
+	if m.MediaType == mediaTypeOCIIndex || m.MediaType == mediaTypeDockerList {
+		return true
+	}
+	var probe struct {
+		Manifests json.RawMessage `json:"manifests"`
+	}
+	return json.Unmarshal(m.Body, &probe) == nil && len(probe.Manifests) > 0
+}
+
+// selectPlatforms picks the index entries for the wanted platforms, or every
+// image platform when none are set (the layer is architecture-independent)
+func selectPlatforms(entries []descriptor, wanted []string, image string) ([]descriptor, error) {
+	var images []descriptor
+	for _, e := range entries {
+		// Skip attestation manifests (platform unknown/unknown)
+		if e.Platform == nil || e.Platform.OS == "unknown" || e.Annotations[attestationAnnotation] != "" {
+			continue
+		}
+		images = append(images, e)
+	}
+
+	if len(wanted) == 0 {
+		var selected []descriptor
+		for _, e := range images {
+			if e.Platform.OS == "linux" {
+				selected = append(selected, e)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("base image %s has no linux images", image)
+		}
+		return selected, nil
+	}
+
+	var selected []descriptor
+	for _, want := range wanted {
+		found := false
+		for _, e := range images {
+			if e.Platform.matches(want) {
+				selected = append(selected, e)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("base image %s has no %s image", image, want)
+		}
+	}
+	return selected, nil
+}
+
+func matchesAny(p platform, wanted []string) bool {
+	for _, want := range wanted {
+		if p.matches(want) {
+			return true
+		}
+	}
+	return false
+}
+
+func openFile(path string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+}
+
+func shortDigest(digest string) string {
+	_, hex, _ := strings.Cut(digest, ":")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
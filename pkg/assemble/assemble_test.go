@@ -0,0 +1,241 @@
+package assemble
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/generator"
+	"github.com/coollabsio/coolpack/pkg/registry"
+	"github.com/coollabsio/coolpack/pkg/registry/registrytest"
+)
+
+// pushBase stores a two-platform base image with an attestation manifest in
+// reg, like the official caddy image, and returns its layer digests
+func pushBase(t *testing.T, reg *registrytest.Registry, repository, tag string) []string {
+	t.Helper()
+	var layers []string
+	var entries []descriptor
+	for _, arch := range []string{"amd64", "arm64"} {
+		layer := reg.PutBlob(repository, []byte("base layer "+arch))
+		layers = append(layers, layer)
+		config, _ := json.Marshal(map[string]interface{}{
+			"architecture": arch,
+			"os":           "linux",
+			"config":       map[string]interface{}{"Cmd": []string{"caddy"}, "ExposedPorts": map[string]interface{}{"80/tcp": struct{}{}}},
+			"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": []string{"sha256:" + strings.Repeat("0", 64)}},
+		})
+		configDigest := reg.PutBlob(repository, config)
+		body, _ := json.Marshal(manifest{
+			SchemaVersion: 2,
+			MediaType:     mediaTypeOCIManifest,
+			Config:        descriptor{MediaType: mediaTypeOCIConfig, Digest: configDigest, Size: int64(len(config))},
+			Layers:        []descriptor{{MediaType: mediaTypeOCILayer, Digest: layer, Size: int64(len("base layer " + arch))}},
+		})
+		digest := reg.PutManifest(repository, registry.Digest(body), mediaTypeOCIManifest, body)
+		entries = append(entries, descriptor{MediaType: mediaTypeOCIManifest, Digest: digest, Size: int64(len(body)), Platform: &platform{OS: "linux", Architecture: arch}})
+	}
+	entries = append(entries, descriptor{
+		MediaType:   mediaTypeOCIManifest,
+		Digest:      "sha256:" + strings.Repeat("a", 64),
+		Platform:    &platform{OS: "unknown", Architecture: "unknown"},
+		Annotations: map[string]string{attestationAnnotation: "attestation-manifest"},
+	})
+	body, _ := json.Marshal(index{SchemaVersion: 2, MediaType: mediaTypeOCIIndex, Manifests: entries})
+	reg.PutManifest(repository, tag, mediaTypeOCIIndex, body)
+	return layers
+}
+
+// assembleSite assembles a one-page site on top of baseImage and pushes it to image
+func assembleSite(t *testing.T, baseImage, image string) *Result {
+	t.Helper()
+	appPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(appPath, "dist"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(appPath, "dist", "index.html"), []byte("<h1>hello</h1>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := &generator.StaticRuntime{
+		Image:     baseImage,
+		OutputDir: "dist",
+		Root:      "/srv",
+		User:      "1001:1001",
+		Cmd:       []string{"caddy", "file-server", "--root", "/srv", "--listen", ":8080"},
+		Port:      8080,
+	}
+	opts := Options{
+		AppPath: appPath,
+		Image:   image,
+		Plan:    &app.Plan{Provider: "node", Framework: "vite"},
+		TempDir: t.TempDir(),
+	}
+	result, err := assembleRuntime(context.Background(), opts, rt)
+	if err != nil {
+		t.Fatalf("assemble: %v", err)
+	}
+	return result
+}
+
+// isolate keeps the tests from the user's Docker config and gives the
+// target registry's user as COOLPACK_REGISTRY_* credentials
+func isolate(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("COOLPACK_REGISTRY_USERNAME", "ci")
+	t.Setenv("COOLPACK_REGISTRY_PASSWORD", "secret")
+}
+
+func TestAssembleAcrossRegistries(t *testing.T) {
+	isolate(t)
+	base := registrytest.New(registrytest.Bearer)
+	defer base.Close()
+	baseLayers := pushBase(t, base, "library/caddy", "2")
+	target := registrytest.New(registrytest.Bearer)
+	defer target.Close()
+	target.AddUser("ci", "secret")
+
+	result := assembleSite(t, base.Host+"/library/caddy:2", target.Host+"/acme/site:latest")
+
+	if got := strings.Join(result.Platforms, ","); got != "linux/amd64,linux/arm64" {
+		t.Errorf("platforms = %s, want linux/amd64,linux/arm64", got)
+	}
+	mediaType, body, ok := target.Manifest("acme/site", "latest")
+	if !ok || mediaType != mediaTypeOCIIndex || registry.Digest(body) != result.Digest {
+		t.Fatalf("pushed index %s %s, want %s %s", mediaType, registry.Digest(body), mediaTypeOCIIndex, result.Digest)
+	}
+	var out index
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Manifests) != 2 {
+		t.Fatalf("index has %d manifests, want 2 without the attestation", len(out.Manifests))
+	}
+
+	for i, entry := range out.Manifests {
+		_, body, ok := target.Manifest("acme/site", entry.Digest)
+		if !ok {
+			t.Fatalf("manifest %s wasn't pushed", entry.Digest)
+		}
+		var m manifest
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatal(err)
+		}
+		if len(m.Layers) != 2 || m.Layers[0].Digest != baseLayers[i] {
+			t.Fatalf("%s layers = %+v, want the base layer and the site", entry.Platform, m.Layers)
+		}
+		for _, d := range append(m.Layers, m.Config) {
+			if _, ok := target.Blob("acme/site", d.Digest); !ok {
+				t.Errorf("%s blob %s is missing from the target", entry.Platform, d.Digest)
+			}
+		}
+
+		raw, _ := target.Blob("acme/site", m.Config.Digest)
+		var config struct {
+			Architecture string `json:"architecture"`
+			Config       struct {
+				User         string
+				Cmd          []string
+				ExposedPorts map[string]interface{}
+				Labels       map[string]string
+			} `json:"config"`
+			RootFS struct {
+				DiffIDs []string `json:"diff_ids"`
+			} `json:"rootfs"`
+		}
+		if err := json.Unmarshal(raw, &config); err != nil {
+			t.Fatal(err)
+		}
+		if config.Architecture != entry.Platform.Architecture {
+			t.Errorf("config architecture = %s, want %s", config.Architecture, entry.Platform.Architecture)
+		}
+		if config.Config.User != "1001:1001" || strings.Join(config.Config.Cmd, " ") != "caddy file-server --root /srv --listen :8080" {
+			t.Errorf("config = %+v, want the static runtime's user and command", config.Config)
+		}
+		if _, ok := config.Config.ExposedPorts["8080/tcp"]; !ok {
+			t.Errorf("exposed ports = %v, want 8080/tcp", config.Config.ExposedPorts)
+		}
+		if config.Config.Labels[generator.LabelBaseDigest] == "" {
+			t.Errorf("labels = %v, want the base image digest", config.Config.Labels)
+		}
+		if len(config.RootFS.DiffIDs) != 2 {
+			t.Errorf("diff IDs = %v, want the base layer's and the site's", config.RootFS.DiffIDs)
+		}
+	}
+
+	var m manifest
+	_, body, _ = target.Manifest("acme/site", out.Manifests[0].Digest)
+	_ = json.Unmarshal(body, &m)
+	layer, _ := target.Blob("acme/site", m.Layers[1].Digest)
+	files := layerFiles(t, layer)
+	if hdr, ok := files["srv/index.html"]; !ok || hdr.Uid != 1001 || hdr.Gid != 1001 {
+		t.Errorf("layer entries = %v, want srv/index.html owned by 1001:1001", files)
+	}
+
+	for _, auth := range base.TokenAuthorizations() {
+		if auth != "" {
+			t.Errorf("base registry received credentials %q", auth)
+		}
+	}
+}
+
+// TestAssembleMountsBaseLayers checks base layers are mounted, not copied,
+// when the base image is on the target registry
+func TestAssembleMountsBaseLayers(t *testing.T) {
+	isolate(t)
+	reg := registrytest.New(registrytest.Bearer)
+	defer reg.Close()
+	reg.AddUser("ci", "secret")
+	baseLayers := pushBase(t, reg, "library/caddy", "2")
+
+	assembleSite(t, reg.Host+"/library/caddy:2", reg.Host+"/acme/site:latest")
+
+	mounts := 0
+	for _, req := range reg.Requests() {
+		if req.Method == "POST" && strings.Contains(req.Query, "mount=") {
+			mounts++
+		}
+		for _, layer := range baseLayers {
+			if req.Method == "GET" && strings.HasSuffix(req.Path, "/blobs/"+layer) {
+				t.Errorf("base layer was downloaded: %s %s", req.Method, req.Path)
+			}
+		}
+	}
+	if mounts != len(baseLayers) {
+		t.Errorf("%d mount requests, want %d", mounts, len(baseLayers))
+	}
+	for _, layer := range baseLayers {
+		if _, ok := reg.Blob("acme/site", layer); !ok {
+			t.Errorf("base layer %s wasn't mounted", layer)
+		}
+	}
+}
+
+// layerFiles returns the tar headers of a gzipped layer by name
+func layerFiles(t *testing.T, layer []byte) map[string]*tar.Header {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(layer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]*tar.Header)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = hdr
+	}
+}
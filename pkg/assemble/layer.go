@@ -0,0 +1,168 @@
+package assemble
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/generator"
+)
+
+// layer is a gzipped layer tarball written to a temporary file
+type layer struct {
+	Path string
+
+	// Digest is the digest of the compressed tarball, DiffID of the uncompressed one
+	Digest string
+	DiffID string
+	Size   int64
+}
+
+// writeLayer writes the runtime layer: the static output under rt.Root, owned
+// by the runtime user like `COPY` + `chown` in the Dockerfile, and the server config.
+func writeLayer(outputDir string, rt *generator.StaticRuntime, tmpDir string) (*layer, error) {
+	uid, gid, err := parseUser(rt.User)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(tmpDir, "layer-*.tar.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create layer file: %w", err)
+	}
+	defer f.Close()
+
+	compressed := sha256.New()
+	uncompressed := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, compressed))
+	tw := tar.NewWriter(io.MultiWriter(gz, uncompressed))
+
+	root := strings.Trim(rt.Root, "/")
+	rootInfo, err := os.Stat(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(outputDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		switch {
+		case info.Mode().IsRegular(), info.IsDir():
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		default:
+			// Sockets, devices and pipes have no place in static output
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(outputDir, p)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(root, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Uid, hdr.Gid = uid, gid
+		hdr.Uname, hdr.Gname = "", ""
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(tw, p)
+	})
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to write layer: %w", err)
+	}
+
+	if rt.ConfigPath != "" {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     strings.TrimPrefix(rt.ConfigPath, "/"),
+			Mode:     0644,
+			Size:     int64(len(rt.Config)),
+			ModTime:  rootInfo.ModTime(),
+		}
+		if err := tw.WriteHeader(hdr); err == nil {
+			_, err = io.WriteString(tw, rt.Config)
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, fmt.Errorf("failed to write layer: %w", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to write layer: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to write layer: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &layer{
+		Path:   f.Name(),
+		Digest: "sha256:" + hex.EncodeToString(compressed.Sum(nil)),
+		DiffID: "sha256:" + hex.EncodeToString(uncompressed.Sum(nil)),
+		Size:   info.Size(),
+	}, nil
+}
+
+func copyFile(w io.Writer, p string) error {
+	src, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// parseUser parses a numeric "uid:gid" user
+func parseUser(user string) (int, int, error) {
+	u, g, _ := strings.Cut(user, ":")
+	if g == "" {
+		g = u
+	}
+	uid, err := strconv.Atoi(u)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid runtime user %q", user)
+	}
+	gid, err := strconv.Atoi(g)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid runtime user %q", user)
+	}
+	return uid, gid, nil
+}
@@ -0,0 +1,192 @@
+// Package cache manages coolpack's on-disk caches: it locates the cache root,
+// lists cache entries with their sizes and ages, and selects entries to prune
+// under size and age policies.
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dir returns the root of coolpack's caches: COOLPACK_CACHE_DIR, or coolpack
+// in the user cache directory (e.g., ~/.cache/coolpack)
+func Dir() string {
+	if dir := os.Getenv("COOLPACK_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(userCache, "coolpack")
+}
+
+// Path returns the directory of a named cache under the root (e.g., "plans")
+func Path(name string) string {
+	root := Dir()
+	if root == "" {
+		return ""
+	}
+	return filepath.Join(root, name)
+}
+
+// Cache is an on-disk cache directory
+type Cache struct {
+	// Name identifies the cache on the command line (e.g., "data")
+	Name string
+
+	Dir string
+}
+
+// Entry is a top-level file or directory of a cache
+type Entry struct {
+	Cache string    `json:"cache"`
+	Path  string    `json:"path"`
+	Size  int64     `json:"size"`
+	Files int       `json:"files"`
+	Used  time.Time `json:"used"`
+}
+
+// Entries lists the top-level entries of the cache. Directory sizes are the
+// sum of their files, and Used is the newest modification time inside them.
+// A missing cache directory has no entries.
+func (c Cache) Entries() ([]Entry, error) {
+	if c.Dir == "" {
+		return nil, nil
+	}
+	items, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, item := range items {
+		entry := Entry{Cache: c.Name, Path: filepath.Join(c.Dir, item.Name())}
+		err := filepath.WalkDir(entry.Path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.ModTime().After(entry.Used) {
+				entry.Used = info.ModTime()
+			}
+			if info.Mode().IsRegular() {
+				entry.Size += info.Size()
+				entry.Files++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Policy decides which entries are pruned
+type Policy struct {
+	// MaxAge prunes entries not used for longer; zero disables it
+	MaxAge time.Duration
+
+	// MaxSize prunes the least recently used entries until the total size fits; zero disables it
+	MaxSize int64
+
+	// All prunes every entry
+	All bool
+}
+
+// Select returns the entries the policy prunes, least recently used first
+func (p Policy) Select(entries []Entry, now time.Time) []Entry {
+	sorted := append([]Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Used.Before(sorted[j].Used)
+	})
+	if p.All {
+		return sorted
+	}
+
+	var total int64
+	for _, e := range sorted {
+		total += e.Size
+	}
+
+	var selected []Entry
+	for _, e := range sorted {
+		expired := p.MaxAge > 0 && now.Sub(e.Used) > p.MaxAge
+		oversize := p.MaxSize > 0 && total > p.MaxSize
+		if expired || oversize {
+			selected = append(selected, e)
+			total -= e.Size
+		}
+	}
+	return selected
+}
+
+// Remove deletes the entries and returns the bytes freed
+func Remove(entries []Entry) (int64, error) {
+	var freed int64
+	for _, e := range entries {
+		if err := os.RemoveAll(e.Path); err != nil {
+			return freed, fmt.Errorf("failed to remove %s: %w", e.Path, err)
+		}
+		freed += e.Size
+	}
+	return freed, nil
+}
+
+// ParseSize parses a size like "500MB", "2G" or "1048576" (binary units)
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		bytes  int64
+	}{
+		{"GB", 1 << 30}, {"G", 1 << 30},
+		{"MB", 1 << 20}, {"M", 1 << 20},
+		{"KB", 1 << 10}, {"K", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g., 500MB, 2G)", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// ParseAge parses an age like "30d", "12h" or "90m" (days plus Go durations)
+func ParseAge(s string) (time.Duration, error) {
+	value := strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q (e.g., 30d, 12h)", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g., 30d, 12h)", s)
+	}
+	return d, nil
+}
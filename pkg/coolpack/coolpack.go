@@ -0,0 +1,207 @@
+// Package coolpack is the stable Go API for embedding coolpack.
+//
+// It wraps detection and Dockerfile generation behind a small set of functions
+// and option structs, so programs don't depend on provider packages whose
+// internals change between releases:
+//
+//	plan, err := coolpack.Detect("./my-app", coolpack.DetectOptions{})
+//	if err != nil {
+//		return err
+//	}
+//	dockerfile, err := coolpack.GenerateDockerfile(plan, coolpack.GenerateOptions{
+//		StaticServer: "nginx",
+//	})
+//
+// The functions in this package don't read the process environment unless
+// DetectOptions.UseProcessEnv is set, never modify the plan passed in, and are
+// safe to call from multiple goroutines.
+package coolpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/detector"
+	"github.com/coollabsio/coolpack/pkg/generator"
+)
+
+// Plan is the detected build plan
+type Plan = app.Plan
+
+// ErrNotDetected is returned by Detect when no provider recognizes the application
+var ErrNotDetected = errors.New("no supported application detected")
+
+// DetectOptions configures detection
+type DetectOptions struct {
+	// Env holds COOLPACK_* and other variables that influence detection
+	// (e.g., COOLPACK_NODE_VERSION, COOLPACK_BASE_IMAGE)
+	Env map[string]string
+
+	// UseProcessEnv reads detection variables from the process environment,
+	// like the CLI does. Values in Env take precedence.
+	UseProcessEnv bool
+
+	// EnvFiles are .env files (KEY=value lines) whose variables are passed to
+	// detection, later files overriding earlier ones. Values in Env and the
+	// process environment (with UseProcessEnv) take precedence.
+	EnvFiles []string
+
+	// Timeout limits detection; zero uses detector.DefaultTimeout and a
+	// negative value disables the limit
+	Timeout time.Duration
+
+	// CacheDir caches detection results on disk (e.g., cache.Path("plans")), so
+	// detecting an unchanged application again returns at once. Empty disables
+	// the cache.
+	CacheDir string
+
+	// Logger receives the detection log (matched provider, decisions, files that
+	// couldn't be read or parsed). Nil discards it.
+	Logger *slog.Logger
+}
+
+// GenerateOptions overrides plan values when generating a Dockerfile.
+// Zero values keep what the plan already has.
+type GenerateOptions struct {
+	// InstallCommand, BuildCommand and StartCommand replace the detected commands
+	InstallCommand string
+	BuildCommand   string
+	StartCommand   string
+
+	// StaticServer is the static file server for static output: "caddy" or "nginx"
+	StaticServer string
+
+	// OutputDir replaces the static output directory
+	OutputDir string
+
+	// SPA forces SPA mode on or off; nil keeps the detected value
+	SPA *bool
+
+	// Precompress writes brotli/gzip files for static output during the build
+	Precompress bool
+
+	// Packages are additional APT packages to install
+	Packages []string
+
+	// BuildEnv holds build-time variables declared as ARG/ENV in the builder stage,
+	// added to the plan's (replacing defaults such as CI)
+	BuildEnv map[string]string
+}
+
+// Detect analyzes the application at path and returns its build plan.
+// Returns ErrNotDetected if no provider recognizes the application.
+func Detect(path string, opts DetectOptions) (*Plan, error) {
+	return DetectContext(context.Background(), path, opts)
+}
+
+// DetectContext is Detect under a context: detection stops with the context's
+// error when it is canceled, or when opts.Timeout passes.
+func DetectContext(c context.Context, path string, opts DetectOptions) (*Plan, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("invalid application path: %w", err)
+	}
+
+	env := make(map[string]string)
+	for _, file := range opts.EnvFiles {
+		vars, err := detector.LoadEnvFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vars {
+			env[k] = v
+		}
+	}
+	if opts.UseProcessEnv {
+		for k, v := range detector.LoadEnv() {
+			env[k] = v
+		}
+	}
+	for k, v := range opts.Env {
+		env[k] = v
+	}
+
+	d := detector.NewWithEnv(path, env)
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	d.SetLogger(logger)
+	if opts.Timeout != 0 {
+		d.SetTimeout(opts.Timeout)
+	}
+	d.SetCache(opts.CacheDir)
+	plan, err := d.DetectContext(c)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, ErrNotDetected
+	}
+	return plan, nil
+}
+
+// GenerateDockerfile generates a Dockerfile for the plan with the given overrides applied
+func GenerateDockerfile(plan *Plan, opts GenerateOptions) (string, error) {
+	if plan == nil {
+		return "", errors.New("plan is nil")
+	}
+
+	return generator.New(applyOptions(plan, opts)).GenerateDockerfile()
+}
+
+// applyOptions returns a copy of the plan with the generate options applied
+func applyOptions(plan *Plan, opts GenerateOptions) *Plan {
+	p := *plan
+
+	if opts.InstallCommand != "" {
+		p.InstallCommand = opts.InstallCommand
+	}
+	if opts.BuildCommand != "" {
+		p.BuildCommand = opts.BuildCommand
+	}
+	if opts.StartCommand != "" {
+		p.StartCommand = opts.StartCommand
+	}
+	if opts.StaticServer != "" {
+		p.Metadata.StaticServer = opts.StaticServer
+	}
+	if opts.OutputDir != "" {
+		output := app.OutputInfo{}
+		if plan.Output != nil {
+			output = *plan.Output
+		}
+		output.DirOverride = opts.OutputDir
+		p.Output = &output
+	}
+	if opts.SPA != nil {
+		if *opts.SPA {
+			p.SPA = &app.SPAInfo{Enabled: true, Reason: "GenerateOptions.SPA"}
+		} else {
+			p.SPA = nil
+		}
+	}
+	if opts.Precompress {
+		p.Metadata.Precompress = true
+	}
+	if len(opts.Packages) > 0 {
+		packages := append([]string(nil), plan.Metadata.CustomPackages...)
+		p.Metadata.CustomPackages = append(packages, opts.Packages...)
+	}
+	if len(opts.BuildEnv) > 0 {
+		buildEnv := make(map[string]string, len(p.BuildEnv)+len(opts.BuildEnv))
+		for k, v := range p.BuildEnv {
+			buildEnv[k] = v
+		}
+		p.BuildEnv = buildEnv
+		for k, v := range opts.BuildEnv {
+			p.BuildEnv[k] = v
+		}
+	}
+
+	return &p
+}
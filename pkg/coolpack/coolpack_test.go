@@ -0,0 +1,83 @@
+package coolpack
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// apps are fixture applications shared with the detector tests
+var apps = []string{"express-npm", "next-pnpm", "vite-yarn", "astro-bun", "nuxt-npm"}
+
+func appPath(name string) string {
+	return filepath.Join("..", "detector", "testdata", "apps", name)
+}
+
+// TestConcurrentUse detects and generates Dockerfiles for several apps from
+// many goroutines, generating from one shared plan per app, and checks the
+// results match a sequential run and the shared plans are left unchanged.
+// Run with -race to catch shared state.
+func TestConcurrentUse(t *testing.T) {
+	opts := DetectOptions{Env: map[string]string{"COOLPACK_NODE_DEFAULT": "lts"}}
+	gen := GenerateOptions{StaticServer: "nginx", Packages: []string{"curl"}, BuildEnv: map[string]string{"SITE_URL": "https://example.com"}}
+
+	plans := make(map[string]*Plan, len(apps))
+	wantPlan := make(map[string]string, len(apps))
+	wantDockerfile := make(map[string]string, len(apps))
+	for _, name := range apps {
+		plan, err := Detect(appPath(name), opts)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		plans[name] = plan
+		wantPlan[name] = marshal(t, plan)
+		if wantDockerfile[name], err = GenerateDockerfile(plan, gen); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		for _, name := range apps {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				plan, err := Detect(appPath(name), opts)
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+					return
+				}
+				if got := marshal(t, plan); got != wantPlan[name] {
+					t.Errorf("%s: concurrent plan differs from sequential one\ngot:  %s\nwant: %s", name, got, wantPlan[name])
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				dockerfile, err := GenerateDockerfile(plans[name], gen)
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+					return
+				}
+				if dockerfile != wantDockerfile[name] {
+					t.Errorf("%s: concurrent Dockerfile differs from sequential one", name)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	for _, name := range apps {
+		if got := marshal(t, plans[name]); got != wantPlan[name] {
+			t.Errorf("%s: GenerateDockerfile modified the plan\ngot:  %s\nwant: %s", name, got, wantPlan[name])
+		}
+	}
+}
+
+func marshal(t *testing.T, plan *Plan) string {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Error(err)
+	}
+	return string(data)
+}
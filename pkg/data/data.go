@@ -0,0 +1,342 @@
+// Package data provides the version database used during detection: the Node.js
+// release table, the base image catalog and native dependency mappings.
+//
+// The data is embedded in the binary, so detection works without network access.
+// `coolpack data update` refreshes it into the data directory; a refreshed asset
+// is used instead of the embedded one when its version is newer.
+package data
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/cache"
+)
+
+// Asset file names
+const (
+	NodeReleasesAsset    = "node-releases.json"
+	BaseImagesAsset      = "base-images.json"
+	NativeDepsAsset      = "native-deps.json"
+	PackageManagersAsset = "package-managers.json"
+)
+
+// Assets lists the data assets in load order
+var Assets = []string{NodeReleasesAsset, BaseImagesAsset, NativeDepsAsset, PackageManagersAsset}
+
+// DefaultSource is where `coolpack data update` fetches assets from
+const DefaultSource = "https://raw.githubusercontent.com/coollabsio/coolpack/main/pkg/data/assets"
+
+//go:embed assets/*.json
+var embedded embed.FS
+
+// NodeRelease is a Node.js major release line
+type NodeRelease struct {
+	Major    int    `json:"major"`
+	Codename string `json:"codename,omitempty"`
+	Released string `json:"released"`
+	LTS      string `json:"lts,omitempty"`
+	EOL      string `json:"eol"`
+}
+
+// IsLTS checks if the release line is (or was) a long-term support release
+func (r NodeRelease) IsLTS() bool {
+	return r.LTS != ""
+}
+
+// IsEOL checks if the release line reached end-of-life at the given time
+func (r NodeRelease) IsEOL(now time.Time) bool {
+	return r.EOL != "" && now.Format("2006-01-02") >= r.EOL
+}
+
+// BaseImage is a base image in the catalog
+type BaseImage struct {
+	// Repository is the image repository (e.g., "node", "oven/bun")
+	Repository string `json:"repository"`
+
+	// Tag is the tag template; {version} is replaced with the runtime version
+	Tag string `json:"tag"`
+
+	// DefaultVersion is used when no version is known
+	DefaultVersion string `json:"default_version,omitempty"`
+
+	// Distro is the image's distribution (e.g., "debian", "alpine")
+	Distro string `json:"distro,omitempty"`
+}
+
+// Image returns the image reference for a runtime version (e.g., "node:22-slim")
+func (b BaseImage) Image(version string) string {
+	if version == "" {
+		version = b.DefaultVersion
+	}
+	return b.Repository + ":" + strings.ReplaceAll(b.Tag, "{version}", version)
+}
+
+// NativeDependency maps an npm package to the APT packages it needs
+type NativeDependency struct {
+	Package     string   `json:"package"`
+	AptPackages []string `json:"apt_packages"`
+	Description string   `json:"description"`
+	Runtime     bool     `json:"runtime,omitempty"`
+}
+
+// AssetInfo describes the loaded version of an asset
+type AssetInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Source is "embedded" or the path of the refreshed asset
+	Source string `json:"source"`
+}
+
+// Database is the loaded version database
+type Database struct {
+	NodeReleases       []NodeRelease
+	BaseImages         map[string]BaseImage
+	NativeDependencies []NativeDependency
+	// PackageManagers are known-good versions of pnpm, yarn and bun, newest
+	// first, used to pin a package manager the project doesn't pin itself
+	PackageManagers map[string][]string
+	Assets          []AssetInfo
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Database
+)
+
+// Load returns the version database, preferring refreshed assets in Dir()
+// that are newer than the embedded ones. It is loaded once per process.
+func Load() *Database {
+	loadOnce.Do(func() {
+		loaded = load(Dir())
+	})
+	return loaded
+}
+
+// Embedded returns the version database as built into the binary, ignoring
+// refreshed assets, for results that must not depend on the data directory
+func Embedded() *Database {
+	return load("")
+}
+
+func load(dir string) *Database {
+	db := &Database{}
+
+	for _, name := range Assets {
+		// Refreshed assets are validated before use, so only a broken embedded asset fails here
+		raw, info := readAsset(dir, name)
+		if err := db.decode(name, raw); err != nil {
+			panic(fmt.Sprintf("data: invalid asset %s: %v", name, err))
+		}
+		db.Assets = append(db.Assets, info)
+	}
+
+	return db
+}
+
+// decode parses an asset into the database
+func (db *Database) decode(name string, raw []byte) error {
+	switch name {
+	case NodeReleasesAsset:
+		var asset struct {
+			Releases []NodeRelease `json:"releases"`
+		}
+		if err := json.Unmarshal(raw, &asset); err != nil {
+			return err
+		}
+		db.NodeReleases = asset.Releases
+	case BaseImagesAsset:
+		var asset struct {
+			Images map[string]BaseImage `json:"images"`
+		}
+		if err := json.Unmarshal(raw, &asset); err != nil {
+			return err
+		}
+		db.BaseImages = asset.Images
+	case NativeDepsAsset:
+		var asset struct {
+			Dependencies []NativeDependency `json:"dependencies"`
+		}
+		if err := json.Unmarshal(raw, &asset); err != nil {
+			return err
+		}
+		db.NativeDependencies = asset.Dependencies
+	case PackageManagersAsset:
+		var asset struct {
+			PackageManagers map[string][]string `json:"package_managers"`
+		}
+		if err := json.Unmarshal(raw, &asset); err != nil {
+			return err
+		}
+		db.PackageManagers = asset.PackageManagers
+	}
+	return nil
+}
+
+// readAsset returns the newer of the embedded and refreshed asset
+func readAsset(dir, name string) ([]byte, AssetInfo) {
+	raw, err := embedded.ReadFile("assets/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("data: missing embedded asset %s", name))
+	}
+	info := AssetInfo{Name: name, Version: assetVersion(raw), Source: "embedded"}
+
+	if dir == "" {
+		return raw, info
+	}
+	path := filepath.Join(dir, name)
+	refreshed, err := os.ReadFile(path)
+	if err == nil {
+		err = validateAsset(name, refreshed)
+	}
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Info("using the embedded asset", "asset", name, "error", err)
+		}
+		return raw, info
+	}
+	if v := assetVersion(refreshed); v > info.Version {
+		return refreshed, AssetInfo{Name: name, Version: v, Source: path}
+	}
+	return raw, info
+}
+
+// assetVersion returns the "version" of an asset (e.g., "2026.10.01")
+func assetVersion(raw []byte) string {
+	var header struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return ""
+	}
+	return header.Version
+}
+
+// validateAsset checks that an asset parses and has a version. Refreshed assets
+// that fail validation are ignored in favor of the embedded ones.
+func validateAsset(name string, raw []byte) error {
+	var asset map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &asset); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if assetVersion(raw) == "" {
+		return fmt.Errorf("%s: missing version", name)
+	}
+	if err := (&Database{}).decode(name, raw); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Dir returns the directory for refreshed assets: COOLPACK_DATA_DIR, or data
+// in coolpack's cache directory
+func Dir() string {
+	if dir := os.Getenv("COOLPACK_DATA_DIR"); dir != "" {
+		return dir
+	}
+	return cache.Path("data")
+}
+
+// Update fetches all assets from source (an http(s) URL or a local directory,
+// for air-gapped environments) and writes them to dir. Assets that aren't newer
+// than the loaded ones are still written, so dir always matches source.
+func Update(ctx context.Context, source, dir string) ([]AssetInfo, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no data directory (set COOLPACK_DATA_DIR)")
+	}
+
+	// Fetch and validate everything before writing, so a failed update leaves dir untouched
+	fetched := make(map[string][]byte, len(Assets))
+	for _, name := range Assets {
+		raw, err := fetchAsset(ctx, source, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateAsset(name, raw); err != nil {
+			return nil, err
+		}
+		fetched[name] = raw
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	var infos []AssetInfo
+	for _, name := range Assets {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, fetched[name], 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		infos = append(infos, AssetInfo{Name: name, Version: assetVersion(fetched[name]), Source: path})
+	}
+	return infos, nil
+}
+
+func fetchAsset(ctx context.Context, source, name string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(filepath.Join(source, name))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(source, "/")+"/"+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", name, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
+
+// NodeRelease returns the release line for a major version
+func (db *Database) NodeRelease(major int) (NodeRelease, bool) {
+	for _, r := range db.NodeReleases {
+		if r.Major == major {
+			return r, true
+		}
+	}
+	return NodeRelease{}, false
+}
+
+// LatestLTS returns the newest release line that entered LTS at the given time
+func (db *Database) LatestLTS(now time.Time) (NodeRelease, bool) {
+	today := now.Format("2006-01-02")
+	var latest NodeRelease
+	var found bool
+	for _, r := range db.NodeReleases {
+		if r.IsLTS() && r.LTS <= today && !r.IsEOL(now) && (!found || r.Major > latest.Major) {
+			latest, found = r, true
+		}
+	}
+	return latest, found
+}
+
+// NodeReleaseByCodename returns the LTS release line with a codename (e.g., "iron")
+func (db *Database) NodeReleaseByCodename(codename string) (NodeRelease, bool) {
+	for _, r := range db.NodeReleases {
+		if r.Codename != "" && strings.EqualFold(r.Codename, codename) {
+			return r, true
+		}
+	}
+	return NodeRelease{}, false
+}
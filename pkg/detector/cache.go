@@ -0,0 +1,152 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/coollabsio/coolpack/pkg/version"
+)
+
+// cacheFormat is increased when cache entries change incompatibly
+const cacheFormat = 1
+
+// cacheEntry is a cached detection result: the plan, the decisions behind it
+// (which plan JSON doesn't carry) and the inputs it was detected from
+type cacheEntry struct {
+	Format    int             `json:"format"`
+	Plan      json.RawMessage `json:"plan"`
+	Decisions []app.Decision  `json:"decisions,omitempty"`
+	Inputs    []app.Input     `json:"inputs"`
+}
+
+// SetCache caches detection results in dir (e.g., cache.Path("plans")); an
+// empty dir disables the cache, which is the default.
+//
+// A cached plan is returned when the coolpack binary, the detection versions,
+// the version data, the day, the environment and every file, directory and
+// variable the detection consulted are unchanged, so repeated detection of an
+// unchanged application skips parsing it.
+func (d *Detector) SetCache(dir string) {
+	d.cacheDir = dir
+}
+
+// cacheKey returns the cache file for detecting ctx.Path with ctx.Env
+func (d *Detector) cacheKey(ctx *app.Context) string {
+	// Relative paths depend on the working directory
+	path, err := filepath.Abs(ctx.Path)
+	if err != nil {
+		path = ctx.Path
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "format %d\npath %s\nversion %s %s\n", cacheFormat, path, version.Version, version.Commit)
+
+	// Development builds share a version; the binary tells them apart
+	if exe, err := os.Executable(); err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			fmt.Fprintf(h, "binary %s %d %d\n", exe, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	for _, p := range d.Providers() {
+		fmt.Fprintf(h, "provider %s %d\n", p.Name, p.DetectionVersion)
+		for _, fw := range p.Frameworks {
+			fmt.Fprintf(h, "framework %s %d\n", fw.Name, fw.DetectionVersion)
+		}
+	}
+	for _, asset := range data.Load().Assets {
+		fmt.Fprintf(h, "data %s %s\n", asset.Name, asset.Version)
+	}
+	// Default versions follow the release calendar
+	fmt.Fprintf(h, "date %s\n", time.Now().Format("2006-01-02"))
+
+	keys := make([]string, 0, len(ctx.Env))
+	for k := range ctx.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, "env %s=%s\n", k, ctx.Env[k])
+	}
+
+	return filepath.Join(d.cacheDir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// cachedPlan returns the cached plan for the key, or nil when there is none or
+// an input changed since it was cached
+func (d *Detector) cachedPlan(ctx *app.Context, key string) *Plan {
+	raw, err := os.ReadFile(key)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil || entry.Format != cacheFormat {
+		ctx.Log().Debug("ignoring invalid plan cache entry", "file", key)
+		return nil
+	}
+	if ctx.InputsChanged(entry.Inputs) {
+		ctx.Log().Debug("cached plan is stale", "file", key)
+		return nil
+	}
+	var plan Plan
+	if err := json.Unmarshal(entry.Plan, &plan); err != nil {
+		return nil
+	}
+	plan.Decisions = entry.Decisions
+
+	// Mark the entry as used for cache prune
+	now := time.Now()
+	os.Chtimes(key, now, now)
+	return &plan
+}
+
+// cachePlan stores a plan with the inputs it was detected from. Errors are
+// logged: the cache only saves time.
+func (d *Detector) cachePlan(ctx *app.Context, key string, plan *Plan) {
+	planJSON, err := json.Marshal(plan)
+	if err == nil {
+		var raw []byte
+		raw, err = json.Marshal(cacheEntry{
+			Format:    cacheFormat,
+			Plan:      planJSON,
+			Decisions: plan.Decisions,
+			Inputs:    ctx.Inputs(),
+		})
+		if err == nil {
+			err = writeFileAtomic(key, raw)
+		}
+	}
+	if err != nil {
+		ctx.Log().Info("could not cache the plan", "error", err)
+	}
+}
+
+// writeFileAtomic writes a file through a temporary file, so concurrent
+// detections never read a partial entry
+func writeFileAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), strings.TrimSuffix(filepath.Base(name), ".json")+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,208 @@
+package detector
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// ConfigFile is the project settings file in the application root
+const ConfigFile = "coolpack.toml"
+
+// ProjectConfig holds project defaults for the COOLPACK_* settings, so they can
+// be committed with the application. Environment variables and CLI flags take
+// precedence over it.
+type ProjectConfig struct {
+	Build struct {
+		Install   string   `toml:"install"`
+		Build     string   `toml:"build"`
+		Start     string   `toml:"start"`
+		BaseImage string   `toml:"base_image"`
+		Packages  []string `toml:"packages"`
+		Platforms []string `toml:"platforms"`
+	} `toml:"build"`
+
+	Node struct {
+		Version string `toml:"version"`
+		Default string `toml:"default"`
+	} `toml:"node"`
+
+	Static struct {
+		Server      string `toml:"server"`
+		OutputDir   string `toml:"output_dir"`
+		SPA         *bool  `toml:"spa"`
+		Precompress *bool  `toml:"precompress"`
+	} `toml:"static"`
+
+	// Providers limits detection to some providers, e.g. enable = ["node"]
+	Providers struct {
+		Enable  []string `toml:"enable"`
+		Disable []string `toml:"disable"`
+	} `toml:"providers"`
+}
+
+// LoadProjectConfig reads coolpack.toml from the application root. It returns
+// nil without an error when the file doesn't exist. Unknown keys are errors,
+// so typos don't get silently ignored.
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	// Read through a context, which refuses symlinks leading out of the application
+	raw, err := app.NewContext(path).ReadFile(ConfigFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var config ProjectConfig
+	meta, err := toml.Decode(string(raw), &config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ConfigFile, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		sort.Strings(keys)
+		return nil, fmt.Errorf("invalid %s: unknown keys %s", ConfigFile, strings.Join(keys, ", "))
+	}
+	return &config, nil
+}
+
+// Env returns the settings as the COOLPACK_* variables they stand for
+func (c *ProjectConfig) Env() map[string]string {
+	env := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			env[key] = value
+		}
+	}
+
+	set("COOLPACK_INSTALL_CMD", c.Build.Install)
+	set("COOLPACK_BUILD_CMD", c.Build.Build)
+	set("COOLPACK_START_CMD", c.Build.Start)
+	set("COOLPACK_BASE_IMAGE", c.Build.BaseImage)
+	set("COOLPACK_PACKAGES", strings.Join(c.Build.Packages, ","))
+	set("COOLPACK_PLATFORMS", strings.Join(c.Build.Platforms, ","))
+	set("COOLPACK_NODE_VERSION", c.Node.Version)
+	set("COOLPACK_NODE_DEFAULT", c.Node.Default)
+	set("COOLPACK_STATIC_SERVER", c.Static.Server)
+	set("COOLPACK_SPA_OUTPUT_DIR", c.Static.OutputDir)
+	set("COOLPACK_PROVIDERS", strings.Join(c.Providers.Enable, ","))
+	set("COOLPACK_DISABLE_PROVIDERS", strings.Join(c.Providers.Disable, ","))
+
+	if c.Static.SPA != nil {
+		if *c.Static.SPA {
+			env["COOLPACK_SPA"] = "true"
+		} else {
+			env["COOLPACK_NO_SPA"] = "true"
+		}
+	}
+	if c.Static.Precompress != nil && *c.Static.Precompress {
+		env["COOLPACK_PRECOMPRESS"] = "true"
+	}
+
+	return env
+}
+
+// RenderProjectConfig returns a commented coolpack.toml that pins the plan's
+// detected commands, Node.js version and static settings, with the reason for
+// each value (see app.Decision). Settings that weren't detected are included as
+// comments, so they are easy to find.
+func RenderProjectConfig(plan *app.Plan) string {
+	var sb strings.Builder
+	setting := func(key, value, field string) {
+		if d, ok := plan.Decision(field); ok {
+			sb.WriteString(fmt.Sprintf("# %s\n", d.Describe()))
+		}
+		sb.WriteString(fmt.Sprintf("%s = %q\n", key, value))
+	}
+	example := func(key, value string) {
+		sb.WriteString(fmt.Sprintf("# %s = %s\n", key, value))
+	}
+
+	sb.WriteString("# Coolpack project settings, generated by `coolpack init` from the detected\n")
+	sb.WriteString(fmt.Sprintf("# %s %s application. COOLPACK_* environment variables and CLI flags\n", plan.Language, plan.Framework))
+	sb.WriteString("# take precedence over this file; remove a setting to go back to detection.\n\n")
+
+	sb.WriteString("[build]\n")
+	commands := []struct{ key, value, field string }{
+		{"install", plan.InstallCommand, "install_command"},
+		{"build", plan.BuildCommand, "build_command"},
+		{"start", plan.StartCommand, "start_command"},
+	}
+	for _, c := range commands {
+		if c.value != "" {
+			setting(c.key, c.value, c.field)
+		} else {
+			example(c.key, `""`)
+		}
+	}
+	if plan.Metadata.BaseImage != "" {
+		setting("base_image", plan.Metadata.BaseImage, "metadata.base_image")
+	} else if plan.Language == "nodejs" && plan.LanguageVersion != "" {
+		example("base_image", fmt.Sprintf("%q", "node:"+plan.LanguageVersion+"-alpine"))
+	}
+	if len(plan.Metadata.CustomPackages) > 0 {
+		sb.WriteString(fmt.Sprintf("packages = %s\n", tomlArray(plan.Metadata.CustomPackages)))
+	} else {
+		example("packages", `["curl"]`)
+	}
+	if len(plan.Platforms) > 0 {
+		sb.WriteString(fmt.Sprintf("platforms = %s\n", tomlArray(plan.Platforms)))
+	} else {
+		example("platforms", `["linux/amd64", "linux/arm64"]`)
+	}
+
+	// bun images follow the bun version, which COOLPACK_NODE_VERSION doesn't change
+	if plan.Language == "nodejs" && plan.LanguageVersion != "" {
+		sb.WriteString("\n[node]\n")
+		setting("version", plan.LanguageVersion, "language_version")
+	}
+
+	if plan.OutputType() == app.OutputTypeStatic {
+		sb.WriteString("\n[static]\n")
+		if plan.Output.DirOverride != "" {
+			setting("output_dir", plan.Output.DirOverride, "output.dir_override")
+		} else if plan.Output.Dir != "" {
+			setting("output_dir", plan.Output.Dir, "output.dir")
+		} else {
+			example("output_dir", `"dist"`)
+		}
+		server := plan.Metadata.StaticServer
+		if server == "" {
+			server = "caddy"
+		}
+		sb.WriteString(fmt.Sprintf("server = %q\n", server))
+		if plan.IsSPA() {
+			if d, ok := plan.Decision("spa.enabled"); ok {
+				sb.WriteString(fmt.Sprintf("# %s\n", d.Describe()))
+			}
+			sb.WriteString("spa = true\n")
+		} else {
+			example("spa", "true")
+		}
+		if plan.Metadata.Precompress {
+			sb.WriteString("precompress = true\n")
+		} else {
+			example("precompress", "true")
+		}
+	}
+
+	return sb.String()
+}
+
+// tomlArray formats strings as a TOML array
+func tomlArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -1,15 +1,35 @@
 package detector
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/coollabsio/coolpack/pkg/app"
 	"github.com/coollabsio/coolpack/pkg/providers/node"
 )
 
-// Detector handles application detection using registered providers
+// DefaultTimeout limits a detection, so a pathological repository or a slow
+// network filesystem can't hang the caller
+const DefaultTimeout = time.Minute
+
+// Detector handles application detection using registered providers.
+//
+// A Detector is safe for concurrent use: providers keep no state between calls,
+// each detection gets its own app.Context and parsers, and the detector's
+// environment is copied per call. One Detector can serve many paths via DetectAt.
 type Detector struct {
 	path      string
+	env       map[string]string
+	logger    *slog.Logger
+	timeout   time.Duration
+	cacheDir  string
 	providers []Provider
 }
 
@@ -17,6 +37,7 @@ type Detector struct {
 func New(path string) *Detector {
 	d := &Detector{
 		path:      path,
+		timeout:   DefaultTimeout,
 		providers: make([]Provider, 0),
 	}
 
@@ -26,6 +47,45 @@ func New(path string) *Detector {
 	return d
 }
 
+// NewWithEnv creates a new Detector that uses the given environment variables
+// instead of reading COOLPACK_* variables from the process environment
+func NewWithEnv(path string, env map[string]string) *Detector {
+	d := New(path)
+	d.env = make(map[string]string, len(env))
+	for k, v := range env {
+		d.env[k] = v
+	}
+	return d
+}
+
+// SetLogger sets the logger detection reports to. Without one, detection logs
+// to slog.Default().
+func (d *Detector) SetLogger(logger *slog.Logger) {
+	d.logger = logger
+}
+
+// SetTimeout limits each detection to timeout (DefaultTimeout unless set);
+// zero or less disables the limit
+func (d *Detector) SetTimeout(timeout time.Duration) {
+	d.timeout = timeout
+}
+
+// withTimeout returns c limited to the detector's timeout
+func (d *Detector) withTimeout(c context.Context) (context.Context, context.CancelFunc) {
+	if d.timeout <= 0 {
+		return context.WithCancel(c)
+	}
+	return context.WithTimeout(c, d.timeout)
+}
+
+// stopped returns the error for a detection whose context is done
+func (d *Detector) stopped(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && d.timeout > 0 {
+		return fmt.Errorf("timed out after %s: %w", d.timeout, err)
+	}
+	return err
+}
+
 // registerProviders adds all available providers to the detector
 func (d *Detector) registerProviders() {
 	// Node.js provider
@@ -34,31 +94,296 @@ func (d *Detector) registerProviders() {
 	// TODO: Add more providers here (python, go, rust, etc.)
 }
 
-// Detect runs detection using all registered providers and returns a plan
-func (d *Detector) Detect() (*Plan, error) {
-	ctx := app.NewContext(d.path)
+// enabledProviders returns the providers detection tries: those named in
+// COOLPACK_PROVIDERS in that order (all registered providers when it isn't set),
+// without those named in COOLPACK_DISABLE_PROVIDERS. This lets a repository
+// force its provider when it contains decoy files, such as a package.json only
+// used for tooling. Unknown names are logged and ignored, so settings for
+// providers of other coolpack versions don't break detection.
+func (d *Detector) enabledProviders(ctx *app.Context) []Provider {
+	providers := d.providers
+	if names := providerList(ctx.Env["COOLPACK_PROVIDERS"]); len(names) > 0 {
+		providers = nil
+		for _, name := range names {
+			if provider := d.provider(name); provider == nil {
+				ctx.Log().Warn("unknown provider in COOLPACK_PROVIDERS", "provider", name)
+			} else if !slices.Contains(providers, provider) {
+				providers = append(providers, provider)
+			}
+		}
+	}
+
+	disabled := providerList(ctx.Env["COOLPACK_DISABLE_PROVIDERS"])
+	for _, name := range disabled {
+		if d.provider(name) == nil {
+			ctx.Log().Warn("unknown provider in COOLPACK_DISABLE_PROVIDERS", "provider", name)
+		}
+	}
+	providers = slices.DeleteFunc(slices.Clone(providers), func(provider Provider) bool {
+		return slices.Contains(disabled, provider.Name())
+	})
+
+	if len(providers) == 0 {
+		ctx.Log().Warn("every provider is disabled by COOLPACK_PROVIDERS or COOLPACK_DISABLE_PROVIDERS")
+	} else if len(providers) < len(d.providers) {
+		names := make([]string, len(providers))
+		for i, provider := range providers {
+			names[i] = provider.Name()
+		}
+		ctx.Log().Info("providers restricted", "enabled", strings.Join(names, ","))
+	}
+	return providers
+}
 
-	// Load environment variables that might influence detection
-	ctx.Env = loadRelevantEnvVars()
+// provider returns the registered provider with the given name, or nil
+func (d *Detector) provider(name string) Provider {
+	for _, provider := range d.providers {
+		if provider.Name() == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// providerList splits a comma-separated list of provider names
+func providerList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
 
-	// Try each provider in order
+// Providers describes the registered providers and the frameworks they detect,
+// in detection order. Providers that don't implement Describer are listed by name.
+func (d *Detector) Providers() []app.ProviderDescription {
+	descriptions := make([]app.ProviderDescription, 0, len(d.providers))
 	for _, provider := range d.providers {
-		detected, err := provider.Detect(ctx)
+		if describer, ok := provider.(Describer); ok {
+			descriptions = append(descriptions, describer.Describe())
+		} else {
+			descriptions = append(descriptions, app.ProviderDescription{Name: provider.Name()})
+		}
+	}
+	return descriptions
+}
+
+// Detect runs detection on the detector's path using all registered providers and returns a plan
+func (d *Detector) Detect() (*Plan, error) {
+	return d.DetectAt(context.Background(), d.path)
+}
+
+// DetectContext runs detection on the detector's path under the given context
+func (d *Detector) DetectContext(c context.Context) (*Plan, error) {
+	return d.DetectAt(c, d.path)
+}
+
+// DetectAt runs detection on the given path and returns a plan.
+// Detection stops with the context's error when it is canceled or the
+// detector's timeout passes, even if a provider is stuck reading a file.
+func (d *Detector) DetectAt(c context.Context, path string) (*Plan, error) {
+	c, cancel := d.withTimeout(c)
+	defer cancel()
+	ctx := d.newContext(c, path)
+
+	var cacheKey string
+	if d.cacheDir != "" {
+		cacheKey = d.cacheKey(ctx)
+		if plan := d.cachedPlan(ctx, cacheKey); plan != nil {
+			ctx.Log().Info("using cached plan", "file", cacheKey)
+			logPlan(ctx.Log(), plan)
+			return plan, nil
+		}
+		ctx.TrackInputs()
+	}
+
+	// Try each enabled provider in order
+	for _, provider := range d.enabledProviders(ctx) {
+		if err := c.Err(); err != nil {
+			return nil, d.stopped(err)
+		}
+
+		detected, err := safeDetect(c, provider, ctx)
+		if c.Err() != nil {
+			return nil, d.stopped(c.Err())
+		}
 		if err != nil {
-			// Log error but continue to next provider
+			// A failing provider must not stop the others from detecting
+			logProviderError(ctx.Log(), provider.Name(), err)
+			continue
+		}
+		if !detected {
+			ctx.Log().Debug("provider did not match", "provider", provider.Name())
 			continue
 		}
 
-		if detected {
-			return provider.Plan(ctx)
+		ctx.Log().Info("provider matched", "provider", provider.Name())
+		plan, err := safePlan(c, provider, ctx)
+		if c.Err() != nil {
+			return nil, d.stopped(c.Err())
+		}
+		if err == nil {
+			warnMiscased(ctx, plan)
+			warnDiagnostics(ctx, plan)
+			logPlan(ctx.Log(), plan)
+			if cacheKey != "" && plan != nil {
+				d.cachePlan(ctx, cacheKey, plan)
+			}
 		}
+		return plan, err
 	}
 
+	ctx.Log().Info("no provider matched")
+	warnMiscased(ctx, nil)
 	return nil, nil
 }
 
-// loadRelevantEnvVars loads environment variables that influence detection
-func loadRelevantEnvVars() map[string]string {
+// logProviderError logs a provider error that detection continues after,
+// with the stack of a panic at debug level
+func logProviderError(logger *slog.Logger, provider string, err error) {
+	logger.Warn("provider failed", "provider", provider, "error", err)
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		logger.Debug("provider panic stack", "provider", provider, "stack", string(panicErr.Stack))
+	}
+}
+
+// logPlan logs the detected plan, and each decision behind it at debug level
+func logPlan(logger *slog.Logger, plan *Plan) {
+	if plan == nil {
+		return
+	}
+	logger.Info("detected",
+		"language", plan.Language,
+		"language_version", plan.LanguageVersion,
+		"framework", plan.Framework,
+		"package_manager", plan.PackageManager,
+	)
+	for _, d := range plan.Decisions {
+		logger.Debug("decision", "field", d.Field, "value", d.Value, "source", d.Source, "reason", d.Describe())
+	}
+	for _, w := range plan.Warnings {
+		logger.Debug("plan warning", "code", w.Code, "message", w.Message)
+	}
+}
+
+// newContext creates the app.Context for a path with the environment variables
+// that might influence detection (copied, since providers may run concurrently
+// on the same detector)
+func (d *Detector) newContext(c context.Context, path string) *app.Context {
+	ctx := app.NewContext(path)
+	ctx.SetContext(c)
+	if d.logger != nil {
+		ctx.Logger = d.logger
+	}
+	ctx.Logger = ctx.Logger.With("path", path)
+	if d.env != nil {
+		for k, v := range d.env {
+			ctx.Env[k] = v
+		}
+		ctx.ProcessEnv = false
+	} else {
+		ctx.Env = LoadEnv()
+	}
+	switch ctx.Env["COOLPACK_CASE_INSENSITIVE"] {
+	case "true", "1":
+		ctx.CaseInsensitive = true
+	case "false", "0":
+		ctx.CaseInsensitive = false
+	}
+	return ctx
+}
+
+// warnMiscased reports the files detection found only with different casing:
+// as plan warnings, or in the log when nothing was detected (which they may
+// be the reason for)
+func warnMiscased(ctx *app.Context, plan *Plan) {
+	for _, f := range ctx.MiscasedFiles() {
+		if plan == nil {
+			ctx.Log().Warn("file name has unexpected casing; builds on Linux are case-sensitive", "expected", f.Name, "found", f.Found)
+			continue
+		}
+		plan.AddWarning("file_name_case",
+			fmt.Sprintf("%s should be named %s: builds run on Linux, where file names are case-sensitive", f.Found, f.Name),
+			f.Found)
+	}
+}
+
+// warnDiagnostics adds the problems providers found in project files as plan
+// warnings, with their location
+func warnDiagnostics(ctx *app.Context, plan *Plan) {
+	if plan == nil {
+		return
+	}
+	for _, d := range ctx.Diagnostics() {
+		plan.AddWarningAt(d.Code, fmt.Sprintf("%s: %s", d.Location(), d.Message), d.File, d.Line)
+	}
+}
+
+// PanicError is returned when a provider panics while analyzing an application.
+// Repositories are untrusted input, so a parser bug must not crash the caller.
+type PanicError struct {
+	Provider string
+	Value    interface{}
+	Stack    []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s provider panicked: %v", e.Provider, e.Value)
+}
+
+// safeDetect runs provider.Detect, turning a panic into a PanicError
+func safeDetect(c context.Context, provider Provider, ctx *app.Context) (bool, error) {
+	return await(c, func() (detected bool, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				detected, err = false, &PanicError{Provider: provider.Name(), Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return provider.Detect(c, ctx)
+	})
+}
+
+// safePlan runs provider.Plan, turning a panic into a PanicError
+func safePlan(c context.Context, provider Provider, ctx *app.Context) (*app.Plan, error) {
+	return await(c, func() (plan *app.Plan, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				plan, err = nil, &PanicError{Provider: provider.Name(), Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return provider.Plan(c, ctx)
+	})
+}
+
+// await runs fn in a goroutine and returns its result, or the context's error
+// as soon as the context is done. A provider blocked in a system call (e.g., a
+// read on a hung network filesystem) can't be interrupted; it is left to
+// finish in the background, and its file operations fail from then on.
+func await[T any](c context.Context, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-c.Done():
+		var zero T
+		return zero, c.Err()
+	}
+}
+
+// LoadEnv loads the process environment variables that influence detection
+func LoadEnv() map[string]string {
 	env := make(map[string]string)
 
 	// Coolpack config
@@ -67,15 +392,29 @@ func loadRelevantEnvVars() map[string]string {
 		"COOLPACK_INSTALL_CMD",
 		"COOLPACK_BUILD_CMD",
 		"COOLPACK_START_CMD",
+		// Framework override ("none" for no framework)
+		"COOLPACK_FRAMEWORK",
+		// Provider allowlist and denylist
+		"COOLPACK_PROVIDERS",
+		"COOLPACK_DISABLE_PROVIDERS",
 		// Image and version overrides
 		"COOLPACK_BASE_IMAGE",
 		"COOLPACK_NODE_VERSION",
+		"COOLPACK_NODE_DEFAULT",
 		"COOLPACK_SPA_OUTPUT_DIR",
 		// Static server (caddy or nginx)
 		"COOLPACK_STATIC_SERVER",
 		// SPA mode
 		"COOLPACK_SPA",
 		"COOLPACK_NO_SPA",
+		// Static asset precompression
+		"COOLPACK_PRECOMPRESS",
+		// V8 heap limit for the build in MB (0 turns it off)
+		"COOLPACK_BUILD_HEAP_SIZE",
+		// Target platforms for multi-architecture builds
+		"COOLPACK_PLATFORMS",
+		// File name matching (true on macOS and Windows by default)
+		"COOLPACK_CASE_INSENSITIVE",
 		// Legacy support
 		"NODE_VERSION",
 	}
@@ -0,0 +1,169 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// fixtureApps returns the paths of the applications under testdata/apps
+func fixtureApps(t *testing.T) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join("testdata", "apps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apps []string
+	for _, e := range entries {
+		if e.IsDir() {
+			apps = append(apps, filepath.Join("testdata", "apps", e.Name()))
+		}
+	}
+	if len(apps) < 2 {
+		t.Fatalf("expected several fixture apps, found %d", len(apps))
+	}
+	return apps
+}
+
+// planJSON detects path and returns the plan as JSON
+func planJSON(t *testing.T, d *Detector, path string) string {
+	plan, err := d.DetectAt(context.Background(), path)
+	if err != nil {
+		t.Errorf("%s: %v", path, err)
+		return ""
+	}
+	if plan == nil {
+		t.Errorf("%s: nothing detected", path)
+		return ""
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Errorf("%s: %v", path, err)
+		return ""
+	}
+	return string(data)
+}
+
+// TestDetectAtConcurrent runs detections of several apps in parallel on one
+// Detector and checks each returns the plan a sequential detection does.
+// Run with -race to catch shared state between detections.
+func TestDetectAtConcurrent(t *testing.T) {
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		t.Run(name, func(t *testing.T) {
+			apps := fixtureApps(t)
+			d := NewWithEnv(".", map[string]string{"COOLPACK_NODE_DEFAULT": "lts"})
+			d.SetLogger(slog.New(slog.DiscardHandler))
+
+			want := make(map[string]string, len(apps))
+			for _, app := range apps {
+				want[app] = planJSON(t, d, app)
+			}
+			if cached {
+				d.SetCache(t.TempDir())
+			}
+
+			const rounds = 8
+			var wg sync.WaitGroup
+			for range rounds {
+				for _, app := range apps {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if got := planJSON(t, d, app); got != want[app] {
+							t.Errorf("%s: concurrent plan differs from sequential one\ngot:  %s\nwant: %s", app, got, want[app])
+						}
+					}()
+				}
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// panicProvider panics in Detect or Plan, like a parser bug on a malformed file
+type panicProvider struct {
+	inPlan bool
+}
+
+func (p panicProvider) Name() string { return "panicky" }
+
+func (p panicProvider) Detect(context.Context, *app.Context) (bool, error) {
+	if !p.inPlan {
+		panic("malformed input")
+	}
+	return true, nil
+}
+
+func (p panicProvider) Plan(context.Context, *app.Context) (*app.Plan, error) {
+	panic("malformed input")
+}
+
+// TestDetectRecoversProviderPanic checks a provider panic is returned as a
+// PanicError (Plan) or skipped like a failed provider (Detect) instead of
+// crashing the caller
+func TestDetectRecoversProviderPanic(t *testing.T) {
+	apps := fixtureApps(t)
+
+	d := NewWithEnv(".", nil)
+	d.SetLogger(slog.New(slog.DiscardHandler))
+	d.providers = []Provider{panicProvider{}}
+	plan, err := d.DetectAt(context.Background(), apps[0])
+	if plan != nil || err != nil {
+		t.Errorf("panic in Detect: got plan %v and error %v, want neither", plan, err)
+	}
+
+	d.providers = []Provider{panicProvider{inPlan: true}}
+	plan, err = d.DetectAt(context.Background(), apps[0])
+	var panicErr *PanicError
+	if plan != nil || !errors.As(err, &panicErr) {
+		t.Fatalf("panic in Plan: got plan %v and error %v, want a PanicError", plan, err)
+	}
+	if panicErr.Provider != "panicky" || panicErr.Value != "malformed input" || len(panicErr.Stack) == 0 {
+		t.Errorf("PanicError = %+v, want the provider, panic value and stack", panicErr)
+	}
+}
+
+// TestDetectSuppliedEnv checks variables an application needs are looked up
+// in the environment given to NewWithEnv, not the process environment
+func TestDetectSuppliedEnv(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"name": "app", "scripts": {"start": "node index.js"}}`,
+		"index.js":     "",
+		".env.example": "API_KEY=\nPROCESS_ONLY=\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PROCESS_ONLY", "1")
+
+	d := NewWithEnv(".", map[string]string{"API_KEY": "secret"})
+	d.SetLogger(slog.New(slog.DiscardHandler))
+	plan, err := d.DetectAt(context.Background(), dir)
+	if err != nil || plan == nil {
+		t.Fatalf("DetectAt = %v, %v", plan, err)
+	}
+	var missing string
+	for _, w := range plan.Warnings {
+		if w.Code == "env_example_missing" {
+			missing = w.Message
+		}
+	}
+	if !strings.HasSuffix(missing, "not set: PROCESS_ONLY") {
+		t.Errorf("env_example_missing warning = %q, want only PROCESS_ONLY missing", missing)
+	}
+}
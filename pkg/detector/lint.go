@@ -0,0 +1,59 @@
+package detector
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// Lint checks the detector's path for deployability issues without generating
+// a plan. It returns nil when no provider detects the application; providers
+// that don't implement Linter report no findings. Linting is limited by the
+// detector's timeout like detection.
+func (d *Detector) Lint() (*app.LintReport, error) {
+	c, cancel := d.withTimeout(context.Background())
+	defer cancel()
+	ctx := d.newContext(c, d.path)
+
+	for _, provider := range d.enabledProviders(ctx) {
+		detected, err := safeDetect(c, provider, ctx)
+		if c.Err() != nil {
+			return nil, d.stopped(c.Err())
+		}
+		if err != nil {
+			logProviderError(ctx.Log(), provider.Name(), err)
+			continue
+		}
+		if !detected {
+			continue
+		}
+
+		report := &app.LintReport{Provider: provider.Name(), Findings: []app.Finding{}}
+		if linter, ok := provider.(Linter); ok {
+			findings, err := safeLint(c, provider.Name(), linter, ctx)
+			if c.Err() != nil {
+				return nil, d.stopped(c.Err())
+			}
+			if err != nil {
+				return nil, err
+			}
+			report.Findings = append(report.Findings, findings...)
+		}
+		return report, nil
+	}
+
+	return nil, nil
+}
+
+// safeLint runs linter.Lint, turning a panic into a PanicError
+func safeLint(c context.Context, name string, linter Linter, ctx *app.Context) ([]app.Finding, error) {
+	return await(c, func() (findings []app.Finding, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				findings, err = nil, &PanicError{Provider: name, Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return linter.Lint(ctx), nil
+	})
+}
@@ -1,21 +1,42 @@
 package detector
 
 import (
+	"context"
+
 	"github.com/coollabsio/coolpack/pkg/app"
 )
 
 // Plan is an alias to app.Plan for convenience
 type Plan = app.Plan
 
-// Provider is the interface that all language/framework providers must implement
+// Provider is the interface that all language/framework providers must implement.
+// Providers must be safe for concurrent use: keep per-detection state in locals
+// or the app.Context, never in the provider or package-level variables.
+//
+// Detect and Plan get the context detection runs under (also set on the
+// app.Context, whose file operations fail once it is done). Providers pass it
+// to anything that may block and return its error when it is done, rather
+// than a plan built from files they could no longer read.
 type Provider interface {
 	// Name returns the name of the provider
 	Name() string
 
 	// Detect checks if this provider can handle the application at the given path
 	// Returns true if the provider detected a matching application
-	Detect(ctx *app.Context) (bool, error)
+	Detect(c context.Context, ctx *app.Context) (bool, error)
 
 	// Plan generates a build plan for the detected application
-	Plan(ctx *app.Context) (*app.Plan, error)
+	Plan(c context.Context, ctx *app.Context) (*app.Plan, error)
+}
+
+// Linter is implemented by providers that can check an application for
+// deployability issues without planning it
+type Linter interface {
+	Lint(ctx *app.Context) []app.Finding
+}
+
+// Describer is implemented by providers that can describe what they detect
+// (see Detector.Providers)
+type Describer interface {
+	Describe() app.ProviderDescription
 }
@@ -2,9 +2,19 @@ package generator
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/coollabsio/coolpack/pkg/semver"
+)
+
+// Caddy commands of the static runtime stage
+var (
+	caddyConfigCmd     = []string{"caddy", "run", "--config", "/etc/caddy/Caddyfile"}
+	caddyFileServerCmd = []string{"caddy", "file-server", "--root", "/srv", "--listen", ":80"}
 )
 
 // Generator generates build files from a plan
@@ -19,6 +29,15 @@ func New(plan *app.Plan) *Generator {
 
 // GenerateDockerfile generates a Dockerfile based on the plan
 func (g *Generator) GenerateDockerfile() (string, error) {
+	// Plans for apps that can't run in a container (e.g., Electron) are refused
+	if !g.plan.Metadata.IsDeployable() {
+		reason := "application cannot be served from a container"
+		if r := g.plan.Metadata.NotDeployableReason; r != "" {
+			reason = r
+		}
+		return "", fmt.Errorf("not a deployable web app: %s", reason)
+	}
+
 	switch g.plan.Provider {
 	case "node":
 		return g.generateNodeDockerfile()
@@ -30,30 +49,9 @@ func (g *Generator) GenerateDockerfile() (string, error) {
 func (g *Generator) generateNodeDockerfile() (string, error) {
 	var sb strings.Builder
 
-	nodeVersion := g.plan.LanguageVersion
-	if nodeVersion == "" {
-		nodeVersion = "24"
-	}
+	outputType := g.plan.OutputType()
 
-	outputType := "server"
-	if ot, ok := g.plan.Metadata["output_type"].(string); ok {
-		outputType = ot
-	}
-
-	// Determine base image variant (COOLPACK_BASE_IMAGE overrides default)
-	var baseImage string
-	if customBase, ok := g.plan.Metadata["base_image"].(string); ok && customBase != "" {
-		baseImage = customBase
-	} else if g.plan.PackageManager == "bun" {
-		// Use official bun image when bun is the package manager
-		bunVersion := "latest"
-		if g.plan.PackageManagerVersion != "" {
-			bunVersion = g.plan.PackageManagerVersion
-		}
-		baseImage = fmt.Sprintf("oven/bun:%s-slim", bunVersion)
-	} else {
-		baseImage = fmt.Sprintf("node:%s-slim", nodeVersion)
-	}
+	baseImage := g.pinned(g.baseImage())
 
 	// Write Dockerfile with BuildKit syntax for cache mounts
 	sb.WriteString("# syntax=docker/dockerfile:1\n")
@@ -69,6 +67,60 @@ func (g *Generator) generateNodeDockerfile() (string, error) {
 	return sb.String(), nil
 }
 
+// baseImage returns the builder image (COOLPACK_BASE_IMAGE overrides the default)
+func (g *Generator) baseImage() string {
+	if customBase := g.plan.Metadata.BaseImage; customBase != "" {
+		return customBase
+	}
+
+	images := data.Load().BaseImages
+	if g.plan.PackageManager == "bun" {
+		// Use official bun image when bun is the package manager
+		return images["bun"].Image(g.plan.PackageManagerVersion)
+	}
+
+	return images["node"].Image(g.plan.LanguageVersion)
+}
+
+// staticServer returns the static file server (caddy is default, nginx is option)
+func (g *Generator) staticServer() string {
+	if ss := g.plan.Metadata.StaticServer; ss != "" {
+		return ss
+	}
+	return "caddy"
+}
+
+// staticServerImage returns the image of the static file server stage
+func (g *Generator) staticServerImage() string {
+	images := data.Load().BaseImages
+	if g.staticServer() == "nginx" {
+		return images["nginx"].Image("")
+	}
+	return images["caddy"].Image("")
+}
+
+// Images returns the images the generated Dockerfile is built from, without digests
+func (g *Generator) Images() []string {
+	switch g.plan.Provider {
+	case "node":
+		images := []string{g.baseImage()}
+		if g.plan.OutputType() == "static" {
+			images = append(images, g.staticServerImage())
+		}
+		return images
+	default:
+		return nil
+	}
+}
+
+// pinned appends the plan's recorded digest to an image reference, if any
+func (g *Generator) pinned(image string) string {
+	if digest := g.plan.ImageDigests[image]; digest != "" && !strings.Contains(image, "@") {
+		return image + "@" + digest
+	}
+	return image
+}
+
 func (g *Generator) writeServerDockerfile(sb *strings.Builder, baseImage string) {
 	pm := g.plan.PackageManager
 	if pm == "" {
@@ -92,22 +144,43 @@ func (g *Generator) writeServerDockerfile(sb *strings.Builder, baseImage string)
 	g.writeCopyPackageFiles(sb, pm)
 
 	// Install dependencies with cache mount
-	cacheMount := g.getCacheMount(pm)
+	cacheMount := g.getCacheMount(pm) + g.getSecretMounts()
 	sb.WriteString(fmt.Sprintf("RUN %s%s\n\n", cacheMount, g.plan.InstallCommand))
 
+	// Fail early if a per-architecture binary is missing for the target platform
+	g.writePrebuiltBinaryChecks(sb, baseImage)
+
 	// Copy source code
 	sb.WriteString("COPY . .\n\n")
 
+	// Run install scripts that need the source (the install skipped them)
+	if rebuild := g.plan.Metadata.RebuildCommand; rebuild != "" {
+		sb.WriteString("# Run install scripts\n")
+		sb.WriteString(fmt.Sprintf("RUN %s%s\n\n", g.getSecretMounts(), rebuild))
+	}
+
 	// Build if there's a build command
 	if g.plan.BuildCommand != "" {
-		buildCacheMount := g.getBuildCacheMount()
+		buildCacheMount := g.getBuildCacheMount() + g.getSecretMounts()
 		sb.WriteString(fmt.Sprintf("RUN %s%s\n\n", buildCacheMount, g.plan.BuildCommand))
 	}
 
+	// Run the tests when asked to, before devDependencies are pruned
+	g.writeTestCommand(sb)
+
+	// Remove devDependencies so compilers and test frameworks aren't copied into the runtime stage
+	if prune := g.plan.Metadata.PruneCommand; prune != "" {
+		sb.WriteString("# Prune devDependencies\n")
+		sb.WriteString(fmt.Sprintf("RUN %s%s\n\n", cacheMount, prune))
+	}
+
 	// Production stage
 	sb.WriteString(fmt.Sprintf("FROM %s AS runner\n", baseImage))
 	sb.WriteString("WORKDIR /app\n\n")
 
+	// Install APT packages needed at runtime (fonts, browsers, etc.)
+	g.writeRuntimeAptInstall(sb)
+
 	// Install package manager if not npm
 	g.writePackageManagerInstall(sb, pm)
 
@@ -116,17 +189,37 @@ func (g *Generator) writeServerDockerfile(sb *strings.Builder, baseImage string)
 	sb.WriteString("    adduser --system --uid 1001 --ingroup coolgroup cooluser\n\n")
 
 	// Set production environment (build envs are NOT included - pass at runtime via docker run -e)
-	sb.WriteString("ENV NODE_ENV=production\n\n")
+	port := g.getServerPort()
+	if _, ok := g.plan.Env["NODE_ENV"]; !ok {
+		// Plans written before providers set NODE_ENV in env
+		sb.WriteString("ENV NODE_ENV=production\n")
+	}
+	sb.WriteString(fmt.Sprintf("ENV PORT=%d\n", port))
+	g.writeRuntimeEnv(sb)
+	sb.WriteString("\n")
 
 	// Copy built application
 	g.writeServerCopyStatements(sb, pm)
 
+	// Create volume mount points so they are owned by the app user
+	if len(g.plan.Volumes) > 0 {
+		paths := make([]string, 0, len(g.plan.Volumes))
+		for _, v := range g.plan.Volumes {
+			paths = append(paths, v.Path)
+		}
+		list := strings.Join(paths, " ")
+		sb.WriteString(fmt.Sprintf("RUN mkdir -p %s && chown cooluser:coolgroup %s\n", list, list))
+	}
+
 	// Set ownership and switch to non-root user
 	sb.WriteString("RUN chown -R cooluser:coolgroup /app\n")
 	sb.WriteString("USER cooluser\n\n")
 
 	// Expose port
-	sb.WriteString("EXPOSE 3000\n\n")
+	sb.WriteString(fmt.Sprintf("EXPOSE %d\n\n", port))
+
+	// Labels go last so plan changes don't invalidate cached layers
+	g.writeLabels(sb)
 
 	// Start command
 	if g.plan.StartCommand != "" {
@@ -159,27 +252,44 @@ func (g *Generator) writeStaticDockerfile(sb *strings.Builder, baseImage string)
 	g.writeCopyPackageFiles(sb, pm)
 
 	// Install dependencies with cache mount
-	cacheMount := g.getCacheMount(pm)
+	cacheMount := g.getCacheMount(pm) + g.getSecretMounts()
 	sb.WriteString(fmt.Sprintf("RUN %s%s\n\n", cacheMount, g.plan.InstallCommand))
 
+	// Fail early if a per-architecture binary is missing for the target platform
+	g.writePrebuiltBinaryChecks(sb, baseImage)
+
 	// Copy source code
 	sb.WriteString("COPY . .\n\n")
 
+	// Run install scripts that need the source (the install skipped them)
+	if rebuild := g.plan.Metadata.RebuildCommand; rebuild != "" {
+		sb.WriteString("# Run install scripts\n")
+		sb.WriteString(fmt.Sprintf("RUN %s%s\n\n", g.getSecretMounts(), rebuild))
+	}
+
 	// Build
 	if g.plan.BuildCommand != "" {
-		buildCacheMount := g.getBuildCacheMount()
+		buildCacheMount := g.getBuildCacheMount() + g.getSecretMounts()
 		sb.WriteString(fmt.Sprintf("RUN %s%s\n\n", buildCacheMount, g.plan.BuildCommand))
 	}
 
-	// Determine static server (caddy is default, nginx is option)
-	staticServer := "caddy"
-	if ss, ok := g.plan.Metadata["static_server"].(string); ok && ss != "" {
-		staticServer = ss
-	}
+	// Run the tests when asked to
+	g.writeTestCommand(sb)
 
 	outputDir := g.getStaticOutputDir()
 
-	if staticServer == "nginx" {
+	// Precompress static output so the server can send .br/.gz files as-is
+	if g.precompress() {
+		runtime := "node"
+		if strings.HasPrefix(baseImage, "oven/bun") {
+			runtime = "bun"
+		}
+		sb.WriteString("# Precompress static output (brotli + gzip)\n")
+		writeHeredoc(sb, "/tmp/precompress.mjs", precompressScript)
+		sb.WriteString(fmt.Sprintf("RUN %s /tmp/precompress.mjs /app/%s\n\n", runtime, outputDir))
+	}
+
+	if g.staticServer() == "nginx" {
 		g.writeNginxStaticStage(sb, outputDir)
 	} else {
 		g.writeCaddyStaticStage(sb, outputDir)
@@ -188,19 +298,19 @@ func (g *Generator) writeStaticDockerfile(sb *strings.Builder, baseImage string)
 
 func (g *Generator) writeCaddyStaticStage(sb *strings.Builder, outputDir string) {
 	// Serve stage - use Caddy for static files (default)
-	sb.WriteString("FROM caddy:alpine AS runner\n\n")
+	sb.WriteString(fmt.Sprintf("FROM %s AS runner\n\n", g.pinned(g.staticServerImage())))
 
 	// Create non-root user
 	sb.WriteString("RUN addgroup --system --gid 1001 coolgroup && \\\n")
 	sb.WriteString("    adduser --system --uid 1001 -G coolgroup cooluser\n\n")
 
 	// Copy built static files
-	sb.WriteString(fmt.Sprintf("COPY --from=builder /app/%s /srv\n\n", outputDir))
+	sb.WriteString(fmt.Sprintf("COPY --from=builder /app/%s %s\n\n", outputDir, g.staticRoot("/srv")))
 
-	// Add SPA Caddyfile if needed
-	if g.isSPA() {
-		sb.WriteString("# SPA routing: serve index.html for all routes\n")
-		sb.WriteString("RUN printf '%s\\n' ':80 {' '    root * /srv' '    try_files {path} /index.html' '    file_server' '}' > /etc/caddy/Caddyfile\n\n")
+	// Add Caddyfile for SPA fallback, redirects/rewrites/headers and caching
+	if g.hasServerConfig() {
+		sb.WriteString("# Server config: SPA fallback, redirects, rewrites, headers, caching and compression\n")
+		writeHeredoc(sb, "/etc/caddy/Caddyfile", g.caddyfile())
 	}
 
 	// Set ownership
@@ -211,18 +321,77 @@ func (g *Generator) writeCaddyStaticStage(sb *strings.Builder, outputDir string)
 	// Expose port
 	sb.WriteString("EXPOSE 80\n\n")
 
+	g.writeLabels(sb)
+
 	// Caddy command
-	if g.isSPA() {
-		// Use Caddyfile for SPA routing
-		sb.WriteString("CMD [\"caddy\", \"run\", \"--config\", \"/etc/caddy/Caddyfile\"]\n")
+	if g.hasServerConfig() {
+		// Use the generated Caddyfile
+		sb.WriteString(fmt.Sprintf("CMD %s\n", execForm(caddyConfigCmd)))
 	} else {
-		sb.WriteString("CMD [\"caddy\", \"file-server\", \"--root\", \"/srv\", \"--listen\", \":80\"]\n")
+		sb.WriteString(fmt.Sprintf("CMD %s\n", execForm(caddyFileServerCmd)))
 	}
 }
 
+// StaticRuntime describes the runtime stage of a static plan served by Caddy,
+// so the image can be assembled without running the Dockerfile
+type StaticRuntime struct {
+	// Image is the static server image, with the plan's digest if pinned
+	Image string
+
+	// OutputDir is the static output directory, relative to the application
+	OutputDir string
+
+	// Root is the directory the output is copied to (e.g., "/srv", or
+	// "/srv/app/" when it is served under a base path)
+	Root string
+
+	// ConfigPath and Config are the generated Caddyfile, empty when Caddy's
+	// file-server defaults are used
+	ConfigPath string
+	Config     string
+
+	// User is the non-root user and group the server runs as ("1001:1001")
+	User string
+
+	Cmd  []string
+	Port int
+}
+
+// StaticRuntime returns the runtime stage of a static plan. Plans that need
+// commands in the runtime stage (nginx setup, precompression) aren't supported.
+func (g *Generator) StaticRuntime() (*StaticRuntime, error) {
+	if g.plan.Provider != "node" {
+		return nil, fmt.Errorf("unsupported provider: %s", g.plan.Provider)
+	}
+	if g.plan.OutputType() != "static" {
+		return nil, fmt.Errorf("output is %q, only static output can be served without a build stage", g.plan.OutputType())
+	}
+	if g.staticServer() != "caddy" {
+		return nil, fmt.Errorf("static server %q needs setup commands, only caddy is supported", g.staticServer())
+	}
+	if g.precompress() {
+		return nil, fmt.Errorf("precompression runs in the build stage")
+	}
+
+	rt := &StaticRuntime{
+		Image:     g.pinned(g.staticServerImage()),
+		OutputDir: g.getStaticOutputDir(),
+		Root:      g.staticRoot("/srv"),
+		User:      "1001:1001",
+		Cmd:       caddyFileServerCmd,
+		Port:      80,
+	}
+	if g.hasServerConfig() {
+		rt.ConfigPath = "/etc/caddy/Caddyfile"
+		rt.Config = g.caddyfile()
+		rt.Cmd = caddyConfigCmd
+	}
+	return rt, nil
+}
+
 func (g *Generator) writeNginxStaticStage(sb *strings.Builder, outputDir string) {
 	// Serve stage - use nginx for static files
-	sb.WriteString("FROM nginx:alpine AS runner\n\n")
+	sb.WriteString(fmt.Sprintf("FROM %s AS runner\n\n", g.pinned(g.staticServerImage())))
 
 	// Create non-root user and configure nginx to run on port 80 as non-root
 	sb.WriteString("RUN addgroup --system --gid 1001 coolgroup && \\\n")
@@ -237,19 +406,12 @@ func (g *Generator) writeNginxStaticStage(sb *strings.Builder, outputDir string)
 	sb.WriteString("    chown cooluser:coolgroup /var/run/nginx.pid\n\n")
 
 	// Copy built static files to nginx
-	sb.WriteString(fmt.Sprintf("COPY --from=builder /app/%s /usr/share/nginx/html\n\n", outputDir))
+	sb.WriteString(fmt.Sprintf("COPY --from=builder /app/%s %s\n\n", outputDir, g.staticRoot("/usr/share/nginx/html")))
 
-	// Add SPA nginx config if needed
-	if g.isSPA() {
-		sb.WriteString("# SPA routing: serve index.html for all routes\n")
-		sb.WriteString("RUN echo 'server { \\\n")
-		sb.WriteString("    listen 80; \\\n")
-		sb.WriteString("    root /usr/share/nginx/html; \\\n")
-		sb.WriteString("    index index.html; \\\n")
-		sb.WriteString("    location / { \\\n")
-		sb.WriteString("        try_files $uri $uri/ /index.html; \\\n")
-		sb.WriteString("    } \\\n")
-		sb.WriteString("}' > /etc/nginx/conf.d/default.conf\n\n")
+	// Add nginx config for SPA fallback, redirects/rewrites/headers and caching
+	if g.hasServerConfig() {
+		sb.WriteString("# Server config: SPA fallback, redirects, rewrites, headers, caching and compression\n")
+		writeHeredoc(sb, "/etc/nginx/conf.d/default.conf", g.nginxConfig())
 	}
 
 	sb.WriteString("USER cooluser\n\n")
@@ -257,15 +419,77 @@ func (g *Generator) writeNginxStaticStage(sb *strings.Builder, outputDir string)
 	// Expose port
 	sb.WriteString("EXPOSE 80\n\n")
 
+	g.writeLabels(sb)
+
 	sb.WriteString("CMD [\"nginx\", \"-g\", \"daemon off;\"]\n")
 }
 
+// execForm formats a command as a JSON array for CMD (e.g., ["caddy", "run"])
+func execForm(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = strconv.Quote(arg)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// writeTestCommand runs the plan's test command in the builder stage when
+// Metadata.RunTests is set; a failing test fails the build
+func (g *Generator) writeTestCommand(sb *strings.Builder) {
+	if !g.plan.Metadata.RunTests || g.plan.TestCommand == "" {
+		return
+	}
+	sb.WriteString("# Run tests\n")
+	sb.WriteString(fmt.Sprintf("RUN %s%s\n\n", g.getSecretMounts(), g.plan.TestCommand))
+}
+
+// writeRuntimeEnv writes ENV instructions for the plan's runtime environment variables
+func (g *Generator) writeRuntimeEnv(sb *strings.Builder) {
+	keys := make([]string, 0, len(g.plan.Env))
+	for k := range g.plan.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("ENV %s=%q\n", k, g.plan.Env[k]))
+	}
+}
+
+// getServerPort returns the primary port of a server application
+func (g *Generator) getServerPort() int {
+	if len(g.plan.Ports) > 0 && g.plan.Ports[0] > 0 {
+		return g.plan.Ports[0]
+	}
+	return 3000
+}
+
 // isSPA returns true if the application is a Single Page Application
 func (g *Generator) isSPA() bool {
-	if isSPA, ok := g.plan.Metadata["is_spa"].(bool); ok {
-		return isSPA
+	return g.plan.IsSPA()
+}
+
+// writePrebuiltBinaryChecks loads packages with per-architecture binaries when
+// building for explicit target platforms, so a lockfile missing the optional
+// dependency for one architecture fails the build with a clear message instead
+// of crashing at runtime
+func (g *Generator) writePrebuiltBinaryChecks(sb *strings.Builder, baseImage string) {
+	if len(g.plan.Platforms) == 0 || g.plan.NativeDeps == nil || len(g.plan.NativeDeps.PrebuiltBinaries) == 0 {
+		return
 	}
-	return false
+
+	runtime := "node"
+	if strings.HasPrefix(baseImage, "oven/bun") {
+		runtime = "bun"
+	}
+
+	sb.WriteString("# Check per-architecture binaries for the target platform\n")
+	sb.WriteString("ARG TARGETPLATFORM\n")
+	for _, bin := range g.plan.NativeDeps.PrebuiltBinaries {
+		sb.WriteString(fmt.Sprintf("RUN %s -e \"%s\" || { echo \"%s has no binary for $TARGETPLATFORM; regenerate the lockfile so it includes optional dependencies for all platforms\" >&2; exit 1; }\n",
+			runtime, bin.Check, bin.Package))
+	}
+	sb.WriteString("\n")
 }
 
 func (g *Generator) writePackageManagerInstall(sb *strings.Builder, pm string) {
@@ -276,15 +500,19 @@ func (g *Generator) writePackageManagerInstall(sb *strings.Builder, pm string) {
 			version = g.plan.PackageManagerVersion
 		}
 		sb.WriteString(fmt.Sprintf("RUN corepack enable && corepack prepare pnpm@%s --activate\n\n", version))
-	case "yarn":
+	case "yarn", "yarnberry":
 		// yarn v1 is included with node, yarn berry needs corepack
-		if g.plan.PackageManagerVersion != "" && !strings.HasPrefix(g.plan.PackageManagerVersion, "1.") {
-			sb.WriteString("RUN corepack enable\n\n")
+		if g.isYarnBerry() {
+			if v := g.plan.PackageManagerVersion; v != "" {
+				sb.WriteString(fmt.Sprintf("RUN corepack enable && corepack prepare yarn@%s --activate\n\n", v))
+			} else {
+				sb.WriteString("RUN corepack enable\n\n")
+			}
 		}
 	case "bun":
 		// bun is already installed when using oven/bun image
 		// Only install if using a custom base image (non-bun)
-		if customBase, ok := g.plan.Metadata["base_image"].(string); ok && customBase != "" && !strings.Contains(customBase, "bun") {
+		if customBase := g.plan.Metadata.BaseImage; customBase != "" && !strings.Contains(customBase, "bun") {
 			if g.plan.PackageManagerVersion != "" {
 				sb.WriteString(fmt.Sprintf("RUN npm install -g bun@%s\n\n", g.plan.PackageManagerVersion))
 			} else {
@@ -300,7 +528,7 @@ func (g *Generator) writeCopyPackageFiles(sb *strings.Builder, pm string) {
 	switch pm {
 	case "npm":
 		sb.WriteString("package-lock.json* ")
-	case "yarn":
+	case "yarn", "yarnberry":
 		sb.WriteString("yarn.lock* .yarnrc.yml* ")
 	case "pnpm":
 		sb.WriteString("pnpm-lock.yaml* ")
@@ -308,72 +536,81 @@ func (g *Generator) writeCopyPackageFiles(sb *strings.Builder, pm string) {
 		sb.WriteString("bun.lockb* bun.lock* ")
 	}
 
+	// Registry config (tokens are referenced as ${VAR} and mounted as build secrets)
+	sb.WriteString(".npmrc* ")
+
 	sb.WriteString("./\n\n")
 }
 
 func (g *Generator) writeServerCopyStatements(sb *strings.Builder, pm string) {
-	framework := g.plan.Framework
-
 	// Copy node_modules for production
 	sb.WriteString("COPY --from=builder /app/node_modules ./node_modules\n")
 
-	// Framework-specific copy statements
-	switch framework {
-	case "nextjs":
-		sb.WriteString("COPY --from=builder /app/.next ./.next\n")
-		sb.WriteString("COPY --from=builder /app/public ./public\n")
-		sb.WriteString("COPY --from=builder /app/package.json ./\n")
-	case "nuxt":
-		sb.WriteString("COPY --from=builder /app/.output ./.output\n")
-	case "remix":
-		sb.WriteString("COPY --from=builder /app/build ./build\n")
+	// Without a known build output directory, copy everything
+	var outputDir string
+	if g.plan.Output != nil {
+		outputDir = g.plan.Output.Dir
+	}
+	if outputDir == "" {
+		sb.WriteString("COPY --from=builder /app .\n\n")
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("COPY --from=builder /app/%s ./%s\n", outputDir, outputDir))
+
+	// Frameworks that serve public/ from the server at runtime
+	switch g.plan.Framework {
+	case "nextjs", "remix":
 		sb.WriteString("COPY --from=builder /app/public ./public\n")
-		sb.WriteString("COPY --from=builder /app/package.json ./\n")
-	case "astro":
-		sb.WriteString("COPY --from=builder /app/dist ./dist\n")
-	case "sveltekit":
-		sb.WriteString("COPY --from=builder /app/build ./build\n")
-		sb.WriteString("COPY --from=builder /app/package.json ./\n")
-	case "solid-start", "tanstack-start":
-		sb.WriteString("COPY --from=builder /app/.output ./.output\n")
-	default:
-		// Generic: copy everything
-		sb.WriteString("COPY --from=builder /app .\n")
 	}
+
+	// Files read from disk at runtime (e.g., locale directories) outside the output directory
+	for _, file := range g.plan.Metadata.RuntimeFiles {
+		if g.isCopiedWithOutput(file, outputDir) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("COPY --from=builder /app/%s ./%s\n", file, file))
+	}
+
+	// package.json is needed by start scripts (npm start, etc.)
+	sb.WriteString("COPY --from=builder /app/package.json ./\n")
 	sb.WriteString("\n")
 }
 
+// isCopiedWithOutput returns true if a path is already copied into the runtime stage
+// as part of the output directory or the server's public/ directory
+func (g *Generator) isCopiedWithOutput(file, outputDir string) bool {
+	if file == outputDir || strings.HasPrefix(file, outputDir+"/") {
+		return true
+	}
+	switch g.plan.Framework {
+	case "nextjs", "remix":
+		return file == "public" || strings.HasPrefix(file, "public/")
+	}
+	return false
+}
+
 func (g *Generator) getStaticOutputDir() string {
 	// Check for user override first (CLI flag or COOLPACK_SPA_OUTPUT_DIR env var)
-	if override, ok := g.plan.Metadata["output_dir_override"].(string); ok && override != "" {
-		return override
+	if g.plan.Output != nil && g.plan.Output.DirOverride != "" {
+		return g.plan.Output.DirOverride
 	}
 
-	// Framework-specific defaults
-	framework := g.plan.Framework
+	// Output directory detected by the provider
+	if g.plan.Output != nil && g.plan.Output.Dir != "" {
+		return g.plan.Output.Dir
+	}
 
-	switch framework {
-	case "nextjs":
-		return "out"
-	case "nuxt":
-		return ".output/public"
-	case "astro":
-		return "dist"
-	case "gatsby":
-		return "public"
-	case "vite", "create-react-app":
-		return "dist"
-	case "angular":
-		return "dist"
-	case "sveltekit":
-		return "build"
-	case "solid-start", "tanstack-start":
-		return ".output/public"
-	case "eleventy":
-		return "_site"
-	default:
-		return "dist"
+	return "dist"
+}
+
+// staticRoot returns the directory static output is copied to: the server's
+// root, or the base path under it so URLs keep their prefix
+func (g *Generator) staticRoot(root string) string {
+	if g.plan.Output != nil && g.plan.Output.BasePath != "" {
+		return root + g.plan.Output.BasePath
 	}
+	return root
 }
 
 func (g *Generator) formatCmdCommand(cmd string) string {
@@ -386,37 +623,66 @@ func (g *Generator) formatCmdCommand(cmd string) string {
 	return "[" + strings.Join(quoted, ", ") + "]"
 }
 
-// getCacheMount returns the BuildKit cache mount for the package manager (install phase)
+// getCacheMount returns the BuildKit cache mounts for the package manager (install phase)
 func (g *Generator) getCacheMount(pm string) string {
 	var caches []string
 
-	// Package manager cache
+	// Package manager download cache (ids are shared across projects on the same builder)
 	switch pm {
-	case "npm":
-		caches = append(caches, "--mount=type=cache,target=/root/.npm")
-	case "yarn":
-		// Yarn v1 uses /usr/local/share/.cache/yarn, Yarn Berry uses .yarn/cache (local)
-		if g.plan.PackageManagerVersion != "" && !strings.HasPrefix(g.plan.PackageManagerVersion, "1.") {
-			caches = append(caches, "--mount=type=cache,target=/root/.yarn/berry/cache")
+	case "yarn", "yarnberry":
+		if g.isYarnBerry() {
+			caches = append(caches, "--mount=type=cache,id=yarn-berry,target=/root/.yarn/berry/cache")
 		} else {
-			caches = append(caches, "--mount=type=cache,target=/usr/local/share/.cache/yarn")
+			// yarn v1's cache is not safe for concurrent writers
+			caches = append(caches, "--mount=type=cache,id=yarn,target=/usr/local/share/.cache/yarn,sharing=locked")
 		}
 	case "pnpm":
-		caches = append(caches, "--mount=type=cache,target=/root/.local/share/pnpm/store")
+		caches = append(caches, "--mount=type=cache,id=pnpm,target=/root/.local/share/pnpm/store")
 	case "bun":
-		caches = append(caches, "--mount=type=cache,target=/root/.bun/install/cache")
+		caches = append(caches, "--mount=type=cache,id=bun,target=/root/.bun/install/cache")
 	default:
-		caches = append(caches, "--mount=type=cache,target=/root/.npm")
+		// npm keeps its content-addressable cache in /root/.npm/_cacache
+		caches = append(caches, "--mount=type=cache,id=npm,target=/root/.npm")
 	}
 
 	// Cypress cache (downloads happen during install)
-	if _, ok := g.plan.Metadata["has_cypress"].(bool); ok {
-		caches = append(caches, "--mount=type=cache,target=/root/.cache/Cypress")
+	if g.plan.Metadata.HasCypress {
+		caches = append(caches, "--mount=type=cache,id=cypress,target=/root/.cache/Cypress")
 	}
 
 	return strings.Join(caches, " ") + " "
 }
 
+// getSecretMounts returns BuildKit secret mounts exposing the plan's build secrets
+// as environment variables; secrets are optional, so builds without them still run
+func (g *Generator) getSecretMounts() string {
+	if len(g.plan.Secrets) == 0 {
+		return ""
+	}
+
+	mounts := make([]string, 0, len(g.plan.Secrets))
+	for _, secret := range g.plan.Secrets {
+		mount := fmt.Sprintf("--mount=type=secret,id=%s", secret.ID)
+		if secret.Env != "" {
+			mount += fmt.Sprintf(",env=%s", secret.Env)
+		}
+		mounts = append(mounts, mount)
+	}
+	return strings.Join(mounts, " ") + " "
+}
+
+// isYarnBerry returns true if the plan uses Yarn 2+
+func (g *Generator) isYarnBerry() bool {
+	switch g.plan.PackageManager {
+	case "yarnberry":
+		return true
+	case "yarn":
+		major, ok := semver.Major(g.plan.PackageManagerVersion)
+		return ok && major >= 2
+	}
+	return false
+}
+
 // getBuildCacheMount returns BuildKit cache mounts for the build phase
 // Caches framework-specific build artifacts and custom directories
 func (g *Generator) getBuildCacheMount() string {
@@ -441,17 +707,15 @@ func (g *Generator) getBuildCacheMount() string {
 	caches = append(caches, "--mount=type=cache,target=/app/node_modules/.cache")
 
 	// Moon repo cache if detected
-	if _, ok := g.plan.Metadata["has_moon"].(bool); ok {
+	if g.plan.Metadata.HasMoon {
 		caches = append(caches, "--mount=type=cache,target=/app/.moon/cache")
 	}
 
 	// Custom cache directories from package.json
-	if customDirs, ok := g.plan.Metadata["cache_directories"].([]string); ok {
-		for _, dir := range customDirs {
-			// Ensure the path is within /app
-			if !strings.HasPrefix(dir, "/") {
-				caches = append(caches, fmt.Sprintf("--mount=type=cache,target=/app/%s", dir))
-			}
+	for _, dir := range g.plan.Metadata.CacheDirectories {
+		// Ensure the path is within /app
+		if !strings.HasPrefix(dir, "/") {
+			caches = append(caches, fmt.Sprintf("--mount=type=cache,target=/app/%s", dir))
 		}
 	}
 
@@ -472,16 +736,34 @@ func (g *Generator) getBuildCacheMount() string {
 	return strings.Join(unique, " ") + " "
 }
 
-// writeBuildArgs writes ARG and ENV declarations for build-time environment variables
+// writeBuildArgs writes ARG and ENV declarations for build-time environment variables,
+// with the plan's values as ARG defaults (--build-arg overrides them).
+// Required build-time variables are declared too, so they can be passed with --build-arg
 func (g *Generator) writeBuildArgs(sb *strings.Builder) {
-	if len(g.plan.BuildEnv) == 0 {
+	args := make(map[string]string, len(g.plan.BuildEnv))
+	for k, v := range g.plan.BuildEnv {
+		args[k] = v
+	}
+	for _, req := range g.plan.RequiredEnv {
+		if req.Phase == app.PhaseBuild {
+			if _, ok := args[req.Name]; !ok {
+				args[req.Name] = ""
+			}
+		}
+	}
+
+	if len(args) == 0 {
 		return
 	}
 
 	// Write ARG declarations (sorted for consistent output)
-	keys := g.getSortedEnvKeys(g.plan.BuildEnv)
+	keys := g.getSortedEnvKeys(args)
 	for _, key := range keys {
-		sb.WriteString(fmt.Sprintf("ARG %s\n", key))
+		if value := args[key]; value != "" {
+			sb.WriteString(fmt.Sprintf("ARG %s=%q\n", key, value))
+		} else {
+			sb.WriteString(fmt.Sprintf("ARG %s\n", key))
+		}
 	}
 	// Also set as ENV so they're available to build commands (npm run build, etc.)
 	for _, key := range keys {
@@ -509,16 +791,14 @@ func (g *Generator) getSortedEnvKeys(env map[string]string) []string {
 
 // writeAptInstall writes APT package installation for native and custom packages
 func (g *Generator) writeAptInstall(sb *strings.Builder) {
-	// Collect all packages: native (apt_packages) + custom (custom_packages)
+	// Collect all packages: native (native_deps.apt_packages) + custom (custom_packages)
 	var allPackages []string
 
-	if aptPackages, ok := g.plan.Metadata["apt_packages"].([]string); ok {
-		allPackages = append(allPackages, aptPackages...)
+	if g.plan.NativeDeps != nil {
+		allPackages = append(allPackages, g.plan.NativeDeps.AptPackages...)
 	}
 
-	if customPackages, ok := g.plan.Metadata["custom_packages"].([]string); ok {
-		allPackages = append(allPackages, customPackages...)
-	}
+	allPackages = append(allPackages, g.plan.Metadata.CustomPackages...)
 
 	if len(allPackages) == 0 {
 		return
@@ -535,10 +815,10 @@ func (g *Generator) writeAptInstall(sb *strings.Builder) {
 	}
 
 	// Add comment about what packages are being installed
-	if nativePkgs, ok := g.plan.Metadata["native_packages"].([]string); ok && len(nativePkgs) > 0 {
-		sb.WriteString(fmt.Sprintf("# Native dependencies detected: %s\n", strings.Join(nativePkgs, ", ")))
+	if g.plan.NativeDeps != nil && len(g.plan.NativeDeps.Packages) > 0 {
+		sb.WriteString(fmt.Sprintf("# Native dependencies detected: %s\n", strings.Join(g.plan.NativeDeps.Packages, ", ")))
 	}
-	if customPkgs, ok := g.plan.Metadata["custom_packages"].([]string); ok && len(customPkgs) > 0 {
+	if customPkgs := g.plan.Metadata.CustomPackages; len(customPkgs) > 0 {
 		sb.WriteString(fmt.Sprintf("# Custom packages: %s\n", strings.Join(customPkgs, ", ")))
 	}
 
@@ -548,3 +828,18 @@ func (g *Generator) writeAptInstall(sb *strings.Builder) {
 	}
 	sb.WriteString("    && rm -rf /var/lib/apt/lists/*\n\n")
 }
+
+// writeRuntimeAptInstall writes APT package installation for the runtime stage
+func (g *Generator) writeRuntimeAptInstall(sb *strings.Builder) {
+	if g.plan.NativeDeps == nil || len(g.plan.NativeDeps.RuntimeAptPackages) == 0 {
+		return
+	}
+	runtimePackages := g.plan.NativeDeps.RuntimeAptPackages
+
+	sb.WriteString("# Runtime dependencies for native packages\n")
+	sb.WriteString("RUN apt-get update && apt-get install -y --no-install-recommends \\\n")
+	for _, pkg := range runtimePackages {
+		sb.WriteString(fmt.Sprintf("    %s \\\n", pkg))
+	}
+	sb.WriteString("    && rm -rf /var/lib/apt/lists/*\n\n")
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/version"
+)
+
+// Label keys stamped on generated images. OCI keys follow the image-spec
+// annotations; coolpack keys describe how the image was produced.
+const (
+	LabelTitle      = "org.opencontainers.image.title"
+	LabelVersion    = "org.opencontainers.image.version"
+	LabelCreated    = "org.opencontainers.image.created"
+	LabelRevision   = "org.opencontainers.image.revision"
+	LabelSource     = "org.opencontainers.image.source"
+	LabelBaseName   = "org.opencontainers.image.base.name"
+	LabelBaseDigest = "org.opencontainers.image.base.digest"
+
+	LabelCoolpackVersion   = "io.coollabs.coolpack.version"
+	LabelCoolpackProvider  = "io.coollabs.coolpack.provider"
+	LabelCoolpackFramework = "io.coollabs.coolpack.framework"
+	LabelCoolpackOutput    = "io.coollabs.coolpack.output"
+	LabelCoolpackPlanHash  = "io.coollabs.coolpack.plan-hash"
+)
+
+// Labels returns the labels derived from the plan. Values that change on every
+// build (created, revision, source) aren't included; pass them with
+// `docker build --label` so the Dockerfile stays cacheable.
+func (g *Generator) Labels() map[string]string {
+	labels := map[string]string{
+		LabelCoolpackVersion:  version.Version,
+		LabelCoolpackProvider: g.plan.Provider,
+		LabelCoolpackOutput:   g.plan.OutputType(),
+	}
+
+	if g.plan.Framework != "" {
+		labels[LabelCoolpackFramework] = g.plan.Framework
+	}
+	if hash, err := g.plan.Hash(); err == nil {
+		labels[LabelCoolpackPlanHash] = hash
+	}
+	if name := g.plan.Metadata.Name; name != "" {
+		labels[LabelTitle] = name
+	}
+	if v := g.plan.Metadata.Version; v != "" {
+		labels[LabelVersion] = v
+	}
+
+	// The runtime stage's image is the base of the final image
+	base := g.baseImage()
+	if g.plan.OutputType() == "static" {
+		base = g.staticServerImage()
+	}
+	labels[LabelBaseName] = base
+	if digest := g.plan.ImageDigests[base]; digest != "" {
+		labels[LabelBaseDigest] = digest
+	}
+
+	return labels
+}
+
+// writeLabels writes the plan labels as a single LABEL instruction.
+// It is written at the end of the runtime stage, so a changed plan hash
+// doesn't invalidate the cached layers before it.
+func (g *Generator) writeLabels(sb *strings.Builder) {
+	labels := g.Labels()
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sb.WriteString("LABEL")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(" \\\n     ")
+		}
+		sb.WriteString(fmt.Sprintf(" %s=%s", k, strconv.Quote(labels[k])))
+	}
+	sb.WriteString("\n\n")
+}
@@ -0,0 +1,347 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// groupRefPattern matches $1..$n group references in rule destinations
+var groupRefPattern = regexp.MustCompile(`\$(\d+)`)
+
+// Cache-Control values for content-hashed assets and everything else
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+)
+
+// nginxGzipTypes are the MIME types nginx compresses on the fly (text/html is always included)
+var nginxGzipTypes = []string{
+	"text/css",
+	"text/plain",
+	"text/xml",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/wasm",
+	"image/svg+xml",
+}
+
+// precompressScript writes .br and .gz siblings for compressible files of at least 1KB
+// under the directory given as its argument. It only uses node:zlib, so it runs on
+// node and bun without extra packages.
+const precompressScript = `import { readdirSync, readFileSync, statSync, writeFileSync } from "node:fs";
+import { extname, join } from "node:path";
+import { brotliCompressSync, constants, gzipSync } from "node:zlib";
+
+const extensions = new Set([".html", ".js", ".mjs", ".css", ".svg", ".json", ".xml", ".txt", ".wasm", ".map"]);
+
+function walk(dir) {
+  for (const entry of readdirSync(dir, { withFileTypes: true })) {
+    const file = join(dir, entry.name);
+    if (entry.isDirectory()) {
+      walk(file);
+    } else if (entry.isFile() && extensions.has(extname(file)) && statSync(file).size >= 1024) {
+      const data = readFileSync(file);
+      writeFileSync(file + ".gz", gzipSync(data, { level: 9 }));
+      writeFileSync(file + ".br", brotliCompressSync(data, { params: { [constants.BROTLI_PARAM_QUALITY]: 11 } }));
+    }
+  }
+}
+
+walk(process.argv[2]);
+`
+
+// hasRouting returns true if the plan has redirect, rewrite, header or error page rules
+func (g *Generator) hasRouting() bool {
+	r := g.plan.Routing
+	return r != nil && (len(r.Redirects) > 0 || len(r.Rewrites) > 0 || len(r.Headers) > 0 || len(r.ErrorPages) > 0)
+}
+
+// cachePolicy returns the plan's Cache-Control policy, or nil when none is set
+func (g *Generator) cachePolicy() *app.CachePolicy {
+	if g.plan.Routing == nil {
+		return nil
+	}
+	return g.plan.Routing.Cache
+}
+
+// precompress returns true if static output should be precompressed during the build
+func (g *Generator) precompress() bool {
+	return g.plan.Metadata.Precompress
+}
+
+// hasServerConfig returns true if the static server needs a generated config
+// instead of its defaults
+func (g *Generator) hasServerConfig() bool {
+	return g.isSPA() || g.hasRouting() || g.cachePolicy() != nil || g.precompress()
+}
+
+// spaFallback returns the SPA fallback rule, or nil when SPA mode is off.
+// SPA mode enabled without detected rules (e.g., --spa) falls back to /index.html for every path.
+func (g *Generator) spaFallback() *app.Fallback {
+	if !g.isSPA() {
+		return nil
+	}
+	if g.plan.Routing != nil && g.plan.Routing.Fallback != nil && g.plan.Routing.Fallback.Document != "" {
+		return g.plan.Routing.Fallback
+	}
+	return &app.Fallback{Document: "/index.html"}
+}
+
+// caddyfile builds a Caddyfile serving /srv with the plan's routing rules and SPA fallback.
+// Named matchers are declared at site level; route keeps redirects, rewrites and
+// the SPA fallback in source order (Caddy would otherwise sort them by directive).
+func (g *Generator) caddyfile() string {
+	var sb strings.Builder
+	r := g.plan.Routing
+	if r == nil {
+		r = &app.Routing{}
+	}
+
+	sb.WriteString(":80 {\n")
+	sb.WriteString("\troot * /srv\n")
+	sb.WriteString("\tencode zstd gzip\n")
+
+	// Cache headers come first so rules from _headers/vercel.json can override them
+	if cache := r.Cache; cache != nil {
+		if len(cache.ImmutablePrefixes) > 0 {
+			sb.WriteString(fmt.Sprintf("\n\t@immutable path %s\n", strings.Join(caddyPrefixes(cache.ImmutablePrefixes), " ")))
+			sb.WriteString(fmt.Sprintf("\theader @immutable Cache-Control %s\n", caddyQuote(immutableCacheControl)))
+		}
+		if cache.Revalidate {
+			if len(cache.ImmutablePrefixes) > 0 {
+				sb.WriteString(fmt.Sprintf("\n\t@revalidate not path %s\n", strings.Join(caddyPrefixes(cache.ImmutablePrefixes), " ")))
+				sb.WriteString(fmt.Sprintf("\theader @revalidate Cache-Control %s\n", caddyQuote(revalidateCacheControl)))
+			} else {
+				sb.WriteString(fmt.Sprintf("\n\theader Cache-Control %s\n", caddyQuote(revalidateCacheControl)))
+			}
+		}
+	}
+
+	for i, rule := range r.Headers {
+		name := fmt.Sprintf("header%d", i)
+		sb.WriteString(fmt.Sprintf("\n\t@%s path_regexp %s\n", name, caddyQuote(rule.Match)))
+		sb.WriteString(fmt.Sprintf("\theader @%s {\n", name))
+		for _, h := range rule.Headers {
+			sb.WriteString(fmt.Sprintf("\t\t%s %s\n", h.Name, caddyQuote(h.Value)))
+		}
+		sb.WriteString("\t}\n")
+	}
+
+	for i, rule := range r.Redirects {
+		name := fmt.Sprintf("redirect%d", i)
+		sb.WriteString(fmt.Sprintf("\n\t@%s path_regexp %s %s\n", name, name, caddyQuote(rule.Match)))
+	}
+	for i, rule := range r.Rewrites {
+		name := fmt.Sprintf("rewrite%d", i)
+		// Like Netlify and Vercel, rewrites don't shadow existing files
+		sb.WriteString(fmt.Sprintf("\n\t@%s {\n\t\tnot file\n\t\tpath_regexp %s %s\n\t}\n", name, name, caddyQuote(rule.Match)))
+	}
+
+	fallback := g.spaFallback()
+	if fallback != nil {
+		sb.WriteString("\n\t@spa {\n\t\tnot file\n")
+		if excludes := caddyExcludes(fallback); len(excludes) > 0 {
+			sb.WriteString(fmt.Sprintf("\t\tnot path %s\n", strings.Join(excludes, " ")))
+		}
+		sb.WriteString("\t}\n")
+	}
+
+	sb.WriteString("\n\troute {\n")
+	for i, rule := range r.Redirects {
+		name := fmt.Sprintf("redirect%d", i)
+		sb.WriteString(fmt.Sprintf("\t\tredir @%s %s %d\n", name, caddyQuote(caddyGroupRefs(rule.Destination, name)), rule.Status))
+	}
+	for i, rule := range r.Rewrites {
+		name := fmt.Sprintf("rewrite%d", i)
+		sb.WriteString(fmt.Sprintf("\t\trewrite @%s %s\n", name, caddyQuote(caddyGroupRefs(rule.Destination, name))))
+	}
+	if fallback != nil {
+		sb.WriteString(fmt.Sprintf("\t\trewrite @spa %s\n", caddyQuote(fallback.Document)))
+	}
+	if g.precompress() {
+		sb.WriteString("\t\tfile_server {\n\t\t\tprecompressed br gzip\n\t\t}\n")
+	} else {
+		sb.WriteString("\t\tfile_server\n")
+	}
+	sb.WriteString("\t}\n")
+
+	// file_server keeps the error status when serving the page
+	for _, page := range r.ErrorPages {
+		sb.WriteString(fmt.Sprintf("\n\thandle_errors %d {\n", page.Status))
+		sb.WriteString(fmt.Sprintf("\t\trewrite * %s\n", caddyQuote(page.Document)))
+		sb.WriteString("\t\tfile_server\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// nginxConfig builds an nginx server config serving /usr/share/nginx/html with the
+// plan's routing rules, SPA fallback and cache policy. Headers use one map per header
+// name so the first matching rule wins and unmatched paths get the map default, which
+// is no header (nginx skips empty add_header values) unless the cache policy sets one.
+func (g *Generator) nginxConfig() string {
+	var sb strings.Builder
+	r := g.plan.Routing
+	if r == nil {
+		r = &app.Routing{}
+	}
+
+	// Group header rules by header name
+	var headerNames []string
+	headerRules := make(map[string][]string)
+	for _, rule := range r.Headers {
+		for _, h := range rule.Headers {
+			if _, ok := headerRules[h.Name]; !ok {
+				headerNames = append(headerNames, h.Name)
+			}
+			headerRules[h.Name] = append(headerRules[h.Name],
+				fmt.Sprintf("\t%s %s;\n", nginxQuote("~"+rule.Match), nginxQuote(h.Value)))
+		}
+	}
+
+	// Cache-Control joins the user's map after its rules, so those take precedence
+	defaults := make(map[string]string)
+	if cache := r.Cache; cache != nil {
+		name := "Cache-Control"
+		for _, existing := range headerNames {
+			if strings.EqualFold(existing, name) {
+				name = existing
+			}
+		}
+		if _, ok := headerRules[name]; !ok {
+			headerNames = append(headerNames, name)
+		}
+		for _, prefix := range cache.ImmutablePrefixes {
+			headerRules[name] = append(headerRules[name],
+				fmt.Sprintf("\t%s %s;\n", nginxQuote("~^"+regexp.QuoteMeta(prefix)), nginxQuote(immutableCacheControl)))
+		}
+		if cache.Revalidate {
+			defaults[name] = revalidateCacheControl
+		}
+	}
+
+	for i, name := range headerNames {
+		sb.WriteString(fmt.Sprintf("map $uri $coolpack_header_%d {\n", i))
+		for _, entry := range headerRules[name] {
+			sb.WriteString(entry)
+		}
+		sb.WriteString(fmt.Sprintf("\tdefault %s;\n", nginxQuote(defaults[name])))
+		sb.WriteString("}\n\n")
+	}
+
+	sb.WriteString("server {\n")
+	sb.WriteString("\tlisten 80;\n")
+	sb.WriteString("\troot /usr/share/nginx/html;\n")
+	sb.WriteString("\tindex index.html;\n")
+	sb.WriteString("\n\tgzip on;\n")
+	sb.WriteString(fmt.Sprintf("\tgzip_types %s;\n", strings.Join(nginxGzipTypes, " ")))
+	if g.precompress() {
+		// The stock nginx image has no brotli module; .br files are only served when requested directly
+		sb.WriteString("\tgzip_static on;\n")
+	}
+	sb.WriteString("\n")
+
+	for i, name := range headerNames {
+		sb.WriteString(fmt.Sprintf("\tadd_header %s $coolpack_header_%d always;\n", name, i))
+	}
+
+	for _, page := range r.ErrorPages {
+		sb.WriteString(fmt.Sprintf("\terror_page %s %s;\n", nginxErrorStatuses(page.Status), page.Document))
+	}
+
+	for _, rule := range r.Redirects {
+		sb.WriteString(fmt.Sprintf("\n\tif ($uri ~ %s) {\n", nginxQuote(rule.Match)))
+		sb.WriteString(fmt.Sprintf("\t\treturn %d %s;\n", rule.Status, nginxQuote(rule.Destination)))
+		sb.WriteString("\t}\n")
+	}
+
+	sb.WriteString("\n\tlocation / {\n")
+	if len(r.Rewrites) > 0 {
+		// Like Netlify and Vercel, rewrites don't shadow existing files
+		sb.WriteString("\t\tif (!-e $request_filename) {\n")
+		for _, rule := range r.Rewrites {
+			sb.WriteString(fmt.Sprintf("\t\t\trewrite %s %s last;\n", nginxQuote(rule.Match), nginxQuote(rule.Destination)))
+		}
+		sb.WriteString("\t\t}\n")
+	}
+	fallback := g.spaFallback()
+	if fallback != nil {
+		sb.WriteString(fmt.Sprintf("\t\ttry_files $uri $uri/ %s;\n", fallback.Document))
+	} else {
+		sb.WriteString("\t\ttry_files $uri $uri/ =404;\n")
+	}
+	sb.WriteString("\t}\n")
+
+	// Missing bundles and assets return 404 instead of the SPA document
+	if fallback != nil {
+		for _, prefix := range fallback.ExcludePrefixes {
+			sb.WriteString(fmt.Sprintf("\n\tlocation %s {\n\t\ttry_files $uri =404;\n\t}\n", prefix))
+		}
+		if len(fallback.ExcludeExtensions) > 0 {
+			exts := make([]string, 0, len(fallback.ExcludeExtensions))
+			for _, ext := range fallback.ExcludeExtensions {
+				exts = append(exts, regexp.QuoteMeta(strings.TrimPrefix(ext, ".")))
+			}
+			sb.WriteString(fmt.Sprintf("\n\tlocation ~* \\.(?:%s)$ {\n\t\ttry_files $uri =404;\n\t}\n", strings.Join(exts, "|")))
+		}
+	}
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// nginxErrorStatuses returns the statuses an error page covers; a 500 page also
+// covers gateway errors
+func nginxErrorStatuses(status int) string {
+	if status == 500 {
+		return "500 502 503 504"
+	}
+	return strconv.Itoa(status)
+}
+
+// caddyPrefixes returns Caddy path patterns matching everything under the given prefixes
+func caddyPrefixes(prefixes []string) []string {
+	patterns := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		patterns = append(patterns, strings.TrimSuffix(prefix, "/")+"/*")
+	}
+	return patterns
+}
+
+// caddyExcludes returns Caddy path patterns for the fallback's excluded prefixes and extensions
+func caddyExcludes(fallback *app.Fallback) []string {
+	patterns := caddyPrefixes(fallback.ExcludePrefixes)
+	for _, ext := range fallback.ExcludeExtensions {
+		patterns = append(patterns, "*"+ext)
+	}
+	return patterns
+}
+
+// writeHeredoc writes a COPY heredoc creating a file with the given content
+func writeHeredoc(sb *strings.Builder, dest, content string) {
+	sb.WriteString(fmt.Sprintf("COPY <<'EOF' %s\n", dest))
+	sb.WriteString(content)
+	sb.WriteString("EOF\n\n")
+}
+
+// caddyGroupRefs replaces $n group references with Caddy regexp placeholders
+func caddyGroupRefs(destination, matcher string) string {
+	return groupRefPattern.ReplaceAllString(destination, fmt.Sprintf("{re.%s.$1}", matcher))
+}
+
+// caddyQuote quotes a Caddyfile token as a backtick string (no escape processing)
+func caddyQuote(s string) string {
+	return "`" + s + "`"
+}
+
+// nginxQuote quotes an nginx config value
+func nginxQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,153 @@
+// Package i18n translates user-facing CLI messages.
+//
+// Messages are looked up by stable keys (e.g., "plan.provider") in per-locale
+// catalogs, falling back to English for missing locales or keys. Warning codes
+// and JSON output are never translated, so automation can rely on them.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used when no supported locale is configured
+const DefaultLocale = "en"
+
+var (
+	catalogsMu sync.RWMutex
+	catalogs   = map[string]map[string]string{
+		"en": en,
+		"de": de,
+		"es": es,
+		"fr": fr,
+	}
+)
+
+// Register adds or extends the catalog for a locale (e.g., "pt-BR"), so
+// platforms embedding coolpack can ship additional translations
+func Register(locale string, messages map[string]string) {
+	locale = normalizeLocale(locale)
+
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	catalog, ok := catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		catalogs[locale] = catalog
+	}
+	for key, msg := range messages {
+		catalog[key] = msg
+	}
+}
+
+// Locales returns the locales with a catalog in sorted order
+func Locales() []string {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Localizer translates messages for one locale
+type Localizer struct {
+	locales []string
+}
+
+// New creates a localizer for a locale like "de", "de-AT" or "de_AT.UTF-8".
+// Lookups try the full locale, then its language, then English.
+func New(locale string) *Localizer {
+	locale = normalizeLocale(locale)
+
+	var locales []string
+	if locale != "" {
+		locales = append(locales, locale)
+		if lang, _, ok := strings.Cut(locale, "-"); ok {
+			locales = append(locales, lang)
+		}
+	}
+	locales = append(locales, DefaultLocale)
+
+	return &Localizer{locales: locales}
+}
+
+// Locale returns the preferred locale of the localizer
+func (l *Localizer) Locale() string {
+	return l.locales[0]
+}
+
+// T returns the message for key formatted with args. Unknown keys return the key itself.
+func (l *Localizer) T(key string, args ...interface{}) string {
+	msg, ok := l.lookup(key)
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Has checks if the localizer's locale (not the English fallback) translates key
+func (l *Localizer) Has(key string) bool {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	for _, locale := range l.locales {
+		if locale == DefaultLocale {
+			return false
+		}
+		if _, ok := catalogs[locale][key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Localizer) lookup(key string) (string, bool) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	for _, locale := range l.locales {
+		if msg, ok := catalogs[locale][key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// DetectLocale returns the locale from COOLPACK_LANG, LC_ALL, LC_MESSAGES or LANG
+func DetectLocale() string {
+	for _, name := range []string{"COOLPACK_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if locale := normalizeLocale(value); locale != "" {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// normalizeLocale turns "de_AT.UTF-8" or "de-at" into "de-AT". POSIX "C" locales map to "".
+func normalizeLocale(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return ""
+	}
+
+	lang, region, ok := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	lang = strings.ToLower(lang)
+	if !ok {
+		return lang
+	}
+	return lang + "-" + strings.ToUpper(region)
+}
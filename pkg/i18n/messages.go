@@ -0,0 +1,273 @@
+package i18n
+
+// Message keys are grouped by command ("plan.*", "build.*") and "warning.<code>"
+// for short titles of plan warnings. English is the reference catalog; other
+// catalogs may be partial.
+
+var en = map[string]string{
+	"no_app_detected": "No supported application detected",
+
+	"plan.title":                   "=== Coolpack Build Plan ===",
+	"plan.provider":                "Provider",
+	"plan.language":                "Language",
+	"plan.language_version":        "Language Version",
+	"plan.framework":               "Framework",
+	"plan.framework_version":       "Framework Version",
+	"plan.package_manager":         "Package Manager",
+	"plan.package_manager_version": "Package Manager Version",
+	"plan.install_command":         "Install Command",
+	"plan.build_command":           "Build Command",
+	"plan.start_command":           "Start Command",
+	"plan.test_command":            "Test Command",
+	"plan.ports":                   "Ports",
+	"plan.output":                  "Output",
+	"plan.output_override":         "%s, overrides %s",
+	"plan.output_base_path":        "served under %s",
+	"plan.spa":                     "SPA",
+	"plan.spa_enabled":             "enabled (%s)",
+	"plan.prebuilt_binaries":       "Prebuilt Binaries",
+	"plan.platforms":               "Platforms",
+	"plan.workspaces":              "Workspaces",
+	"plan.native_deps":             "Native Dependencies",
+	"plan.apt_packages":            "APT Packages",
+	"plan.detected_files":          "Detected Files",
+	"plan.build_env":               "Build Environment",
+	"plan.runtime_env":             "Runtime Environment",
+	"plan.metadata":                "Metadata",
+	"plan.services":                "Services",
+	"plan.volumes":                 "Volumes",
+	"plan.required_env":            "Required Environment",
+	"plan.build_secrets":           "Build Secrets",
+	"plan.features":                "Features",
+	"plan.image_digests":           "Image Digests",
+	"plan.extensions":              "Extensions",
+	"plan.warnings":                "Warnings",
+	"plan.optional":                "optional",
+	"plan.required":                "required",
+	"plan.secret":                  "secret",
+	"plan.written":                 "Plan written to %s",
+	"plan.explain":                 "Explanation",
+
+	"build.generating_dockerfile": "Generating Dockerfile...",
+	"build.building_image":        "Building Docker image...",
+	"build.success":               "Successfully built image: %s",
+	"build.run_with":              "Run with: %s",
+	"build.run_dev":               "Run (development only): %s",
+	"prepare.generated":           "Generated files in %s:",
+	"using_plan_file":             "Using plan file: %s",
+
+	"warning.secret_file":               "Committed file would bake secrets into the image",
+	"warning.lockfile_conflict":         "Lockfiles of another package manager found",
+	"warning.lockfile_version_mismatch": "Lockfile format does not match the package manager version",
+	"warning.overrides_ignored":         "Dependency overrides ignored by the package manager",
+	"warning.npm_registry_no_auth":      "Private registry without an auth token",
+	"warning.image_digest_unresolved":   "Base image could not be pinned to a digest",
+	"warning.env_example_missing":       "Variables from .env.example are not set",
+	"warning.expo_native_only":          "Expo project has no web platform",
+	"warning.expo_server_output":        "Expo server output is not supported",
+	"warning.routing_rule_unsupported":  "Routing rule not supported by the static server",
+	"warning.config_unreadable":         "Config file could not be read",
+	"warning.config_syntax_error":       "Config file has a syntax error",
+	"warning.config_value_dynamic":      "Config value is only known at build time",
+}
+
+var de = map[string]string{
+	"no_app_detected": "Keine unterstützte Anwendung erkannt",
+
+	"plan.title":                   "=== Coolpack-Buildplan ===",
+	"plan.provider":                "Provider",
+	"plan.language":                "Sprache",
+	"plan.language_version":        "Sprachversion",
+	"plan.framework":               "Framework",
+	"plan.framework_version":       "Framework-Version",
+	"plan.package_manager":         "Paketmanager",
+	"plan.package_manager_version": "Paketmanager-Version",
+	"plan.install_command":         "Installationsbefehl",
+	"plan.build_command":           "Build-Befehl",
+	"plan.start_command":           "Startbefehl",
+	"plan.test_command":            "Testbefehl",
+	"plan.ports":                   "Ports",
+	"plan.output":                  "Ausgabe",
+	"plan.output_override":         "%s, ersetzt %s",
+	"plan.output_base_path":        "ausgeliefert unter %s",
+	"plan.spa":                     "SPA",
+	"plan.spa_enabled":             "aktiviert (%s)",
+	"plan.prebuilt_binaries":       "Vorkompilierte Binaries",
+	"plan.platforms":               "Plattformen",
+	"plan.workspaces":              "Workspaces",
+	"plan.native_deps":             "Native Abhängigkeiten",
+	"plan.apt_packages":            "APT-Pakete",
+	"plan.detected_files":          "Erkannte Dateien",
+	"plan.build_env":               "Build-Umgebung",
+	"plan.runtime_env":             "Laufzeitumgebung",
+	"plan.metadata":                "Metadaten",
+	"plan.services":                "Dienste",
+	"plan.volumes":                 "Volumes",
+	"plan.required_env":            "Erforderliche Umgebungsvariablen",
+	"plan.build_secrets":           "Build-Secrets",
+	"plan.features":                "Funktionen",
+	"plan.image_digests":           "Image-Digests",
+	"plan.extensions":              "Erweiterungen",
+	"plan.warnings":                "Warnungen",
+	"plan.optional":                "optional",
+	"plan.required":                "erforderlich",
+	"plan.secret":                  "geheim",
+	"plan.written":                 "Plan nach %s geschrieben",
+	"plan.explain":                 "Begründung",
+
+	"build.generating_dockerfile": "Dockerfile wird erzeugt...",
+	"build.building_image":        "Docker-Image wird gebaut...",
+	"build.success":               "Image erfolgreich gebaut: %s",
+	"build.run_with":              "Starten mit: %s",
+	"build.run_dev":               "Starten (nur Entwicklung): %s",
+	"prepare.generated":           "Erzeugte Dateien in %s:",
+	"using_plan_file":             "Verwende Plandatei: %s",
+
+	"warning.secret_file":               "Eingecheckte Datei würde Secrets ins Image übernehmen",
+	"warning.lockfile_conflict":         "Lockfiles eines anderen Paketmanagers gefunden",
+	"warning.lockfile_version_mismatch": "Lockfile-Format passt nicht zur Paketmanager-Version",
+	"warning.overrides_ignored":         "Abhängigkeits-Overrides werden vom Paketmanager ignoriert",
+	"warning.npm_registry_no_auth":      "Private Registry ohne Auth-Token",
+	"warning.image_digest_unresolved":   "Basis-Image konnte nicht auf einen Digest festgelegt werden",
+	"warning.env_example_missing":       "Variablen aus .env.example sind nicht gesetzt",
+	"warning.expo_native_only":          "Expo-Projekt hat keine Web-Plattform",
+	"warning.expo_server_output":        "Expo-Server-Ausgabe wird nicht unterstützt",
+	"warning.routing_rule_unsupported":  "Routing-Regel wird vom statischen Server nicht unterstützt",
+	"warning.config_unreadable":         "Konfigurationsdatei konnte nicht gelesen werden",
+	"warning.config_syntax_error":       "Konfigurationsdatei enthält einen Syntaxfehler",
+	"warning.config_value_dynamic":      "Konfigurationswert steht erst beim Build fest",
+}
+
+var es = map[string]string{
+	"no_app_detected": "No se detectó ninguna aplicación compatible",
+
+	"plan.title":                   "=== Plan de compilación de Coolpack ===",
+	"plan.provider":                "Proveedor",
+	"plan.language":                "Lenguaje",
+	"plan.language_version":        "Versión del lenguaje",
+	"plan.framework":               "Framework",
+	"plan.framework_version":       "Versión del framework",
+	"plan.package_manager":         "Gestor de paquetes",
+	"plan.package_manager_version": "Versión del gestor",
+	"plan.install_command":         "Comando de instalación",
+	"plan.build_command":           "Comando de compilación",
+	"plan.start_command":           "Comando de inicio",
+	"plan.test_command":            "Comando de pruebas",
+	"plan.ports":                   "Puertos",
+	"plan.output":                  "Salida",
+	"plan.output_override":         "%s, reemplaza %s",
+	"plan.output_base_path":        "servido bajo %s",
+	"plan.spa":                     "SPA",
+	"plan.spa_enabled":             "activado (%s)",
+	"plan.prebuilt_binaries":       "Binarios precompilados",
+	"plan.platforms":               "Plataformas",
+	"plan.workspaces":              "Workspaces",
+	"plan.native_deps":             "Dependencias nativas",
+	"plan.apt_packages":            "Paquetes APT",
+	"plan.detected_files":          "Archivos detectados",
+	"plan.build_env":               "Entorno de compilación",
+	"plan.runtime_env":             "Entorno de ejecución",
+	"plan.metadata":                "Metadatos",
+	"plan.services":                "Servicios",
+	"plan.volumes":                 "Volúmenes",
+	"plan.required_env":            "Variables requeridas",
+	"plan.build_secrets":           "Secretos de compilación",
+	"plan.features":                "Funcionalidades",
+	"plan.image_digests":           "Digests de imágenes",
+	"plan.extensions":              "Extensiones",
+	"plan.warnings":                "Advertencias",
+	"plan.optional":                "opcional",
+	"plan.required":                "requerido",
+	"plan.secret":                  "secreto",
+	"plan.written":                 "Plan guardado en %s",
+	"plan.explain":                 "Explicación",
+
+	"build.generating_dockerfile": "Generando Dockerfile...",
+	"build.building_image":        "Compilando imagen Docker...",
+	"build.success":               "Imagen compilada correctamente: %s",
+	"build.run_with":              "Ejecutar con: %s",
+	"build.run_dev":               "Ejecutar (solo desarrollo): %s",
+	"prepare.generated":           "Archivos generados en %s:",
+	"using_plan_file":             "Usando archivo de plan: %s",
+
+	"warning.secret_file":               "Un archivo versionado incluiría secretos en la imagen",
+	"warning.lockfile_conflict":         "Se encontraron lockfiles de otro gestor de paquetes",
+	"warning.lockfile_version_mismatch": "El formato del lockfile no coincide con la versión del gestor de paquetes",
+	"warning.overrides_ignored":         "El gestor de paquetes ignora los overrides de dependencias",
+	"warning.npm_registry_no_auth":      "Registro privado sin token de autenticación",
+	"warning.image_digest_unresolved":   "No se pudo fijar la imagen base a un digest",
+	"warning.env_example_missing":       "Faltan variables de .env.example",
+	"warning.expo_native_only":          "El proyecto Expo no tiene plataforma web",
+	"warning.expo_server_output":        "La salida de servidor de Expo no es compatible",
+	"warning.routing_rule_unsupported":  "Regla de enrutamiento no compatible con el servidor estático",
+	"warning.config_unreadable":         "No se pudo leer el archivo de configuración",
+	"warning.config_syntax_error":       "El archivo de configuración tiene un error de sintaxis",
+	"warning.config_value_dynamic":      "El valor de configuración solo se conoce al compilar",
+}
+
+var fr = map[string]string{
+	"no_app_detected": "Aucune application prise en charge détectée",
+
+	"plan.title":                   "=== Plan de build Coolpack ===",
+	"plan.provider":                "Fournisseur",
+	"plan.language":                "Langage",
+	"plan.language_version":        "Version du langage",
+	"plan.framework":               "Framework",
+	"plan.framework_version":       "Version du framework",
+	"plan.package_manager":         "Gestionnaire de paquets",
+	"plan.package_manager_version": "Version du gestionnaire",
+	"plan.install_command":         "Commande d'installation",
+	"plan.build_command":           "Commande de build",
+	"plan.start_command":           "Commande de démarrage",
+	"plan.test_command":            "Commande de test",
+	"plan.ports":                   "Ports",
+	"plan.output":                  "Sortie",
+	"plan.output_override":         "%s, remplace %s",
+	"plan.output_base_path":        "servi sous %s",
+	"plan.spa":                     "SPA",
+	"plan.spa_enabled":             "activé (%s)",
+	"plan.prebuilt_binaries":       "Binaires précompilés",
+	"plan.platforms":               "Plateformes",
+	"plan.workspaces":              "Workspaces",
+	"plan.native_deps":             "Dépendances natives",
+	"plan.apt_packages":            "Paquets APT",
+	"plan.detected_files":          "Fichiers détectés",
+	"plan.build_env":               "Environnement de build",
+	"plan.runtime_env":             "Environnement d'exécution",
+	"plan.metadata":                "Métadonnées",
+	"plan.services":                "Services",
+	"plan.volumes":                 "Volumes",
+	"plan.required_env":            "Variables requises",
+	"plan.build_secrets":           "Secrets de build",
+	"plan.features":                "Fonctionnalités",
+	"plan.image_digests":           "Digests des images",
+	"plan.extensions":              "Extensions",
+	"plan.warnings":                "Avertissements",
+	"plan.optional":                "optionnel",
+	"plan.required":                "requis",
+	"plan.secret":                  "secret",
+	"plan.written":                 "Plan écrit dans %s",
+	"plan.explain":                 "Explication",
+
+	"build.generating_dockerfile": "Génération du Dockerfile...",
+	"build.building_image":        "Construction de l'image Docker...",
+	"build.success":               "Image construite avec succès : %s",
+	"build.run_with":              "Lancer avec : %s",
+	"build.run_dev":               "Lancer (développement uniquement) : %s",
+	"prepare.generated":           "Fichiers générés dans %s :",
+	"using_plan_file":             "Utilisation du fichier de plan : %s",
+
+	"warning.secret_file":               "Un fichier versionné intégrerait des secrets dans l'image",
+	"warning.lockfile_conflict":         "Lockfiles d'un autre gestionnaire de paquets trouvés",
+	"warning.lockfile_version_mismatch": "Le format du lockfile ne correspond pas à la version du gestionnaire de paquets",
+	"warning.overrides_ignored":         "Overrides de dépendances ignorés par le gestionnaire de paquets",
+	"warning.npm_registry_no_auth":      "Registre privé sans jeton d'authentification",
+	"warning.image_digest_unresolved":   "L'image de base n'a pas pu être figée sur un digest",
+	"warning.env_example_missing":       "Des variables de .env.example ne sont pas définies",
+	"warning.expo_native_only":          "Le projet Expo n'a pas de plateforme web",
+	"warning.expo_server_output":        "La sortie serveur d'Expo n'est pas prise en charge",
+	"warning.routing_rule_unsupported":  "Règle de routage non prise en charge par le serveur statique",
+	"warning.config_unreadable":         "Le fichier de configuration n'a pas pu être lu",
+	"warning.config_syntax_error":       "Le fichier de configuration contient une erreur de syntaxe",
+	"warning.config_value_dynamic":      "La valeur de configuration n'est connue qu'au build",
+}
@@ -0,0 +1,186 @@
+package nixpacks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// ConfigFiles are the nixpacks configuration files, in the order nixpacks reads them
+var ConfigFiles = []string{"nixpacks.toml", "nixpacks.json"}
+
+// extend is the nixpacks placeholder for "the provider's defaults" in lists
+const extend = "..."
+
+// nixNodePattern matches nixpkgs Node.js packages (nodejs_20, nodejs-18_x)
+var nixNodePattern = regexp.MustCompile(`^nodejs(?:[-_](\d+)(?:_x)?)?$`)
+
+// variableSettings maps nixpacks environment settings to coolpack's
+var variableSettings = map[string]string{
+	"NIXPACKS_NODE_VERSION": "COOLPACK_NODE_VERSION",
+	"NIXPACKS_INSTALL_CMD":  "COOLPACK_INSTALL_CMD",
+	"NIXPACKS_BUILD_CMD":    "COOLPACK_BUILD_CMD",
+	"NIXPACKS_START_CMD":    "COOLPACK_START_CMD",
+	"NIXPACKS_APT_PKGS":     "COOLPACK_PACKAGES",
+}
+
+// Config is a nixpacks configuration file
+type Config struct {
+	// File is the name of the file the configuration was read from
+	File string
+
+	Plan
+}
+
+// LoadConfig reads nixpacks.toml or nixpacks.json from the application root.
+// It returns nil without an error when there is neither.
+func LoadConfig(path string) (*Config, error) {
+	for _, name := range ConfigFiles {
+		// Read through a context, which refuses symlinks leading out of the application
+		text, err := app.NewContext(path).ReadFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		config := &Config{File: name}
+		if strings.HasSuffix(name, ".json") {
+			err = json.Unmarshal(text, &config.Plan)
+		} else {
+			_, err = toml.Decode(string(text), &config.Plan)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return config, nil
+	}
+	return nil, nil
+}
+
+// Env returns the settings that map to COOLPACK_* variables, and notes on the
+// settings that coolpack can't take over
+func (c *Config) Env() (map[string]string, []string) {
+	env := make(map[string]string)
+	var notes []string
+
+	// NIXPACKS_* variables are nixpacks' own overrides; phases take precedence over them
+	for name, value := range c.Variables {
+		if key, ok := variableSettings[name]; ok {
+			if value != "" {
+				env[key] = value
+			}
+		} else if strings.HasPrefix(name, "NIXPACKS_") {
+			notes = append(notes, fmt.Sprintf("variable %s has no coolpack equivalent", name))
+		}
+	}
+	if pkgs := env["COOLPACK_PACKAGES"]; pkgs != "" {
+		env["COOLPACK_PACKAGES"] = strings.Join(strings.Fields(pkgs), ",")
+	}
+
+	for _, provider := range c.Providers {
+		if provider != extend && provider != "node" {
+			notes = append(notes, fmt.Sprintf("provider %q is not supported", provider))
+		}
+	}
+	if c.BuildImage != "" {
+		notes = append(notes, "buildImage is ignored; coolpack builds from its own base images (use base_image in coolpack.toml)")
+	}
+
+	names := make([]string, 0, len(c.Phases))
+	for name := range c.Phases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		phase := c.Phases[name]
+		if phase == nil {
+			continue
+		}
+		switch name {
+		case "setup":
+			packages := strings.Split(env["COOLPACK_PACKAGES"], ",")
+			for _, pkg := range phase.AptPkgs {
+				if pkg != extend {
+					packages = append(packages, pkg)
+				}
+			}
+			if joined := strings.Trim(strings.Join(packages, ","), ","); joined != "" {
+				env["COOLPACK_PACKAGES"] = joined
+			}
+			for _, pkg := range phase.NixPkgs {
+				if pkg == extend {
+					continue
+				}
+				if m := nixNodePattern.FindStringSubmatch(pkg); m != nil {
+					if m[1] != "" {
+						env["COOLPACK_NODE_VERSION"] = m[1]
+					}
+					continue
+				}
+				notes = append(notes, fmt.Sprintf("nix package %q is not installed; add the APT package to packages in coolpack.toml", pkg))
+			}
+			if len(phase.NixLibs) > 0 {
+				notes = append(notes, "setup.nixLibs are not installed; add the APT packages to packages in coolpack.toml")
+			}
+		case "install", "build":
+			if cmd, ok := phaseCommand(phase.Cmds); ok {
+				if cmd != "" {
+					env["COOLPACK_"+strings.ToUpper(name)+"_CMD"] = cmd
+				}
+			} else {
+				notes = append(notes, fmt.Sprintf("%s.cmds extends the provider's commands with %q; set the full command instead", name, extend))
+			}
+			if len(phase.CacheDirectories) > 0 {
+				notes = append(notes, fmt.Sprintf("%s.cacheDirectories are not cached; list them in package.json cacheDirectories", name))
+			}
+		default:
+			notes = append(notes, fmt.Sprintf("phase %q is not supported; coolpack runs install, build and start", name))
+		}
+	}
+
+	if c.Start != nil {
+		if c.Start.Cmd != "" {
+			env["COOLPACK_START_CMD"] = c.Start.Cmd
+		}
+		if c.Start.RunImage != "" {
+			notes = append(notes, "start.runImage is ignored; coolpack picks the runtime image from the plan")
+		}
+	}
+
+	return env, notes
+}
+
+// AppVariables returns the variables for the application itself, without the
+// NIXPACKS_* settings. nixpacks makes them available during the build and at
+// runtime.
+func (c *Config) AppVariables() map[string]string {
+	vars := make(map[string]string)
+	for name, value := range c.Variables {
+		if !strings.HasPrefix(name, "NIXPACKS_") {
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+// phaseCommand joins a phase's commands into one. It reports false when the
+// commands extend the provider's defaults with "...", which can't be expressed
+// as an override.
+func phaseCommand(cmds []string) (string, bool) {
+	for _, cmd := range cmds {
+		if cmd == extend {
+			return "", false
+		}
+	}
+	return strings.Join(cmds, " && "), true
+}
@@ -0,0 +1,155 @@
+// Package nixpacks converts between coolpack plans and nixpacks, to ease
+// migrating from nixpacks: it exports a plan as a nixpacks build plan and
+// reads nixpacks.toml/nixpacks.json to seed coolpack's overrides.
+package nixpacks
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/generator"
+	"github.com/coollabsio/coolpack/pkg/semver"
+)
+
+// Plan is a nixpacks build plan (the output of `nixpacks plan`). nixpacks.toml
+// and nixpacks.json use the same structure.
+type Plan struct {
+	Providers  []string          `json:"providers" toml:"providers"`
+	BuildImage string            `json:"buildImage,omitempty" toml:"buildImage"`
+	Variables  map[string]string `json:"variables,omitempty" toml:"variables"`
+	Phases     map[string]*Phase `json:"phases,omitempty" toml:"phases"`
+	Start      *Start            `json:"start,omitempty" toml:"start"`
+}
+
+// Phase is a nixpacks build phase (setup, install, build)
+type Phase struct {
+	Name             string   `json:"name,omitempty" toml:"name"`
+	DependsOn        []string `json:"dependsOn,omitempty" toml:"dependsOn"`
+	NixPkgs          []string `json:"nixPkgs,omitempty" toml:"nixPkgs"`
+	NixLibs          []string `json:"nixLibs,omitempty" toml:"nixLibs"`
+	AptPkgs          []string `json:"aptPkgs,omitempty" toml:"aptPkgs"`
+	Cmds             []string `json:"cmds,omitempty" toml:"cmds"`
+	OnlyIncludeFiles []string `json:"onlyIncludeFiles,omitempty" toml:"onlyIncludeFiles"`
+	CacheDirectories []string `json:"cacheDirectories,omitempty" toml:"cacheDirectories"`
+	Paths            []string `json:"paths,omitempty" toml:"paths"`
+}
+
+// Start is the nixpacks start phase
+type Start struct {
+	Cmd              string   `json:"cmd,omitempty" toml:"cmd"`
+	RunImage         string   `json:"runImage,omitempty" toml:"runImage"`
+	OnlyIncludeFiles []string `json:"onlyIncludeFiles,omitempty" toml:"onlyIncludeFiles"`
+}
+
+// FromPlan exports a coolpack plan as a nixpacks build plan. The build image is
+// the image coolpack builds from, and nixPkgs name the nixpkgs equivalents of
+// the runtime and package manager, so the plan reads like one nixpacks made.
+func FromPlan(plan *app.Plan) *Plan {
+	out := &Plan{
+		Providers: []string{},
+		Variables: map[string]string{},
+		Phases:    map[string]*Phase{},
+	}
+	if plan.Provider != "" {
+		out.Providers = append(out.Providers, plan.Provider)
+	}
+	if images := generator.New(plan).Images(); len(images) > 0 {
+		out.BuildImage = images[0]
+	}
+
+	for k, v := range plan.BuildEnv {
+		out.Variables[k] = v
+	}
+	for k, v := range plan.Env {
+		out.Variables[k] = v
+	}
+
+	setup := &Phase{Name: "setup", NixPkgs: nixPackages(plan), AptPkgs: aptPackages(plan)}
+	out.Phases["setup"] = setup
+
+	if plan.InstallCommand != "" {
+		install := &Phase{
+			Name:      "install",
+			DependsOn: []string{"setup"},
+			Cmds:      []string{plan.InstallCommand},
+		}
+		if plan.Provider == "node" {
+			install.Paths = []string{"/app/node_modules/.bin"}
+		}
+		install.CacheDirectories = append(install.CacheDirectories, plan.Metadata.CacheDirectories...)
+		out.Phases["install"] = install
+	}
+
+	if plan.BuildCommand != "" {
+		out.Phases["build"] = &Phase{
+			Name:      "build",
+			DependsOn: []string{"install"},
+			Cmds:      []string{plan.BuildCommand},
+		}
+	}
+
+	if cmd := startCommand(plan); cmd != "" {
+		out.Start = &Start{Cmd: cmd}
+	}
+	return out
+}
+
+// nixPackages returns the nixpkgs packages of the plan's runtime and package manager
+func nixPackages(plan *app.Plan) []string {
+	var pkgs []string
+	switch plan.Language {
+	case "nodejs":
+		if major, ok := semver.Major(plan.LanguageVersion); ok {
+			pkgs = append(pkgs, "nodejs_"+strconv.Itoa(major))
+		} else {
+			pkgs = append(pkgs, "nodejs")
+		}
+	case "bun":
+		pkgs = append(pkgs, "bun")
+	}
+
+	switch plan.PackageManager {
+	case "pnpm":
+		pkgs = append(pkgs, "pnpm")
+	case "yarn", "yarnberry":
+		pkgs = append(pkgs, "yarn")
+	}
+	return pkgs
+}
+
+// aptPackages returns the APT packages the build stage installs
+func aptPackages(plan *app.Plan) []string {
+	seen := make(map[string]bool)
+	var pkgs []string
+	add := func(list []string) {
+		for _, p := range list {
+			if !seen[p] {
+				seen[p] = true
+				pkgs = append(pkgs, p)
+			}
+		}
+	}
+	if plan.NativeDeps != nil {
+		add(plan.NativeDeps.AptPackages)
+	}
+	add(plan.Metadata.CustomPackages)
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// startCommand returns the start command; static sites are served by the
+// static file server
+func startCommand(plan *app.Plan) string {
+	if plan.OutputType() != app.OutputTypeStatic {
+		return plan.StartCommand
+	}
+	if plan.Metadata.StaticServer == "nginx" {
+		return `nginx -g "daemon off;"`
+	}
+	if rt, err := generator.New(plan).StaticRuntime(); err == nil {
+		return strings.Join(rt.Cmd, " ")
+	}
+	return "caddy run --config /etc/caddy/Caddyfile"
+}
@@ -0,0 +1,196 @@
+// Package provenance builds SLSA v1 provenance statements (in-toto) for images
+// built by coolpack, describing the source, the build plan and the builder.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/version"
+)
+
+// In-toto and SLSA identifiers
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+	PredicateType = "https://slsa.dev/provenance/v1"
+	BuildType     = "https://github.com/coollabsio/coolpack/buildtypes/dockerfile/v1"
+	BuilderID     = "https://github.com/coollabsio/coolpack"
+)
+
+// Statement is an in-toto statement with a SLSA provenance predicate
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is an artifact the provenance is about
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate is a SLSA v1 provenance predicate
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes the inputs of the build
+type BuildDefinition struct {
+	BuildType            string                 `json:"buildType"`
+	ExternalParameters   map[string]interface{} `json:"externalParameters"`
+	InternalParameters   map[string]interface{} `json:"internalParameters,omitempty"`
+	ResolvedDependencies []ResourceDescriptor   `json:"resolvedDependencies,omitempty"`
+}
+
+// ResourceDescriptor identifies a build input (source repository or base image)
+type ResourceDescriptor struct {
+	URI    string            `json:"uri,omitempty"`
+	Name   string            `json:"name,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// RunDetails describes the builder and the build invocation
+type RunDetails struct {
+	Builder  Builder  `json:"builder"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Builder identifies coolpack as the builder
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// Metadata holds the build timestamps
+type Metadata struct {
+	StartedOn  string `json:"startedOn,omitempty"`
+	FinishedOn string `json:"finishedOn,omitempty"`
+}
+
+// Source describes where the application was built from
+type Source struct {
+	// Path is the application directory
+	Path string
+
+	// Repository is the browsable URL of the git remote (e.g., https://github.com/org/app)
+	Repository string
+
+	// Commit is the git commit the build was made from
+	Commit string
+}
+
+// Options are the inputs of a provenance statement
+type Options struct {
+	// Image is the image name with tag, Digest its "sha256:..." digest
+	Image  string
+	Digest string
+
+	Source     Source
+	Plan       *app.Plan
+	Dockerfile string
+
+	StartedOn  time.Time
+	FinishedOn time.Time
+}
+
+// New builds the provenance statement for an image
+func New(opts Options) *Statement {
+	algo, digest, _ := strings.Cut(opts.Digest, ":")
+
+	external := map[string]interface{}{
+		"path": opts.Source.Path,
+	}
+	if opts.Plan != nil {
+		external["plan"] = opts.Plan
+	}
+
+	internal := map[string]interface{}{}
+	if opts.Plan != nil {
+		if hash, err := opts.Plan.Hash(); err == nil {
+			internal["planHash"] = hash
+		}
+	}
+	if opts.Dockerfile != "" {
+		sum := sha256.Sum256([]byte(opts.Dockerfile))
+		internal["dockerfileSha256"] = hex.EncodeToString(sum[:])
+	}
+
+	var deps []ResourceDescriptor
+	if opts.Source.Repository != "" || opts.Source.Commit != "" {
+		source := ResourceDescriptor{Name: "source"}
+		if opts.Source.Repository != "" {
+			source.URI = "git+" + opts.Source.Repository
+			if opts.Source.Commit != "" {
+				source.URI += "@" + opts.Source.Commit
+			}
+		}
+		if opts.Source.Commit != "" {
+			source.Digest = map[string]string{"gitCommit": opts.Source.Commit}
+		}
+		deps = append(deps, source)
+	}
+	if opts.Plan != nil {
+		images := make([]string, 0, len(opts.Plan.ImageDigests))
+		for image := range opts.Plan.ImageDigests {
+			images = append(images, image)
+		}
+		sort.Strings(images)
+		for _, image := range images {
+			imageDigest := opts.Plan.ImageDigests[image]
+			dep := ResourceDescriptor{URI: "pkg:docker/" + image, Name: image}
+			if a, d, ok := strings.Cut(imageDigest, ":"); ok {
+				dep.Digest = map[string]string{a: d}
+			}
+			deps = append(deps, dep)
+		}
+	}
+
+	return &Statement{
+		Type:          StatementType,
+		Subject:       []Subject{{Name: opts.Image, Digest: map[string]string{algo: digest}}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType:            BuildType,
+				ExternalParameters:   external,
+				InternalParameters:   internal,
+				ResolvedDependencies: deps,
+			},
+			RunDetails: RunDetails{
+				Builder: Builder{
+					ID:      BuilderID + "@" + version.Version,
+					Version: map[string]string{"coolpack": version.Version},
+				},
+				Metadata: Metadata{
+					StartedOn:  formatTime(opts.StartedOn),
+					FinishedOn: formatTime(opts.FinishedOn),
+				},
+			},
+		},
+	}
+}
+
+// Encode returns the statement as indented JSON
+func (s *Statement) Encode() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// EncodePredicate returns only the predicate, as cosign attest expects
+func (s *Statement) EncodePredicate() ([]byte, error) {
+	return json.MarshalIndent(s.Predicate, "", "  ")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
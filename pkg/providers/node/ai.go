@@ -0,0 +1,74 @@
+package node
+
+// AISDK represents an AI/LLM SDK package
+type AISDK struct {
+	// Package is the npm package name
+	Package string
+	// Provider is the model provider or toolkit (e.g., "openai")
+	Provider string
+	// Secrets are the API key variables the SDK reads by default
+	Secrets []string
+}
+
+// AISDKs is a list of known AI/LLM SDKs
+var AISDKs = []AISDK{
+	// Provider SDKs
+	{Package: "openai", Provider: "openai", Secrets: []string{"OPENAI_API_KEY"}},
+	{Package: "@anthropic-ai/sdk", Provider: "anthropic", Secrets: []string{"ANTHROPIC_API_KEY"}},
+	{Package: "@google/generative-ai", Provider: "google", Secrets: []string{"GEMINI_API_KEY"}},
+	{Package: "@google/genai", Provider: "google", Secrets: []string{"GEMINI_API_KEY"}},
+	{Package: "@mistralai/mistralai", Provider: "mistral", Secrets: []string{"MISTRAL_API_KEY"}},
+	{Package: "cohere-ai", Provider: "cohere", Secrets: []string{"CO_API_KEY"}},
+	{Package: "groq-sdk", Provider: "groq", Secrets: []string{"GROQ_API_KEY"}},
+	{Package: "replicate", Provider: "replicate", Secrets: []string{"REPLICATE_API_TOKEN"}},
+
+	// Vercel AI SDK and its provider packages
+	{Package: "ai", Provider: "vercel-ai"},
+	{Package: "@ai-sdk/openai", Provider: "openai", Secrets: []string{"OPENAI_API_KEY"}},
+	{Package: "@ai-sdk/anthropic", Provider: "anthropic", Secrets: []string{"ANTHROPIC_API_KEY"}},
+	{Package: "@ai-sdk/google", Provider: "google", Secrets: []string{"GOOGLE_GENERATIVE_AI_API_KEY"}},
+	{Package: "@ai-sdk/mistral", Provider: "mistral", Secrets: []string{"MISTRAL_API_KEY"}},
+	{Package: "@ai-sdk/groq", Provider: "groq", Secrets: []string{"GROQ_API_KEY"}},
+
+	// LangChain and its provider packages
+	{Package: "langchain", Provider: "langchain"},
+	{Package: "@langchain/core", Provider: "langchain"},
+	{Package: "@langchain/openai", Provider: "openai", Secrets: []string{"OPENAI_API_KEY"}},
+	{Package: "@langchain/anthropic", Provider: "anthropic", Secrets: []string{"ANTHROPIC_API_KEY"}},
+}
+
+// aiWebSocketPackages indicate realtime (WebSocket) model connections
+var aiWebSocketPackages = []string{"@openai/realtime-api-beta", "ws", "socket.io"}
+
+// AIRecommendedTimeout is the proxy timeout (in seconds) recommended for long model responses
+const AIRecommendedTimeout = 300
+
+// DetectAISDKs checks which AI/LLM SDKs are used by the project
+func DetectAISDKs(pkg *PackageJSON) []AISDK {
+	var detected []AISDK
+
+	for _, sdk := range AISDKs {
+		if pkg.HasDependency(sdk.Package) {
+			detected = append(detected, sdk)
+		}
+	}
+
+	return detected
+}
+
+// GetAIProxyHints returns reverse proxy recommendations for apps streaming model responses
+func GetAIProxyHints(pkg *PackageJSON) []string {
+	hints := []string{
+		"Disable response buffering so streamed (SSE) tokens reach clients immediately",
+		"Raise proxy read timeouts for long-running model responses",
+	}
+
+	for _, dep := range aiWebSocketPackages {
+		if pkg.HasDependency(dep) {
+			hints = append(hints, "Enable WebSocket upgrades for realtime connections")
+			break
+		}
+	}
+
+	return hints
+}
@@ -0,0 +1,159 @@
+package node
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// APMAgent represents an APM/tracing agent package
+type APMAgent struct {
+	// Package is the npm package name
+	Package string
+	// Name is the agent vendor (e.g., "datadog")
+	Name string
+	// Env are the non-secret configuration variables the agent reads
+	Env []string
+	// Secrets are the credential variables the agent needs
+	Secrets []string
+	// Preload is the module that must be required before the app starts, if any
+	Preload string
+}
+
+// APMAgents is a list of known APM/tracing agents
+var APMAgents = []APMAgent{
+	{Package: "dd-trace", Name: "datadog", Env: []string{"DD_SERVICE", "DD_ENV", "DD_AGENT_HOST"}, Preload: "dd-trace/init"},
+	{Package: "newrelic", Name: "newrelic", Env: []string{"NEW_RELIC_APP_NAME"}, Secrets: []string{"NEW_RELIC_LICENSE_KEY"}, Preload: "newrelic"},
+	{Package: "elastic-apm-node", Name: "elastic-apm", Env: []string{"ELASTIC_APM_SERVER_URL", "ELASTIC_APM_SERVICE_NAME"}, Secrets: []string{"ELASTIC_APM_SECRET_TOKEN"}, Preload: "elastic-apm-node/start"},
+	{Package: "@opentelemetry/auto-instrumentations-node", Name: "opentelemetry", Env: []string{"OTEL_SERVICE_NAME", "OTEL_EXPORTER_OTLP_ENDPOINT"}, Preload: "@opentelemetry/auto-instrumentations-node/register"},
+	{Package: "@sentry/node", Name: "sentry", Env: []string{"SENTRY_DSN"}},
+}
+
+// Loggers are logging libraries whose transports may write to files
+var Loggers = []string{
+	"pino",
+	"winston",
+	"winston-daily-rotate-file",
+	"log4js",
+	"bunyan",
+}
+
+// logFilePattern matches literal log destinations such as filename: 'logs/error.log',
+// pino.destination('./app.log'), destination: '/var/log/app.log' or dirname: 'logs'
+var logFilePattern = regexp.MustCompile(`\b(filename|destination|path|dirname|file)\s*[:(]\s*['"]([^'"]+)['"]`)
+
+// APMInfo contains the detected logging and APM setup of the application
+type APMInfo struct {
+	// Agents are the APM agents found
+	Agents []APMAgent
+	// Preloads are agent modules the app doesn't load itself
+	Preloads []string
+	// Loggers are the logging libraries found
+	Loggers []string
+	// FileLogging is true when a logger writes to files instead of stdout
+	FileLogging bool
+	// LogDirs are directories log files are written to, relative to the app root
+	// unless absolute
+	LogDirs []string
+}
+
+// DetectAPM checks which APM agents and logging libraries the project uses, whether
+// agents must be preloaded, and where file-based logs are written
+func DetectAPM(ctx *app.Context, pkg *PackageJSON) APMInfo {
+	var info APMInfo
+
+	for _, agent := range APMAgents {
+		if pkg.HasDependency(agent.Package) {
+			info.Agents = append(info.Agents, agent)
+		}
+	}
+	for _, logger := range Loggers {
+		if pkg.HasDependency(logger) {
+			info.Loggers = append(info.Loggers, logger)
+		}
+	}
+	if len(info.Agents) == 0 && len(info.Loggers) == 0 {
+		return info
+	}
+
+	// Agents already loaded by the start script or imported in source don't need a preload
+	loaded := make(map[string]bool)
+	start := pkg.GetScript("start")
+	for _, agent := range info.Agents {
+		if strings.Contains(start, agent.Package) {
+			loaded[agent.Package] = true
+		}
+	}
+
+	scanSourceFiles(ctx, []string{"."}, jsSourceExtensions, func(rel string, data []byte) bool {
+		text := string(data)
+		for _, agent := range info.Agents {
+			if !loaded[agent.Package] && (strings.Contains(text, "'"+agent.Package) || strings.Contains(text, `"`+agent.Package)) {
+				loaded[agent.Package] = true
+			}
+		}
+		if len(info.Loggers) > 0 {
+			for _, m := range logFilePattern.FindAllStringSubmatch(text, -1) {
+				dir, ok := logDir(m[1], m[2])
+				if !ok {
+					continue
+				}
+				info.FileLogging = true
+				if dir != "" {
+					info.LogDirs = appendUnique(info.LogDirs, dir)
+				}
+			}
+		}
+		return true
+	})
+
+	for _, agent := range info.Agents {
+		if agent.Preload != "" && !loaded[agent.Package] {
+			info.Preloads = append(info.Preloads, agent.Preload)
+		}
+	}
+
+	return info
+}
+
+// logDir returns the directory of a log destination and whether it is a log file
+// destination at all. The directory is "" for files written to the app root.
+func logDir(key, dest string) (string, bool) {
+	dir := path.Clean(dest)
+	if key != "dirname" {
+		// Other keys point at files; only *.log files are log destinations
+		if path.Ext(dest) != ".log" {
+			return "", false
+		}
+		dir = path.Dir(dir)
+	}
+	if dir == "." || strings.HasPrefix(dir, "..") {
+		return "", true
+	}
+	return strings.TrimPrefix(dir, AppDir+"/"), true
+}
+
+// injectPreloads adds -r <module> flags to a start command that runs node directly.
+// Returns false if the command doesn't start with node (e.g., npm start).
+func injectPreloads(startCmd string, preloads []string) (string, bool) {
+	if !strings.HasPrefix(startCmd, "node ") {
+		return startCmd, false
+	}
+
+	var flags []string
+	for _, module := range preloads {
+		flags = append(flags, "-r "+module)
+	}
+	return "node " + strings.Join(flags, " ") + strings.TrimPrefix(startCmd, "node"), true
+}
+
+// nodeOptionsPreloads builds a NODE_OPTIONS value requiring the given modules
+func nodeOptionsPreloads(preloads []string) string {
+	var flags []string
+	for _, module := range preloads {
+		flags = append(flags, "--require "+module)
+	}
+	return strings.Join(flags, " ")
+}
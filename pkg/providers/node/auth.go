@@ -0,0 +1,282 @@
+package node
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/semver"
+)
+
+// AuthLibrary represents an authentication library package
+type AuthLibrary struct {
+	// Package is the npm package name
+	Package string
+	// Name is the library (e.g., "authjs", "passport")
+	Name string
+	// Env are the non-secret runtime variables (e.g., the canonical app URL)
+	Env []string
+	// BuildEnv are the variables inlined into the client bundle
+	BuildEnv []string
+	// Secrets are the runtime secret variables (session signing keys, API keys)
+	Secrets []string
+	// CallbackPath is the OAuth callback path pattern with a {provider} placeholder.
+	// Empty when the auth service hosts the callbacks itself (e.g., Clerk).
+	CallbackPath string
+	// ProviderIDEnv and ProviderSecretEnv are the conventional client ID/secret
+	// variable names for an OAuth provider (%s is the upper-cased provider name)
+	ProviderIDEnv     string
+	ProviderSecretEnv string
+	// TrustHost is true when the library rejects requests behind a reverse proxy
+	// unless AUTH_TRUST_HOST is set
+	TrustHost bool
+}
+
+// authjs is shared by Auth.js framework integrations (next-auth v5, @auth/*)
+var authjs = AuthLibrary{
+	Name:              "authjs",
+	Secrets:           []string{"AUTH_SECRET"},
+	CallbackPath:      "/auth/callback/{provider}",
+	ProviderIDEnv:     "AUTH_%s_ID",
+	ProviderSecretEnv: "AUTH_%s_SECRET",
+	TrustHost:         true,
+}
+
+// AuthLibraries is a list of known authentication libraries
+var AuthLibraries = []AuthLibrary{
+	// NextAuth.js v4 (v5 is Auth.js, see authLibraryFor)
+	{Package: "next-auth", Name: "next-auth", Env: []string{"NEXTAUTH_URL"}, Secrets: []string{"NEXTAUTH_SECRET"}, CallbackPath: "/api/auth/callback/{provider}", ProviderIDEnv: "%s_ID", ProviderSecretEnv: "%s_SECRET"},
+
+	// Auth.js framework integrations
+	withPackage(authjs, "@auth/sveltekit"),
+	withPackage(authjs, "@auth/express"),
+	withPackage(authjs, "@auth/solid-start"),
+	withPackage(authjs, "@auth/qwik"),
+
+	// Better Auth
+	{Package: "better-auth", Name: "better-auth", Env: []string{"BETTER_AUTH_URL"}, Secrets: []string{"BETTER_AUTH_SECRET"}, CallbackPath: "/api/auth/callback/{provider}", ProviderIDEnv: "%s_CLIENT_ID", ProviderSecretEnv: "%s_CLIENT_SECRET"},
+
+	// Passport and Lucia leave routes to the app; paths are the documented conventions
+	{Package: "passport", Name: "passport", CallbackPath: "/auth/{provider}/callback", ProviderIDEnv: "%s_CLIENT_ID", ProviderSecretEnv: "%s_CLIENT_SECRET"},
+	{Package: "lucia", Name: "lucia", CallbackPath: "/login/{provider}/callback", ProviderIDEnv: "%s_CLIENT_ID", ProviderSecretEnv: "%s_CLIENT_SECRET"},
+
+	// Clerk hosts OAuth callbacks on its own domain
+	{Package: "@clerk/nextjs", Name: "clerk", BuildEnv: []string{"NEXT_PUBLIC_CLERK_PUBLISHABLE_KEY"}, Secrets: []string{"CLERK_SECRET_KEY"}},
+	{Package: "@clerk/remix", Name: "clerk", Env: []string{"CLERK_PUBLISHABLE_KEY"}, Secrets: []string{"CLERK_SECRET_KEY"}},
+	{Package: "@clerk/astro", Name: "clerk", BuildEnv: []string{"PUBLIC_CLERK_PUBLISHABLE_KEY"}, Secrets: []string{"CLERK_SECRET_KEY"}},
+	{Package: "@clerk/nuxt", Name: "clerk", BuildEnv: []string{"NUXT_PUBLIC_CLERK_PUBLISHABLE_KEY"}, Secrets: []string{"NUXT_CLERK_SECRET_KEY"}},
+	{Package: "@clerk/express", Name: "clerk", Env: []string{"CLERK_PUBLISHABLE_KEY"}, Secrets: []string{"CLERK_SECRET_KEY"}},
+	{Package: "@clerk/clerk-sdk-node", Name: "clerk", Env: []string{"CLERK_PUBLISHABLE_KEY"}, Secrets: []string{"CLERK_SECRET_KEY"}},
+	{Package: "@clerk/clerk-react", Name: "clerk", BuildEnv: []string{"VITE_CLERK_PUBLISHABLE_KEY"}},
+}
+
+// PassportStrategies maps Passport OAuth strategy packages to their provider
+var PassportStrategies = map[string]string{
+	"passport-google-oauth20":  "google",
+	"passport-google-oauth2":   "google",
+	"passport-github":          "github",
+	"passport-github2":         "github",
+	"passport-gitlab2":         "gitlab",
+	"passport-facebook":        "facebook",
+	"passport-twitter":         "twitter",
+	"passport-discord":         "discord",
+	"passport-microsoft":       "microsoft",
+	"passport-azure-ad":        "azure-ad",
+	"passport-apple":           "apple",
+	"passport-auth0":           "auth0",
+	"passport-linkedin-oauth2": "linkedin",
+	"passport-slack-oauth2":    "slack",
+	"passport-oauth2":          "oauth",
+}
+
+// nonOAuthProviders are Auth.js providers that don't use an OAuth callback
+var nonOAuthProviders = map[string]bool{
+	"credentials":  true,
+	"email":        true,
+	"nodemailer":   true,
+	"resend":       true,
+	"sendgrid":     true,
+	"postmark":     true,
+	"mailgun":      true,
+	"forwardemail": true,
+	"passkey":      true,
+	"webauthn":     true,
+}
+
+// authProviderImportPattern matches Auth.js provider imports (e.g., next-auth/providers/github)
+var authProviderImportPattern = regexp.MustCompile(`['"](?:next-auth|@auth/[a-z-]+)/providers/([a-z0-9-]+)['"]`)
+
+// arcticImportPattern matches named imports from arctic, Lucia's OAuth client library
+var arcticImportPattern = regexp.MustCompile(`import\s*\{([^}]*)\}\s*from\s*['"]arctic['"]`)
+
+// socialProvidersPattern matches the start of Better Auth's socialProviders option
+var socialProvidersPattern = regexp.MustCompile(`socialProviders\s*:\s*\{`)
+
+// callbackURLPattern matches literal Passport callback URLs (callbackURL: '/auth/github/callback')
+var callbackURLPattern = regexp.MustCompile(`\bcallbackURL\s*:\s*['"]([^'"]+)['"]`)
+
+// AuthInfo contains the detected authentication setup of the application
+type AuthInfo struct {
+	// Libraries are the auth libraries found
+	Libraries []AuthLibrary
+	// Providers are the OAuth providers configured (sorted)
+	Providers []string
+	// CallbackURLs are the OAuth callback paths to register with each provider
+	CallbackURLs []string
+}
+
+// DetectAuth checks which auth libraries and OAuth providers the project uses and
+// derives the callback URLs that must be registered with each provider
+func DetectAuth(ctx *app.Context, pkg *PackageJSON) AuthInfo {
+	var info AuthInfo
+
+	for _, lib := range AuthLibraries {
+		if pkg.HasDependency(lib.Package) {
+			info.Libraries = append(info.Libraries, authLibraryFor(lib, pkg))
+		}
+	}
+	if len(info.Libraries) == 0 {
+		return info
+	}
+
+	providers := make(map[string]bool)
+	for strategy, provider := range PassportStrategies {
+		if pkg.HasDependency(strategy) {
+			providers[provider] = true
+		}
+	}
+
+	var callbacks []string
+	scanSourceFiles(ctx, []string{"."}, jsSourceExtensions, func(rel string, data []byte) bool {
+		text := string(data)
+		for _, m := range authProviderImportPattern.FindAllStringSubmatch(text, -1) {
+			if !nonOAuthProviders[m[1]] {
+				providers[m[1]] = true
+			}
+		}
+		for _, m := range arcticImportPattern.FindAllStringSubmatch(text, -1) {
+			for _, name := range arcticProviders(m[1]) {
+				providers[name] = true
+			}
+		}
+		if loc := socialProvidersPattern.FindStringIndex(text); loc != nil {
+			for _, name := range objectKeys(text[loc[1]:]) {
+				providers[name] = true
+			}
+		}
+		for _, m := range callbackURLPattern.FindAllStringSubmatch(text, -1) {
+			callbacks = appendUnique(callbacks, m[1])
+		}
+		return true
+	})
+
+	for name := range providers {
+		info.Providers = append(info.Providers, name)
+	}
+	sort.Strings(info.Providers)
+
+	for _, lib := range info.Libraries {
+		if lib.CallbackPath == "" {
+			continue
+		}
+		// Passport callback URLs set in source replace the conventional path
+		if lib.Name == "passport" && len(callbacks) > 0 {
+			for _, callback := range callbacks {
+				info.CallbackURLs = appendUnique(info.CallbackURLs, callback)
+			}
+			continue
+		}
+		if len(info.Providers) == 0 {
+			info.CallbackURLs = appendUnique(info.CallbackURLs, lib.CallbackPath)
+			continue
+		}
+		for _, provider := range info.Providers {
+			info.CallbackURLs = appendUnique(info.CallbackURLs, strings.ReplaceAll(lib.CallbackPath, "{provider}", provider))
+		}
+	}
+
+	return info
+}
+
+// authLibraryFor returns the library definition matching the installed version.
+// next-auth v5 is Auth.js and uses its variable names and /api/auth base path.
+func authLibraryFor(lib AuthLibrary, pkg *PackageJSON) AuthLibrary {
+	if lib.Package != "next-auth" {
+		return lib
+	}
+	version := cleanVersion(pkg.GetDependencyVersion("next-auth"))
+	if major, ok := semver.Major(version); (ok && major >= 5) || strings.Contains(version, "beta") {
+		v5 := withPackage(authjs, "next-auth")
+		v5.CallbackPath = "/api/auth/callback/{provider}"
+		return v5
+	}
+	return lib
+}
+
+// ProviderEnv returns the client ID and secret variable names for an OAuth provider
+func (lib AuthLibrary) ProviderEnv(provider string) (id, secret string) {
+	if lib.ProviderIDEnv == "" {
+		return "", ""
+	}
+	name := strings.ToUpper(strings.ReplaceAll(provider, "-", "_"))
+	return fmt.Sprintf(lib.ProviderIDEnv, name), fmt.Sprintf(lib.ProviderSecretEnv, name)
+}
+
+// withPackage returns a copy of an auth library definition for another package
+func withPackage(lib AuthLibrary, pkg string) AuthLibrary {
+	lib.Package = pkg
+	return lib
+}
+
+// arcticProviders returns the provider names among arctic's named imports
+// (e.g., "GitHub, generateState" -> ["github"])
+func arcticProviders(imports string) []string {
+	var names []string
+	for _, name := range strings.Split(imports, ",") {
+		name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "type "))
+		if i := strings.Index(name, " as "); i >= 0 {
+			name = name[:i]
+		}
+		if name == "" || !isUpper(name[0]) || strings.HasSuffix(name, "Error") || strings.HasPrefix(name, "OAuth2") {
+			continue
+		}
+		names = append(names, strings.ToLower(name))
+	}
+	return names
+}
+
+// objectKeys returns the top-level keys of an object literal, given the text right
+// after its opening brace. Keys are identifiers followed by a colon at depth zero.
+func objectKeys(text string) []string {
+	var keys []string
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			if depth == 0 {
+				return keys
+			}
+			depth--
+		case depth == 0 && isIdentStart(c) && (i == 0 || !isIdentChar(text[i-1])):
+			end := i
+			for end < len(text) && isIdentChar(text[end]) {
+				end++
+			}
+			if strings.HasPrefix(strings.TrimLeft(text[end:], " \t"), ":") {
+				keys = append(keys, text[i:end])
+			}
+			i = end - 1
+		}
+	}
+	return keys
+}
+
+// isUpper returns true for ASCII upper-case letters
+func isUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
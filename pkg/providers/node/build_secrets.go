@@ -0,0 +1,62 @@
+package node
+
+import (
+	"regexp"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// npmrcEnvPattern matches ${VAR} references, which npm/pnpm/yarn expand from the environment
+var npmrcEnvPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// knownBuildSecrets describes common registry token variables
+var knownBuildSecrets = map[string]string{
+	"NPM_TOKEN":                  "npm registry token",
+	"NPM_AUTH_TOKEN":             "npm registry token",
+	"NODE_AUTH_TOKEN":            "npm registry token",
+	"GITHUB_TOKEN":               "GitHub Packages token",
+	"GH_TOKEN":                   "GitHub Packages token",
+	"FONTAWESOME_TOKEN":          "Font Awesome Pro registry token",
+	"FONTAWESOME_NPM_AUTH_TOKEN": "Font Awesome Pro registry token",
+	"FONTAWESOME_PACKAGE_TOKEN":  "Font Awesome Pro registry token",
+}
+
+// buildSecretFiles are registry config files that may reference ${VAR} credentials
+var buildSecretFiles = []string{".npmrc", ".yarnrc.yml", ".yarnrc.yaml"}
+
+// DetectBuildSecrets finds credentials the install step reads from the environment.
+// Variables referenced in .npmrc or .yarnrc.yml are mounted as build secrets instead
+// of build args, so tokens never end up in image layers or history.
+func DetectBuildSecrets(ctx *app.Context) []app.BuildSecret {
+	var secrets []app.BuildSecret
+
+	seen := make(map[string]bool)
+	for _, file := range buildSecretFiles {
+		data, err := ctx.ReadFile(file)
+		if err != nil {
+			logSkipped(ctx, file, err)
+			continue
+		}
+
+		for _, match := range npmrcEnvPattern.FindAllSubmatch(data, -1) {
+			name := string(match[1])
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			description, ok := knownBuildSecrets[name]
+			if !ok {
+				description = "registry credential referenced in " + file
+			}
+			secrets = append(secrets, app.BuildSecret{
+				ID:          name,
+				Env:         name,
+				Description: description,
+				Source:      file,
+			})
+		}
+	}
+
+	return secrets
+}
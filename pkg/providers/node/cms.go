@@ -0,0 +1,122 @@
+package node
+
+// ContentSource represents a content layer or headless CMS SDK used at build time
+type ContentSource struct {
+	// Package is the npm package name
+	Package string
+	// Name is the content source identifier (e.g., "contentful")
+	Name string
+	// Remote is true when content is fetched from an API, so content changes need a rebuild
+	Remote bool
+	// Env are configuration variables the SDK reads during build
+	Env []string
+	// Secrets are API tokens the SDK reads during build
+	Secrets []string
+}
+
+// ContentSources is a list of known content layers and headless CMS SDKs
+var ContentSources = []ContentSource{
+	// Local content layers (content lives in the repository)
+	{Package: "contentlayer", Name: "contentlayer"},
+	{Package: "contentlayer2", Name: "contentlayer"},
+	{Package: "next-contentlayer", Name: "contentlayer"},
+	{Package: "next-contentlayer2", Name: "contentlayer"},
+	{Package: "@nuxt/content", Name: "nuxt-content"},
+	{Package: "@content-collections/core", Name: "content-collections"},
+	{Package: "velite", Name: "velite"},
+
+	// Headless CMS SDKs
+	{
+		Package: "contentful",
+		Name:    "contentful",
+		Remote:  true,
+		Env:     []string{"CONTENTFUL_SPACE_ID"},
+		Secrets: []string{"CONTENTFUL_ACCESS_TOKEN"},
+	},
+	{
+		Package: "@sanity/client",
+		Name:    "sanity",
+		Remote:  true,
+		Env:     []string{"SANITY_PROJECT_ID", "SANITY_DATASET"},
+		Secrets: []string{"SANITY_API_TOKEN"},
+	},
+	{
+		Package: "next-sanity",
+		Name:    "sanity",
+		Remote:  true,
+		Env:     []string{"NEXT_PUBLIC_SANITY_PROJECT_ID", "NEXT_PUBLIC_SANITY_DATASET"},
+		Secrets: []string{"SANITY_API_READ_TOKEN"},
+	},
+	{
+		Package: "@storyblok/js",
+		Name:    "storyblok",
+		Remote:  true,
+		Secrets: []string{"STORYBLOK_TOKEN"},
+	},
+	{
+		Package: "storyblok-js-client",
+		Name:    "storyblok",
+		Remote:  true,
+		Secrets: []string{"STORYBLOK_TOKEN"},
+	},
+	{
+		Package: "@prismicio/client",
+		Name:    "prismic",
+		Remote:  true,
+		Env:     []string{"PRISMIC_REPOSITORY_NAME"},
+		Secrets: []string{"PRISMIC_ACCESS_TOKEN"},
+	},
+	{
+		Package: "@datocms/cda-client",
+		Name:    "datocms",
+		Remote:  true,
+		Secrets: []string{"DATOCMS_API_TOKEN"},
+	},
+	{
+		Package: "@tryghost/content-api",
+		Name:    "ghost",
+		Remote:  true,
+		Env:     []string{"GHOST_API_URL"},
+		Secrets: []string{"GHOST_CONTENT_API_KEY"},
+	},
+	{
+		Package: "@strapi/client",
+		Name:    "strapi",
+		Remote:  true,
+		Env:     []string{"STRAPI_API_URL"},
+		Secrets: []string{"STRAPI_API_TOKEN"},
+	},
+	{
+		Package: "@directus/sdk",
+		Name:    "directus",
+		Remote:  true,
+		Env:     []string{"DIRECTUS_URL"},
+		Secrets: []string{"DIRECTUS_TOKEN"},
+	},
+	{
+		Package: "@builder.io/sdk",
+		Name:    "builder",
+		Remote:  true,
+		Secrets: []string{"BUILDER_API_KEY"},
+	},
+	{
+		Package: "tinacms",
+		Name:    "tina",
+		Remote:  true,
+		Env:     []string{"NEXT_PUBLIC_TINA_CLIENT_ID"},
+		Secrets: []string{"TINA_TOKEN"},
+	},
+}
+
+// DetectContentSources checks which content layers and CMS SDKs are used by the project
+func DetectContentSources(pkg *PackageJSON) []ContentSource {
+	var detected []ContentSource
+
+	for _, src := range ContentSources {
+		if pkg.HasDependency(src.Package) {
+			detected = append(detected, src)
+		}
+	}
+
+	return detected
+}
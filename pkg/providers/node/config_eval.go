@@ -0,0 +1,441 @@
+package node
+
+import (
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// maxResolveDepth bounds how many wrappers, variables and spreads are followed
+// when resolving a config value, so self-references can't loop
+const maxResolveDepth = 32
+
+// configObject is an object literal of a config file with its spreads merged,
+// as the properties' value nodes by key. Later properties override earlier
+// ones, like at runtime: { ...base, output: 'export' } has output 'export'
+// whatever base says.
+type configObject struct {
+	props map[string]*sitter.Node
+	// keys are the property names in order, for nested searches
+	keys []string
+}
+
+// configExport is what a config file exports, in the ESM or CommonJS style
+type configExport struct {
+	// value is the exported expression: export default ..., module.exports = ...,
+	// exports.default = ... or TypeScript's export = ...; nil when the file
+	// only assigns properties
+	value *sitter.Node
+	// props are properties assigned to the export one by one
+	// (module.exports.output = 'export'), as assignment expressions
+	props []*sitter.Node
+}
+
+// findExport finds the export of a config file among its top-level
+// statements, or returns nil when it exports nothing. As at runtime, the last
+// assignment to module.exports wins and replaces the properties assigned
+// before it; exports.output = ... only adds to the export while
+// module.exports hasn't been replaced.
+func findExport(root *sitter.Node, source []byte) *configExport {
+	var exp *configExport
+	replaced := false
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		stmt := root.NamedChild(i)
+		switch stmt.Type() {
+		case "export_statement":
+			if value := defaultExport(stmt); value != nil {
+				exp = &configExport{value: value}
+			}
+		case "expression_statement":
+			assign := stmt.NamedChild(0)
+			if assign == nil || assign.Type() != "assignment_expression" {
+				continue
+			}
+			left, right := assign.ChildByFieldName("left"), assign.ChildByFieldName("right")
+			if left == nil || right == nil {
+				continue
+			}
+			switch target := getNodeText(left, source); {
+			case target == "module.exports":
+				exp = &configExport{value: right}
+				replaced = true
+			case target == "exports.default" || target == "module.exports.default":
+				// Compiled ESM: the default export is the config
+				exp = &configExport{value: right}
+			case isPropertyAssignment(left, source, "module.exports") || (!replaced && isPropertyAssignment(left, source, "exports")):
+				if exp == nil {
+					exp = &configExport{}
+				}
+				exp.props = append(exp.props, assign)
+			}
+		}
+	}
+	return exp
+}
+
+// defaultExport returns the exported value of export default ... (an
+// expression or a function declaration) and TypeScript's export = ...
+func defaultExport(stmt *sitter.Node) *sitter.Node {
+	if value := stmt.ChildByFieldName("value"); value != nil {
+		return value
+	}
+	isDefault := false
+	for i := 0; i < int(stmt.ChildCount()); i++ {
+		switch child := stmt.Child(i); child.Type() {
+		case "default":
+			isDefault = true
+		case "=":
+			return stmt.NamedChild(0)
+		default:
+			if isDefault && child.IsNamed() {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// isPropertyAssignment checks if the left side of an assignment sets a
+// property of object (config.output for "config")
+func isPropertyAssignment(left *sitter.Node, source []byte, object string) bool {
+	if left.Type() != "member_expression" {
+		return false
+	}
+	obj := left.ChildByFieldName("object")
+	return obj != nil && getNodeText(obj, source) == object && left.ChildByFieldName("property") != nil
+}
+
+// resolve resolves the export to its config object, with the properties
+// assigned one by one applied. It returns nil when the exported value isn't
+// an object that can be resolved statically.
+func (exp *configExport) resolve(root *sitter.Node, source []byte) *configObject {
+	obj := &configObject{props: make(map[string]*sitter.Node)}
+	if exp.value != nil {
+		if obj = resolveObject(root, exp.value, source, 0); obj == nil {
+			return nil
+		}
+	}
+	obj.assign(exp.props, source)
+	return obj
+}
+
+// exportedConfig finds the object a config file exports (see findExport),
+// through defineConfig() and other wrappers and local variables. It returns
+// nil when the file exports nothing or the export isn't an object that can
+// be resolved statically.
+func exportedConfig(root *sitter.Node, source []byte) *configObject {
+	exp := findExport(root, source)
+	if exp == nil {
+		return nil
+	}
+	return exp.resolve(root, source)
+}
+
+// resolveExpr follows an expression to the node it evaluates to: variables to
+// their initializers, parentheses, await and TypeScript type expressions to
+// their content
+func resolveExpr(root, node *sitter.Node, source []byte, depth int) *sitter.Node {
+	for ; node != nil && depth < maxResolveDepth; depth++ {
+		switch node.Type() {
+		case "parenthesized_expression", "await_expression":
+			node = node.NamedChild(0)
+		case "identifier", "shorthand_property_identifier":
+			node = findDeclaration(root, source, getNodeText(node, source))
+		default:
+			if inner := unwrapTypes(node); inner != node {
+				node = inner
+				continue
+			}
+			return node
+		}
+	}
+	return nil
+}
+
+// unwrapTypes returns the expression inside TypeScript type expressions:
+// `{...} satisfies NextConfig`, `'export' as const`, `config!` and
+// `<NextConfig>{...}` all have the value of their expression
+func unwrapTypes(node *sitter.Node) *sitter.Node {
+	for node != nil {
+		switch node.Type() {
+		case "satisfies_expression", "as_expression", "non_null_expression":
+			node = node.NamedChild(0)
+		case "type_assertion":
+			node = node.NamedChild(int(node.NamedChildCount()) - 1)
+		default:
+			return node
+		}
+	}
+	return node
+}
+
+// stringValue returns a value as text: string literals and template literals
+// without interpolation without their quotes, other expressions as written
+func stringValue(node *sitter.Node, source []byte) string {
+	node = unwrapTypes(node)
+	if node == nil {
+		return ""
+	}
+	text := getNodeText(node, source)
+	if node.Type() == "template_string" {
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if node.NamedChild(i).Type() == "template_substitution" {
+				return text
+			}
+		}
+		if len(text) >= 2 {
+			return text[1 : len(text)-1]
+		}
+	}
+	return trimQuotes(text)
+}
+
+// resolveObject resolves an expression to an object literal and merges it.
+// Calls such as defineConfig({...}) or withPlugins(config) resolve to their
+// first argument that is an object; functions, as in defineConfig(() => ({...})),
+// to the object they return.
+func resolveObject(root, node *sitter.Node, source []byte, depth int) *configObject {
+	if depth > maxResolveDepth || node == nil {
+		return nil
+	}
+	if node.Type() == "identifier" {
+		// const config = {}; config.output = 'export'; export default config
+		name := getNodeText(node, source)
+		value := findDeclaration(root, source, name)
+		obj := resolveObject(root, value, source, depth+1)
+		if obj != nil {
+			obj.assign(propertyAssignments(enclosingBlock(value), source, name), source)
+		}
+		return obj
+	}
+	node = resolveExpr(root, node, source, depth)
+	if node == nil {
+		return nil
+	}
+
+	switch node.Type() {
+	case "object":
+		return mergeObject(root, node, source, depth)
+	case "call_expression":
+		if args := node.ChildByFieldName("arguments"); args != nil {
+			for i := 0; i < int(args.NamedChildCount()); i++ {
+				if obj := resolveObject(root, args.NamedChild(i), source, depth+1); obj != nil {
+					return obj
+				}
+			}
+		}
+		fn := node.ChildByFieldName("function")
+		if fn == nil {
+			return nil
+		}
+		// withMDX(options)(nextConfig) wraps the config in the outer call
+		if fn.Type() == "call_expression" {
+			return resolveObject(root, fn, source, depth+1)
+		}
+		// A config factory defined in the file: module.exports = createConfig()
+		if fn.Type() == "identifier" {
+			if decl := resolveExpr(root, fn, source, depth+1); decl != nil && isFunction(decl) {
+				return resolveObject(root, decl, source, depth+1)
+			}
+		}
+	case "arrow_function", "function", "function_expression", "function_declaration":
+		body := node.ChildByFieldName("body")
+		if body == nil {
+			return nil
+		}
+		if body.Type() != "statement_block" {
+			return resolveObject(root, body, source, depth+1)
+		}
+		if value := returnedValue(body); value != nil {
+			return resolveObject(root, value, source, depth+1)
+		}
+	}
+	return nil
+}
+
+// isFunction checks if a node is a function (declaration or expression)
+func isFunction(node *sitter.Node) bool {
+	switch node.Type() {
+	case "arrow_function", "function", "function_expression", "function_declaration":
+		return true
+	}
+	return false
+}
+
+// returnedValue returns the value a function body returns: its first
+// unconditional return, or else the last return in its branches, which for
+// config factories such as (phase) => { if (phase === DEV) return {...}; ... }
+// is usually the production build's. Returns of nested functions don't count.
+func returnedValue(body *sitter.Node) *sitter.Node {
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		if stmt := body.NamedChild(i); stmt.Type() == "return_statement" {
+			return stmt.NamedChild(0)
+		}
+	}
+
+	var last *sitter.Node
+	var visit func(n *sitter.Node, depth int)
+	visit = func(n *sitter.Node, depth int) {
+		if depth > maxNodeDepth {
+			return
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			child := n.NamedChild(i)
+			switch {
+			case child.Type() == "return_statement":
+				if child.NamedChildCount() > 0 {
+					last = child.NamedChild(0)
+				}
+			case isFunction(child) || child.Type() == "class_declaration" || child.Type() == "class":
+			default:
+				visit(child, depth+1)
+			}
+		}
+	}
+	visit(body, 0)
+	return last
+}
+
+// findDeclaration returns what a name is declared as in the file: the value of
+// a variable (const config = ...) or a function declaration
+// (function createConfig() {...}), or nil
+func findDeclaration(root *sitter.Node, source []byte, name string) *sitter.Node {
+	var decl *sitter.Node
+	walkNodes(root, func(n *sitter.Node) bool {
+		switch n.Type() {
+		case "variable_declarator":
+			if id := n.ChildByFieldName("name"); id != nil && getNodeText(id, source) == name {
+				decl = n.ChildByFieldName("value")
+				return false
+			}
+		case "function_declaration":
+			if id := n.ChildByFieldName("name"); id != nil && getNodeText(id, source) == name {
+				decl = n
+				return false
+			}
+		}
+		return true
+	})
+	return decl
+}
+
+// enclosingBlock returns the block or program a node is declared in
+func enclosingBlock(node *sitter.Node) *sitter.Node {
+	for ; node != nil; node = node.Parent() {
+		if t := node.Type(); t == "statement_block" || t == "program" {
+			return node
+		}
+	}
+	return nil
+}
+
+// mergeObject collects the properties of an object literal, merging spreads
+// of objects that resolve
+func mergeObject(root, node *sitter.Node, source []byte, depth int) *configObject {
+	obj := &configObject{props: make(map[string]*sitter.Node)}
+	set := func(key string, value *sitter.Node) {
+		if _, ok := obj.props[key]; !ok {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.props[key] = value
+	}
+
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		switch child.Type() {
+		case "pair":
+			key := child.ChildByFieldName("key")
+			value := child.ChildByFieldName("value")
+			if key != nil && value != nil && key.Type() != "computed_property_name" {
+				set(trimQuotes(getNodeText(key, source)), value)
+			}
+		case "shorthand_property_identifier":
+			// { output } takes the variable's value
+			set(getNodeText(child, source), child)
+		case "method_definition":
+			if key := child.ChildByFieldName("name"); key != nil {
+				set(trimQuotes(getNodeText(key, source)), child)
+			}
+		case "spread_element":
+			if spread := resolveObject(root, child.NamedChild(0), source, depth+1); spread != nil {
+				for _, key := range spread.keys {
+					set(key, spread.props[key])
+				}
+			}
+		}
+	}
+	return obj
+}
+
+// assign sets the properties of assignment expressions such as
+// config.output = 'export', in order
+func (obj *configObject) assign(assignments []*sitter.Node, source []byte) {
+	for _, assign := range assignments {
+		left, right := assign.ChildByFieldName("left"), assign.ChildByFieldName("right")
+		key := trimQuotes(getNodeText(left.ChildByFieldName("property"), source))
+		if _, ok := obj.props[key]; !ok {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.props[key] = right
+	}
+}
+
+// propertyAssignments returns the assignments to properties of a variable
+// (config.output = ...) among the statements of a block, in order
+func propertyAssignments(block *sitter.Node, source []byte, name string) []*sitter.Node {
+	if block == nil {
+		return nil
+	}
+	var assignments []*sitter.Node
+	for i := 0; i < int(block.NamedChildCount()); i++ {
+		stmt := block.NamedChild(i)
+		if stmt.Type() != "expression_statement" {
+			continue
+		}
+		if assign := stmt.NamedChild(0); assign != nil && assign.Type() == "assignment_expression" {
+			left := assign.ChildByFieldName("left")
+			if left != nil && assign.ChildByFieldName("right") != nil && isPropertyAssignment(left, source, name) {
+				assignments = append(assignments, assign)
+			}
+		}
+	}
+	return assignments
+}
+
+// get returns the value of one of the object's own properties, or nil
+func (obj *configObject) get(name string) *sitter.Node {
+	return obj.props[name]
+}
+
+// configValueText returns a property value as text, like stringValue, with
+// variables resolved to their values
+func configValueText(root, value *sitter.Node, source []byte) string {
+	if resolved := resolveExpr(root, value, source, 0); resolved != nil {
+		value = resolved
+	}
+	return stringValue(value, source)
+}
+
+// configScope resolves the config object of a file (given its root) or of an
+// expression in it. When it can't be resolved, scope is the node to search
+// as written instead: the exported value when the file has an export, so
+// keys elsewhere in the file aren't picked up, otherwise the node itself.
+func configScope(root, node *sitter.Node, source []byte) (obj *configObject, scope *sitter.Node) {
+	if node.Type() != "program" {
+		return resolveObject(root, node, source, 0), node
+	}
+	exp := findExport(root, source)
+	if exp == nil {
+		return nil, node
+	}
+	if obj := exp.resolve(root, source); obj != nil {
+		return obj, nil
+	}
+	return nil, exp.value
+}
+
+// programNode returns the root of the syntax tree a node belongs to
+func programNode(node *sitter.Node) *sitter.Node {
+	for node.Parent() != nil {
+		node = node.Parent()
+	}
+	return node
+}
@@ -2,25 +2,83 @@ package node
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/coollabsio/coolpack/pkg/app"
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/javascript"
 	"github.com/smacker/go-tree-sitter/typescript/typescript"
 )
 
-// ConfigParser parses JavaScript/TypeScript config files using tree-sitter
+// Limits that keep pathological or adversarial source files from exhausting
+// memory or CPU. Config and entry files are small; anything beyond these is skipped.
+const (
+	// MaxSourceSize is the largest source file parsed (bigger files are usually bundles)
+	MaxSourceSize = 1 << 20
+
+	// parseTimeout bounds the time spent parsing one file
+	parseTimeout = 2 * time.Second
+
+	// maxNodeDepth bounds recursion when walking syntax trees
+	maxNodeDepth = 500
+)
+
+// ErrSourceTooLarge is returned when a file exceeds MaxSourceSize
+var ErrSourceTooLarge = errors.New("source file too large to parse")
+
+// ConfigParser parses JavaScript/TypeScript config files using tree-sitter.
+// It keeps the tree of its last parse: nodes returned by ParseTS and ParseJS
+// are valid until the next parse or Release. A ConfigParser must not be used
+// concurrently.
 type ConfigParser struct {
 	tsParser *sitter.Parser
 	jsParser *sitter.Parser
+	tree     *sitter.Tree
+}
+
+// configParsers holds parsers for reuse: each is two tree-sitter parsers
+// allocated in C, and a detection checks many config and source files
+var configParsers = sync.Pool{
+	New: func() any { return NewConfigParser() },
+}
+
+// acquireConfigParser returns a parser from the shared pool; the caller
+// releases it when done with its nodes
+func acquireConfigParser() *ConfigParser {
+	return configParsers.Get().(*ConfigParser)
+}
+
+// Release frees the tree of the last parse, invalidating its nodes, and
+// returns the parser to the shared pool
+func (p *ConfigParser) Release() {
+	p.closeTree()
+	configParsers.Put(p)
+}
+
+// closeTree frees the tree of the last parse now rather than when the
+// garbage collector gets to it
+func (p *ConfigParser) closeTree() {
+	if p.tree != nil {
+		p.tree.Close()
+		p.tree = nil
+	}
 }
 
 // NewConfigParser creates a new config parser
 func NewConfigParser() *ConfigParser {
 	tsParser := sitter.NewParser()
 	tsParser.SetLanguage(typescript.GetLanguage())
+	tsParser.SetOperationLimit(int(parseTimeout.Microseconds()))
 
 	jsParser := sitter.NewParser()
 	jsParser.SetLanguage(javascript.GetLanguage())
+	jsParser.SetOperationLimit(int(parseTimeout.Microseconds()))
 
 	return &ConfigParser{
 		tsParser: tsParser,
@@ -30,121 +88,316 @@ func NewConfigParser() *ConfigParser {
 
 // ParseTS parses TypeScript source code and returns the root node
 func (p *ConfigParser) ParseTS(source []byte) (*sitter.Node, error) {
-	tree, err := p.tsParser.ParseCtx(context.Background(), nil, source)
-	if err != nil {
-		return nil, err
-	}
-	return tree.RootNode(), nil
+	return p.parse(p.tsParser, source)
 }
 
 // ParseJS parses JavaScript source code and returns the root node
 func (p *ConfigParser) ParseJS(source []byte) (*sitter.Node, error) {
-	tree, err := p.jsParser.ParseCtx(context.Background(), nil, source)
+	return p.parse(p.jsParser, source)
+}
+
+// parse parses source within the size and time limits, replacing the tree of
+// the last parse
+func (p *ConfigParser) parse(parser *sitter.Parser, source []byte) (*sitter.Node, error) {
+	p.closeTree()
+	if len(source) > MaxSourceSize {
+		return nil, ErrSourceTooLarge
+	}
+
+	// The time limit is tree-sitter's own (SetOperationLimit): a parse under a
+	// cancelable context can leave the parser's cancel flag set after it
+	// finished, failing the next parse of the pooled parser
+	tree, err := parser.ParseCtx(context.Background(), nil, source)
 	if err != nil {
+		// A halted parse would otherwise resume on the next source
+		parser.Reset()
 		return nil, err
 	}
+	p.tree = tree
 	return tree.RootNode(), nil
 }
 
-// FindPropertyValue searches for a property with the given name in an object
-// and returns its string value if found
-func FindPropertyValue(node *sitter.Node, source []byte, propertyName string) string {
-	if node == nil {
-		return ""
+// parseConfigFile reads and parses a JavaScript or TypeScript file of the
+// application (TypeScript for .ts, .mts and .cts). Files that can't be read or
+// parsed are logged and return false.
+func parseConfigFile(ctx *app.Context, parser *ConfigParser, file string) (*sitter.Node, []byte, bool) {
+	root, data, err := parseSourceFile(ctx, parser, file)
+	if err != nil {
+		logSkipped(ctx, file, err)
+		return nil, nil, false
 	}
-
-	// Recursively search the tree
-	return findPropertyInNode(node, source, propertyName)
+	return root, data, true
 }
 
-// FindNestedPropertyValue searches for a nested property path (e.g., "server.preset")
-// and returns its string value if found
-func FindNestedPropertyValue(node *sitter.Node, source []byte, path ...string) string {
-	if node == nil || len(path) == 0 {
-		return ""
+// parseConfig parses a framework config file like parseConfigFile. Settings
+// in a file that can't be read or only partly parses are missed, so such
+// files are reported as diagnostics (plan warnings) rather than only logged.
+func parseConfig(ctx *app.Context, parser *ConfigParser, file string) (*sitter.Node, []byte, bool) {
+	root, data, err := parseSourceFile(ctx, parser, file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, false
+		}
+		ctx.AddDiagnostic(app.Diagnostic{
+			Code:    "config_unreadable",
+			File:    file,
+			Message: fmt.Sprintf("config file skipped (%v); detection used the framework's defaults", err),
+		})
+		return nil, nil, false
+	}
+	if root.HasError() {
+		d := app.Diagnostic{
+			Code:    "config_syntax_error",
+			File:    file,
+			Message: "config file doesn't fully parse; settings near this point may have been missed",
+		}
+		if bad := firstSyntaxError(root); bad != nil {
+			d.Line, d.Column = nodePosition(bad)
+		}
+		ctx.AddDiagnostic(d)
 	}
+	return root, data, true
+}
 
-	// Find the first property in the path
-	objectNode := findPropertyObjectNode(node, source, path[0])
-	if objectNode == nil {
-		return ""
+// parseSourceFile reads and parses a JavaScript or TypeScript file
+func parseSourceFile(ctx *app.Context, parser *ConfigParser, file string) (*sitter.Node, []byte, error) {
+	data, err := ctx.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// If there's only one property in the path, return its value
-	if len(path) == 1 {
-		return trimQuotes(getNodeText(objectNode, source))
+	var root *sitter.Node
+	switch filepath.Ext(file) {
+	case ".ts", ".mts", ".cts":
+		root, err = parser.ParseTS(data)
+	default:
+		root, err = parser.ParseJS(data)
 	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, data, nil
+}
 
-	// Otherwise, continue searching in the nested object
-	return FindNestedPropertyValue(objectNode, source, path[1:]...)
+// firstSyntaxError returns the first node tree-sitter couldn't parse or had
+// to insert
+func firstSyntaxError(root *sitter.Node) *sitter.Node {
+	var bad *sitter.Node
+	walkNodes(root, func(n *sitter.Node) bool {
+		if n.IsError() || n.IsMissing() {
+			bad = n
+			return false
+		}
+		return true
+	})
+	return bad
 }
 
-// findPropertyObjectNode finds a property and returns its value node (for nested lookups)
-func findPropertyObjectNode(node *sitter.Node, source []byte, propertyName string) *sitter.Node {
-	if node == nil {
-		return nil
-	}
+// nodePosition returns the 1-based line and column a node starts at
+func nodePosition(node *sitter.Node) (line, col int) {
+	p := node.StartPoint()
+	return int(p.Row) + 1, int(p.Column) + 1
+}
 
-	nodeType := node.Type()
+// configHasValue parses the config files that exist, in order, until match
+// returns true for one of them
+func configHasValue(ctx *app.Context, files []string, match func(root *sitter.Node, data []byte) bool) bool {
+	parser := acquireConfigParser()
+	defer parser.Release()
+	for _, file := range files {
+		if !ctx.HasFile(file) {
+			continue
+		}
+		if root, data, ok := parseConfig(ctx, parser, file); ok && match(root, data) {
+			return true
+		}
+	}
+	return false
+}
 
-	if nodeType == "pair" || nodeType == "property_assignment" {
-		keyNode := node.ChildByFieldName("key")
-		valueNode := node.ChildByFieldName("value")
+// configSetting returns a setting from the first of the config files that
+// sets it (e.g., output in next.config.*), read with configSettingOf
+func configSetting(ctx *app.Context, files []string, path ...string) string {
+	parser := acquireConfigParser()
+	defer parser.Release()
+	for _, file := range files {
+		if !ctx.HasFile(file) {
+			continue
+		}
+		root, data, ok := parseConfig(ctx, parser, file)
+		if !ok {
+			continue
+		}
+		if value, found := configSettingOf(ctx, file, root, data, path...); found {
+			return value
+		}
+	}
+	return ""
+}
 
-		if keyNode != nil && valueNode != nil {
-			key := getNodeText(keyNode, source)
-			key = trimQuotes(key)
+// configSettingOf returns a setting of a parsed config file and whether the
+// file sets it. A value only known when the config runs, such as
+// process.env.OUTPUT or a function call, is reported as a config_value_dynamic
+// diagnostic and returns "", so detection goes on with the framework's default.
+func configSettingOf(ctx *app.Context, file string, root *sitter.Node, data []byte, path ...string) (string, bool) {
+	value := findConfigValue(root, data, path...)
+	if value == nil {
+		return "", false
+	}
+	if resolved := resolveExpr(root, value, data, 0); resolved != nil {
+		if isStaticValue(resolved) {
+			return stringValue(resolved, data), true
+		}
+		value = resolved
+	}
+	reportDynamicValue(ctx, file, strings.Join(path, "."), value, data)
+	return "", true
+}
 
-			if key == propertyName {
-				return valueNode
+// isStaticValue checks if a value is written out in the config (strings,
+// numbers, booleans, objects and arrays) rather than computed when it runs
+func isStaticValue(node *sitter.Node) bool {
+	node = unwrapTypes(node)
+	switch node.Type() {
+	case "string", "number", "true", "false", "null", "undefined", "object", "array":
+		return true
+	case "template_string":
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if node.NamedChild(i).Type() == "template_substitution" {
+				return false
 			}
 		}
+		return true
 	}
+	return false
+}
 
-	// Recurse into children
-	for i := 0; i < int(node.ChildCount()); i++ {
-		child := node.Child(i)
-		if result := findPropertyObjectNode(child, source, propertyName); result != nil {
-			return result
-		}
+// reportDynamicValue reports a setting whose value detection can't know
+func reportDynamicValue(ctx *app.Context, file, setting string, value *sitter.Node, source []byte) {
+	text := strings.Join(strings.Fields(getNodeText(value, source)), " ")
+	if len(text) > 60 {
+		text = text[:57] + "..."
+	}
+	line, col := nodePosition(value)
+	ctx.AddDiagnostic(app.Diagnostic{
+		Code:    "config_value_dynamic",
+		File:    file,
+		Line:    line,
+		Column:  col,
+		Message: fmt.Sprintf("%s is set to %s, which is only known when the config runs; detection used the framework's default", setting, text),
+	})
+}
+
+// logSkipped logs a project file that detection skipped because it couldn't
+// be read or parsed, which would otherwise go unnoticed. Missing files are
+// expected and not logged.
+func logSkipped(ctx *app.Context, file string, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	ctx.Log().Info("skipping file", "file", file, "error", err)
+}
+
+// FindPropertyValue returns the string value of a top-level property of a
+// config, or "". Given the root of a config file, it reads the object the
+// file exports (export default, module.exports or exports.default), through
+// defineConfig() wrappers, local variables and object spreads. Only the
+// export's own properties count: an output key in images: { ... } or in
+// plugin options doesn't set the config's output. Files without an export
+// are searched as a whole.
+func FindPropertyValue(node *sitter.Node, source []byte, propertyName string) string {
+	return FindNestedPropertyValue(node, source, propertyName)
+}
+
+// FindNestedPropertyValue returns the string value of a property path from
+// the top level of a config (e.g., "server", "preset" for server.preset),
+// resolving the config like FindPropertyValue
+func FindNestedPropertyValue(node *sitter.Node, source []byte, path ...string) string {
+	value := findConfigValue(node, source, path...)
+	if value == nil {
+		return ""
+	}
+	return configValueText(programNode(value), value, source)
+}
+
+// findConfigValue returns the value node of a property path, found as
+// FindNestedPropertyValue describes, or nil
+func findConfigValue(node *sitter.Node, source []byte, path ...string) *sitter.Node {
+	if node == nil || len(path) == 0 {
+		return nil
 	}
 
+	root := programNode(node)
+	obj, scope := configScope(root, node, source)
+	if obj == nil {
+		return findNestedProperty(scope, source, path...)
+	}
+
+	for i, name := range path {
+		value := obj.get(name)
+		if value == nil || i == len(path)-1 {
+			return value
+		}
+		if obj = resolveObject(root, value, source, 0); obj == nil {
+			// A nested object that can't be resolved is searched as written
+			return findNestedProperty(value, source, path[i+1:]...)
+		}
+	}
 	return nil
 }
 
-func findPropertyInNode(node *sitter.Node, source []byte, propertyName string) string {
-	if node == nil {
+// findPluginOption returns the string value of an option passed to a plugin
+// in the config's plugins list (plugins: [sitemap({ hostname: '...' })]), or ""
+func findPluginOption(node *sitter.Node, source []byte, option string) string {
+	plugins := findConfigValue(node, source, "plugins")
+	if plugins == nil {
 		return ""
 	}
+	if resolved := resolveExpr(programNode(plugins), plugins, source, 0); resolved != nil {
+		plugins = resolved
+	}
+	value := findPropertyObjectNode(plugins, source, option, 0)
+	if value == nil {
+		return ""
+	}
+	return configValueText(programNode(value), value, source)
+}
+
+// findNestedProperty searches for a nested property path anywhere in the
+// tree and returns its value node
+func findNestedProperty(node *sitter.Node, source []byte, path ...string) *sitter.Node {
+	if node == nil || len(path) == 0 {
+		return nil
+	}
+
+	// Find the first property in the path
+	objectNode := findPropertyObjectNode(node, source, path[0], 0)
+	if objectNode == nil || len(path) == 1 {
+		return objectNode
+	}
+
+	// Continue searching in the nested object
+	return findNestedProperty(objectNode, source, path[1:]...)
+}
+
+// findPropertyObjectNode finds a property and returns its value node (for nested lookups)
+func findPropertyObjectNode(node *sitter.Node, source []byte, propertyName string, depth int) *sitter.Node {
+	if node == nil || depth > maxNodeDepth {
+		return nil
+	}
 
-	// Check if this is a property assignment (pair in tree-sitter terms)
 	nodeType := node.Type()
 
 	if nodeType == "pair" || nodeType == "property_assignment" {
-		// Get the key and value
 		keyNode := node.ChildByFieldName("key")
 		valueNode := node.ChildByFieldName("value")
 
 		if keyNode != nil && valueNode != nil {
 			key := getNodeText(keyNode, source)
-			// Remove quotes if present
 			key = trimQuotes(key)
 
 			if key == propertyName {
-				value := getNodeText(valueNode, source)
-				return trimQuotes(value)
-			}
-		}
-	}
-
-	// Also handle shorthand property patterns like { output: 'export' }
-	if nodeType == "property_identifier" || nodeType == "identifier" {
-		text := getNodeText(node, source)
-		if text == propertyName {
-			// Check if sibling is the value
-			sibling := node.NextSibling()
-			if sibling != nil {
-				return trimQuotes(getNodeText(sibling, source))
+				return valueNode
 			}
 		}
 	}
@@ -152,12 +405,12 @@ func findPropertyInNode(node *sitter.Node, source []byte, propertyName string) s
 	// Recurse into children
 	for i := 0; i < int(node.ChildCount()); i++ {
 		child := node.Child(i)
-		if result := findPropertyInNode(child, source, propertyName); result != "" {
+		if result := findPropertyObjectNode(child, source, propertyName, depth+1); result != nil {
 			return result
 		}
 	}
 
-	return ""
+	return nil
 }
 
 func getNodeText(node *sitter.Node, source []byte) string {
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"testing"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// FuzzConfigExtraction parses arbitrary config files as JavaScript and
+// TypeScript and reads settings from them the way framework detection does;
+// none may panic or hang
+func FuzzConfigExtraction(f *testing.F) {
+	seeds := []string{
+		`export default { output: 'export' }`,
+		`module.exports = { output: "standalone", images: { output: "x" } }`,
+		`import { defineConfig } from 'vite'; export default defineConfig({ base: '/app/', build: { outDir: 'build' } })`,
+		`const base = { output: 'server' }; export default { ...base, output: 'static' }`,
+		`export default defineNuxtConfig(() => ({ ssr: false }))`,
+		"module.exports = (phase) => { if (phase === PHASE_DEVELOPMENT_SERVER) return {}; return { output: `export` } }",
+		`const config = {}; config.output = 'export'; export default config`,
+		`export = { server: { preset: 'static' } } satisfies Config`,
+		`exports.default = withMDX({ extension: /\.mdx?$/ })(nextConfig)`,
+		`export default { plugins: [sitemap({ hostname: 'https://example.com' })] }`,
+		`const a = b; const b = a; export default a`,
+		`function f() { return f() } export default f()`,
+		`export default {{{{{{{{{{`,
+		"\xef\xbb\xbfexport default { output: 'export' }",
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, source []byte) {
+		parser := acquireConfigParser()
+		defer parser.Release()
+
+		for _, parse := range []func([]byte) (*sitter.Node, error){parser.ParseJS, parser.ParseTS} {
+			root, err := parse(source)
+			if err != nil {
+				continue
+			}
+			firstSyntaxError(root)
+			FindPropertyValue(root, source, "output")
+			FindNestedPropertyValue(root, source, "server", "preset")
+			FindNestedPropertyValue(root, source, "build", "outDir")
+			findPluginOption(root, source, "hostname")
+		}
+	})
+}
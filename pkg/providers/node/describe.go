@@ -0,0 +1,95 @@
+package node
+
+import (
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// DetectionVersion is the version of the provider's own detection: package
+// manager, Node.js version and commands. Increase it when a change plans an
+// existing application differently.
+const DetectionVersion = 2
+
+// frameworkDetectionVersions are the versions of each framework's detection:
+// output type, directories and port. Increase a framework's version when a
+// change plans an existing application differently.
+var frameworkDetectionVersions = map[Framework]int{
+	FrameworkNextJS:     2,
+	FrameworkRemix:      2,
+	FrameworkNuxt:       2,
+	FrameworkAstro:      2,
+	FrameworkSvelteKit:  1,
+	FrameworkSolidStart: 2,
+	FrameworkTanStack:   2,
+	FrameworkExpo:       2,
+	FrameworkGatsby:     1,
+	FrameworkEleventy:   1,
+	FrameworkAngular:    1,
+	FrameworkAdonisJS:   1,
+	FrameworkNestJS:     1,
+	FrameworkFastify:    1,
+	FrameworkExpress:    1,
+	FrameworkCRA:        1,
+	FrameworkVite:       2,
+}
+
+// frameworkOutputTypes are the output types a framework can build, the
+// default first (the other one depends on its configuration)
+var frameworkOutputTypes = map[Framework][]OutputType{
+	FrameworkNextJS:     {OutputTypeServer, OutputTypeStatic},
+	FrameworkRemix:      {OutputTypeServer, OutputTypeStatic},
+	FrameworkNuxt:       {OutputTypeServer, OutputTypeStatic},
+	FrameworkAstro:      {OutputTypeStatic, OutputTypeServer},
+	FrameworkSvelteKit:  {OutputTypeServer, OutputTypeStatic},
+	FrameworkSolidStart: {OutputTypeServer, OutputTypeStatic},
+	FrameworkTanStack:   {OutputTypeServer, OutputTypeStatic},
+	FrameworkExpo:       {OutputTypeStatic},
+	FrameworkGatsby:     {OutputTypeStatic},
+	FrameworkEleventy:   {OutputTypeStatic},
+	FrameworkAngular:    {OutputTypeStatic, OutputTypeServer},
+	FrameworkAdonisJS:   {OutputTypeServer},
+	FrameworkNestJS:     {OutputTypeServer},
+	FrameworkFastify:    {OutputTypeServer},
+	FrameworkExpress:    {OutputTypeServer},
+	FrameworkCRA:        {OutputTypeStatic},
+	FrameworkVite:       {OutputTypeStatic},
+}
+
+// Describe lists what the provider detects (see detector.Describer)
+func (p *Provider) Describe() app.ProviderDescription {
+	desc := app.ProviderDescription{
+		Name:             p.Name(),
+		DetectionVersion: DetectionVersion,
+		Languages:        []string{"nodejs", "bun"},
+		DetectFiles:      []string{"package.json"},
+		PackageManagers: []string{
+			string(PackageManagerNPM),
+			string(PackageManagerYarn1),
+			string(PackageManagerYarnBerry),
+			string(PackageManagerPNPM),
+			string(PackageManagerBun),
+		},
+	}
+
+	for _, fw := range Frameworks {
+		fd := app.FrameworkDescription{
+			Name:             string(fw),
+			Provider:         p.Name(),
+			DetectionVersion: frameworkDetectionVersions[fw],
+			Packages:         frameworkPackages[fw],
+			ConfigFiles:      frameworkConfigFiles[fw],
+			DefaultPort:      FrameworkInfo{Name: fw}.GetDefaultPort(),
+		}
+		for _, outputType := range frameworkOutputTypes[fw] {
+			fd.OutputTypes = append(fd.OutputTypes, string(outputType))
+			if dir := (FrameworkInfo{Name: fw, OutputType: outputType}).GetOutputDir(); dir != "" {
+				if fd.OutputDirs == nil {
+					fd.OutputDirs = make(map[string]string)
+				}
+				fd.OutputDirs[string(outputType)] = dir
+			}
+		}
+		desc.Frameworks = append(desc.Frameworks, fd)
+	}
+
+	return desc
+}
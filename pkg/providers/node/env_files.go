@@ -0,0 +1,111 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// envFiles are dotenv files that provide values, in load order
+var envFiles = []string{".env", ".env.local", ".env.production", ".env.production.local"}
+
+// envExampleFiles are dotenv templates documenting the variables an app expects
+var envExampleFiles = []string{".env.example", ".env.sample", ".env.template"}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// EnvFileInfo contains the variable names declared in dotenv files.
+// Values are never read into the plan.
+type EnvFileInfo struct {
+	// Files lists the dotenv files found (including templates)
+	Files []string
+	// Vars lists variables defined in value files (sorted)
+	Vars []string
+	// ExampleFile is the template file found, if any
+	ExampleFile string
+	// ExampleVars lists variables declared in the template (sorted)
+	ExampleVars []string
+	// Missing lists template variables that are neither in a value file nor
+	// in the current environment (sorted)
+	Missing []string
+}
+
+// DetectEnvFiles reads variable names from .env files and compares them with .env.example
+func DetectEnvFiles(ctx *app.Context) EnvFileInfo {
+	var info EnvFileInfo
+	defined := make(map[string]bool)
+
+	for _, file := range envFiles {
+		data, err := ctx.ReadFile(file)
+		if err != nil {
+			logSkipped(ctx, file, err)
+			continue
+		}
+		info.Files = append(info.Files, file)
+		for _, name := range parseEnvFileNames(data) {
+			defined[name] = true
+		}
+	}
+	for name := range defined {
+		info.Vars = append(info.Vars, name)
+	}
+	sort.Strings(info.Vars)
+
+	for _, file := range envExampleFiles {
+		data, err := ctx.ReadFile(file)
+		if err != nil {
+			logSkipped(ctx, file, err)
+			continue
+		}
+		info.Files = append(info.Files, file)
+		info.ExampleFile = file
+		info.ExampleVars = parseEnvFileNames(data)
+		sort.Strings(info.ExampleVars)
+		break
+	}
+
+	for _, name := range info.ExampleVars {
+		if defined[name] {
+			continue
+		}
+		if _, ok := ctx.LookupEnv(name); ok {
+			continue
+		}
+		info.Missing = append(info.Missing, name)
+	}
+
+	return info
+}
+
+// parseEnvFileNames returns the variable names declared in a dotenv file
+// (KEY=value and export KEY=value lines), discarding values
+func parseEnvFileNames(data []byte) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, _, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if !envNamePattern.MatchString(name) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names
+}
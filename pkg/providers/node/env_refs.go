@@ -0,0 +1,154 @@
+package node
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// PublicEnvPrefixes are prefixes of variables that frameworks inline into client bundles at build time
+var PublicEnvPrefixes = []string{
+	"NEXT_PUBLIC_",
+	"NUXT_PUBLIC_",
+	"VITE_",
+	"PUBLIC_",
+	"REACT_APP_",
+	"GATSBY_",
+	"EXPO_PUBLIC_",
+}
+
+// ignoredEnvRefs are variables set by coolpack or the framework itself
+var ignoredEnvRefs = map[string]bool{
+	"NODE_ENV": true,
+	"PORT":     true,
+	// Vite built-ins
+	"MODE":     true,
+	"DEV":      true,
+	"PROD":     true,
+	"SSR":      true,
+	"BASE_URL": true,
+}
+
+// EnvReferences contains the environment variables referenced in source code
+type EnvReferences struct {
+	// All lists every referenced variable (sorted)
+	All []string
+	// Build lists variables inlined at build time (public prefixes, sorted)
+	Build []string
+}
+
+// DetectEnvReferences scans JavaScript/TypeScript sources for process.env.X and
+// import.meta.env.X usages and returns the referenced variable names
+func DetectEnvReferences(ctx *app.Context) EnvReferences {
+	found := make(map[string]bool)
+	parser := acquireConfigParser()
+	defer parser.Release()
+
+	scanSourceFiles(ctx, []string{"."}, jsSourceExtensions, func(rel string, data []byte) bool {
+		var root *sitter.Node
+		var err error
+		switch {
+		case hasExtension(rel, []string{".ts", ".tsx", ".mts", ".cts"}):
+			root, err = parser.ParseTS(data)
+		case hasExtension(rel, []string{".js", ".jsx", ".mjs", ".cjs"}):
+			root, err = parser.ParseJS(data)
+		default:
+			// Single-file components (.vue, .svelte, .astro) aren't plain JS
+			for _, m := range envRefPattern.FindAllSubmatch(data, -1) {
+				found[string(m[1])] = true
+			}
+			return true
+		}
+		if err != nil {
+			logSkipped(ctx, rel, err)
+			return true
+		}
+
+		for _, name := range findEnvReferences(root, data) {
+			found[name] = true
+		}
+		return true
+	})
+
+	var refs EnvReferences
+	for name := range found {
+		if ignoredEnvRefs[name] {
+			continue
+		}
+		refs.All = append(refs.All, name)
+		if isPublicEnvVar(name) {
+			refs.Build = append(refs.Build, name)
+		}
+	}
+	sort.Strings(refs.All)
+	sort.Strings(refs.Build)
+
+	return refs
+}
+
+// findEnvReferences returns variable names read via process.env.X, process.env["X"],
+// import.meta.env.X or const { X } = process.env
+func findEnvReferences(root *sitter.Node, source []byte) []string {
+	var names []string
+
+	walkNodes(root, func(n *sitter.Node) bool {
+		switch n.Type() {
+		case "member_expression":
+			if isEnvObject(n.ChildByFieldName("object"), source) {
+				if property := n.ChildByFieldName("property"); property != nil {
+					names = append(names, getNodeText(property, source))
+				}
+			}
+		case "subscript_expression":
+			if isEnvObject(n.ChildByFieldName("object"), source) {
+				index := n.ChildByFieldName("index")
+				if index != nil && index.Type() == "string" {
+					names = append(names, trimQuotes(getNodeText(index, source)))
+				}
+			}
+		case "variable_declarator":
+			pattern := n.ChildByFieldName("name")
+			if pattern != nil && pattern.Type() == "object_pattern" && isEnvObject(n.ChildByFieldName("value"), source) {
+				for i := 0; i < int(pattern.NamedChildCount()); i++ {
+					child := pattern.NamedChild(i)
+					switch child.Type() {
+					case "shorthand_property_identifier_pattern":
+						names = append(names, getNodeText(child, source))
+					case "pair_pattern", "object_assignment_pattern":
+						key := child.ChildByFieldName("key")
+						if key == nil {
+							key = child.ChildByFieldName("left")
+						}
+						if key != nil {
+							names = append(names, trimQuotes(getNodeText(key, source)))
+						}
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	return names
+}
+
+// isEnvObject checks if a node is process.env or import.meta.env
+func isEnvObject(node *sitter.Node, source []byte) bool {
+	if node == nil {
+		return false
+	}
+	text := strings.Join(strings.Fields(getNodeText(node, source)), "")
+	return text == "process.env" || text == "import.meta.env"
+}
+
+// isPublicEnvVar checks if a variable uses a framework's public (client-inlined) prefix
+func isPublicEnvVar(name string) bool {
+	for _, prefix := range PublicEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
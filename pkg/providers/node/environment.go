@@ -0,0 +1,82 @@
+package node
+
+import (
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// EnvDefault is an environment variable coolpack sets for the build or the runtime stage
+type EnvDefault struct {
+	// Name and Value of the variable
+	Name  string
+	Value string
+
+	// Phase is app.PhaseBuild (ARG/ENV in the builder stage) or app.PhaseRuntime (ENV in the runtime stage)
+	Phase string
+
+	// Reason explains why the variable is set
+	Reason string
+}
+
+// frameworkTelemetry are the variables that opt a framework's CLI out of telemetry,
+// which would otherwise try to reach the network (or prompt) during the build
+var frameworkTelemetry = map[Framework]EnvDefault{
+	FrameworkNextJS:  {Name: "NEXT_TELEMETRY_DISABLED", Value: "1"},
+	FrameworkNuxt:    {Name: "NUXT_TELEMETRY_DISABLED", Value: "1"},
+	FrameworkAstro:   {Name: "ASTRO_TELEMETRY_DISABLED", Value: "1"},
+	FrameworkGatsby:  {Name: "GATSBY_TELEMETRY_DISABLED", Value: "1"},
+	FrameworkAngular: {Name: "NG_CLI_ANALYTICS", Value: "false"},
+	FrameworkExpo:    {Name: "EXPO_NO_TELEMETRY", Value: "1"},
+}
+
+// nitroPackages build their server with Nitro, whose output depends on NITRO_PRESET
+var nitroPackages = []string{"nuxt", "nuxt3", "nitropack", "nitro", "vinxi"}
+
+// DetectEnvDefaults returns the variables the generated Dockerfile sets for the
+// application: CI and telemetry opt-outs during the build, the Nitro preset for
+// Nitro servers, and NODE_ENV (plus framework settings) at runtime.
+// NODE_ENV is not set during the build, because the build-time ENV is in place
+// before the install step and package managers skip devDependencies under
+// NODE_ENV=production; build scripts such as next build set it themselves.
+func DetectEnvDefaults(pkg *PackageJSON, fw FrameworkInfo) []EnvDefault {
+	var env []EnvDefault
+
+	// Most tools switch to non-interactive output in CI. Create React App treats
+	// lint warnings as errors when CI is set, so it is left unset there.
+	if fw.Name != FrameworkCRA {
+		env = append(env, EnvDefault{Name: "CI", Value: "true", Phase: app.PhaseBuild, Reason: "non-interactive build"})
+	}
+
+	if telemetry, ok := frameworkTelemetry[fw.Name]; ok {
+		telemetry.Phase = app.PhaseBuild
+		telemetry.Reason = string(fw.Name) + " telemetry opt-out"
+		env = append(env, telemetry)
+		// next start reports telemetry too
+		if fw.Name == FrameworkNextJS && fw.OutputType == OutputTypeServer {
+			telemetry.Phase = app.PhaseRuntime
+			env = append(env, telemetry)
+		}
+	}
+
+	// Git hook installers in lifecycle scripts have no repository to hook into
+	env = append(env, gitHookEnv(pkg)...)
+
+	if fw.OutputType != OutputTypeServer {
+		return env
+	}
+
+	for _, name := range nitroPackages {
+		if pkg.HasDependency(name) {
+			env = append(env, EnvDefault{Name: "NITRO_PRESET", Value: "node-server", Phase: app.PhaseBuild, Reason: name + " builds a Nitro server; node-server is the preset for a Node.js container"})
+			break
+		}
+	}
+
+	env = append(env, EnvDefault{Name: "NODE_ENV", Value: "production", Phase: app.PhaseRuntime, Reason: "production server"})
+
+	// The Astro Node adapter listens on localhost unless HOST is set
+	if fw.Name == FrameworkAstro {
+		env = append(env, EnvDefault{Name: "HOST", Value: "0.0.0.0", Phase: app.PhaseRuntime, Reason: "@astrojs/node listens on localhost by default"})
+	}
+
+	return env
+}
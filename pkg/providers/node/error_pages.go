@@ -0,0 +1,67 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// ErrorPageSource describes a source file (or framework) that produces an error page in
+// the static output
+type ErrorPageSource struct {
+	// Framework limits the source to one framework (FrameworkNone matches any)
+	Framework Framework
+	// Status is the HTTP status the page is for
+	Status int
+	// Files are the source files producing the page; empty when the framework always emits it
+	Files []string
+}
+
+// ErrorPageSources is a list of known error page sources, in priority order
+var ErrorPageSources = []ErrorPageSource{
+	// Next.js static export always emits 404.html; pages/500 emits 500.html
+	{Framework: FrameworkNextJS, Status: 404},
+	{Framework: FrameworkNextJS, Status: 500, Files: []string{"pages/500.js", "pages/500.jsx", "pages/500.ts", "pages/500.tsx", "src/pages/500.js", "src/pages/500.jsx", "src/pages/500.ts", "src/pages/500.tsx"}},
+
+	// nuxt generate always emits 404.html
+	{Framework: FrameworkNuxt, Status: 404},
+
+	// Page routes named 404
+	{Framework: FrameworkAstro, Status: 404, Files: []string{"src/pages/404.astro", "src/pages/404.md", "src/pages/404.mdx", "src/pages/404.html"}},
+	{Framework: FrameworkGatsby, Status: 404, Files: []string{"src/pages/404.js", "src/pages/404.jsx", "src/pages/404.ts", "src/pages/404.tsx"}},
+
+	// Static files copied to the output as-is
+	{Status: 404, Files: []string{"public/404.html", "static/404.html"}},
+	{Status: 500, Files: []string{"public/500.html", "static/500.html"}},
+}
+
+// DetectErrorPages returns the custom error pages the static output will contain and
+// the source files they were found in
+func DetectErrorPages(ctx *app.Context, fw FrameworkInfo) ([]app.ErrorPage, []string) {
+	var pages []app.ErrorPage
+	var files []string
+	found := make(map[int]bool)
+
+	for _, source := range ErrorPageSources {
+		if found[source.Status] || (source.Framework != FrameworkNone && source.Framework != fw.Name) {
+			continue
+		}
+		if len(source.Files) > 0 {
+			file := ""
+			for _, f := range source.Files {
+				if ctx.HasFile(f) {
+					file = f
+					break
+				}
+			}
+			if file == "" {
+				continue
+			}
+			files = append(files, file)
+		}
+		found[source.Status] = true
+		pages = append(pages, app.ErrorPage{Status: source.Status, Document: fmt.Sprintf("/%d.html", source.Status)})
+	}
+
+	return pages, files
+}
@@ -0,0 +1,175 @@
+package node
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// frameworkPackages are the dependencies that identify a framework, in the
+// order DetectFramework checks them (see Frameworks)
+var frameworkPackages = map[Framework][]string{
+	FrameworkNextJS:     {"next"},
+	FrameworkRemix:      {"@remix-run/react", "@remix-run/node", "react-router"},
+	FrameworkNuxt:       {"nuxt", "nuxt3"},
+	FrameworkAstro:      {"astro"},
+	FrameworkSvelteKit:  {"@sveltejs/kit"},
+	FrameworkSolidStart: {"@solidjs/start", "solid-start"},
+	FrameworkTanStack:   {"@tanstack/start", "@tanstack/react-start"},
+	FrameworkExpo:       {"expo"},
+	FrameworkGatsby:     {"gatsby"},
+	FrameworkEleventy:   {"@11ty/eleventy"},
+	FrameworkAngular:    {"@angular/core"},
+	FrameworkAdonisJS:   {"@adonisjs/core"},
+	FrameworkNestJS:     {"@nestjs/core"},
+	FrameworkFastify:    {"fastify"},
+	FrameworkExpress:    {"express"},
+	FrameworkCRA:        {"react-scripts"},
+	FrameworkVite:       {"vite"},
+}
+
+// frameworkConfigFiles identify a framework without its dependency
+var frameworkConfigFiles = map[Framework][]string{
+	FrameworkAstro:   {"astro.config.mjs", "astro.config.js", "astro.config.ts"},
+	FrameworkAngular: {"angular.json"},
+	FrameworkVite:    {"vite.config.js", "vite.config.ts", "vite.config.mjs"},
+}
+
+// envDecision is a value taken from an environment variable
+func envDecision(name string) app.Decision {
+	return app.Decision{Source: app.SourceEnv, Env: name, Reason: name}
+}
+
+// packageJSONDecision is a value read from package.json; keys locate its line
+// (see app.LineOf)
+func packageJSONDecision(ctx *app.Context, reason string, keys ...string) app.Decision {
+	d := app.Decision{Source: app.SourceFile, File: "package.json", Reason: reason}
+	if data, err := ctx.ReadFile("package.json"); err == nil {
+		d.Line = app.LineOf(data, keys...)
+	}
+	return d
+}
+
+// dependencySection returns the package.json section that lists a dependency
+func dependencySection(pkg *PackageJSON, name string) string {
+	if _, ok := pkg.Dependencies[name]; ok {
+		return "dependencies"
+	}
+	return "devDependencies"
+}
+
+// explainFramework returns the decision for the detected framework
+func explainFramework(ctx *app.Context, pkg *PackageJSON, fw FrameworkInfo) app.Decision {
+	value := string(fw.Name)
+	if fw.Version != "" {
+		value += " " + fw.Version
+	}
+
+	if ctx.Env["COOLPACK_FRAMEWORK"] == string(fw.Name) {
+		d := envDecision("COOLPACK_FRAMEWORK")
+		d.Field, d.Value = "framework", value
+		return d
+	}
+	for _, dep := range frameworkPackages[fw.Name] {
+		if pkg.HasDependency(dep) {
+			section := dependencySection(pkg, dep)
+			d := packageJSONDecision(ctx, fmt.Sprintf("%s in package.json %s", dep, section), `"`+section+`"`, `"`+dep+`"`)
+			d.Field, d.Value = "framework", value
+			return d
+		}
+	}
+	for _, file := range frameworkConfigFiles[fw.Name] {
+		if ctx.HasFile(file) {
+			return app.Decision{Field: "framework", Value: value, Source: app.SourceFile, File: file, Reason: file + " found"}
+		}
+	}
+	return app.Decision{Field: "framework", Value: value, Source: app.SourceDefault, Reason: "detected from the project"}
+}
+
+// explainCommands records the decisions for the install, build and start
+// commands, following determineBuildCommand and determineStartCommand
+func explainCommands(ctx *app.Context, plan *app.Plan, pkg *PackageJSON, pm PackageManagerInfo, fw FrameworkInfo, ts TypeScriptBackend) {
+	script := func(field, name, value string) {
+		d := packageJSONDecision(ctx, fmt.Sprintf("%q script in package.json", name), `"scripts"`, `"`+name+`"`)
+		d.Field, d.Value = field, value
+		plan.Explain(d)
+	}
+	frameworkDefault := func(field, value string) {
+		plan.Explain(app.Decision{Field: field, Value: value, Source: app.SourceDefault, Reason: fmt.Sprintf("%s default", fw.Name)})
+	}
+	typeScript := func(field, value string) {
+		plan.Explain(app.Decision{Field: field, Value: value, Source: app.SourceFile, File: ts.Entry, Reason: fmt.Sprintf("TypeScript entry %s (tsconfig.json found), %s", ts.Entry, ts.Tool)})
+	}
+
+	plan.Explain(app.Decision{
+		Field:  "install_command",
+		Value:  plan.InstallCommand,
+		Source: app.SourceDefault,
+		Reason: installReason(plan, pm),
+	})
+
+	switch {
+	case plan.BuildCommand == "":
+	case pkg.HasScript("build"):
+		script("build_command", "build", plan.BuildCommand)
+	case plan.BuildCommand == ts.BuildCommand:
+		typeScript("build_command", plan.BuildCommand)
+	default:
+		frameworkDefault("build_command", plan.BuildCommand)
+	}
+
+	switch {
+	case plan.StartCommand == "":
+	case pkg.HasScript("start"):
+		script("start_command", "start", plan.StartCommand)
+	case pkg.HasScript("serve"):
+		script("start_command", "serve", plan.StartCommand)
+	case plan.StartCommand == ts.StartCommand:
+		typeScript("start_command", plan.StartCommand)
+	case fw.GetDefaultStartCommand(pm) != "":
+		frameworkDefault("start_command", plan.StartCommand)
+	default:
+		entry, field := startEntry(ctx, pkg, ts)
+		var d app.Decision
+		switch {
+		case entry == "":
+			return
+		case field == "":
+			d = app.Decision{Source: app.SourceFile, File: entry, Reason: entry + " found"}
+		default:
+			d = packageJSONDecision(ctx, field+" in package.json", `"`+field+`"`)
+		}
+		d.Field, d.Value = "start_command", plan.StartCommand
+		plan.Explain(d)
+	}
+}
+
+// installReason explains the install command
+func installReason(plan *app.Plan, pm PackageManagerInfo) string {
+	reason := fmt.Sprintf("frozen lockfile install for %s", pm.Name)
+	if plan.Metadata.RebuildCommand != "" {
+		reason += "; install scripts run after the source is copied"
+	}
+	return reason
+}
+
+// explainPort returns the decision for the primary port
+func explainPort(port PortInfo, fw FrameworkInfo) app.Decision {
+	d := app.Decision{Field: "ports", Value: strconv.Itoa(port.Port)}
+	switch {
+	case port.File != "":
+		d.Source, d.File, d.Line = app.SourceFile, port.File, port.Line
+		d.Reason = "listen() call"
+		if port.FromEnv {
+			d.Reason = "listen() call (PORT from the environment, with this default)"
+		}
+	case fw.OutputType == OutputTypeStatic:
+		d.Source, d.Reason = app.SourceDefault, "static file server port"
+	case port.FromEnv:
+		d.Source, d.Reason = app.SourceDefault, fmt.Sprintf("%s default port", fw.Name)
+	default:
+		d.Source, d.Reason = app.SourceDefault, "default server port (no listen() call found)"
+	}
+	return d
+}
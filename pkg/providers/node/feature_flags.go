@@ -0,0 +1,59 @@
+package node
+
+import "github.com/coollabsio/coolpack/pkg/app"
+
+// FeatureFlagSDK represents a feature flag/remote config SDK package
+type FeatureFlagSDK struct {
+	// Package is the npm package name
+	Package string
+	// Provider is the flag service (e.g., "launchdarkly")
+	Provider string
+	// Phase is when the SDK key is needed: runtime for server SDKs, build for
+	// client SDKs whose keys are inlined into the bundle
+	Phase string
+	// Env are the non-secret configuration variables (endpoints, client IDs)
+	Env []string
+	// Secrets are the SDK key variables
+	Secrets []string
+	// Service is the self-hostable flag server the SDK connects to, if any
+	Service string
+}
+
+// FeatureFlagSDKs is a list of known feature flag SDKs
+var FeatureFlagSDKs = []FeatureFlagSDK{
+	// LaunchDarkly (SaaS only)
+	{Package: "@launchdarkly/node-server-sdk", Provider: "launchdarkly", Phase: app.PhaseRuntime, Secrets: []string{"LAUNCHDARKLY_SDK_KEY"}},
+	{Package: "launchdarkly-node-server-sdk", Provider: "launchdarkly", Phase: app.PhaseRuntime, Secrets: []string{"LAUNCHDARKLY_SDK_KEY"}},
+	{Package: "launchdarkly-js-client-sdk", Provider: "launchdarkly", Phase: app.PhaseBuild, Env: []string{"LAUNCHDARKLY_CLIENT_SIDE_ID"}},
+	{Package: "launchdarkly-react-client-sdk", Provider: "launchdarkly", Phase: app.PhaseBuild, Env: []string{"LAUNCHDARKLY_CLIENT_SIDE_ID"}},
+
+	// Unleash (hosted or self-hosted)
+	{Package: "unleash-client", Provider: "unleash", Phase: app.PhaseRuntime, Env: []string{"UNLEASH_URL"}, Secrets: []string{"UNLEASH_API_TOKEN"}, Service: "unleash"},
+	{Package: "@unleash/proxy-client-react", Provider: "unleash", Phase: app.PhaseBuild, Env: []string{"UNLEASH_FRONTEND_URL", "UNLEASH_FRONTEND_TOKEN"}, Service: "unleash"},
+	{Package: "unleash-proxy-client", Provider: "unleash", Phase: app.PhaseBuild, Env: []string{"UNLEASH_FRONTEND_URL", "UNLEASH_FRONTEND_TOKEN"}, Service: "unleash"},
+
+	// Flagsmith (hosted or self-hosted)
+	{Package: "flagsmith-nodejs", Provider: "flagsmith", Phase: app.PhaseRuntime, Env: []string{"FLAGSMITH_API_URL"}, Secrets: []string{"FLAGSMITH_ENVIRONMENT_KEY"}, Service: "flagsmith"},
+	{Package: "flagsmith", Provider: "flagsmith", Phase: app.PhaseBuild, Env: []string{"FLAGSMITH_API_URL", "FLAGSMITH_ENVIRONMENT_ID"}, Service: "flagsmith"},
+
+	// GrowthBook (hosted or self-hosted)
+	{Package: "@growthbook/growthbook", Provider: "growthbook", Phase: app.PhaseRuntime, Env: []string{"GROWTHBOOK_API_HOST", "GROWTHBOOK_CLIENT_KEY"}, Service: "growthbook"},
+	{Package: "@growthbook/growthbook-react", Provider: "growthbook", Phase: app.PhaseBuild, Env: []string{"GROWTHBOOK_API_HOST", "GROWTHBOOK_CLIENT_KEY"}, Service: "growthbook"},
+
+	// SaaS flag services
+	{Package: "configcat-node", Provider: "configcat", Phase: app.PhaseRuntime, Secrets: []string{"CONFIGCAT_SDK_KEY"}},
+	{Package: "@splitsoftware/splitio", Provider: "split", Phase: app.PhaseRuntime, Secrets: []string{"SPLIT_SDK_KEY"}},
+}
+
+// DetectFeatureFlagSDKs checks which feature flag SDKs are used by the project
+func DetectFeatureFlagSDKs(pkg *PackageJSON) []FeatureFlagSDK {
+	var detected []FeatureFlagSDK
+
+	for _, sdk := range FeatureFlagSDKs {
+		if pkg.HasDependency(sdk.Package) {
+			detected = append(detected, sdk)
+		}
+	}
+
+	return detected
+}
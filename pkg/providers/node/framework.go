@@ -1,6 +1,9 @@
 package node
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/coollabsio/coolpack/pkg/app"
 	sitter "github.com/smacker/go-tree-sitter"
 )
@@ -27,6 +30,7 @@ const (
 	FrameworkTanStack    Framework = "tanstack-start"
 	FrameworkGatsby      Framework = "gatsby"
 	FrameworkEleventy    Framework = "eleventy"
+	FrameworkExpo        Framework = "expo"
 )
 
 // OutputType represents the type of output the framework produces
@@ -45,8 +49,95 @@ type FrameworkInfo struct {
 	OutputType OutputType
 }
 
-// DetectFramework detects the framework used by the project
+// Frameworks lists the frameworks coolpack detects, in the order they are checked
+var Frameworks = []Framework{
+	FrameworkNextJS,
+	FrameworkRemix,
+	FrameworkNuxt,
+	FrameworkAstro,
+	FrameworkSvelteKit,
+	FrameworkSolidStart,
+	FrameworkTanStack,
+	FrameworkExpo,
+	FrameworkGatsby,
+	FrameworkEleventy,
+	FrameworkAngular,
+	FrameworkAdonisJS,
+	FrameworkNestJS,
+	FrameworkFastify,
+	FrameworkExpress,
+	FrameworkCRA,
+	FrameworkVite,
+}
+
+// DetectFramework detects the framework used by the project, or returns the
+// one named by COOLPACK_FRAMEWORK ("none" for a plain Node.js application)
 func DetectFramework(ctx *app.Context, pkg *PackageJSON) FrameworkInfo {
+	if name := ctx.Env["COOLPACK_FRAMEWORK"]; name != "" && pkg != nil {
+		if info, ok := forceFramework(ctx, pkg, Framework(name)); ok {
+			return info
+		}
+	}
+	return detectFramework(ctx, pkg)
+}
+
+// forceFramework returns the given framework with the output type detection
+// would find if it were the project's only framework. It returns false for an
+// unknown framework name, which leaves detection to decide.
+func forceFramework(ctx *app.Context, pkg *PackageJSON, name Framework) (FrameworkInfo, bool) {
+	if name == "none" {
+		return FrameworkInfo{Name: FrameworkNone}, true
+	}
+	if !slices.Contains(Frameworks, name) {
+		return FrameworkInfo{}, false
+	}
+
+	// Detect on a copy of package.json without the other frameworks' packages
+	otherPackages := make(map[string]bool)
+	for fw, packages := range frameworkPackages {
+		for _, p := range packages {
+			otherPackages[p] = fw != name
+		}
+	}
+	only := *pkg
+	only.Dependencies = make(map[string]string)
+	only.DevDependencies = make(map[string]string)
+	for dep, v := range pkg.Dependencies {
+		if !otherPackages[dep] {
+			only.Dependencies[dep] = v
+		}
+	}
+	for dep, v := range pkg.DevDependencies {
+		if !otherPackages[dep] {
+			only.DevDependencies[dep] = v
+		}
+	}
+	version := ""
+	for _, p := range frameworkPackages[name] {
+		if version = pkg.GetDependencyVersion(p); version != "" {
+			break
+		}
+	}
+	if version == "" {
+		only.Dependencies[frameworkPackages[name][0]] = ""
+	}
+
+	info := detectFramework(ctx, &only)
+	if info.Name == name {
+		return info, true
+	}
+
+	// A config file of another framework matched first (e.g., vite.config.ts)
+	info = FrameworkInfo{Name: name, Version: cleanVersion(version), OutputType: OutputTypeServer}
+	switch name {
+	case FrameworkAstro, FrameworkExpo, FrameworkGatsby, FrameworkEleventy, FrameworkAngular, FrameworkCRA, FrameworkVite:
+		info.OutputType = OutputTypeStatic
+	}
+	return info, true
+}
+
+// detectFramework detects the framework from package.json and config files
+func detectFramework(ctx *app.Context, pkg *PackageJSON) FrameworkInfo {
 	info := FrameworkInfo{
 		Name:       FrameworkNone,
 		Version:    "",
@@ -155,6 +246,17 @@ func DetectFramework(ctx *app.Context, pkg *PackageJSON) FrameworkInfo {
 		return info
 	}
 
+	// Expo can only be containerized when it targets the web platform with
+	// static output: "server" output needs an API routes server of its own
+	if pkg.HasDependency("expo") {
+		info.Name = FrameworkExpo
+		info.Version = cleanVersion(pkg.GetDependencyVersion("expo"))
+		if web := detectExpoWebConfig(ctx, pkg); web.HasWeb && web.Output != "server" {
+			info.OutputType = OutputTypeStatic
+		}
+		return info
+	}
+
 	if pkg.HasDependency("gatsby") {
 		info.Name = FrameworkGatsby
 		info.Version = cleanVersion(pkg.GetDependencyVersion("gatsby"))
@@ -254,216 +356,117 @@ func isSPAProject(pkg *PackageJSON) bool {
 // isNextJSStaticExport checks if Next.js is configured for static export
 // by looking for output: 'export' in next.config.* files using tree-sitter
 func isNextJSStaticExport(ctx *app.Context) bool {
-	parser := NewConfigParser()
-
-	// Check TypeScript config first
-	if ctx.HasFile("next.config.ts") {
-		data, err := ctx.ReadFile("next.config.ts")
-		if err == nil {
-			root, err := parser.ParseTS(data)
-			if err == nil {
-				value := FindPropertyValue(root, data, "output")
-				if value == "export" {
-					return true
-				}
-			}
-		}
-	}
-
-	// Check JavaScript configs
-	jsConfigs := []string{"next.config.mjs", "next.config.js"}
-	for _, configFile := range jsConfigs {
-		if ctx.HasFile(configFile) {
-			data, err := ctx.ReadFile(configFile)
-			if err != nil {
-				continue
-			}
-			root, err := parser.ParseJS(data)
-			if err != nil {
-				continue
-			}
-			value := FindPropertyValue(root, data, "output")
-			if value == "export" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return configSetting(ctx, []string{"next.config.ts", "next.config.mjs", "next.config.js"}, "output") == "export"
 }
 
 // isAstroSSRMode checks if Astro is configured for SSR mode
 // by looking for output: 'server' or 'hybrid' in astro.config.* files
 func isAstroSSRMode(ctx *app.Context) bool {
-	parser := NewConfigParser()
-
-	configFiles := []string{"astro.config.ts", "astro.config.mjs", "astro.config.js"}
-	for _, configFile := range configFiles {
-		if ctx.HasFile(configFile) {
-			data, err := ctx.ReadFile(configFile)
-			if err != nil {
-				continue
-			}
-
-			var root *sitter.Node
-			if configFile == "astro.config.ts" {
-				root, err = parser.ParseTS(data)
-			} else {
-				root, err = parser.ParseJS(data)
-			}
-			if err != nil {
-				continue
-			}
-
-			value := FindPropertyValue(root, data, "output")
-			if value == "server" || value == "hybrid" {
-				return true
-			}
-		}
-	}
-
-	return false
+	value := configSetting(ctx, []string{"astro.config.ts", "astro.config.mjs", "astro.config.js"}, "output")
+	return value == "server" || value == "hybrid"
 }
 
 // isNuxtSPAMode checks if Nuxt is configured for SPA mode
 // by looking for ssr: false in nuxt.config.* files
 func isNuxtSPAMode(ctx *app.Context) bool {
-	parser := NewConfigParser()
-
-	configFiles := []string{"nuxt.config.ts", "nuxt.config.js", "nuxt.config.mjs"}
-	for _, configFile := range configFiles {
-		if ctx.HasFile(configFile) {
-			data, err := ctx.ReadFile(configFile)
-			if err != nil {
-				continue
-			}
-
-			var root *sitter.Node
-			if configFile == "nuxt.config.ts" {
-				root, err = parser.ParseTS(data)
-			} else {
-				root, err = parser.ParseJS(data)
-			}
-			if err != nil {
-				continue
-			}
-
-			value := FindPropertyValue(root, data, "ssr")
-			if value == "false" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return configSetting(ctx, []string{"nuxt.config.ts", "nuxt.config.js", "nuxt.config.mjs"}, "ssr") == "false"
 }
 
 // isReactRouterSPAMode checks if React Router/Remix is configured for SPA mode
 // by looking for ssr: false in react-router.config.* files
 func isReactRouterSPAMode(ctx *app.Context) bool {
-	parser := NewConfigParser()
-
-	// Check TypeScript config first
-	if ctx.HasFile("react-router.config.ts") {
-		data, err := ctx.ReadFile("react-router.config.ts")
-		if err == nil {
-			root, err := parser.ParseTS(data)
-			if err == nil {
-				value := FindPropertyValue(root, data, "ssr")
-				if value == "false" {
-					return true
-				}
-			}
-		}
-	}
-
-	// Check JavaScript config
-	if ctx.HasFile("react-router.config.js") {
-		data, err := ctx.ReadFile("react-router.config.js")
-		if err == nil {
-			root, err := parser.ParseJS(data)
-			if err == nil {
-				value := FindPropertyValue(root, data, "ssr")
-				if value == "false" {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
+	return configSetting(ctx, []string{"react-router.config.ts", "react-router.config.js"}, "ssr") == "false"
 }
 
 // isSolidStartSPAMode checks if Solid Start is configured for SPA mode
 // by looking for ssr: false in app.config.* files
 func isSolidStartSPAMode(ctx *app.Context) bool {
-	parser := NewConfigParser()
-
-	// Check TypeScript config first
-	if ctx.HasFile("app.config.ts") {
-		data, err := ctx.ReadFile("app.config.ts")
-		if err == nil {
-			root, err := parser.ParseTS(data)
-			if err == nil {
-				value := FindPropertyValue(root, data, "ssr")
-				if value == "false" {
-					return true
-				}
-			}
-		}
-	}
-
-	// Check JavaScript config
-	if ctx.HasFile("app.config.js") {
-		data, err := ctx.ReadFile("app.config.js")
-		if err == nil {
-			root, err := parser.ParseJS(data)
-			if err == nil {
-				value := FindPropertyValue(root, data, "ssr")
-				if value == "false" {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
+	return configSetting(ctx, []string{"app.config.ts", "app.config.js"}, "ssr") == "false"
 }
 
 // isTanStackStartStaticMode checks if TanStack Start is configured for static mode
 // by looking for server.preset: 'static' in app.config.* files
 func isTanStackStartStaticMode(ctx *app.Context) bool {
-	parser := NewConfigParser()
-
-	// Check TypeScript config first
-	if ctx.HasFile("app.config.ts") {
-		data, err := ctx.ReadFile("app.config.ts")
-		if err == nil {
-			root, err := parser.ParseTS(data)
-			if err == nil {
-				value := FindNestedPropertyValue(root, data, "server", "preset")
-				if value == "static" {
-					return true
-				}
+	return configSetting(ctx, []string{"app.config.ts", "app.config.js"}, "server", "preset") == "static"
+}
+
+// expoWebConfig holds the web-related settings of an Expo app config
+type expoWebConfig struct {
+	// HasWeb is true when the web platform is enabled
+	HasWeb bool
+	// Output is the web.output setting: "single" (default), "static" or "server"
+	Output string
+}
+
+// detectExpoWebConfig determines whether an Expo app targets the web platform
+// by reading expo.platforms and expo.web from app.json or app.config.*
+// When platforms is not set, Expo enables web if react-native-web is installed
+func detectExpoWebConfig(ctx *app.Context, pkg *PackageJSON) expoWebConfig {
+	var cfg expoWebConfig
+	var platforms []string
+	platformsSet := false
+	hasWebKey := false
+
+	if ctx.HasFile("app.json") {
+		var appJSON struct {
+			Expo *struct {
+				Platforms []string `json:"platforms"`
+				Web       *struct {
+					Output string `json:"output"`
+				} `json:"web"`
+			} `json:"expo"`
+		}
+		// Expo reads app.json as JSON5, so comments and trailing commas are allowed
+		if err := ctx.ReadJSONC("app.json", &appJSON); err != nil {
+			logSkipped(ctx, "app.json", err)
+		} else if appJSON.Expo != nil {
+			if appJSON.Expo.Platforms != nil {
+				platformsSet = true
+				platforms = appJSON.Expo.Platforms
+			}
+			if appJSON.Expo.Web != nil {
+				hasWebKey = true
+				cfg.Output = appJSON.Expo.Web.Output
 			}
 		}
 	}
 
-	// Check JavaScript config
-	if ctx.HasFile("app.config.js") {
-		data, err := ctx.ReadFile("app.config.js")
-		if err == nil {
-			root, err := parser.ParseJS(data)
-			if err == nil {
-				value := FindNestedPropertyValue(root, data, "server", "preset")
-				if value == "static" {
-					return true
-				}
+	// Dynamic configs take precedence over app.json
+	configHasValue(ctx, []string{"app.config.ts", "app.config.js"}, func(root *sitter.Node, data []byte) bool {
+		// The config is the expo object itself or wraps it ({ expo: {...} })
+		expoValue := func(path ...string) *sitter.Node {
+			if value := findConfigValue(root, data, path...); value != nil {
+				return value
+			}
+			return findConfigValue(root, data, append([]string{"expo"}, path...)...)
+		}
+		if value := expoValue("platforms"); value != nil {
+			platformsSet = true
+			platforms = nil
+			if text := configValueText(root, value, data); strings.Contains(text, "'web'") || strings.Contains(text, "\"web\"") {
+				platforms = []string{"web"}
+			}
+		}
+		if expoValue("web") != nil {
+			hasWebKey = true
+			if output := expoValue("web", "output"); output != nil {
+				cfg.Output = configValueText(root, output, data)
+			}
+		}
+		return true
+	})
+
+	if platformsSet {
+		for _, p := range platforms {
+			if p == "web" {
+				cfg.HasWeb = true
 			}
 		}
+	} else {
+		cfg.HasWeb = hasWebKey || pkg.HasDependency("react-native-web")
 	}
 
-	return false
+	return cfg
 }
 
 // cleanVersion removes common prefixes from version strings
@@ -498,11 +501,76 @@ func (f FrameworkInfo) GetDefaultBuildCommand(pm PackageManagerInfo) string {
 		return run + " build"
 	case FrameworkGatsby:
 		return run + " build"
+	case FrameworkExpo:
+		// Native-only Expo apps have nothing to build for a container
+		if f.OutputType == OutputTypeStatic {
+			return pm.GetExecCommand() + " expo export --platform web"
+		}
+		return ""
 	default:
 		return ""
 	}
 }
 
+// GetOutputDir returns the directory the framework writes its build output to
+// For static output this is the directory to serve, for server output the directory to ship
+func (f FrameworkInfo) GetOutputDir() string {
+	static := f.OutputType == OutputTypeStatic
+
+	switch f.Name {
+	case FrameworkNextJS:
+		if static {
+			return "out"
+		}
+		return ".next"
+	case FrameworkNuxt, FrameworkSolidStart, FrameworkTanStack:
+		if static {
+			return ".output/public"
+		}
+		return ".output"
+	case FrameworkRemix, FrameworkReactRouter:
+		if static {
+			return "build/client"
+		}
+		return "build"
+	case FrameworkAstro, FrameworkVite, FrameworkAngular:
+		return "dist"
+	case FrameworkExpo:
+		// Only static web exports are deployed; server output is refused
+		if static {
+			return "dist"
+		}
+		return ""
+	case FrameworkSvelteKit, FrameworkCRA:
+		return "build"
+	case FrameworkGatsby:
+		return "public"
+	case FrameworkEleventy:
+		return "_site"
+	default:
+		// Backend frameworks run from their sources, so the whole app is shipped
+		return ""
+	}
+}
+
+// GetDefaultPort returns the port the framework's server listens on by default
+// Returns 0 when the port is chosen by application code
+func (f FrameworkInfo) GetDefaultPort() int {
+	switch f.Name {
+	case FrameworkNextJS, FrameworkNuxt, FrameworkRemix, FrameworkReactRouter,
+		FrameworkSvelteKit, FrameworkSolidStart, FrameworkTanStack, FrameworkNestJS:
+		return 3000
+	case FrameworkAstro:
+		return 4321
+	case FrameworkAngular:
+		return 4000
+	case FrameworkAdonisJS:
+		return 3333
+	default:
+		return 0
+	}
+}
+
 // GetDefaultStartCommand returns the default start command for a framework
 func (f FrameworkInfo) GetDefaultStartCommand(pm PackageManagerInfo) string {
 	run := pm.GetRunCommand()
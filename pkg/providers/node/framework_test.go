@@ -0,0 +1,74 @@
+package node
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// TestExpoWebOutput checks Expo's web.output: "single" and "static" export a
+// static site to dist, while "server" (dist/client plus dist/server) is not
+// planned as static but refused with a warning.
+func TestExpoWebOutput(t *testing.T) {
+	tests := []struct {
+		output     string
+		wantType   OutputType
+		wantDir    string
+		wantWarned bool
+	}{
+		{"", OutputTypeStatic, "dist", false},
+		{"single", OutputTypeStatic, "dist", false},
+		{"static", OutputTypeStatic, "dist", false},
+		{"server", OutputTypeNone, "", true},
+	}
+	for _, tt := range tests {
+		t.Run("output="+tt.output, func(t *testing.T) {
+			web := `{}`
+			if tt.output != "" {
+				web = `{"output": "` + tt.output + `"}`
+			}
+			dir := t.TempDir()
+			files := map[string]string{
+				"package.json": `{"name": "app", "dependencies": {"expo": "~52.0.0", "react-native-web": "~0.19.13"}}`,
+				"app.json":     `{"expo": {"name": "app", "platforms": ["ios", "android", "web"], "web": ` + web + `}}`,
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctx := app.NewContext(dir)
+			pkg, err := ParsePackageJSON([]byte(files["package.json"]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fw := detectFramework(ctx, pkg)
+			if fw.Name != FrameworkExpo || fw.OutputType != tt.wantType {
+				t.Fatalf("detectFramework = %s/%s, want %s/%s", fw.Name, fw.OutputType, FrameworkExpo, tt.wantType)
+			}
+
+			plan, err := New().Plan(context.Background(), ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outDir := ""
+			if plan.Output != nil {
+				outDir = plan.Output.Dir
+			}
+			if outDir != tt.wantDir {
+				t.Errorf("output dir = %q, want %q", outDir, tt.wantDir)
+			}
+			warned := false
+			for _, w := range plan.Warnings {
+				warned = warned || w.Code == "expo_server_output"
+			}
+			if warned != tt.wantWarned {
+				t.Errorf("expo_server_output warning = %v, want %v", warned, tt.wantWarned)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package node
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// DefaultBuildHeapSize is the V8 heap limit in MB given to heavy builds
+const DefaultBuildHeapSize = 4096
+
+// heavyBuildFrameworks are frameworks whose builds often exhaust the default
+// V8 heap, with the project size from which the limit is raised: source
+// files or dependencies (dependencies and devDependencies)
+var heavyBuildFrameworks = map[Framework]struct {
+	files int
+	deps  int
+}{
+	FrameworkAngular: {files: 300, deps: 60},
+	FrameworkNextJS:  {files: 800, deps: 120},
+	FrameworkNuxt:    {files: 800, deps: 120},
+	FrameworkGatsby:  {files: 500, deps: 100},
+}
+
+// HeapInfo is the V8 heap limit for the build
+type HeapInfo struct {
+	// SizeMB is the limit passed as --max-old-space-size (0 for none)
+	SizeMB int
+	// Reason explains the limit
+	Reason string
+	// FromEnv is true when COOLPACK_BUILD_HEAP_SIZE set it
+	FromEnv bool
+}
+
+// NodeOptions returns the NODE_OPTIONS value that sets the limit
+func (h HeapInfo) NodeOptions() string {
+	return fmt.Sprintf("--max-old-space-size=%d", h.SizeMB)
+}
+
+// DetectBuildHeapSize raises the V8 heap limit for builds of large projects
+// in frameworks known to run out of memory (Angular, Next.js, Nuxt, Gatsby).
+// COOLPACK_BUILD_HEAP_SIZE sets the limit in MB for any build, or turns it
+// off with 0. A build script that sets the limit itself is left alone.
+func DetectBuildHeapSize(ctx *app.Context, pkg *PackageJSON, fw FrameworkInfo) HeapInfo {
+	if env := strings.TrimSpace(ctx.Env["COOLPACK_BUILD_HEAP_SIZE"]); env != "" {
+		size, err := strconv.Atoi(env)
+		if err != nil || size < 0 {
+			ctx.Log().Warn("ignoring COOLPACK_BUILD_HEAP_SIZE: not a size in MB", "value", env)
+		} else {
+			return HeapInfo{SizeMB: size, FromEnv: true}
+		}
+	}
+
+	threshold, ok := heavyBuildFrameworks[fw.Name]
+	if !ok || strings.Contains(pkg.GetScript("build"), "max-old-space-size") {
+		return HeapInfo{}
+	}
+
+	deps := len(pkg.Dependencies) + len(pkg.DevDependencies)
+	if deps >= threshold.deps {
+		return HeapInfo{
+			SizeMB: DefaultBuildHeapSize,
+			Reason: fmt.Sprintf("%s build with %d dependencies may exceed the default V8 heap", fw.Name, deps),
+		}
+	}
+
+	files := 0
+	scanFiles(ctx, []string{"."}, func(name string) bool {
+		return hasExtension(name, jsSourceExtensions)
+	}, func(string, []byte) bool {
+		files++
+		return files < threshold.files
+	})
+	if files >= threshold.files {
+		return HeapInfo{
+			SizeMB: DefaultBuildHeapSize,
+			Reason: fmt.Sprintf("%s build with %d or more source files may exceed the default V8 heap", fw.Name, threshold.files),
+		}
+	}
+	return HeapInfo{}
+}
@@ -0,0 +1,161 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// I18nLoader represents an i18n package that reads locale files from disk at runtime
+type I18nLoader struct {
+	// Package is the npm package name
+	Package string
+	// DefaultDirs are the locale directories used when none is configured
+	DefaultDirs []string
+	// ConfigFiles are config files the package requires at runtime
+	ConfigFiles []string
+}
+
+// I18nLoaders is a list of known runtime locale loaders. Libraries that compile
+// messages into the bundle (react-intl, lingui, paraglide) don't need locale files
+// at runtime and aren't listed.
+var I18nLoaders = []I18nLoader{
+	{Package: "next-i18next", DefaultDirs: []string{"public/locales"}, ConfigFiles: []string{"next-i18next.config.js", "next-i18next.config.cjs", "next-i18next.config.mjs"}},
+	{Package: "i18next-fs-backend", DefaultDirs: []string{"locales"}},
+	{Package: "i18next-node-fs-backend", DefaultDirs: []string{"locales"}},
+	{Package: "i18n", DefaultDirs: []string{"locales"}},
+}
+
+// localePathPattern matches configured locale paths such as loadPath: './locales/{{lng}}/{{ns}}.json',
+// localePath: path.resolve('./public/locales') or directory: __dirname + '/locales'
+var localePathPattern = regexp.MustCompile(`\b(?:loadPath|localePath|directory)\s*:\s*(?:path\.(?:join|resolve)\(\s*(?:(?:__dirname|process\.cwd\(\))\s*,\s*)?|(?:__dirname|process\.cwd\(\))\s*\+\s*)?['"]([^'"]+)['"]`)
+
+// nestI18nDir is where nestjs-i18n projects keep translations; nest build only copies
+// them to dist/ when they are listed in nest-cli.json assets
+const nestI18nDir = "src/i18n"
+
+// nestCLIConfig holds the asset settings of nest-cli.json, which the Nest CLI
+// reads as JSONC
+type nestCLIConfig struct {
+	CompilerOptions nestCompilerOptions `json:"compilerOptions"`
+	// Projects are the apps and libraries of a monorepo
+	Projects map[string]struct {
+		CompilerOptions nestCompilerOptions `json:"compilerOptions"`
+	} `json:"projects"`
+}
+
+type nestCompilerOptions struct {
+	// Assets are globs ("i18n/**/*") or objects ({ "include": "i18n/**/*" })
+	Assets []json.RawMessage `json:"assets"`
+}
+
+// nestCopiesI18n checks if nest-cli.json lists the translations as assets.
+// A file that can't be parsed is assumed to, rather than warning about it.
+func nestCopiesI18n(ctx *app.Context) bool {
+	var config nestCLIConfig
+	if err := ctx.ReadJSONC("nest-cli.json", &config); err != nil {
+		logSkipped(ctx, "nest-cli.json", err)
+		return !errors.Is(err, fs.ErrNotExist)
+	}
+	assets := config.CompilerOptions.Assets
+	for _, project := range config.Projects {
+		assets = append(assets, project.CompilerOptions.Assets...)
+	}
+	for _, asset := range assets {
+		if strings.Contains(string(asset), "i18n") {
+			return true
+		}
+	}
+	return false
+}
+
+// I18nInfo contains the runtime locale data requirements of the application
+type I18nInfo struct {
+	// Packages are the runtime locale loaders found
+	Packages []string
+	// Dirs are the locale directories that must be present at runtime, relative to the app root
+	Dirs []string
+	// ConfigFiles are the loader config files that must be present at runtime
+	ConfigFiles []string
+	// DevOnly lists loaders declared in devDependencies, which are missing once dev
+	// dependencies are pruned
+	DevOnly []string
+	// NestAssetsMissing is true when nestjs-i18n translations aren't copied to dist/
+	NestAssetsMissing bool
+}
+
+// DetectI18n checks which i18n packages load locale files at runtime and where the
+// locale files live
+func DetectI18n(ctx *app.Context, pkg *PackageJSON) I18nInfo {
+	var info I18nInfo
+	var defaults []string
+
+	for _, loader := range I18nLoaders {
+		if !pkg.HasDependency(loader.Package) {
+			continue
+		}
+		info.Packages = append(info.Packages, loader.Package)
+		if _, ok := pkg.Dependencies[loader.Package]; !ok {
+			info.DevOnly = append(info.DevOnly, loader.Package)
+		}
+		defaults = append(defaults, loader.DefaultDirs...)
+		for _, file := range loader.ConfigFiles {
+			if ctx.HasFile(file) {
+				info.ConfigFiles = appendUnique(info.ConfigFiles, file)
+			}
+		}
+	}
+	if pkg.HasDependency("nestjs-i18n") && ctx.HasFile(nestI18nDir) {
+		info.NestAssetsMissing = !nestCopiesI18n(ctx)
+	}
+	if len(info.Packages) == 0 {
+		return info
+	}
+
+	// Configured paths take precedence over the loaders' defaults
+	scanSourceFiles(ctx, []string{"."}, jsSourceExtensions, func(rel string, data []byte) bool {
+		for _, m := range localePathPattern.FindAllStringSubmatch(string(data), -1) {
+			if dir := localeDir(m[1]); dir != "" && ctx.HasFile(dir) {
+				info.Dirs = appendUnique(info.Dirs, dir)
+			}
+		}
+		return true
+	})
+	if len(info.Dirs) == 0 {
+		for _, dir := range defaults {
+			if ctx.HasFile(dir) {
+				info.Dirs = appendUnique(info.Dirs, dir)
+				break
+			}
+		}
+	}
+
+	return info
+}
+
+// localeDir returns the directory part of a locale path, cutting it at the first
+// templated segment ({{lng}}) or file name. Leading ../ segments are dropped, since
+// __dirname-relative paths usually climb out of src/ or dist/ to the app root.
+func localeDir(p string) string {
+	p = path.Clean(strings.TrimPrefix(p, "/"))
+	for strings.HasPrefix(p, "../") {
+		p = strings.TrimPrefix(p, "../")
+	}
+	var dirs []string
+	for _, segment := range strings.Split(p, "/") {
+		if strings.Contains(segment, "{{") || path.Ext(segment) != "" {
+			break
+		}
+		dirs = append(dirs, segment)
+	}
+	dir := strings.Join(dirs, "/")
+	if dir == "" || dir == "." || dir == ".." {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,164 @@
+package node
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// installLifecycleScripts are the root package.json scripts an install runs,
+// in the order it runs them
+var installLifecycleScripts = []string{"preinstall", "install", "postinstall", "prepare"}
+
+// gitHookTools install git hooks from a lifecycle script. The image has no
+// .git directory, so they are turned off with their environment variable.
+var gitHookTools = []struct {
+	tool  string
+	env   string
+	value string
+}{
+	{"husky", "HUSKY", "0"},
+	{"lefthook", "LEFTHOOK", "0"},
+	{"simple-git-hooks", "SKIP_INSTALL_SIMPLE_GIT_HOOKS", "1"},
+}
+
+// lifecycleTools are tools recorded when a lifecycle script runs them
+var lifecycleTools = []string{
+	"husky", "lefthook", "simple-git-hooks",
+	"patch-package", "node-gyp", "prisma", "nuxt", "nuxi", "svelte-kit", "ngcc", "electron-builder", "playwright",
+}
+
+// harmlessCommands don't need the source, so scripts made of them and git
+// hook tools can run in the install step
+var harmlessCommands = []string{"is-ci", "echo", "true", "exit", "cd"}
+
+// scriptCommandSeparator splits a script into its commands
+var scriptCommandSeparator = regexp.MustCompile(`\s*(?:&&|\|\||;|\|)\s*`)
+
+// LifecycleInfo describes the root lifecycle scripts the install runs
+type LifecycleInfo struct {
+	// Scripts are the lifecycle scripts by name (e.g., "postinstall": "patch-package")
+	Scripts map[string]string
+	// Tools are the known tools the scripts run (e.g., "husky", "patch-package")
+	Tools []string
+	// Deferred lists the scripts that need the source, which isn't copied yet
+	// when dependencies are installed
+	Deferred []string
+	// IgnoreScripts is appended to the install command when scripts are deferred
+	IgnoreScripts string
+	// RebuildCommand runs the install scripts of dependencies and the deferred
+	// scripts once the source is copied
+	RebuildCommand string
+}
+
+// DetectLifecycleScripts detects the root preinstall, install, postinstall
+// and prepare scripts. The install step only has package.json and the
+// lockfile, so scripts that need more (patch-package, node-gyp rebuild,
+// prisma generate) fail there: the install then runs with --ignore-scripts,
+// and a rebuild step after the source is copied runs the install scripts of
+// dependencies and the project's own. Scripts that only install git hooks
+// stay in the install step, turned off by DetectEnvDefaults. Bun only runs
+// install scripts of trusted dependencies and isn't deferred.
+func DetectLifecycleScripts(pkg *PackageJSON, pm PackageManagerInfo) LifecycleInfo {
+	info := LifecycleInfo{}
+	for _, name := range installLifecycleScripts {
+		script := pkg.GetScript(name)
+		if strings.TrimSpace(script) == "" {
+			continue
+		}
+		if info.Scripts == nil {
+			info.Scripts = make(map[string]string)
+		}
+		info.Scripts[name] = script
+		for _, tool := range lifecycleTools {
+			if commandRunsBinary(script, tool) {
+				info.Tools = appendUnique(info.Tools, tool)
+			}
+		}
+		if needsSource(script) {
+			info.Deferred = append(info.Deferred, name)
+		}
+	}
+	if len(info.Deferred) == 0 || pm.Name == PackageManagerBun {
+		return info
+	}
+
+	run := "npm run"
+	switch pm.Name {
+	case PackageManagerPNPM:
+		info.IgnoreScripts = "--ignore-scripts"
+		info.RebuildCommand = "pnpm rebuild"
+		run = "pnpm run"
+	case PackageManagerYarnBerry:
+		info.IgnoreScripts = "--mode=skip-build"
+		info.RebuildCommand = "yarn rebuild"
+		run = "yarn run"
+	case PackageManagerYarn1:
+		// yarn v1 has no rebuild; npm rebuilds the installed node_modules
+		info.IgnoreScripts = "--ignore-scripts"
+		info.RebuildCommand = "npm rebuild"
+		run = "yarn run"
+	default:
+		info.IgnoreScripts = "--ignore-scripts"
+		info.RebuildCommand = "npm rebuild"
+	}
+	for _, name := range info.Deferred {
+		info.RebuildCommand += " && " + run + " " + name
+	}
+	return info
+}
+
+// needsSource checks if a lifecycle script does more than install git hooks
+func needsSource(script string) bool {
+	for _, command := range scriptCommandSeparator.Split(strings.TrimSpace(script), -1) {
+		if command == "" || isHarmlessCommand(command) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// isHarmlessCommand checks if a command only installs git hooks or does nothing
+func isHarmlessCommand(command string) bool {
+	for _, hook := range gitHookTools {
+		if commandRunsBinary(command, hook.tool) {
+			return true
+		}
+	}
+	fields := strings.Fields(command)
+	// Skip leading environment assignments (e.g., "HUSKY=0 husky")
+	for len(fields) > 0 && strings.Contains(fields[0], "=") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return true
+	}
+	for _, harmless := range harmlessCommands {
+		if fields[0] == harmless {
+			return true
+		}
+	}
+	return false
+}
+
+// gitHookEnv returns the variables that turn off git hook tools run by
+// lifecycle scripts during the install
+func gitHookEnv(pkg *PackageJSON) []EnvDefault {
+	var env []EnvDefault
+	for _, hook := range gitHookTools {
+		for _, name := range installLifecycleScripts {
+			if commandRunsBinary(pkg.GetScript(name), hook.tool) {
+				env = append(env, EnvDefault{
+					Name:   hook.env,
+					Value:  hook.value,
+					Phase:  app.PhaseBuild,
+					Reason: "the " + name + " script runs " + hook.tool + ", which needs the .git directory the image doesn't have",
+				})
+				break
+			}
+		}
+	}
+	return env
+}
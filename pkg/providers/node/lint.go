@@ -0,0 +1,341 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// Lint finding codes
+const (
+	LintInvalidPackageJSON = "invalid_package_json"
+	LintMissingLockfile    = "missing_lockfile"
+	LintMissingStart       = "missing_start_script"
+	LintDevCommand         = "dev_command"
+	LintHardcodedPort      = "hardcoded_port"
+	LintLocalhostURL       = "localhost_url"
+	LintCommittedSecret    = "committed_secret"
+)
+
+// lockfiles are the lockfiles each package manager installs from
+var lockfiles = map[PackageManager][]string{
+	PackageManagerNPM:       {"package-lock.json", "npm-shrinkwrap.json"},
+	PackageManagerPNPM:      {"pnpm-lock.yaml"},
+	PackageManagerYarn1:     {"yarn.lock"},
+	PackageManagerYarnBerry: {"yarn.lock"},
+	PackageManagerBun:       {"bun.lock", "bun.lockb"},
+}
+
+// devCommands are development servers and watchers, keyed by executable. The
+// value lists the subcommands or flags that make it a dev command; an empty
+// list matches the executable alone, and "" matches it without a subcommand.
+var devCommands = map[string][]string{
+	"next":               {"dev"},
+	"nuxt":               {"dev"},
+	"nuxi":               {"dev"},
+	"astro":              {"dev"},
+	"remix":              {"dev"},
+	"react-router":       {"dev"},
+	"svelte-kit":         {"dev"},
+	"gatsby":             {"develop"},
+	"ng":                 {"serve"},
+	"vite":               {"", "dev", "serve"},
+	"parcel":             {"", "serve", "watch"},
+	"webpack":            {"serve", "--watch", "-w"},
+	"webpack-dev-server": {},
+	"react-scripts":      {"start"},
+	"vue-cli-service":    {"serve"},
+	"nodemon":            {},
+	"ts-node-dev":        {},
+	"tsx":                {"watch"},
+	"node":               {"--watch"},
+	"nest":               {"--watch", "-w"},
+	"expo":               {"start"},
+}
+
+// commandRunners prefix the executable in scripts (e.g., npx next dev)
+var commandRunners = map[string]bool{
+	"npx": true, "bunx": true, "pnpx": true, "exec": true, "cross-env": true, "dotenv": true, "--": true,
+}
+
+// scriptSeparator splits a script into its commands
+var scriptSeparator = regexp.MustCompile(`&&|\|\||;|\|`)
+
+// localhostURLPattern matches URLs pointing at the local machine
+var localhostURLPattern = regexp.MustCompile(`\b(?:https?|wss?|postgres(?:ql)?|mysql|mongodb(?:\+srv)?|redis|amqp)://(?:[^/\s"'@]*@)?(?:localhost|127\.0\.0\.1|0\.0\.0\.0)\b(?::\d+)?`)
+
+// envReadPattern matches reads of the environment, which make a localhost URL a fallback
+var envReadPattern = regexp.MustCompile(`process\.env|import\.meta\.env|Deno\.env|Bun\.env`)
+
+// lintSkippedFile matches test, story and tooling files, where localhost URLs are expected
+var lintSkippedFile = regexp.MustCompile(`(?:\.(?:test|spec|stories|e2e)\.[a-z]+$|(?:^|/)(?:__tests__|__mocks__|tests?|e2e|cypress|playwright|scripts)/|(?:^|/)(?:vite|vitest|playwright|cypress|jest|webpack|nuxt|astro|svelte)\.config\.)`)
+
+// Lint checks the application for deployability issues without planning it
+func (p *Provider) Lint(ctx *app.Context) []app.Finding {
+	pkg := &PackageJSON{}
+	if err := ctx.ReadJSON("package.json", pkg); err != nil {
+		finding := app.Finding{Code: LintInvalidPackageJSON, Severity: app.SeverityError, File: "package.json", Message: err.Error()}
+		var fileErr *app.FileError
+		if errors.As(err, &fileErr) {
+			finding.Line = fileErr.Line
+			finding.Message = fileErr.Err.Error()
+		}
+		return []app.Finding{finding}
+	}
+
+	pm := DetectPackageManager(ctx, pkg)
+	fw := DetectFramework(ctx, pkg)
+
+	var findings []app.Finding
+	findings = append(findings, lintLockfile(ctx, pm)...)
+	findings = append(findings, lintStart(ctx, pkg, pm, fw)...)
+	findings = append(findings, lintDevCommands(pkg)...)
+	findings = append(findings, lintPort(ctx, pkg, fw)...)
+	findings = append(findings, lintLocalhostURLs(ctx)...)
+	findings = append(findings, lintSecrets(ctx)...)
+	return findings
+}
+
+// lintLockfile reports a missing lockfile: the install commands are frozen
+// installs, or plain ones that don't lock versions when only lockfiles of
+// other package managers are present
+func lintLockfile(ctx *app.Context, pm PackageManagerInfo) []app.Finding {
+	if ctx.Env["COOLPACK_INSTALL_CMD"] != "" {
+		return nil
+	}
+	for _, file := range lockfiles[pm.Name] {
+		if ctx.HasFile(file) {
+			return nil
+		}
+	}
+	message := fmt.Sprintf("No %s found; the install runs `%s`, which requires a lockfile", pm.GetLockFile(), pm.GetInstallCommand())
+	if command := installCommand(ctx, pm); command != pm.GetInstallCommand() {
+		message = fmt.Sprintf("No %s found; the install runs `%s`, which doesn't lock dependency versions", pm.GetLockFile(), command)
+	}
+	return []app.Finding{{
+		Code:       LintMissingLockfile,
+		Severity:   app.SeverityError,
+		Message:    message,
+		Suggestion: fmt.Sprintf("Run `%s install` and commit %s", strings.Fields(pm.GetRunCommand())[0], pm.GetLockFile()),
+	}}
+}
+
+// lintStart reports server apps without a way to start them
+func lintStart(ctx *app.Context, pkg *PackageJSON, pm PackageManagerInfo, fw FrameworkInfo) []app.Finding {
+	if fw.OutputType == OutputTypeStatic || ctx.Env["COOLPACK_START_CMD"] != "" {
+		return nil
+	}
+	ts, _ := DetectTypeScriptBackend(ctx, pkg, pm, fw)
+	if determineStartCommand(ctx, pkg, pm, fw, ts) != "" {
+		return nil
+	}
+
+	message := "No start script, and no start command can be derived"
+	if fw.Name != FrameworkNone {
+		message = fmt.Sprintf("No start script for the %s server, and no start command can be derived", fw.Name)
+	}
+	return []app.Finding{{
+		Code:       LintMissingStart,
+		Severity:   app.SeverityError,
+		Message:    message,
+		File:       "package.json",
+		Suggestion: `Add a "start" script to package.json (e.g., "node server.js") or set "main" to an existing file`,
+	}}
+}
+
+// lintDevCommands reports start and build scripts that run development servers
+func lintDevCommands(pkg *PackageJSON) []app.Finding {
+	// serve is only used when there is no start script
+	scripts := []string{"start", "build"}
+	if !pkg.HasScript("start") {
+		scripts[0] = "serve"
+	}
+
+	var findings []app.Finding
+	for _, script := range scripts {
+		if dev := findDevCommand(pkg.GetScript(script)); dev != "" {
+			findings = append(findings, app.Finding{
+				Code:       LintDevCommand,
+				Severity:   app.SeverityError,
+				Message:    fmt.Sprintf("The %q script runs `%s`, a development server or watcher", script, dev),
+				File:       "package.json",
+				Suggestion: "Run the production build and server in deployed scripts; keep dev servers in a \"dev\" script",
+			})
+		}
+	}
+	return findings
+}
+
+// findDevCommand returns the development command a script runs, or ""
+func findDevCommand(script string) string {
+	for _, segment := range scriptSeparator.Split(script, -1) {
+		fields := strings.Fields(segment)
+
+		// Skip environment assignments and runners (NODE_ENV=x npx next dev)
+		for len(fields) > 0 && (strings.Contains(fields[0], "=") || commandRunners[fields[0]]) {
+			fields = fields[1:]
+		}
+		if len(fields) > 1 && (fields[0] == "yarn" || fields[0] == "pnpm" || fields[0] == "bun") && fields[1] == "exec" {
+			fields = fields[2:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		matches, ok := devCommands[path.Base(fields[0])]
+		if !ok {
+			continue
+		}
+		if len(matches) == 0 {
+			return strings.Join(fields, " ")
+		}
+		for _, m := range matches {
+			if m == "" && (len(fields) == 1 || strings.HasPrefix(fields[1], "-")) {
+				return strings.Join(fields, " ")
+			}
+			for _, arg := range fields[1:] {
+				if m != "" && arg == m {
+					return strings.Join(fields, " ")
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// lintPort reports servers listening on a fixed port instead of PORT
+func lintPort(ctx *app.Context, pkg *PackageJSON, fw FrameworkInfo) []app.Finding {
+	if fw.OutputType == OutputTypeStatic {
+		return nil
+	}
+	info := DetectPort(ctx, pkg, fw)
+	if info.File == "" || info.FromEnv {
+		return nil
+	}
+	return []app.Finding{{
+		Code:       LintHardcodedPort,
+		Severity:   app.SeverityWarning,
+		Message:    fmt.Sprintf("The server listens on port %d instead of reading PORT", info.Port),
+		File:       info.File,
+		Suggestion: fmt.Sprintf("Listen on process.env.PORT (e.g., `process.env.PORT || %d`)", info.Port),
+	}}
+}
+
+// lintLocalhostURLs reports source files that connect to the local machine,
+// which is the container itself in production. URLs used as a fallback for an
+// environment variable on the same line are allowed.
+func lintLocalhostURLs(ctx *app.Context) []app.Finding {
+	var findings []app.Finding
+	scanSourceFiles(ctx, []string{"."}, jsSourceExtensions, func(rel string, data []byte) bool {
+		if lintSkippedFile.MatchString(rel) {
+			return true
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			url := localhostURLPattern.FindString(line)
+			if url == "" || envReadPattern.MatchString(line) || isCommentLine(line) {
+				continue
+			}
+			findings = append(findings, app.Finding{
+				Code:       LintLocalhostURL,
+				Severity:   app.SeverityWarning,
+				Message:    fmt.Sprintf("%s points at the container itself in production", url),
+				File:       rel,
+				Line:       i + 1,
+				Suggestion: "Read the URL from an environment variable",
+			})
+			// One finding per file is enough to point at the problem
+			break
+		}
+		return true
+	})
+	return findings
+}
+
+// isCommentLine checks if a source line is a comment
+func isCommentLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "/*")
+}
+
+// lintSecrets reports secret files that aren't excluded by .gitignore, so they
+// are (or will be) committed to the repository
+func lintSecrets(ctx *app.Context) []app.Finding {
+	ignore := readGitignore(ctx)
+
+	var findings []app.Finding
+	for _, secret := range DetectSecretFiles(ctx) {
+		if ignore.Ignored(secret.File) {
+			continue
+		}
+		findings = append(findings, app.Finding{
+			Code:       LintCommittedSecret,
+			Severity:   app.SeverityError,
+			Message:    fmt.Sprintf("%s (%s) is not in .gitignore", secret.File, secret.Description),
+			File:       secret.File,
+			Suggestion: "Remove it from the repository, add it to .gitignore and set the values in the deployment environment",
+		})
+	}
+	return findings
+}
+
+// gitignore holds the patterns of the root .gitignore
+type gitignore []string
+
+// readGitignore reads the root .gitignore; a missing file ignores nothing
+func readGitignore(ctx *app.Context) gitignore {
+	data, err := ctx.ReadFile(".gitignore")
+	if err != nil {
+		return nil
+	}
+	var patterns gitignore
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns
+}
+
+// Ignored checks if a slash-separated path relative to the root is ignored.
+// Later patterns override earlier ones, and "!" negates a pattern.
+func (g gitignore) Ignored(rel string) bool {
+	ignored := false
+	for _, pattern := range g {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if gitignoreMatch(pattern, rel) {
+			ignored = !negate
+		}
+	}
+	return ignored
+}
+
+// gitignoreMatch matches a single .gitignore pattern against a path or its parent directories
+func gitignoreMatch(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(pattern, "**/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		// Directory patterns only match parents, not the file itself
+		if dirOnly && i == len(parts)-1 {
+			break
+		}
+		candidate := parts[i]
+		if anchored {
+			candidate = strings.Join(parts[:i+1], "/")
+		}
+		if ok, _ := path.Match(pattern, candidate); ok {
+			return true
+		}
+	}
+	return false
+}
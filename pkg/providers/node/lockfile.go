@@ -0,0 +1,285 @@
+package node
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/coollabsio/coolpack/pkg/semver"
+)
+
+// lockfileOwners maps lockfiles to the package manager that writes them
+var lockfileOwners = []struct {
+	file string
+	pm   string
+}{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"bun.lockb", "bun"},
+	{"bun.lock", "bun"},
+	{"yarn.lock", "yarn"},
+	{"package-lock.json", "npm"},
+	{"npm-shrinkwrap.json", "npm"},
+}
+
+// lockfileFormat is the format a lockfile declares and the versions of its
+// package manager that write it
+type lockfileFormat struct {
+	// File is the lockfile
+	File string
+	// Version is the declared format (e.g., "9.0" for pnpm-lock.yaml, "3" for
+	// package-lock.json, "8" for a Yarn 2+ yarn.lock, "v1" for Yarn 1)
+	Version string
+	// Writers is the range of package manager versions that write the format
+	// (e.g., ">=9"), empty when unknown
+	Writers string
+}
+
+var (
+	pnpmLockfileVersion = regexp.MustCompile(`(?m)^lockfileVersion:\s*['"]?(\d+)(?:\.(\d+))?`)
+	npmLockfileVersion  = regexp.MustCompile(`"lockfileVersion"\s*:\s*(\d+)`)
+	yarnMetadataVersion = regexp.MustCompile(`(?m)^__metadata:\s*\n\s+version:\s*(\d+)`)
+	yarnClassicHeader   = regexp.MustCompile(`(?m)^# yarn lockfile v1`)
+)
+
+// pnpmLockfileWriters maps the lockfileVersion of pnpm-lock.yaml to the pnpm
+// majors that write it
+var pnpmLockfileWriters = map[string]string{
+	"9":   ">=9",
+	"6":   "8",
+	"5.4": "7",
+	"5.3": "6",
+}
+
+// npmLockfileWriters maps the lockfileVersion of package-lock.json to the npm
+// versions that write it (npm 7+ writes 2 or 3, 3 by default since npm 9)
+var npmLockfileWriters = map[string]string{
+	"1": "<7",
+	"2": ">=7",
+	"3": ">=7",
+}
+
+// npmVersions are npm releases to test ranges against (npm is bundled with
+// Node.js, so the package manager index doesn't list it)
+var npmVersions = []string{"11.6.0", "10.9.2", "9.9.4", "8.19.4", "7.24.2", "6.14.18", "5.10.0"}
+
+// readLockfileFormat reads the format a lockfile declares from its header
+func readLockfileFormat(ctx *app.Context, file string) (lockfileFormat, bool) {
+	content, err := ctx.ReadFile(file)
+	if err != nil {
+		return lockfileFormat{}, false
+	}
+	// The format is declared at the top; lockfiles can be large
+	if len(content) > 4096 {
+		content = content[:4096]
+	}
+
+	format := lockfileFormat{File: file}
+	switch file {
+	case "pnpm-lock.yaml":
+		m := pnpmLockfileVersion.FindSubmatch(content)
+		if m == nil {
+			return format, false
+		}
+		format.Version = string(m[1])
+		if m[2] != nil {
+			format.Version += "." + string(m[2])
+		}
+		format.Writers = pnpmLockfileWriters[string(m[1])]
+		if format.Writers == "" && m[2] != nil {
+			format.Writers = pnpmLockfileWriters[format.Version]
+		}
+	case "package-lock.json", "npm-shrinkwrap.json":
+		m := npmLockfileVersion.FindSubmatch(content)
+		if m == nil {
+			return format, false
+		}
+		format.Version = string(m[1])
+		format.Writers = npmLockfileWriters[format.Version]
+	case "yarn.lock":
+		if m := yarnMetadataVersion.FindSubmatch(content); m != nil {
+			format.Version = string(m[1])
+			v, _ := strconv.Atoi(format.Version)
+			format.Writers = yarnLockfileWriters(v)
+		} else if yarnClassicHeader.Match(content) {
+			format.Version, format.Writers = "v1", "1"
+		} else {
+			return format, false
+		}
+	default:
+		return format, false
+	}
+	return format, true
+}
+
+// yarnLockfileWriters maps the __metadata version of a Yarn 2+ yarn.lock to
+// the yarn major that writes it
+func yarnLockfileWriters(version int) string {
+	switch {
+	case version >= 8:
+		return "4"
+	case version >= 5:
+		return "3"
+	case version >= 4:
+		return "2"
+	}
+	return ">=2"
+}
+
+// packageManagerVersions returns the versions of a package manager to test
+// ranges against
+func packageManagerVersions(pm string) []string {
+	if pm == "npm" {
+		return npmVersions
+	}
+	return data.Load().PackageManagers[pm]
+}
+
+// rangesOverlap checks if any known version of a package manager is in both
+// ranges. Ranges that don't parse overlap with everything.
+func rangesOverlap(pm, a, b string) bool {
+	for _, v := range packageManagerVersions(pm) {
+		okA, validA := semver.Satisfies(v, a)
+		okB, validB := semver.Satisfies(v, b)
+		if (okA || !validA) && (okB || !validB) {
+			return true
+		}
+	}
+	return false
+}
+
+// pmFamily returns the package manager's name as lockfiles and engines know it
+func pmFamily(name PackageManager) string {
+	if name == PackageManagerYarn1 || name == PackageManagerYarnBerry {
+		return "yarn"
+	}
+	return string(name)
+}
+
+// findLockfiles returns the lockfiles present of the package manager and of
+// other package managers
+func findLockfiles(ctx *app.Context, pm PackageManagerInfo) (own, other []string) {
+	family := pmFamily(pm.Name)
+	for _, lock := range lockfileOwners {
+		if !ctx.HasFile(lock.file) {
+			continue
+		}
+		if lock.pm == family {
+			own = append(own, lock.file)
+		} else {
+			other = append(other, lock.file)
+		}
+	}
+	return own, other
+}
+
+// installCommand returns the package manager's frozen install, unless only
+// lockfiles of other package managers are present: the frozen install would
+// fail without its own lockfile, so those projects get a plain install
+func installCommand(ctx *app.Context, pm PackageManagerInfo) string {
+	if own, other := findLockfiles(ctx, pm); len(own) == 0 && len(other) > 0 {
+		return pm.GetUnfrozenInstallCommand()
+	}
+	return pm.GetInstallCommand()
+}
+
+// checkLockfiles warns when the lockfiles disagree with the detected package
+// manager: lockfiles of several package managers, a lockfile of another one
+// than packageManager names, or a lockfile format that the pinned version or
+// the engines range can't have written. The message says what is used.
+func checkLockfiles(ctx *app.Context, plan *app.Plan, pkg *PackageJSON, pm PackageManagerInfo) {
+	family := pmFamily(pm.Name)
+
+	own, other := findLockfiles(ctx, pm)
+	switch {
+	case len(other) > 0 && len(own) > 0:
+		plan.AddWarning("lockfile_conflict",
+			fmt.Sprintf("lockfiles of several package managers found (%s); %s is used with %s, so %s %s ignored and can be deleted",
+				strings.Join(append(append([]string{}, own...), other...), ", "), pm.Name, own[0], strings.Join(other, ", "), isOrAre(len(other))),
+			other[0])
+	case len(other) > 0:
+		present := "the only lockfile is " + other[0]
+		if len(other) > 1 {
+			present = "the lockfiles present are " + strings.Join(other, ", ")
+		}
+		plan.AddWarning("lockfile_conflict",
+			fmt.Sprintf("%s is used (%s) but %s, which it doesn't read, so dependencies are installed without a lockfile (`%s`) and their versions aren't locked; commit %s",
+				pm.Name, packageManagerSource(pkg), present, installCommand(ctx, pm), pm.GetLockFile()),
+			other[0])
+	}
+	if len(own) == 0 {
+		return
+	}
+
+	format, ok := readLockfileFormat(ctx, own[0])
+	if !ok || format.Writers == "" {
+		return
+	}
+	mismatch := func(what string) {
+		plan.AddWarning("lockfile_version_mismatch",
+			fmt.Sprintf("%s has format %s, written by %s %s, but %s; %s",
+				format.File, format.Version, family, format.Writers, what, lockfileUsed(pm, family)),
+			format.File)
+	}
+	switch {
+	case pm.Name == PackageManagerYarn1 && format.Writers != "1":
+		mismatch("yarn 1 is detected (no .yarnrc.yml or packageManager)")
+	case pm.Name == PackageManagerYarnBerry && format.Writers == "1":
+		mismatch("Yarn 2+ is detected")
+	case exactVersion.MatchString(pm.Version):
+		if ok, valid := semver.Satisfies(pm.Version, format.Writers); valid && !ok {
+			mismatch(fmt.Sprintf("%s %s is pinned", family, pm.Version))
+		}
+	}
+	if engine := engineRange(pkg, family); engine != "" && !rangesOverlap(family, engine, format.Writers) {
+		mismatch(fmt.Sprintf("engines.%s is %q", family, engine))
+	}
+}
+
+// engineRange returns the engines range of a package manager in package.json
+func engineRange(pkg *PackageJSON, family string) string {
+	switch family {
+	case "npm":
+		return pkg.Engines.NPM
+	case "pnpm":
+		return pkg.Engines.PNPM
+	case "yarn":
+		return pkg.Engines.Yarn
+	case "bun":
+		return pkg.Engines.Bun
+	}
+	return ""
+}
+
+// packageManagerSource names what selected the package manager when it
+// wasn't a lockfile
+func packageManagerSource(pkg *PackageJSON) string {
+	switch {
+	case pkg.PackageManager != "":
+		return fmt.Sprintf("packageManager %q in package.json", pkg.PackageManager)
+	case len(pkg.DevEngines.PackageManager) > 0:
+		return "devEngines.packageManager in package.json"
+	}
+	return "engines in package.json"
+}
+
+// lockfileUsed says which package manager version installs from the lockfile
+func lockfileUsed(pm PackageManagerInfo, family string) string {
+	switch {
+	case pm.Version != "":
+		return fmt.Sprintf("%s %s is used and may reject or rewrite the lockfile", family, pm.Version)
+	case family == "npm":
+		return "the npm bundled with the Node.js image is used and may reject or rewrite the lockfile"
+	}
+	return fmt.Sprintf("the %s the image provides is used and may reject or rewrite the lockfile", family)
+}
+
+// isOrAre returns the verb for a count
+func isOrAre(n int) string {
+	if n == 1 {
+		return "is"
+	}
+	return "are"
+}
@@ -0,0 +1,91 @@
+package node
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// FuzzReadLockfileFormat reads the format of arbitrary pnpm-lock.yaml,
+// package-lock.json and yarn.lock files; it must not panic, and a format it
+// recognizes must have a version
+func FuzzReadLockfileFormat(f *testing.F) {
+	seeds := []struct {
+		file, content string
+	}{
+		{"pnpm-lock.yaml", "lockfileVersion: '9.0'\n\nimporters:\n"},
+		{"pnpm-lock.yaml", "lockfileVersion: 5.4\n"},
+		{"pnpm-lock.yaml", "lockfileVersion: \"6.0\"\r\n"},
+		{"package-lock.json", `{"name": "app", "lockfileVersion": 3, "requires": true}`},
+		{"npm-shrinkwrap.json", `{"lockfileVersion": 1}`},
+		{"yarn.lock", "# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.\n# yarn lockfile v1\n"},
+		{"yarn.lock", "__metadata:\n  version: 8\n  cacheKey: 10c0\n"},
+		{"yarn.lock", "__metadata:\n  version: 99999999999999999999\n"},
+		{"bun.lock", `{"lockfileVersion": 1}`},
+	}
+	for _, seed := range seeds {
+		f.Add(seed.file, []byte(seed.content))
+	}
+
+	dir := f.TempDir()
+	f.Fuzz(func(t *testing.T, file string, content []byte) {
+		switch file {
+		case "pnpm-lock.yaml", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock":
+		default:
+			return
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		format, ok := readLockfileFormat(app.NewContext(dir), file)
+		if ok && format.Version == "" {
+			t.Errorf("%s %q read as a format without a version", file, content)
+		}
+	})
+}
+
+// TestForeignLockfiles checks a package manager whose lockfile is missing,
+// while other package managers' lockfiles are present, installs without
+// freezing its lockfile and names the lockfiles it found
+func TestForeignLockfiles(t *testing.T) {
+	tests := []struct {
+		lockfiles   []string
+		wantMessage string
+	}{
+		{[]string{"yarn.lock"}, "but the only lockfile is yarn.lock, which"},
+		{[]string{"yarn.lock", "package-lock.json"}, "but the lockfiles present are yarn.lock, package-lock.json, which"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		files := map[string]string{"package.json": `{"name": "app", "packageManager": "pnpm@9.15.0", "scripts": {"start": "node index.js"}}`}
+		for _, lock := range tt.lockfiles {
+			files[lock] = "{}"
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		plan, err := New().Plan(context.Background(), app.NewContext(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "pnpm install --no-frozen-lockfile"; plan.InstallCommand != want {
+			t.Errorf("%v: install command = %q, want %q", tt.lockfiles, plan.InstallCommand, want)
+		}
+		var message string
+		for _, w := range plan.Warnings {
+			if w.Code == "lockfile_conflict" {
+				message = w.Message
+			}
+		}
+		if !strings.Contains(message, tt.wantMessage) {
+			t.Errorf("%v: lockfile_conflict warning = %q, want it to contain %q", tt.lockfiles, message, tt.wantMessage)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package node
+
+import "sort"
+
+// MailSender represents an email or webhook delivery package
+type MailSender struct {
+	// Package is the npm package name
+	Package string
+	// Provider is the delivery provider or protocol (e.g., "smtp", "resend")
+	Provider string
+	// Env are the non-secret configuration variables the sender needs
+	Env []string
+	// Secrets are the credential variables the sender needs
+	Secrets []string
+	// Ports are the outbound TCP ports the sender connects to
+	Ports []int
+}
+
+// smtpPorts are the submission (587), implicit TLS (465) and relay (25) ports
+var smtpPorts = []int{587, 465, 25}
+
+// httpsPorts is used by providers with an HTTP API
+var httpsPorts = []int{443}
+
+// MailSenders is a list of known email/webhook delivery packages
+var MailSenders = []MailSender{
+	// SMTP clients
+	{Package: "nodemailer", Provider: "smtp", Env: []string{"SMTP_HOST", "SMTP_PORT", "SMTP_USER"}, Secrets: []string{"SMTP_PASSWORD"}, Ports: smtpPorts},
+	{Package: "emailjs", Provider: "smtp", Env: []string{"SMTP_HOST", "SMTP_PORT", "SMTP_USER"}, Secrets: []string{"SMTP_PASSWORD"}, Ports: smtpPorts},
+
+	// Email APIs
+	{Package: "resend", Provider: "resend", Secrets: []string{"RESEND_API_KEY"}, Ports: httpsPorts},
+	{Package: "postmark", Provider: "postmark", Secrets: []string{"POSTMARK_API_TOKEN"}, Ports: httpsPorts},
+	{Package: "@sendgrid/mail", Provider: "sendgrid", Secrets: []string{"SENDGRID_API_KEY"}, Ports: httpsPorts},
+	{Package: "mailgun.js", Provider: "mailgun", Env: []string{"MAILGUN_DOMAIN"}, Secrets: []string{"MAILGUN_API_KEY"}, Ports: httpsPorts},
+	{Package: "@aws-sdk/client-ses", Provider: "ses", Env: []string{"AWS_REGION"}, Secrets: []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}, Ports: httpsPorts},
+	{Package: "@aws-sdk/client-sesv2", Provider: "ses", Env: []string{"AWS_REGION"}, Secrets: []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}, Ports: httpsPorts},
+
+	// Webhook delivery
+	{Package: "svix", Provider: "svix", Secrets: []string{"SVIX_AUTH_TOKEN"}, Ports: httpsPorts},
+}
+
+// DetectMailSenders checks which email/webhook delivery packages are used by the project
+func DetectMailSenders(pkg *PackageJSON) []MailSender {
+	var detected []MailSender
+
+	for _, sender := range MailSenders {
+		if pkg.HasDependency(sender.Package) {
+			detected = append(detected, sender)
+		}
+	}
+
+	return detected
+}
+
+// GetOutboundPorts returns the sorted, deduplicated outbound ports of the senders
+func GetOutboundPorts(senders []MailSender) []int {
+	seen := make(map[int]bool)
+	var ports []int
+
+	for _, sender := range senders {
+		for _, port := range sender.Ports {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Ints(ports)
+
+	return ports
+}
+
+// GetOutboundNotes returns firewall notes for the outbound connections of the senders
+func GetOutboundNotes(senders []MailSender) []string {
+	for _, sender := range senders {
+		if sender.Provider == "smtp" {
+			return []string{
+				"Outbound SMTP must be allowed on port 587 (STARTTLS) or 465 (TLS)",
+				"Many cloud providers block outbound port 25; use a submission port or an email API instead",
+			}
+		}
+	}
+	return []string{"Email/webhook delivery uses HTTPS (port 443); no extra outbound ports are required"}
+}
@@ -1,5 +1,7 @@
 package node
 
+import "github.com/coollabsio/coolpack/pkg/data"
+
 // NativeDependency represents a Node.js package that requires native system dependencies
 type NativeDependency struct {
 	// Package is the npm package name
@@ -8,116 +10,27 @@ type NativeDependency struct {
 	AptPackages []string
 	// Description explains why these packages are needed
 	Description string
+	// Runtime is true when AptPackages are also needed in the runtime image
+	Runtime bool
 }
 
-// NativeDependencies is a list of known packages requiring native dependencies
-var NativeDependencies = []NativeDependency{
-	{
-		Package:     "sharp",
-		AptPackages: []string{"libvips-dev"},
-		Description: "Image processing library",
-	},
-	{
-		Package:     "@prisma/client",
-		AptPackages: []string{"openssl"},
-		Description: "Database ORM",
-	},
-	{
-		Package:     "prisma",
-		AptPackages: []string{"openssl"},
-		Description: "Database ORM CLI",
-	},
-	{
-		Package:     "puppeteer",
-		AptPackages: []string{
-			"chromium",
-			"libnss3",
-			"libatk1.0-0",
-			"libatk-bridge2.0-0",
-			"libcups2",
-			"libdrm2",
-			"libxkbcommon0",
-			"libxcomposite1",
-			"libxdamage1",
-			"libxfixes3",
-			"libxrandr2",
-			"libgbm1",
-			"libasound2",
-			"libpango-1.0-0",
-			"libcairo2",
-		},
-		Description: "Headless Chrome automation",
-	},
-	{
-		Package:     "playwright",
-		AptPackages: []string{
-			"libnss3",
-			"libatk1.0-0",
-			"libatk-bridge2.0-0",
-			"libcups2",
-			"libdrm2",
-			"libxkbcommon0",
-			"libxcomposite1",
-			"libxdamage1",
-			"libxfixes3",
-			"libxrandr2",
-			"libgbm1",
-			"libasound2",
-			"libpango-1.0-0",
-			"libcairo2",
-		},
-		Description: "Browser automation",
-	},
-	{
-		Package:     "canvas",
-		AptPackages: []string{"libcairo2-dev", "libjpeg-dev", "libpango1.0-dev", "libgif-dev", "librsvg2-dev"},
-		Description: "Canvas rendering",
-	},
-	{
-		Package:     "bcrypt",
-		AptPackages: []string{"build-essential", "python3"},
-		Description: "Password hashing",
-	},
-	{
-		Package:     "argon2",
-		AptPackages: []string{"build-essential"},
-		Description: "Password hashing",
-	},
-	{
-		Package:     "sqlite3",
-		AptPackages: []string{"build-essential", "python3"},
-		Description: "SQLite database",
-	},
-	{
-		Package:     "better-sqlite3",
-		AptPackages: []string{"build-essential", "python3"},
-		Description: "SQLite database",
-	},
-	{
-		Package:     "node-gyp",
-		AptPackages: []string{"build-essential", "python3"},
-		Description: "Native addon build tool",
-	},
-	{
-		Package:     "cpu-features",
-		AptPackages: []string{"build-essential"},
-		Description: "CPU feature detection",
-	},
-	{
-		Package:     "ssh2",
-		AptPackages: []string{"build-essential"},
-		Description: "SSH client",
-	},
-	{
-		Package:     "libsql",
-		AptPackages: []string{"build-essential"},
-		Description: "LibSQL database",
-	},
-	{
-		Package:     "@libsql/client",
-		AptPackages: []string{"build-essential"},
-		Description: "LibSQL client",
-	},
+// NativeDependencies is a list of known packages requiring native dependencies,
+// loaded from the version database (pkg/data/assets/native-deps.json)
+var NativeDependencies = loadNativeDependencies()
+
+// loadNativeDependencies converts the native dependency data to NativeDependency values
+func loadNativeDependencies() []NativeDependency {
+	entries := data.Load().NativeDependencies
+	deps := make([]NativeDependency, 0, len(entries))
+	for _, e := range entries {
+		deps = append(deps, NativeDependency{
+			Package:     e.Package,
+			AptPackages: e.AptPackages,
+			Description: e.Description,
+			Runtime:     e.Runtime,
+		})
+	}
+	return deps
 }
 
 // DetectNativeDependencies checks which native dependencies are used by the project
@@ -149,3 +62,14 @@ func GetRequiredAptPackages(deps []NativeDependency) []string {
 
 	return packages
 }
+
+// GetRuntimeAptPackages returns a deduplicated list of APT packages needed in the runtime image
+func GetRuntimeAptPackages(deps []NativeDependency) []string {
+	var runtime []NativeDependency
+	for _, dep := range deps {
+		if dep.Runtime {
+			runtime = append(runtime, dep)
+		}
+	}
+	return GetRequiredAptPackages(runtime)
+}
@@ -1,7 +1,10 @@
 package node
 
 import (
+	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/coollabsio/coolpack/pkg/app"
 )
@@ -19,29 +22,26 @@ func (p *Provider) Name() string {
 	return "node"
 }
 
-// Detect checks if the application is a Node.js project
-func (p *Provider) Detect(ctx *app.Context) (bool, error) {
-	return ctx.HasFile("package.json"), nil
+// Detect checks if the application is a Node.js project.
+// A symlinked package.json is detected even if the link is broken, so Plan can report why.
+func (p *Provider) Detect(c context.Context, ctx *app.Context) (bool, error) {
+	return ctx.HasFile("package.json") || ctx.IsLink("package.json"), nil
 }
 
 // Plan generates a build plan for the Node.js application
-func (p *Provider) Plan(ctx *app.Context) (*app.Plan, error) {
-	// Read and parse package.json
-	pkgData, err := ctx.ReadFile("package.json")
-	if err != nil {
-		return nil, fmt.Errorf("failed to read package.json: %w", err)
-	}
-
-	pkg, err := ParsePackageJSON(pkgData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+func (p *Provider) Plan(c context.Context, ctx *app.Context) (*app.Plan, error) {
+	// Read and parse package.json; the errors name the file (and the line of
+	// a syntax error)
+	pkg := &PackageJSON{}
+	if err := ctx.ReadJSON("package.json", pkg); err != nil {
+		return nil, err
 	}
 
 	// Detect package manager
-	pmInfo := DetectPackageManager(ctx, pkg)
+	pmInfo, pmDecision := detectPackageManager(ctx, pkg)
 
 	// Detect Node.js version
-	nodeVersion := DetectNodeVersion(ctx, pkg)
+	nodeVersion, versionDecision := detectNodeVersion(ctx, pkg)
 
 	// Detect framework
 	fwInfo := DetectFramework(ctx, pkg)
@@ -57,6 +57,8 @@ func (p *Provider) Plan(ctx *app.Context) (*app.Plan, error) {
 		} else {
 			languageVersion = "latest"
 		}
+		versionDecision = pmDecision
+		versionDecision.Field, versionDecision.Value = "language_version", languageVersion
 	}
 
 	plan := &app.Plan{
@@ -66,112 +68,799 @@ func (p *Provider) Plan(ctx *app.Context) (*app.Plan, error) {
 		PackageManager:        string(pmInfo.Name),
 		PackageManagerVersion: pmInfo.Version,
 		DetectedFiles:         []string{"package.json"},
-		Metadata:              make(map[string]interface{}),
 	}
+	plan.Explain(app.Decision{Field: "provider", Value: "node", Source: app.SourceFile, File: "package.json", Reason: "package.json found"})
+	if pmInfo.Name == PackageManagerBun {
+		plan.Explain(app.Decision{Field: "language", Value: language, Source: pmDecision.Source, File: pmDecision.File, Line: pmDecision.Line, Reason: "bun package manager"})
+	} else {
+		plan.Explain(app.Decision{Field: "language", Value: language, Source: app.SourceFile, File: "package.json", Reason: "package.json found"})
+	}
+	plan.Explain(versionDecision)
+	plan.Explain(pmDecision)
 
 	// Add runtime info for bun
 	if pmInfo.Name == PackageManagerBun {
-		plan.Metadata["runtime"] = "bun"
-		plan.Metadata["runtime_note"] = "Using Bun runtime (oven/bun image)"
+		plan.Metadata.Runtime = "bun"
+		plan.Metadata.RuntimeNote = "Using Bun runtime (oven/bun image)"
 	}
 
 	// Add framework info
 	if fwInfo.Name != FrameworkNone {
 		plan.Framework = string(fwInfo.Name)
 		plan.FrameworkVersion = fwInfo.Version
-		if fwInfo.OutputType != OutputTypeNone {
-			plan.Metadata["output_type"] = string(fwInfo.OutputType)
+		plan.Explain(explainFramework(ctx, pkg, fwInfo))
+		if fwInfo.OutputType != OutputTypeNone || fwInfo.GetOutputDir() != "" {
+			plan.Output = &app.OutputInfo{
+				Type: string(fwInfo.OutputType),
+				Dir:  fwInfo.GetOutputDir(),
+			}
+			if plan.Output.Type != "" {
+				plan.Explain(app.Decision{Field: "output.type", Value: plan.Output.Type, Source: app.SourceDefault, Reason: fmt.Sprintf("%s builds %s output with this configuration", fwInfo.Name, plan.Output.Type)})
+			}
+			if plan.Output.Dir != "" {
+				plan.Explain(app.Decision{Field: "output.dir", Value: plan.Output.Dir, Source: app.SourceDefault, Reason: fmt.Sprintf("%s default output directory", fwInfo.Name)})
+			}
+		}
+	}
+
+	// Vite projects can move the output and serve it under a base path
+	if fwInfo.Name == FrameworkVite {
+		if vite := DetectViteConfig(ctx); vite != nil {
+			if vite.OutDir != "" {
+				plan.Output.Dir = vite.OutDir
+				plan.Explain(app.Decision{Field: "output.dir", Value: vite.OutDir, Source: app.SourceFile, File: vite.File, Reason: "build.outDir in " + vite.File})
+			}
+			if vite.Base != "" {
+				plan.Output.BasePath = vite.Base
+				plan.Explain(app.Decision{Field: "output.base_path", Value: vite.Base, Source: app.SourceFile, File: vite.File, Reason: "base in " + vite.File})
+			}
+		}
+	}
+
+	// Expo apps without the web platform are native-only
+	if fwInfo.Name == FrameworkExpo {
+		configFile := "package.json"
+		for _, f := range []string{"app.config.ts", "app.config.js", "app.json"} {
+			if ctx.HasFile(f) {
+				plan.DetectedFiles = append(plan.DetectedFiles, f)
+				if configFile == "package.json" {
+					configFile = f
+				}
+			}
 		}
+		if fwInfo.OutputType == OutputTypeNone {
+			if web := detectExpoWebConfig(ctx, pkg); web.HasWeb && web.Output == "server" {
+				// expo export writes dist/client and dist/server, which only
+				// an adapter server (e.g., @expo/server with Express) can serve
+				msg := "Expo web.output is \"server\"; its API routes and server rendering need a server built with an adapter such as @expo/server, which coolpack does not generate. Use web.output \"static\" or \"single\" to deploy it as a static site."
+				plan.Metadata.SetNotDeployable(msg)
+				plan.AddWarning("expo_server_output", msg, configFile)
+			} else {
+				msg := "Expo project does not target the web platform; native iOS/Android apps cannot be containerized. Add \"web\" to expo.platforms and install react-native-web to enable web export."
+				plan.Metadata.SetNotDeployable(msg)
+				plan.AddWarning("expo_native_only", msg, configFile)
+			}
+		}
+	}
+
+	// Electron desktop apps can't be served from a container
+	if isElectronApp(pkg, fwInfo) {
+		msg := "Electron desktop application detected; it is not a deployable web app and cannot be served from a container. Package it with electron-builder or electron-forge instead."
+		plan.Metadata.SetNotDeployable(msg)
+		plan.AddWarning("electron_app", msg, "package.json")
 	}
 
 	// Determine install command
-	plan.InstallCommand = pmInfo.GetInstallCommand()
+	plan.InstallCommand = installCommand(ctx, pmInfo)
+
+	// Lifecycle scripts that need the source run after it is copied
+	lifecycle := DetectLifecycleScripts(pkg, pmInfo)
+	plan.Metadata.LifecycleScripts = lifecycle.Scripts
+	plan.Metadata.LifecycleTools = lifecycle.Tools
+	if lifecycle.RebuildCommand != "" {
+		plan.InstallCommand += " " + lifecycle.IgnoreScripts
+		plan.Metadata.RebuildCommand = lifecycle.RebuildCommand
+		d := packageJSONDecision(ctx, fmt.Sprintf("%s script in package.json needs the source, which the install step doesn't have", strings.Join(lifecycle.Deferred, " and ")), `"scripts"`, `"`+lifecycle.Deferred[0]+`"`)
+		d.Field, d.Value = "metadata.rebuild_command", lifecycle.RebuildCommand
+		plan.Explain(d)
+	}
+
+	// TypeScript servers without scripts are compiled (or run) from their entry
+	tsInfo, _ := DetectTypeScriptBackend(ctx, pkg, pmInfo, fwInfo)
+	if tsInfo.Entry != "" {
+		plan.DetectedFiles = appendUnique(plan.DetectedFiles, tsInfo.Entry)
+	}
 
 	// Determine build command
-	plan.BuildCommand = determineBuildCommand(pkg, pmInfo, fwInfo)
+	plan.BuildCommand = determineBuildCommand(pkg, pmInfo, fwInfo, tsInfo)
 
 	// Determine start command
-	plan.StartCommand = determineStartCommand(pkg, pmInfo, fwInfo)
+	plan.StartCommand = determineStartCommand(ctx, pkg, pmInfo, fwInfo, tsInfo)
+	explainCommands(ctx, plan, pkg, pmInfo, fwInfo, tsInfo)
+
+	// Offer the test script as an optional test phase
+	if test := DetectTestCommand(pkg, pmInfo); test.Command != "" {
+		plan.TestCommand = test.Command
+		plan.Metadata.TestRunner = test.Runner
+		reason := `"test" script in package.json`
+		if test.Runner != "" {
+			reason += " (" + test.Runner + ")"
+		}
+		d := packageJSONDecision(ctx, reason, `"scripts"`, `"test"`)
+		d.Field, d.Value = "test_command", test.Command
+		plan.Explain(d)
+	}
+
+	// Detect the listening port
+	portInfo := DetectPort(ctx, pkg, fwInfo)
+	plan.Ports = []int{portInfo.Port}
+	plan.Explain(explainPort(portInfo, fwInfo))
+	if portInfo.File != "" {
+		plan.DetectedFiles = appendUnique(plan.DetectedFiles, portInfo.File)
+	}
+
+	// Set CI, telemetry opt-outs, NITRO_PRESET and NODE_ENV
+	for _, env := range DetectEnvDefaults(pkg, fwInfo) {
+		field := "env." + env.Name
+		if env.Phase == app.PhaseBuild {
+			if plan.BuildEnv == nil {
+				plan.BuildEnv = make(map[string]string)
+			}
+			plan.BuildEnv[env.Name] = env.Value
+			field = "build_env." + env.Name
+		} else {
+			if plan.Env == nil {
+				plan.Env = make(map[string]string)
+			}
+			plan.Env[env.Name] = env.Value
+		}
+		plan.Explain(app.Decision{Field: field, Value: env.Value, Source: app.SourceDefault, Reason: env.Reason})
+	}
+
+	// Raise the V8 heap limit for builds that tend to run out of memory
+	if plan.BuildCommand != "" {
+		if heap := DetectBuildHeapSize(ctx, pkg, fwInfo); heap.SizeMB > 0 {
+			if plan.BuildEnv == nil {
+				plan.BuildEnv = make(map[string]string)
+			}
+			plan.BuildEnv["NODE_OPTIONS"] = heap.NodeOptions()
+			d := app.Decision{Source: app.SourceDefault, Reason: heap.Reason}
+			if heap.FromEnv {
+				d = envDecision("COOLPACK_BUILD_HEAP_SIZE")
+			}
+			d.Field, d.Value = "build_env.NODE_OPTIONS", heap.NodeOptions()
+			plan.Explain(d)
+		}
+	}
 
 	// Add detected files to the list
 	plan.DetectedFiles = append(plan.DetectedFiles, detectRelevantFiles(ctx, pmInfo)...)
 
 	// Add additional metadata
 	if pkg.Name != "" {
-		plan.Metadata["name"] = pkg.Name
+		plan.Metadata.Name = pkg.Name
 	}
 	if pkg.Version != "" {
-		plan.Metadata["version"] = pkg.Version
+		plan.Metadata.Version = pkg.Version
 	}
 	if pkg.IsMonorepo() {
-		plan.Metadata["is_monorepo"] = true
-		plan.Metadata["workspaces"] = pkg.Workspaces.Packages
+		plan.Monorepo = &app.MonorepoInfo{Workspaces: pkg.Workspaces.Packages}
 	}
 	if pkg.Type != "" {
-		plan.Metadata["module_type"] = pkg.Type
+		plan.Metadata.ModuleType = pkg.Type
 	}
 
 	// Detect native dependencies
 	nativeDeps := DetectNativeDependencies(pkg)
 	if len(nativeDeps) > 0 {
-		aptPackages := GetRequiredAptPackages(nativeDeps)
-		plan.Metadata["apt_packages"] = aptPackages
+		plan.NativeDeps = &app.NativeDeps{
+			AptPackages:        GetRequiredAptPackages(nativeDeps),
+			RuntimeAptPackages: GetRuntimeAptPackages(nativeDeps),
+		}
 
 		// Track which native packages were detected
-		var detected []string
 		for _, dep := range nativeDeps {
-			detected = append(detected, dep.Package)
+			plan.NativeDeps.Packages = append(plan.NativeDeps.Packages, dep.Package)
+		}
+		plan.Explain(app.Decision{
+			Field:  "native_deps.apt_packages",
+			Value:  strings.Join(plan.NativeDeps.AptPackages, " "),
+			Source: app.SourceFile,
+			File:   "package.json",
+			Reason: "native modules in dependencies: " + strings.Join(plan.NativeDeps.Packages, ", "),
+		})
+	}
+
+	// Packages with per-architecture binaries are checked when building for other platforms
+	if prebuilt := DetectPrebuiltBinaries(pkg); len(prebuilt) > 0 {
+		if plan.NativeDeps == nil {
+			plan.NativeDeps = &app.NativeDeps{}
+		}
+		plan.NativeDeps.PrebuiltBinaries = prebuilt
+	}
+
+	// Target platforms for multi-architecture builds
+	if platforms := ctx.Env["COOLPACK_PLATFORMS"]; platforms != "" {
+		plan.Platforms = app.ParsePlatforms(platforms)
+		d := envDecision("COOLPACK_PLATFORMS")
+		d.Field, d.Value = "platforms", strings.Join(plan.Platforms, ",")
+		plan.Explain(d)
+	}
+
+	// Detect sitemap/robots generators that bake the site URL into the build
+	sitemapGens := DetectSitemapGenerators(ctx, pkg)
+	if len(sitemapGens) > 0 {
+		var detected []string
+		for _, gen := range sitemapGens {
+			detected = append(detected, gen.Package)
+			if gen.ConfigFile != "" {
+				plan.DetectedFiles = appendUnique(plan.DetectedFiles, gen.ConfigFile)
+			}
+			if gen.LocalhostURL {
+				plan.AddWarning(
+					"sitemap_localhost_url",
+					fmt.Sprintf("%s is configured with a localhost URL; generated sitemap.xml/robots.txt will point to localhost", gen.Package),
+					gen.ConfigFile,
+				)
+			}
+			if gen.RequiredEnv != "" {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        gen.RequiredEnv,
+					Phase:       app.PhaseBuild,
+					Description: "Public site URL used in generated sitemap.xml/robots.txt",
+					Source:      gen.Package,
+				})
+			}
+		}
+		plan.Metadata.SitemapGenerators = detected
+	}
+
+	// Detect content layers and CMS SDKs that pull content during build
+	contentSources := DetectContentSources(pkg)
+	if len(contentSources) > 0 {
+		var names []string
+		remote := false
+		for _, src := range contentSources {
+			names = appendUnique(names, src.Name)
+			if src.Remote {
+				remote = true
+			}
+			for _, name := range src.Env {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseBuild,
+					Description: fmt.Sprintf("%s configuration used to fetch content during build", src.Name),
+					Source:      src.Package,
+				})
+			}
+			for _, name := range src.Secrets {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseBuild,
+					Secret:      true,
+					Description: fmt.Sprintf("%s API token used to fetch content during build", src.Name),
+					Source:      src.Package,
+				})
+			}
+		}
+		plan.Metadata.ContentSources = names
+		if remote {
+			// Content is baked in at build time, so CMS changes need a rebuild
+			plan.Metadata.ContentDependent = true
+			plan.Metadata.RebuildHint = "Content is fetched from a CMS at build time; configure a CMS webhook to trigger a rebuild when content changes"
+		}
+	}
+
+	// List environment variables referenced in source code
+	envRefs := DetectEnvReferences(ctx)
+	if len(envRefs.All) > 0 {
+		plan.Metadata.ReferencedEnv = envRefs.All
+	}
+	if len(envRefs.Build) > 0 {
+		plan.Metadata.ReferencedBuildEnv = envRefs.Build
+	}
+
+	// List variables declared in .env files (names only, never values)
+	envFileInfo := DetectEnvFiles(ctx)
+	if len(envFileInfo.Files) > 0 {
+		for _, file := range envFileInfo.Files {
+			plan.DetectedFiles = appendUnique(plan.DetectedFiles, file)
+		}
+		plan.Metadata.EnvFiles = envFileInfo.Files
+		if len(envFileInfo.Vars) > 0 {
+			plan.Metadata.EnvFileVars = envFileInfo.Vars
+		}
+		if len(envFileInfo.ExampleVars) > 0 {
+			plan.Metadata.EnvExampleVars = envFileInfo.ExampleVars
+		}
+		if len(envFileInfo.Missing) > 0 {
+			plan.AddWarning("env_example_missing",
+				fmt.Sprintf("%s declares variables that are not set: %s", envFileInfo.ExampleFile, strings.Join(envFileInfo.Missing, ", ")),
+				envFileInfo.ExampleFile)
+		}
+	}
+
+	// Warn about committed files that would bake secrets into the image
+	for _, secret := range DetectSecretFiles(ctx) {
+		plan.AddWarning("secret_file",
+			fmt.Sprintf("%s (%s) will be copied into the image; remove it from the repository or add it to .dockerignore", secret.File, secret.Description),
+			secret.File)
+	}
+
+	// Warn about lockfiles the detected package manager won't install from
+	checkLockfiles(ctx, plan, pkg, pmInfo)
+
+	// Record forced dependency versions, which can make installs differ from local ones
+	if overrides := DetectOverrides(pkg, pmInfo); len(overrides.Fields) > 0 {
+		plan.Metadata.Overrides = make(map[string]map[string]string, len(overrides.Fields))
+		for field, values := range overrides.Fields {
+			plan.Metadata.Overrides[field] = values
+		}
+		if len(overrides.Ignored) > 0 {
+			plan.AddWarning("overrides_ignored", overrides.IgnoredMessage(pmInfo), "package.json")
+		}
+	}
+
+	// Record private registries so install failures can be traced to missing credentials
+	if registries := DetectNpmRegistries(ctx); len(registries) > 0 {
+		var entries []map[string]string
+		for _, r := range registries {
+			entry := map[string]string{"url": r.URL, "file": r.File}
+			if r.Scope != "" {
+				entry["scope"] = r.Scope
+			}
+			if r.AuthEnv != "" {
+				entry["auth_env"] = r.AuthEnv
+			} else if r.Scope != "" {
+				plan.AddWarning("npm_registry_no_auth",
+					fmt.Sprintf("Registry for %s (%s) has no ${VAR} auth token; install fails if it requires credentials", r.Scope, r.URL),
+					r.File)
+			}
+			entries = append(entries, entry)
+		}
+		plan.Metadata.NpmRegistries = entries
+	}
+
+	// Mount registry credentials as build secrets instead of build args
+	for _, secret := range DetectBuildSecrets(ctx) {
+		plan.AddSecret(secret)
+	}
+
+	// Map database/queue/broker clients to the backing services they need
+	for _, client := range DetectServiceClients(pkg) {
+		plan.AddService(client.Service, client.Package, client.Env, client.Optional)
+	}
+	prisma := DetectPrismaDatasource(ctx, pkg)
+	if ds := prisma; ds != nil {
+		plan.DetectedFiles = appendUnique(plan.DetectedFiles, ds.File)
+		if service := ds.Service(); service != "" {
+			var env []string
+			if ds.URLEnv != "" {
+				env = append(env, ds.URLEnv)
+			}
+			plan.AddService(service, "prisma", env, false)
+		}
+	}
+
+	// Detect SQLite databases on local disk (persistent volume, single replica)
+	if sqlite := DetectSQLite(ctx, pkg, prisma); sqlite != nil {
+		for _, dir := range sqlite.DataDirs {
+			plan.AddVolume(app.Volume{
+				Path:        containerPath(dir),
+				Description: "SQLite database files",
+				Source:      sqlite.Package,
+			})
+		}
+		if len(sqlite.Files) > 0 {
+			plan.Metadata.SQLiteDatabases = sqlite.Files
+		}
+		plan.Metadata.SQLiteHints = append([]string(nil), SQLiteHints...)
+		plan.Metadata.MaxReplicas = 1
+		if sqlite.Relocate {
+			plan.AddWarning("sqlite_relocate",
+				fmt.Sprintf("SQLite database shares a directory with source files; move it into %s/%s so it can be mounted as a volume", AppDir, defaultSQLiteDataDir),
+				"")
+		}
+		plan.AddWarning("sqlite_single_replica",
+			"SQLite stores data on local disk; run a single replica, additional replicas would not share data",
+			"")
+	}
+
+	// Detect file uploads: local disk needs a volume, object storage needs credentials
+	storage := DetectStorage(ctx, pkg)
+	for _, dir := range storage.UploadDirs {
+		plan.AddVolume(app.Volume{
+			Path:        AppDir + "/" + dir,
+			Description: "Uploaded files written to local disk",
+			Source:      storage.UploadPackage,
+		})
+	}
+	if len(storage.ObjectStorage) > 0 {
+		var providers []string
+		for _, sdk := range storage.ObjectStorage {
+			providers = appendUnique(providers, sdk.Provider)
+			for _, name := range sdk.Env {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Description: fmt.Sprintf("%s bucket configuration", sdk.Provider),
+					Source:      sdk.Package,
+				})
+			}
+			for _, name := range sdk.Secrets {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Secret:      true,
+					Description: fmt.Sprintf("%s credentials", sdk.Provider),
+					Source:      sdk.Package,
+				})
+			}
+		}
+		plan.Metadata.ObjectStorage = providers
+	}
+
+	// Detect AI/LLM SDKs (API keys, streaming responses, long timeouts)
+	aiSDKs := DetectAISDKs(pkg)
+	if len(aiSDKs) > 0 {
+		var providers []string
+		for _, sdk := range aiSDKs {
+			providers = appendUnique(providers, sdk.Provider)
+			for _, name := range sdk.Secrets {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Secret:      true,
+					Description: fmt.Sprintf("%s API key", sdk.Provider),
+					Source:      sdk.Package,
+				})
+			}
+		}
+		plan.Metadata.AISDKs = providers
+		plan.Metadata.StreamingResponses = true
+		plan.Metadata.ProxyHints = GetAIProxyHints(pkg)
+		plan.Metadata.RecommendedTimeoutSeconds = AIRecommendedTimeout
+	}
+
+	// Detect email/webhook senders (credentials, outbound ports)
+	mailSenders := DetectMailSenders(pkg)
+	if len(mailSenders) > 0 {
+		var providers []string
+		for _, sender := range mailSenders {
+			providers = appendUnique(providers, sender.Provider)
+			for _, name := range sender.Env {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Description: fmt.Sprintf("%s delivery configuration", sender.Provider),
+					Source:      sender.Package,
+				})
+			}
+			for _, name := range sender.Secrets {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Secret:      true,
+					Description: fmt.Sprintf("%s delivery credentials", sender.Provider),
+					Source:      sender.Package,
+				})
+			}
+		}
+		plan.Metadata.MailProviders = providers
+		plan.Metadata.OutboundPorts = GetOutboundPorts(mailSenders)
+		plan.Metadata.OutboundNotes = GetOutboundNotes(mailSenders)
+	}
+
+	// Detect feature flag SDKs (SDK keys, self-hostable flag servers)
+	flagSDKs := DetectFeatureFlagSDKs(pkg)
+	if len(flagSDKs) > 0 {
+		var providers []string
+		for _, sdk := range flagSDKs {
+			providers = appendUnique(providers, sdk.Provider)
+			for _, name := range sdk.Env {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       sdk.Phase,
+					Description: fmt.Sprintf("%s SDK configuration", sdk.Provider),
+					Source:      sdk.Package,
+				})
+			}
+			for _, name := range sdk.Secrets {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       sdk.Phase,
+					Secret:      true,
+					Description: fmt.Sprintf("%s SDK key", sdk.Provider),
+					Source:      sdk.Package,
+				})
+			}
+			if sdk.Service != "" {
+				// Flag SDKs fall back to default values when the server is unreachable
+				plan.AddService(sdk.Service, sdk.Package, sdk.Env, true)
+			}
+		}
+		plan.Metadata.FeatureFlags = providers
+	}
+
+	// Detect auth libraries (secrets, OAuth provider credentials, callback URLs)
+	auth := DetectAuth(ctx, pkg)
+	if len(auth.Libraries) > 0 {
+		var names []string
+		for _, lib := range auth.Libraries {
+			names = appendUnique(names, lib.Name)
+			for _, name := range lib.Env {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Description: fmt.Sprintf("%s base URL or configuration", lib.Name),
+					Source:      lib.Package,
+				})
+			}
+			for _, name := range lib.BuildEnv {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseBuild,
+					Description: fmt.Sprintf("%s publishable key", lib.Name),
+					Source:      lib.Package,
+				})
+			}
+			for _, name := range lib.Secrets {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Secret:      true,
+					Description: fmt.Sprintf("%s secret", lib.Name),
+					Source:      lib.Package,
+				})
+			}
+			for _, provider := range auth.Providers {
+				id, secret := lib.ProviderEnv(provider)
+				if id == "" {
+					continue
+				}
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        id,
+					Phase:       app.PhaseRuntime,
+					Description: fmt.Sprintf("%s OAuth client ID", provider),
+					Source:      lib.Package,
+				})
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        secret,
+					Phase:       app.PhaseRuntime,
+					Secret:      true,
+					Description: fmt.Sprintf("%s OAuth client secret", provider),
+					Source:      lib.Package,
+				})
+			}
+			if lib.TrustHost {
+				// Auth.js rejects proxied requests with UntrustedHost unless told to trust X-Forwarded-Host
+				if plan.Env == nil {
+					plan.Env = make(map[string]string)
+				}
+				plan.Env["AUTH_TRUST_HOST"] = "true"
+				plan.Explain(app.Decision{Field: "env.AUTH_TRUST_HOST", Value: "true", Source: app.SourceFile, File: "package.json", Reason: lib.Package + " rejects proxied requests unless it trusts X-Forwarded-Host"})
+			}
+		}
+		plan.Metadata.AuthLibraries = names
+		if len(auth.Providers) > 0 {
+			plan.Metadata.AuthProviders = auth.Providers
+		}
+		if len(auth.CallbackURLs) > 0 {
+			plan.Metadata.AuthCallbackURLs = auth.CallbackURLs
+		}
+	}
+
+	// Detect APM agents (env, preload) and file-based logging (volumes)
+	apm := DetectAPM(ctx, pkg)
+	if len(apm.Agents) > 0 {
+		var names []string
+		for _, agent := range apm.Agents {
+			names = appendUnique(names, agent.Name)
+			for _, name := range agent.Env {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Description: fmt.Sprintf("%s agent configuration", agent.Name),
+					Source:      agent.Package,
+				})
+			}
+			for _, name := range agent.Secrets {
+				plan.AddRequiredEnv(app.EnvVar{
+					Name:        name,
+					Phase:       app.PhaseRuntime,
+					Secret:      true,
+					Description: fmt.Sprintf("%s agent credentials", agent.Name),
+					Source:      agent.Package,
+				})
+			}
+		}
+		plan.Metadata.APMAgents = names
+	}
+	if len(apm.Preloads) > 0 {
+		// Agents must load before the app; use -r for node commands, NODE_OPTIONS otherwise
+		if cmd, ok := injectPreloads(plan.StartCommand, apm.Preloads); ok {
+			plan.StartCommand = cmd
+		} else {
+			if plan.Env == nil {
+				plan.Env = make(map[string]string)
+			}
+			plan.Env["NODE_OPTIONS"] = nodeOptionsPreloads(apm.Preloads)
+			plan.Explain(app.Decision{Field: "env.NODE_OPTIONS", Value: plan.Env["NODE_OPTIONS"], Source: app.SourceFile, File: "package.json", Reason: "APM agents must load before the application"})
+		}
+		plan.Metadata.AgentPreloads = apm.Preloads
+	}
+	if len(apm.Loggers) > 0 {
+		plan.Metadata.Loggers = apm.Loggers
+	}
+	for _, dir := range apm.LogDirs {
+		plan.AddVolume(app.Volume{
+			Path:        containerPath(dir),
+			Description: "Log files",
+			Source:      apm.Loggers[0],
+		})
+	}
+	if apm.FileLogging {
+		plan.AddWarning("file_logging",
+			"Logs are written to files inside the container; log to stdout so the platform can collect them, or keep the log directory on a volume",
+			"")
+	}
+
+	// Detect i18n packages reading locale files at runtime (copied into the runtime stage)
+	i18n := DetectI18n(ctx, pkg)
+	if len(i18n.Packages) > 0 {
+		plan.Metadata.I18nPackages = i18n.Packages
+		var runtimeFiles []string
+		for _, file := range append(i18n.ConfigFiles, i18n.Dirs...) {
+			runtimeFiles = appendUnique(runtimeFiles, file)
+			plan.DetectedFiles = appendUnique(plan.DetectedFiles, file)
+		}
+		if len(runtimeFiles) > 0 {
+			plan.Metadata.RuntimeFiles = runtimeFiles
+		}
+		for _, name := range i18n.DevOnly {
+			plan.AddWarning("i18n_dev_dependency",
+				fmt.Sprintf("%s loads locale files at runtime but is listed in devDependencies; move it to dependencies so it isn't pruned from the production image", name),
+				"package.json")
+		}
+	}
+	if i18n.NestAssetsMissing {
+		plan.AddWarning("i18n_assets_not_copied",
+			fmt.Sprintf("nestjs-i18n translations in %s are not listed in nest-cli.json assets, so nest build won't copy them to dist/; add { \"include\": \"i18n/**/*\", \"watchAssets\": true } to compilerOptions.assets", nestI18nDir),
+			"nest-cli.json")
+	}
+
+	// Suggest scheduled rebuilds for static sites with time-sensitive content
+	if schedule := DetectRebuildSchedule(ctx, fwInfo); schedule != nil {
+		plan.Metadata.RebuildSchedule = &app.RebuildSchedule{
+			Cron:   schedule.Cron,
+			Reason: schedule.Reason,
 		}
-		plan.Metadata["native_packages"] = detected
+	}
+
+	// Remove devDependencies before node_modules is copied into the runtime stage
+	prune := DetectProductionPrune(ctx, pkg, pmInfo, fwInfo, plan.StartCommand)
+	if prune.Command != "" {
+		plan.Metadata.PruneCommand = prune.Command
+	} else if prune.SkipReason != "" {
+		plan.Metadata.PruneSkippedReason = prune.SkipReason
 	}
 
 	// Check for base image override
 	if baseImage := ctx.Env["COOLPACK_BASE_IMAGE"]; baseImage != "" {
-		plan.Metadata["base_image"] = baseImage
+		plan.Metadata.BaseImage = baseImage
+		d := envDecision("COOLPACK_BASE_IMAGE")
+		d.Field, d.Value = "metadata.base_image", baseImage
+		plan.Explain(d)
 	}
 
 	// Check for output directory override
 	if outputDir := ctx.Env["COOLPACK_SPA_OUTPUT_DIR"]; outputDir != "" {
-		plan.Metadata["output_dir_override"] = outputDir
+		if plan.Output == nil {
+			plan.Output = &app.OutputInfo{}
+		}
+		plan.Output.DirOverride = outputDir
+		d := envDecision("COOLPACK_SPA_OUTPUT_DIR")
+		d.Field, d.Value = "output.dir_override", outputDir
+		plan.Explain(d)
 	}
 
 	// Detect Cypress for cache
 	if pkg.HasDependency("cypress") {
-		plan.Metadata["has_cypress"] = true
+		plan.Metadata.HasCypress = true
 	}
 
 	// Detect moon repo
 	if ctx.HasFile(".moon/workspace.yml") {
-		plan.Metadata["has_moon"] = true
+		plan.Metadata.HasMoon = true
 	}
 
 	// Custom cache directories from package.json
 	if len(pkg.CacheDirectories) > 0 {
-		plan.Metadata["cache_directories"] = pkg.CacheDirectories
+		plan.Metadata.CacheDirectories = pkg.CacheDirectories
 	}
 
-	// Detect SPA (only for static output)
-	if outputType := plan.Metadata["output_type"]; outputType == "static" {
-		if isSPA := detectSPA(pkg, fwInfo); isSPA {
-			plan.Metadata["is_spa"] = true
+	// Detect SPA and hosting routing rules (only for static output)
+	if plan.OutputType() == app.OutputTypeStatic {
+		routing := DetectRouting(ctx)
+		if routing != nil {
+			for _, file := range routing.Files {
+				plan.DetectedFiles = appendUnique(plan.DetectedFiles, file)
+			}
+			r := routing.Routing
+			if len(r.Redirects) > 0 || len(r.Rewrites) > 0 || len(r.Headers) > 0 {
+				plan.Routing = &r
+			}
+			for _, rule := range routing.Unsupported {
+				plan.AddWarning("routing_rule_unsupported",
+					fmt.Sprintf("Rule for %s is not supported by the static server (%s) and was skipped", rule.Source, rule.Reason),
+					rule.File)
+			}
+		}
+
+		if isSPA, reason := detectSPA(ctx, pkg, fwInfo); isSPA {
+			plan.SPA = &app.SPAInfo{Enabled: true, Reason: reason}
+			plan.Explain(app.Decision{Field: "spa.enabled", Value: "true", Source: app.SourceFile, File: "package.json", Reason: reason})
+		} else if routing != nil && routing.SPAFallback {
+			plan.SPA = &app.SPAInfo{Enabled: true, Reason: "catch-all rewrite to /index.html"}
+			plan.Explain(app.Decision{Field: "spa.enabled", Value: "true", Source: app.SourceFile, File: strings.Join(routing.Files, ", "), Reason: plan.SPA.Reason})
+		}
+		// Fallback rules apply whenever SPA mode is on (detected or --spa)
+		if plan.Routing == nil {
+			plan.Routing = &app.Routing{}
+		}
+		plan.Routing.Fallback = GetSPAFallback(fwInfo)
+		plan.Routing.Cache = GetCachePolicy(fwInfo)
+
+		// Custom 404/500 pages in the output
+		errorPages, errorPageFiles := DetectErrorPages(ctx, fwInfo)
+		plan.Routing.ErrorPages = errorPages
+		if plan.Output != nil && plan.Output.BasePath != "" {
+			applyBasePath(plan.Routing, plan.Output.BasePath)
+		}
+		for _, file := range errorPageFiles {
+			plan.DetectedFiles = appendUnique(plan.DetectedFiles, file)
+		}
+
+		// Optional brotli/gzip precompression of the output
+		if env := ctx.Env["COOLPACK_PRECOMPRESS"]; env == "true" || env == "1" {
+			plan.Metadata.Precompress = true
+			d := envDecision("COOLPACK_PRECOMPRESS")
+			d.Field, d.Value = "metadata.precompress", "true"
+			plan.Explain(d)
 		}
 	}
 
+	// Generated Dockerfiles use BuildKit cache mounts
+	plan.AddFeature(app.FeatureCacheMounts, true)
+	if plan.Routing != nil {
+		plan.AddFeature(app.FeatureRouting, false)
+	}
+
+	// File reads fail once the context is done, so the plan would be incomplete
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
 	return plan, nil
 }
 
 // detectSPA checks if the application is a Single Page Application
-// by looking for client-side router dependencies
-func detectSPA(pkg *PackageJSON, fw FrameworkInfo) bool {
+// by looking for client-side router dependencies, and returns the reason
+func detectSPA(ctx *app.Context, pkg *PackageJSON, fw FrameworkInfo) (bool, string) {
 	// Frameworks that handle routing server-side or generate static HTML per route
 	// don't need SPA fallback even in static mode
 	switch fw.Name {
 	case FrameworkGatsby, FrameworkEleventy:
 		// Static site generators that create HTML for each route
-		return false
+		return false, ""
 	case FrameworkNextJS, FrameworkNuxt, FrameworkAstro:
 		// These generate static HTML per route in export mode
-		return false
+		return false, ""
+	case FrameworkExpo:
+		// Expo's default "single" web output is one index.html for all routes
+		if detectExpoWebConfig(ctx, pkg).Output != "static" {
+			return true, "Expo single-page web output"
+		}
+		return false, ""
 	}
 
 	// Client-side router dependencies indicate SPA
@@ -200,15 +889,31 @@ func detectSPA(pkg *PackageJSON, fw FrameworkInfo) bool {
 
 	for _, router := range spaRouters {
 		if pkg.HasDependency(router) {
-			return true
+			return true, router + " dependency"
 		}
 	}
 
-	return false
+	return false, ""
+}
+
+// isElectronApp checks if Electron is the primary dependency of the project
+// Projects that also ship a web framework are treated as web apps
+func isElectronApp(pkg *PackageJSON, fw FrameworkInfo) bool {
+	if !pkg.HasDependency("electron") {
+		return false
+	}
+
+	// Start script launches the desktop app
+	start := pkg.GetScript("start")
+	if strings.Contains(start, "electron") {
+		return true
+	}
+
+	return fw.Name == FrameworkNone
 }
 
 // determineBuildCommand determines the build command to use
-func determineBuildCommand(pkg *PackageJSON, pm PackageManagerInfo, fw FrameworkInfo) string {
+func determineBuildCommand(pkg *PackageJSON, pm PackageManagerInfo, fw FrameworkInfo, ts TypeScriptBackend) string {
 	run := pm.GetRunCommand()
 
 	// Check for explicit build script
@@ -216,6 +921,11 @@ func determineBuildCommand(pkg *PackageJSON, pm PackageManagerInfo, fw Framework
 		return run + " build"
 	}
 
+	// Compile a TypeScript server's entry
+	if ts.BuildCommand != "" {
+		return ts.BuildCommand
+	}
+
 	// Use framework-specific defaults
 	if cmd := fw.GetDefaultBuildCommand(pm); cmd != "" {
 		return cmd
@@ -225,7 +935,7 @@ func determineBuildCommand(pkg *PackageJSON, pm PackageManagerInfo, fw Framework
 }
 
 // determineStartCommand determines the start command to use
-func determineStartCommand(pkg *PackageJSON, pm PackageManagerInfo, fw FrameworkInfo) string {
+func determineStartCommand(ctx *app.Context, pkg *PackageJSON, pm PackageManagerInfo, fw FrameworkInfo, ts TypeScriptBackend) string {
 	run := pm.GetRunCommand()
 
 	// Check for explicit start script
@@ -238,36 +948,80 @@ func determineStartCommand(pkg *PackageJSON, pm PackageManagerInfo, fw Framework
 		return run + " serve"
 	}
 
+	// Run a TypeScript server's compiled entry (or the entry itself), unless
+	// main names the JavaScript to run
+	if ts.StartCommand != "" && (pkg.Main == "" || isTypeScriptFile(pkg.Main)) {
+		return ts.StartCommand
+	}
+
 	// Use framework-specific defaults
 	if cmd := fw.GetDefaultStartCommand(pm); cmd != "" {
 		return cmd
 	}
 
-	// Fallback: check main entry point
-	if pkg.Main != "" {
-		return fmt.Sprintf("node %s", pkg.Main)
+	// Fallback: run the entry file named in package.json or found on disk
+	if entry, _ := startEntry(ctx, pkg, ts); entry != "" {
+		return fmt.Sprintf("node %s", entry)
 	}
 
-	// Check for common entry points
-	entryPoints := []string{"dist/index.js", "build/index.js", "index.js", "server.js", "app.js"}
-	for _, ep := range entryPoints {
-		if hasEntryPoint(pkg, ep) {
-			return fmt.Sprintf("node %s", ep)
+	return ""
+}
+
+// startEntryPoints are common entry files, used when package.json names none
+var startEntryPoints = []string{"dist/index.js", "build/index.js", "index.js", "server.js", "app.js"}
+
+// startEntry returns the file to start with node and the package.json field
+// naming it ("main", "exports" or "bin"; "" for a common entry file found on
+// disk). A file named in package.json must exist, unless the build may
+// create it; common entry files must exist.
+func startEntry(ctx *app.Context, pkg *PackageJSON, ts TypeScriptBackend) (entry, field string) {
+	builds := pkg.HasScript("build") || ts.BuildCommand != ""
+	declared := []struct {
+		file  string
+		field string
+	}{
+		{pkg.Main, "main"},
+		{pkg.ExportsEntry(), "exports"},
+		{pkg.BinEntry(), "bin"},
+	}
+	for _, d := range declared {
+		if d.file == "" {
+			continue
+		}
+		if entryExists(ctx, d.file) || builds {
+			return d.file, d.field
 		}
 	}
-
-	return ""
+	for _, file := range startEntryPoints {
+		if ctx.HasFile(file) {
+			return file, ""
+		}
+	}
+	return "", ""
 }
 
-// hasEntryPoint checks if the entry point might exist (based on package.json hints)
-func hasEntryPoint(pkg *PackageJSON, path string) bool {
-	// This is a simple heuristic - in a real implementation we might check the filesystem
-	if pkg.Main == path {
-		return true
+// entryExists checks if node can load an entry file, which may omit the .js
+// extension or name a directory with an index.js
+func entryExists(ctx *app.Context, file string) bool {
+	file = path.Clean(file)
+	for _, candidate := range []string{file, file + ".js", path.Join(file, "index.js")} {
+		if ctx.HasFile(candidate) {
+			return true
+		}
 	}
 	return false
 }
 
+// appendUnique appends a value to a slice if it is not already present
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
 // detectRelevantFiles returns a list of relevant files that were detected
 func detectRelevantFiles(ctx *app.Context, pm PackageManagerInfo) []string {
 	var files []string
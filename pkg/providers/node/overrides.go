@@ -0,0 +1,65 @@
+package node
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// overrideFields are the package.json fields that force dependency versions,
+// with the package managers that read them
+var overrideFields = []struct {
+	field   string
+	readers []PackageManager
+}{
+	{"overrides", []PackageManager{PackageManagerNPM, PackageManagerBun}},
+	{"resolutions", []PackageManager{PackageManagerYarn1, PackageManagerYarnBerry, PackageManagerPNPM, PackageManagerBun}},
+	{"pnpm.overrides", []PackageManager{PackageManagerPNPM}},
+}
+
+// OverridesInfo describes the forced dependency versions in package.json
+type OverridesInfo struct {
+	// Fields are the overrides by package.json field ("overrides",
+	// "resolutions", "pnpm.overrides")
+	Fields map[string]Overrides
+	// Ignored are the fields the detected package manager doesn't read
+	Ignored []string
+}
+
+// DetectOverrides reads npm overrides, yarn resolutions and pnpm.overrides.
+// They often explain why an install differs from a local one, e.g. when a
+// project switched package managers and the field no longer applies.
+func DetectOverrides(pkg *PackageJSON, pm PackageManagerInfo) OverridesInfo {
+	values := map[string]Overrides{
+		"overrides":      pkg.Overrides,
+		"resolutions":    pkg.Resolutions,
+		"pnpm.overrides": pkg.PNPM.Overrides,
+	}
+	info := OverridesInfo{}
+	for _, f := range overrideFields {
+		overrides := values[f.field]
+		if len(overrides) == 0 {
+			continue
+		}
+		if info.Fields == nil {
+			info.Fields = make(map[string]Overrides)
+		}
+		info.Fields[f.field] = overrides
+		if !slices.Contains(f.readers, pm.Name) {
+			info.Ignored = append(info.Ignored, f.field)
+		}
+	}
+	return info
+}
+
+// IgnoredMessage describes the override fields the package manager ignores
+func (o OverridesInfo) IgnoredMessage(pm PackageManagerInfo) string {
+	var read []string
+	for _, f := range overrideFields {
+		if slices.Contains(f.readers, pm.Name) {
+			read = append(read, f.field)
+		}
+	}
+	return fmt.Sprintf("package.json sets %s, which %s ignores (it reads %s); those versions aren't applied",
+		strings.Join(o.Ignored, " and "), pm.Name, strings.Join(read, " and "))
+}
@@ -2,7 +2,11 @@ package node
 
 import (
 	"encoding/json"
+	"sort"
 	"strings"
+	"unicode"
+
+	"github.com/coollabsio/coolpack/pkg/app"
 )
 
 // PackageJSON represents the structure of a package.json file
@@ -10,12 +14,18 @@ type PackageJSON struct {
 	Name             string            `json:"name"`
 	Version          string            `json:"version"`
 	Main             string            `json:"main"`
+	Exports          json.RawMessage   `json:"exports"`
+	Bin              BinEntries        `json:"bin"`
 	Type             string            `json:"type"`
 	Scripts          map[string]string `json:"scripts"`
 	Dependencies     map[string]string `json:"dependencies"`
 	DevDependencies  map[string]string `json:"devDependencies"`
 	Engines          Engines           `json:"engines"`
+	DevEngines       DevEngines        `json:"devEngines"`
 	PackageManager   string            `json:"packageManager"`
+	Overrides        Overrides         `json:"overrides"`
+	Resolutions      Overrides         `json:"resolutions"`
+	PNPM             PNPMConfig        `json:"pnpm"`
 	Workspaces       Workspaces        `json:"workspaces"`
 	CacheDirectories []string          `json:"cacheDirectories"`
 }
@@ -29,6 +39,135 @@ type Engines struct {
 	Bun  string `json:"bun"`
 }
 
+// PNPMConfig represents the pnpm field in package.json
+type PNPMConfig struct {
+	Overrides Overrides `json:"overrides"`
+}
+
+// Overrides are forced dependency versions (npm overrides, yarn resolutions,
+// pnpm.overrides) by package selector. npm's nested overrides are flattened
+// with pnpm's ">" syntax: {"foo": {".": "2", "bar": "1"}} is "foo": "2" and
+// "foo>bar": "1".
+type Overrides map[string]string
+
+// UnmarshalJSON handles flat and nested overrides; anything else is ignored
+func (o *Overrides) UnmarshalJSON(data []byte) error {
+	*o = nil
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		// Default to empty
+		return nil
+	}
+	result := Overrides{}
+	result.add("", entries, 0)
+	if len(result) > 0 {
+		*o = result
+	}
+	return nil
+}
+
+// maxNestingDepth bounds how deep nested overrides and exports conditions are
+// followed: each level decodes what is below it again, and real ones nest a
+// few levels at most
+const maxNestingDepth = 32
+
+// add adds the overrides of an object under the selector prefix
+func (o Overrides) add(prefix string, entries map[string]json.RawMessage, depth int) {
+	if depth > maxNestingDepth {
+		return
+	}
+	for key, raw := range entries {
+		selector := prefix + key
+		if key == "." {
+			// "." overrides the package the object is nested under
+			selector = strings.TrimSuffix(prefix, ">")
+		}
+		var version string
+		if err := json.Unmarshal(raw, &version); err == nil {
+			if selector != "" {
+				o[selector] = version
+			}
+			continue
+		}
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &nested); err == nil {
+			o.add(selector+">", nested, depth+1)
+		}
+	}
+}
+
+// DevEngines represents the devEngines field in package.json (npm 10.9+): the
+// runtime and package manager the project is developed with
+type DevEngines struct {
+	Runtime        DevEngineList `json:"runtime"`
+	PackageManager DevEngineList `json:"packageManager"`
+}
+
+// DevEngine is one devEngines entry (e.g., {"name": "pnpm", "version": "^10"})
+type DevEngine struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	OnFail  string `json:"onFail"`
+}
+
+// DevEngineList is a devEngines entry, which can be an object or an array of
+// alternatives
+type DevEngineList []DevEngine
+
+// UnmarshalJSON handles both object and array formats for devEngines entries
+func (l *DevEngineList) UnmarshalJSON(data []byte) error {
+	var arr []DevEngine
+	if err := json.Unmarshal(data, &arr); err == nil {
+		*l = arr
+		return nil
+	}
+
+	var obj DevEngine
+	if err := json.Unmarshal(data, &obj); err == nil && obj.Name != "" {
+		*l = DevEngineList{obj}
+		return nil
+	}
+
+	// Default to empty
+	*l = nil
+	return nil
+}
+
+// Find returns the first entry for one of the names
+func (l DevEngineList) Find(names ...string) (DevEngine, bool) {
+	for _, e := range l {
+		for _, name := range names {
+			if e.Name == name {
+				return e, true
+			}
+		}
+	}
+	return DevEngine{}, false
+}
+
+// BinEntries are the commands in the bin field, by name. A bin given as a
+// string is the package's only command, stored under "".
+type BinEntries map[string]string
+
+// UnmarshalJSON handles both string and object formats for bin
+func (b *BinEntries) UnmarshalJSON(data []byte) error {
+	var file string
+	if err := json.Unmarshal(data, &file); err == nil {
+		*b = BinEntries{"": file}
+		return nil
+	}
+
+	var obj map[string]string
+	if err := json.Unmarshal(data, &obj); err == nil {
+		*b = obj
+		return nil
+	}
+
+	// Default to empty
+	*b = nil
+	return nil
+}
+
 // Workspaces can be either an array of strings or an object with a packages field
 type Workspaces struct {
 	Packages []string
@@ -60,7 +199,7 @@ func (w *Workspaces) UnmarshalJSON(data []byte) error {
 // ParsePackageJSON parses a package.json file from bytes
 func ParsePackageJSON(data []byte) (*PackageJSON, error) {
 	var pkg PackageJSON
-	if err := json.Unmarshal(data, &pkg); err != nil {
+	if err := app.DecodeJSON("package.json", data, &pkg); err != nil {
 		return nil, err
 	}
 	return &pkg, nil
@@ -114,9 +253,11 @@ func (p *PackageJSON) GetDependencyVersion(name string) string {
 }
 
 // GetPackageManagerInfo parses the packageManager field (e.g., "pnpm@8.0.0")
-// Returns the package manager name and version
+// Returns the package manager name and version. A field with whitespace is
+// invalid (corepack rejects it) and is ignored, as the version ends up in a
+// RUN instruction of the Dockerfile.
 func (p *PackageJSON) GetPackageManagerInfo() (name, version string) {
-	if p.PackageManager == "" {
+	if p.PackageManager == "" || strings.ContainsFunc(p.PackageManager, unicode.IsSpace) {
 		return "", ""
 	}
 
@@ -129,6 +270,94 @@ func (p *PackageJSON) GetPackageManagerInfo() (name, version string) {
 	return name, version
 }
 
+// exportConditions are the export conditions that apply when the package is
+// started with node, in order of preference
+var exportConditions = []string{"node", "import", "require", "default"}
+
+// ExportsEntry returns the file the package exports as its root ("." or
+// "./index"), resolving conditions for node, or "" when exports names none
+func (p *PackageJSON) ExportsEntry() string {
+	if len(p.Exports) == 0 {
+		return ""
+	}
+	var subpaths map[string]json.RawMessage
+	if err := json.Unmarshal(p.Exports, &subpaths); err == nil {
+		for key := range subpaths {
+			if !strings.HasPrefix(key, ".") {
+				// Conditions without subpaths apply to the root
+				return resolveExport(p.Exports, 0)
+			}
+		}
+		for _, key := range []string{".", "./index"} {
+			if target, ok := subpaths[key]; ok {
+				if file := resolveExport(target, 0); file != "" {
+					return file
+				}
+			}
+		}
+		return ""
+	}
+	return resolveExport(p.Exports, 0)
+}
+
+// resolveExport resolves an exports target: a path, an array of fallbacks or
+// an object of conditions (which may nest)
+func resolveExport(target json.RawMessage, depth int) string {
+	if depth > maxNestingDepth {
+		return ""
+	}
+	var file string
+	if err := json.Unmarshal(target, &file); err == nil {
+		return file
+	}
+	var fallbacks []json.RawMessage
+	if err := json.Unmarshal(target, &fallbacks); err == nil {
+		for _, fallback := range fallbacks {
+			if file := resolveExport(fallback, depth+1); file != "" {
+				return file
+			}
+		}
+		return ""
+	}
+	var conditions map[string]json.RawMessage
+	if err := json.Unmarshal(target, &conditions); err == nil {
+		for _, condition := range exportConditions {
+			if next, ok := conditions[condition]; ok {
+				if file := resolveExport(next, depth+1); file != "" {
+					return file
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// BinEntry returns the file of the package's main command: the only one, or
+// the one named after the package, else the first by name
+func (p *PackageJSON) BinEntry() string {
+	if len(p.Bin) == 0 {
+		return ""
+	}
+	if len(p.Bin) == 1 {
+		for _, file := range p.Bin {
+			return file
+		}
+	}
+	name := p.Name
+	if idx := strings.LastIndex(name, "/"); idx != -1 {
+		name = name[idx+1:]
+	}
+	if file, ok := p.Bin[name]; ok {
+		return file
+	}
+	names := make([]string, 0, len(p.Bin))
+	for n := range p.Bin {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return p.Bin[names[0]]
+}
+
 // IsMonorepo checks if this is a monorepo setup
 func (p *PackageJSON) IsMonorepo() bool {
 	return len(p.Workspaces.Packages) > 0
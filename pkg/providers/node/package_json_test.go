@@ -0,0 +1,57 @@
+package node
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// FuzzParsePackageJSON parses arbitrary package.json files and runs the
+// accessors detection uses on them; none may panic
+func FuzzParsePackageJSON(f *testing.F) {
+	seeds := []string{
+		`{}`,
+		`{"name": "app", "main": "index.js", "scripts": {"build": "vite build", "start": "node ."}}`,
+		`{"packageManager": "pnpm@9.15.0+sha512.abc", "engines": {"node": ">=20", "pnpm": "^9"}}`,
+		`{"devEngines": {"runtime": {"name": "node", "version": "^22"}, "packageManager": [{"name": "pnpm"}, {"name": "npm"}]}}`,
+		`{"workspaces": {"packages": ["apps/*"]}, "bin": "cli.js"}`,
+		`{"name": "@scope/tool", "bin": {"tool": "bin/tool.js", "other": "bin/other.js"}}`,
+		`{"exports": {".": {"node": {"import": "./dist/index.mjs"}, "default": ["./a.js", "./b.js"]}}}`,
+		`{"exports": {"import": "./index.mjs", "require": "./index.cjs"}}`,
+		`{"overrides": {"foo": {".": "2", "bar": {"baz": "1"}}}, "resolutions": {"**/qux": "3"}, "pnpm": {"overrides": {"a>b": "1"}}}`,
+		`{"scripts": {"postinstall": "husky && patch-package", "prepare": "node-gyp rebuild", "test": "npm run test:unit", "test:unit": "vitest"}}`,
+		"\xef\xbb\xbf{\"name\": \"bom\"}",
+		`{"name": 1, "scripts": [], "dependencies": "x"}`,
+		`{"exports": [[[[[[[[]]]]]]]]}`,
+		`[`,
+		`{"packageManager": "pnpm@9.1.0 \nRUN id"}`,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pkg, err := ParsePackageJSON(data)
+		if err != nil {
+			return
+		}
+		// The version is written into a RUN instruction
+		if name, version := pkg.GetPackageManagerInfo(); strings.ContainsFunc(name+version, unicode.IsSpace) {
+			t.Errorf("packageManager %q parsed with whitespace: %q, %q", pkg.PackageManager, name, version)
+		}
+		pkg.ExportsEntry()
+		pkg.BinEntry()
+		pkg.IsMonorepo()
+		pkg.GetDependencyVersion("next")
+		pkg.DevEngines.Runtime.Find("node")
+		for _, pm := range []PackageManager{PackageManagerNPM, PackageManagerPNPM, PackageManagerYarn1, PackageManagerYarnBerry, PackageManagerBun} {
+			info := PackageManagerInfo{Name: pm}
+			overrides := DetectOverrides(pkg, info)
+			if len(overrides.Ignored) > 0 {
+				overrides.IgnoredMessage(info)
+			}
+			DetectLifecycleScripts(pkg, info)
+			DetectTestCommand(pkg, info)
+		}
+	})
+}
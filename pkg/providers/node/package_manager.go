@@ -1,7 +1,13 @@
 package node
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/coollabsio/coolpack/pkg/semver"
 )
 
 // PackageManager represents a Node.js package manager
@@ -24,22 +30,52 @@ type PackageManagerInfo struct {
 // DetectPackageManager detects the package manager used by the project
 // Detection priority:
 // 1. packageManager field in package.json
-// 2. Lock files
-// 3. engines field in package.json
-// 4. Default to npm
+// 2. devEngines.packageManager in package.json
+// 3. Lock files
+// 4. engines field in package.json
+// 5. Default to npm
+//
+// Without an exact version in packageManager or devEngines, pnpm, yarn and
+// bun are pinned to a version from the package manager index (see
+// resolvePackageManagerVersion).
 func DetectPackageManager(ctx *app.Context, pkg *PackageJSON) PackageManagerInfo {
+	info, _ := detectPackageManager(ctx, pkg)
+	return info
+}
+
+// detectPackageManager is DetectPackageManager, also returning the decision for --explain
+func detectPackageManager(ctx *app.Context, pkg *PackageJSON) (PackageManagerInfo, app.Decision) {
 	info := PackageManagerInfo{
 		Name:    PackageManagerNPM,
 		Version: "",
 	}
+	decision := func(d app.Decision) (PackageManagerInfo, app.Decision) {
+		d.Field = "package_manager"
+		d.Value = string(info.Name)
+		if info.Version != "" {
+			d.Value += "@" + info.Version
+		}
+		return info, d
+	}
+	// pinned pins a package manager detected without a packageManager field
+	pinned := func(d app.Decision) (PackageManagerInfo, app.Decision) {
+		if version, reason := resolvePackageManagerVersion(ctx, pkg, info.Name); version != "" {
+			info.Version = version
+			d.Reason += "; " + reason
+		}
+		return decision(d)
+	}
 
 	// 1. Check packageManager field in package.json
 	if pmName, pmVersion := pkg.GetPackageManagerInfo(); pmName != "" {
+		fromField := func() (PackageManagerInfo, app.Decision) {
+			return decision(packageJSONDecision(ctx, fmt.Sprintf("packageManager %q in package.json", pkg.PackageManager), `"packageManager"`))
+		}
 		switch pmName {
 		case "pnpm":
 			info.Name = PackageManagerPNPM
 			info.Version = pmVersion
-			return info
+			return fromField()
 		case "yarn":
 			// Check if it's Yarn Berry (2+)
 			if isYarnBerry(pmVersion) {
@@ -48,73 +84,185 @@ func DetectPackageManager(ctx *app.Context, pkg *PackageJSON) PackageManagerInfo
 				info.Name = PackageManagerYarn1
 			}
 			info.Version = pmVersion
-			return info
+			return fromField()
 		case "bun":
 			info.Name = PackageManagerBun
 			info.Version = pmVersion
-			return info
+			return fromField()
 		case "npm":
 			info.Name = PackageManagerNPM
 			info.Version = pmVersion
-			return info
+			return fromField()
 		}
 	}
 
-	// 2. Check lock files
-	if ctx.HasFile("pnpm-lock.yaml") {
-		info.Name = PackageManagerPNPM
-		return info
+	// 2. Check devEngines.packageManager in package.json (the first entry coolpack knows)
+	if dev, ok := pkg.DevEngines.PackageManager.Find("pnpm", "yarn", "bun", "npm"); ok {
+		switch dev.Name {
+		case "pnpm":
+			info.Name = PackageManagerPNPM
+		case "yarn":
+			// A range decides the yarn family, else .yarnrc.yml does
+			if major, ok := semver.Major(dev.Version); ok {
+				info.Name = PackageManagerYarn1
+				if major >= 2 {
+					info.Name = PackageManagerYarnBerry
+				}
+			} else if ctx.HasFile(".yarnrc.yml") || ctx.HasFile(".yarnrc.yaml") {
+				info.Name = PackageManagerYarnBerry
+			} else {
+				info.Name = PackageManagerYarn1
+			}
+		case "bun":
+			info.Name = PackageManagerBun
+		case "npm":
+			info.Name = PackageManagerNPM
+		}
+		reason := "devEngines.packageManager " + dev.Name
+		if dev.Version != "" {
+			reason += fmt.Sprintf(" %q", dev.Version)
+		}
+		d := packageJSONDecision(ctx, reason+" in package.json", `"devEngines"`, `"packageManager"`)
+		if exactVersion.MatchString(dev.Version) {
+			info.Version = dev.Version
+			return decision(d)
+		}
+		return pinned(d)
 	}
 
-	if ctx.HasFile("bun.lockb") || ctx.HasFile("bun.lock") {
-		info.Name = PackageManagerBun
-		return info
+	// 3. Check lock files
+	lockFiles := []struct {
+		file string
+		name PackageManager
+	}{
+		{"pnpm-lock.yaml", PackageManagerPNPM},
+		{"bun.lockb", PackageManagerBun},
+		{"bun.lock", PackageManagerBun},
+		// .yarnrc.yml indicates Yarn 2+
+		{".yarnrc.yml", PackageManagerYarnBerry},
+		{".yarnrc.yaml", PackageManagerYarnBerry},
+		{"yarn.lock", PackageManagerYarn1},
+		{"package-lock.json", PackageManagerNPM},
+	}
+	for _, lock := range lockFiles {
+		if ctx.HasFile(lock.file) {
+			info.Name = lock.name
+			return pinned(app.Decision{Source: app.SourceFile, File: lock.file, Reason: lock.file + " found"})
+		}
 	}
 
-	// Check for Yarn Berry (.yarnrc.yml indicates Yarn 2+)
-	if ctx.HasFile(".yarnrc.yml") || ctx.HasFile(".yarnrc.yaml") {
-		info.Name = PackageManagerYarnBerry
-		return info
+	// 4. Check engines field
+	engines := []struct {
+		constraint string
+		key        string
+		name       PackageManager
+	}{
+		{pkg.Engines.PNPM, "pnpm", PackageManagerPNPM},
+		{pkg.Engines.Bun, "bun", PackageManagerBun},
+		{pkg.Engines.Yarn, "yarn", PackageManagerYarn1},
+	}
+	for _, engine := range engines {
+		if engine.constraint != "" {
+			info.Name = engine.name
+			return pinned(packageJSONDecision(ctx, fmt.Sprintf("engines.%s in package.json", engine.key), `"engines"`, `"`+engine.key+`"`))
+		}
 	}
 
-	if ctx.HasFile("yarn.lock") {
-		info.Name = PackageManagerYarn1
-		return info
+	// 5. Default to npm
+	return decision(app.Decision{Source: app.SourceDefault, Reason: "no packageManager field or lockfile"})
+}
+
+// isYarnBerry checks if the version indicates Yarn 2+
+func isYarnBerry(version string) bool {
+	major, ok := semver.Major(version)
+	return ok && major >= 2
+}
+
+var (
+	yarnReleaseFile = regexp.MustCompile(`yarn-(\d+\.\d+\.\d+(?:-[0-9A-Za-z.]+)?)\.c?js$`)
+	exactVersion    = regexp.MustCompile(`^\d+\.\d+\.\d+(?:-[0-9A-Za-z.]+)?$`)
+)
+
+// resolvePackageManagerVersion pins pnpm, yarn and bun to the newest version
+// in the package manager index that fits what the project says about it: the
+// release in .yarnrc.yml's yarnPath, the lockfile format, and the
+// devEngines.packageManager and engines ranges in package.json. Installs are
+// then reproducible instead of using whatever corepack or the base image
+// ships. npm comes with Node.js and isn't pinned. Returns "" when no indexed
+// version fits.
+func resolvePackageManagerVersion(ctx *app.Context, pkg *PackageJSON, name PackageManager) (version, reason string) {
+	var key, engine string
+	switch name {
+	case PackageManagerPNPM:
+		key, engine = "pnpm", pkg.Engines.PNPM
+	case PackageManagerYarn1, PackageManagerYarnBerry:
+		key, engine = "yarn", pkg.Engines.Yarn
+	case PackageManagerBun:
+		key, engine = "bun", pkg.Engines.Bun
+	default:
+		return "", ""
 	}
 
-	if ctx.HasFile("package-lock.json") {
-		info.Name = PackageManagerNPM
-		return info
+	// Yarn 2+ runs the release checked in at yarnPath, whatever else is installed
+	if name == PackageManagerYarnBerry {
+		for _, file := range []string{".yarnrc.yml", ".yarnrc.yaml"} {
+			var rc struct {
+				YarnPath string `yaml:"yarnPath"`
+			}
+			if ctx.HasFile(file) && ctx.ReadYAML(file, &rc) == nil {
+				if m := yarnReleaseFile.FindStringSubmatch(rc.YarnPath); m != nil {
+					return m[1], fmt.Sprintf("version %s from yarnPath in %s", m[1], file)
+				}
+			}
+		}
 	}
 
-	// 3. Check engines field
-	if pkg.Engines.PNPM != "" {
-		info.Name = PackageManagerPNPM
-		return info
+	// Ranges the version must be in, with what they come from
+	var ranges, sources []string
+	switch name {
+	case PackageManagerYarn1:
+		ranges = append(ranges, "1")
+	case PackageManagerYarnBerry:
+		ranges = append(ranges, ">=2")
 	}
-	if pkg.Engines.Bun != "" {
-		info.Name = PackageManagerBun
-		return info
+	lockfile := ""
+	switch name {
+	case PackageManagerPNPM:
+		lockfile = "pnpm-lock.yaml"
+	case PackageManagerYarnBerry:
+		// Yarn 2+ migrates a Yarn 1 lockfile, which says nothing about its version
+		lockfile = "yarn.lock"
 	}
-	if pkg.Engines.Yarn != "" {
-		info.Name = PackageManagerYarn1
-		return info
+	if format, ok := readLockfileFormat(ctx, lockfile); ok && format.Writers != "" && format.Version != "v1" {
+		ranges = append(ranges, format.Writers)
+		sources = append(sources, "lockfile format")
 	}
-
-	// 4. Default to npm
-	return info
-}
-
-// isYarnBerry checks if the version indicates Yarn 2+
-func isYarnBerry(version string) bool {
-	if version == "" {
-		return false
+	if dev, ok := pkg.DevEngines.PackageManager.Find(key); ok && dev.Version != "" {
+		ranges = append(ranges, dev.Version)
+		sources = append(sources, "devEngines.packageManager")
+	}
+	if engine != "" {
+		ranges = append(ranges, engine)
+		sources = append(sources, "engines."+key)
 	}
-	// Yarn 2+ starts with 2., 3., 4., etc.
-	if len(version) > 0 && version[0] >= '2' && version[0] <= '9' {
-		return true
+
+	for _, candidate := range data.Load().PackageManagers[key] {
+		fits := true
+		for _, r := range ranges {
+			if ok, valid := semver.Satisfies(candidate, r); valid && !ok {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			reason = fmt.Sprintf("version %s from coolpack's package manager index", candidate)
+			if len(sources) > 0 {
+				reason += fmt.Sprintf(" (newest matching the %s)", strings.Join(sources, " and "))
+			}
+			return candidate, reason
+		}
 	}
-	return false
+	return "", ""
 }
 
 // GetInstallCommand returns the install command for the package manager
@@ -133,6 +281,24 @@ func (pm PackageManagerInfo) GetInstallCommand() string {
 	}
 }
 
+// GetUnfrozenInstallCommand returns the install command for a project without
+// the package manager's lockfile. pnpm and Yarn 2+ freeze the lockfile by
+// default under CI=true, so they opt out explicitly.
+func (pm PackageManagerInfo) GetUnfrozenInstallCommand() string {
+	switch pm.Name {
+	case PackageManagerPNPM:
+		return "pnpm install --no-frozen-lockfile"
+	case PackageManagerYarnBerry:
+		return "yarn install --no-immutable"
+	case PackageManagerYarn1:
+		return "yarn install"
+	case PackageManagerBun:
+		return "bun install"
+	default:
+		return "npm install"
+	}
+}
+
 // GetRunCommand returns the run command prefix for the package manager
 func (pm PackageManagerInfo) GetRunCommand() string {
 	switch pm.Name {
@@ -147,6 +313,20 @@ func (pm PackageManagerInfo) GetRunCommand() string {
 	}
 }
 
+// GetExecCommand returns the command used to run a package binary (e.g., npx)
+func (pm PackageManagerInfo) GetExecCommand() string {
+	switch pm.Name {
+	case PackageManagerPNPM:
+		return "pnpm exec"
+	case PackageManagerYarnBerry, PackageManagerYarn1:
+		return "yarn"
+	case PackageManagerBun:
+		return "bunx"
+	default:
+		return "npx"
+	}
+}
+
 // GetLockFile returns the lock file name for the package manager
 func (pm PackageManagerInfo) GetLockFile() string {
 	switch pm.Name {
@@ -0,0 +1,256 @@
+package node
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// DefaultServerPort is used when a server app doesn't reveal its port
+const DefaultServerPort = 3000
+
+// StaticServerPort is the port the static file server (Caddy/nginx) listens on
+const StaticServerPort = 80
+
+// maxPortResolveDepth limits identifier resolution (const a = b; const b = 3000)
+const maxPortResolveDepth = 5
+
+// entryFileCandidates are common server entry points checked for listen() calls
+var entryFileCandidates = []string{
+	"server.js", "server.ts", "server.mjs",
+	"index.js", "index.ts", "index.mjs",
+	"app.js", "app.ts", "app.mjs",
+	"main.js", "main.ts",
+	"src/server.ts", "src/server.js",
+	"src/index.ts", "src/index.js",
+	"src/main.ts", "src/main.js",
+	"src/app.ts", "src/app.js",
+	"bin/www",
+}
+
+// PortInfo contains the detected listening port of the application
+type PortInfo struct {
+	// Port is the port the application listens on
+	Port int
+	// File is the entry file the port was found in, empty for framework defaults
+	File string
+	// Line is the line of the listen() call in File
+	Line int
+	// FromEnv is true when the application reads PORT from the environment
+	FromEnv bool
+}
+
+// DetectPort determines the port the application listens on by scanning entry files
+// for listen() calls and falling back to framework defaults
+func DetectPort(ctx *app.Context, pkg *PackageJSON, fw FrameworkInfo) PortInfo {
+	if fw.OutputType == OutputTypeStatic {
+		return PortInfo{Port: StaticServerPort}
+	}
+
+	// Meta-frameworks ship their own server; their default port is authoritative
+	if port := fw.GetDefaultPort(); port != 0 && !isBackendFramework(fw.Name) {
+		return PortInfo{Port: port, FromEnv: true}
+	}
+
+	parser := acquireConfigParser()
+	defer parser.Release()
+	for _, file := range portEntryFiles(pkg) {
+		if !ctx.HasFile(file) {
+			continue
+		}
+		root, data, ok := parseConfigFile(ctx, parser, file)
+		if !ok {
+			continue
+		}
+
+		if info, ok := findListenPort(root, data); ok {
+			info.File = file
+			if info.Port == 0 {
+				// Port comes only from the environment, use the framework default
+				info.Port = fw.GetDefaultPort()
+				if info.Port == 0 {
+					info.Port = DefaultServerPort
+				}
+			}
+			return info
+		}
+	}
+
+	if port := fw.GetDefaultPort(); port != 0 {
+		return PortInfo{Port: port, FromEnv: true}
+	}
+
+	return PortInfo{Port: DefaultServerPort}
+}
+
+// portEntryFiles returns the files to scan for listen() calls, most specific first
+func portEntryFiles(pkg *PackageJSON) []string {
+	var files []string
+
+	// File started by the start script (e.g., "node server.js")
+	for _, field := range strings.Fields(pkg.GetScript("start")) {
+		if hasExtension(field, jsSourceExtensions) {
+			files = appendUnique(files, strings.TrimPrefix(field, "./"))
+		}
+	}
+
+	if pkg.Main != "" {
+		files = appendUnique(files, strings.TrimPrefix(pkg.Main, "./"))
+	}
+
+	for _, f := range entryFileCandidates {
+		files = appendUnique(files, f)
+	}
+
+	return files
+}
+
+// findListenPort finds the first .listen(...) call and resolves its port argument
+func findListenPort(root *sitter.Node, source []byte) (PortInfo, bool) {
+	var found *sitter.Node
+
+	walkNodes(root, func(n *sitter.Node) bool {
+		if n.Type() != "call_expression" {
+			return true
+		}
+		callee := n.ChildByFieldName("function")
+		if callee == nil || callee.Type() != "member_expression" {
+			return true
+		}
+		property := callee.ChildByFieldName("property")
+		if property == nil || getNodeText(property, source) != "listen" {
+			return true
+		}
+		args := n.ChildByFieldName("arguments")
+		if args != nil && args.NamedChildCount() > 0 {
+			found = args.NamedChild(0)
+			return false
+		}
+		return true
+	})
+
+	if found == nil {
+		return PortInfo{}, false
+	}
+
+	var info PortInfo
+	port, ok := resolvePort(root, found, source, &info, 0)
+	if !ok && !info.FromEnv {
+		return PortInfo{}, false
+	}
+	info.Port = port
+	info.Line = int(found.StartPoint().Row) + 1
+	return info, true
+}
+
+// resolvePort evaluates a port expression such as 3000, "3000", process.env.PORT || 8080,
+// parseInt(process.env.PORT ?? "3000"), { port: 3000 } or an identifier bound to one of them
+func resolvePort(root, node *sitter.Node, source []byte, info *PortInfo, depth int) (int, bool) {
+	if node == nil || depth > maxPortResolveDepth {
+		return 0, false
+	}
+
+	switch node.Type() {
+	case "number":
+		return parsePort(getNodeText(node, source))
+	case "string":
+		return parsePort(trimQuotes(getNodeText(node, source)))
+	case "parenthesized_expression", "as_expression", "non_null_expression":
+		if node.NamedChildCount() > 0 {
+			return resolvePort(root, node.NamedChild(0), source, info, depth+1)
+		}
+	case "member_expression":
+		if strings.HasPrefix(getNodeText(node, source), "process.env.") {
+			info.FromEnv = true
+		}
+	case "binary_expression":
+		// process.env.PORT || 3000 / process.env.PORT ?? 3000
+		left, leftOK := resolvePort(root, node.ChildByFieldName("left"), source, info, depth+1)
+		right, rightOK := resolvePort(root, node.ChildByFieldName("right"), source, info, depth+1)
+		if leftOK {
+			return left, true
+		}
+		return right, rightOK
+	case "call_expression":
+		// parseInt(x, 10), Number(x), normalizePort(x)
+		args := node.ChildByFieldName("arguments")
+		if args != nil && args.NamedChildCount() > 0 {
+			return resolvePort(root, args.NamedChild(0), source, info, depth+1)
+		}
+	case "object":
+		// app.listen({ port: 3000, host: '0.0.0.0' })
+		if value := findPropertyObjectNode(node, source, "port", 0); value != nil {
+			return resolvePort(root, value, source, info, depth+1)
+		}
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			child := node.NamedChild(i)
+			if child.Type() == "shorthand_property_identifier" && strings.EqualFold(getNodeText(child, source), "port") {
+				return resolvePort(root, findVariableValue(root, source, getNodeText(child, source)), source, info, depth+1)
+			}
+		}
+	case "identifier":
+		return resolvePort(root, findVariableValue(root, source, getNodeText(node, source)), source, info, depth+1)
+	}
+
+	return 0, false
+}
+
+// findVariableValue returns the initializer of the variable with the given name
+func findVariableValue(root *sitter.Node, source []byte, name string) *sitter.Node {
+	var value *sitter.Node
+
+	walkNodes(root, func(n *sitter.Node) bool {
+		if n.Type() != "variable_declarator" {
+			return true
+		}
+		nameNode := n.ChildByFieldName("name")
+		if nameNode != nil && getNodeText(nameNode, source) == name {
+			value = n.ChildByFieldName("value")
+			return false
+		}
+		return true
+	})
+
+	return value
+}
+
+// walkNodes visits nodes depth-first until fn returns false.
+// Subtrees deeper than maxNodeDepth are skipped.
+func walkNodes(node *sitter.Node, fn func(*sitter.Node) bool) bool {
+	return walkNodesDepth(node, fn, 0)
+}
+
+func walkNodesDepth(node *sitter.Node, fn func(*sitter.Node) bool, depth int) bool {
+	if node == nil || depth > maxNodeDepth {
+		return true
+	}
+	if !fn(node) {
+		return false
+	}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if !walkNodesDepth(node.Child(i), fn, depth+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePort parses a port number, rejecting values outside the valid range
+func parsePort(s string) (int, bool) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, false
+	}
+	return port, true
+}
+
+// isBackendFramework checks if the framework is a backend framework whose port is set in code
+func isBackendFramework(fw Framework) bool {
+	switch fw {
+	case FrameworkExpress, FrameworkFastify, FrameworkNestJS, FrameworkAdonisJS, FrameworkNone:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package node
+
+import "github.com/coollabsio/coolpack/pkg/app"
+
+// PrebuiltBinaries lists packages that ship a native binary per OS/architecture,
+// usually as platform-specific optional dependencies (e.g., @img/sharp-linux-arm64).
+// Lockfiles generated on one platform sometimes miss the optional dependencies for
+// others, which only surfaces when the package is loaded on that platform.
+var PrebuiltBinaries = []app.PrebuiltBinary{
+	{Package: "sharp", Check: "require('sharp')"},
+	{Package: "esbuild", Check: "require('esbuild').transformSync('')"},
+	{Package: "@swc/core", Check: "require('@swc/core').transformSync('')"},
+	{Package: "lightningcss", Check: "require('lightningcss')"},
+	{Package: "@parcel/watcher", Check: "require('@parcel/watcher')"},
+	{Package: "@tailwindcss/oxide", Check: "require('@tailwindcss/oxide')"},
+	{Package: "better-sqlite3", Check: "require('better-sqlite3')"},
+	{Package: "bcrypt", Check: "require('bcrypt')"},
+	{Package: "@node-rs/argon2", Check: "require('@node-rs/argon2')"},
+	{Package: "@node-rs/bcrypt", Check: "require('@node-rs/bcrypt')"},
+}
+
+// DetectPrebuiltBinaries returns the direct dependencies that ship per-architecture binaries
+func DetectPrebuiltBinaries(pkg *PackageJSON) []app.PrebuiltBinary {
+	var detected []app.PrebuiltBinary
+
+	for _, bin := range PrebuiltBinaries {
+		if pkg.HasDependency(bin.Package) {
+			detected = append(detected, bin)
+		}
+	}
+
+	return detected
+}
@@ -0,0 +1,115 @@
+package node
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// PruneInfo describes how devDependencies are removed before node_modules is
+// copied into the runtime stage
+type PruneInfo struct {
+	// Command removes devDependencies from node_modules (empty when pruning is skipped)
+	Command string
+	// SkipReason explains why pruning was skipped
+	SkipReason string
+}
+
+// DetectProductionPrune determines the command that removes devDependencies after
+// the build, so the runtime image doesn't ship compilers and test frameworks.
+// Pruning is skipped when the start command runs a binary from devDependencies.
+func DetectProductionPrune(ctx *app.Context, pkg *PackageJSON, pm PackageManagerInfo, fw FrameworkInfo, startCmd string) PruneInfo {
+	if fw.OutputType == OutputTypeStatic {
+		return PruneInfo{}
+	}
+	if len(pkg.DevDependencies) == 0 {
+		return PruneInfo{}
+	}
+
+	// The start command (or the start script it runs) must not need a dev-only binary
+	commands := []string{startCmd, pkg.GetScript("start")}
+	devDeps := make([]string, 0, len(pkg.DevDependencies))
+	for name := range pkg.DevDependencies {
+		devDeps = append(devDeps, name)
+	}
+	sort.Strings(devDeps)
+	for _, name := range devDeps {
+		if _, ok := pkg.Dependencies[name]; ok {
+			continue
+		}
+		for _, bin := range devBinaries(ctx, name) {
+			for _, cmd := range commands {
+				if commandRunsBinary(cmd, bin) {
+					return PruneInfo{SkipReason: "start command uses " + name + " from devDependencies"}
+				}
+			}
+		}
+	}
+
+	return PruneInfo{Command: pm.GetPruneCommand(pkg)}
+}
+
+// devBinaries returns the commands a devDependency installs: the names in the
+// bin field of its package.json in node_modules, else (not installed, or no
+// bin field) its unscoped name. Type packages (@types/*) install none.
+func devBinaries(ctx *app.Context, name string) []string {
+	if strings.HasPrefix(name, "@types/") {
+		return nil
+	}
+	var dep PackageJSON
+	if err := ctx.ReadJSON(path.Join("node_modules", name, "package.json"), &dep); err != nil || dep.Bin == nil {
+		return []string{unscopedName(name)}
+	}
+	bins := make([]string, 0, len(dep.Bin))
+	for bin := range dep.Bin {
+		if bin == "" {
+			// A bin given as a string is named after the package
+			bin = unscopedName(name)
+		}
+		bins = append(bins, bin)
+	}
+	sort.Strings(bins)
+	return bins
+}
+
+// unscopedName returns a package name without its @scope/ prefix
+func unscopedName(name string) string {
+	if idx := strings.LastIndex(name, "/"); idx != -1 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+// GetPruneCommand returns the command that removes devDependencies from an installed node_modules
+func (pm PackageManagerInfo) GetPruneCommand(pkg *PackageJSON) string {
+	switch pm.Name {
+	case PackageManagerPNPM:
+		return "pnpm prune --prod"
+	case PackageManagerYarnBerry:
+		if pkg.IsMonorepo() {
+			return "yarn workspaces focus --all --production"
+		}
+		return "yarn workspaces focus --production"
+	case PackageManagerYarn1:
+		// yarn v1 has no prune; a production install removes devDependencies
+		return "yarn install --production --frozen-lockfile --ignore-scripts --prefer-offline"
+	case PackageManagerBun:
+		// bun has no prune; reinstall production dependencies only
+		return "rm -rf node_modules && bun install --production --frozen-lockfile"
+	default:
+		return "npm prune --omit=dev"
+	}
+}
+
+// commandRunsBinary checks if a shell command invokes the given binary
+func commandRunsBinary(cmd, bin string) bool {
+	for _, field := range strings.Fields(cmd) {
+		if filepath.Base(field) == bin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// TestDetectProductionPrune checks which devDependencies count as binaries of
+// the start command: the names in their installed bin field, else their
+// unscoped name, and never a type package such as @types/node for node.
+func TestDetectProductionPrune(t *testing.T) {
+	installed := map[string]string{
+		"@acme/tools": `{"name": "@acme/tools", "bin": {"acme-serve": "serve.js"}}`,
+		"@acme/cli":   `{"name": "@acme/cli", "bin": "cli.js"}`,
+		"typescript":  `{"name": "typescript", "bin": {"tsc": "bin/tsc", "tsserver": "bin/tsserver"}}`,
+	}
+	dir := t.TempDir()
+	for name, content := range installed {
+		file := filepath.Join(dir, "node_modules", filepath.FromSlash(name), "package.json")
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		devDeps  []string
+		startCmd string
+		pruned   bool
+	}{
+		{"types package", []string{"@types/node"}, "node dist/index.js", true},
+		{"not installed", []string{"tsx"}, "tsx src/index.ts", false},
+		{"scoped not installed", []string{"@nestjs/cli"}, "node_modules/.bin/cli start", false},
+		{"installed bin object", []string{"@acme/tools"}, "acme-serve --port 3000", false},
+		{"installed bin object unscoped name", []string{"@acme/tools"}, "tools start", true},
+		{"installed bin string", []string{"@acme/cli"}, "cli start", false},
+		{"installed bin other than name", []string{"typescript"}, "tsserver", false},
+		{"installed bin unused", []string{"typescript"}, "node dist/index.js", true},
+	}
+	pm := PackageManagerInfo{Name: PackageManagerNPM}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := &PackageJSON{DevDependencies: map[string]string{}}
+			for _, dep := range tt.devDeps {
+				pkg.DevDependencies[dep] = "*"
+			}
+			prune := DetectProductionPrune(app.NewContext(dir), pkg, pm, FrameworkInfo{}, tt.startCmd)
+			if pruned := prune.Command != ""; pruned != tt.pruned {
+				t.Errorf("start command %q with %v: pruned = %v (skip reason %q), want %v", tt.startCmd, tt.devDeps, pruned, prune.SkipReason, tt.pruned)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package node
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// RebuildSchedule is a hint that a static site should be rebuilt periodically
+// because its content is time-sensitive
+type RebuildSchedule struct {
+	// Cron is the suggested rebuild schedule in cron syntax
+	Cron string
+	// Reason explains what makes the content time-sensitive
+	Reason string
+}
+
+var (
+	// export const revalidate = 60 (App Router) or revalidate: 60 (getStaticProps)
+	nextRevalidatePattern = regexp.MustCompile(`(?:export\s+const\s+revalidate\s*=|revalidate\s*:)\s*(\d+)`)
+	// routeRules: { '/blog/**': { isr: 3600 } } or { swr: 3600 }
+	nuxtISRPattern = regexp.MustCompile(`\b(?:isr|swr)\s*:\s*(\d+)`)
+	// date: 2025-01-31 / publishDate: "2025-01-31" / pubDate: 2025-01-31T10:00:00Z
+	frontmatterDatePattern = regexp.MustCompile(`(?m)^(?:date|publishDate|pubDate|publishedAt)\s*:\s*["']?(\d{4}-\d{2}-\d{2})`)
+)
+
+// contentDirs are the conventional locations of markdown content
+var contentDirs = []string{"content", "src/content", "posts", "_posts", "blog", "src/posts"}
+
+// DetectRebuildSchedule returns a rebuild hint for static sites with time-sensitive content,
+// or nil when the site doesn't need scheduled rebuilds
+func DetectRebuildSchedule(ctx *app.Context, fw FrameworkInfo) *RebuildSchedule {
+	if fw.OutputType != OutputTypeStatic {
+		return nil
+	}
+
+	// ISR-style revalidation is lost in static exports, so rebuild on the same interval
+	if seconds := findRevalidateInterval(ctx, fw); seconds > 0 {
+		return &RebuildSchedule{
+			Cron:   cronForInterval(seconds),
+			Reason: fmt.Sprintf("revalidation every %ds is configured, but static output is only refreshed by rebuilding", seconds),
+		}
+	}
+
+	// Posts dated in the future are only published once the site is rebuilt
+	if file := findScheduledContent(ctx, time.Now()); file != "" {
+		return &RebuildSchedule{
+			Cron:   "0 0 * * *",
+			Reason: fmt.Sprintf("scheduled content found (%s); a daily rebuild publishes it on time", file),
+		}
+	}
+
+	return nil
+}
+
+// findRevalidateInterval returns the shortest revalidation interval (in seconds)
+// configured for the framework, or 0 if none is found
+func findRevalidateInterval(ctx *app.Context, fw FrameworkInfo) int {
+	shortest := 0
+	track := func(data []byte, re *regexp.Regexp) {
+		for _, m := range re.FindAllSubmatch(data, -1) {
+			if n, err := strconv.Atoi(string(m[1])); err == nil && n > 0 && (shortest == 0 || n < shortest) {
+				shortest = n
+			}
+		}
+	}
+
+	switch fw.Name {
+	case FrameworkNextJS:
+		dirs := []string{"app", "pages", "src/app", "src/pages"}
+		scanSourceFiles(ctx, dirs, jsSourceExtensions, func(rel string, data []byte) bool {
+			track(data, nextRevalidatePattern)
+			return true
+		})
+	case FrameworkNuxt:
+		for _, configFile := range nuxtConfigs {
+			if data, err := ctx.ReadFile(configFile); err == nil {
+				track(data, nuxtISRPattern)
+			}
+		}
+	}
+
+	return shortest
+}
+
+// findScheduledContent returns the first markdown file whose frontmatter date is in the future
+func findScheduledContent(ctx *app.Context, now time.Time) string {
+	found := ""
+	today := now.Format("2006-01-02")
+
+	scanSourceFiles(ctx, contentDirs, []string{".md", ".mdx", ".markdoc"}, func(rel string, data []byte) bool {
+		if m := frontmatterDatePattern.FindSubmatch(data); m != nil && string(m[1]) > today {
+			found = rel
+			return false
+		}
+		return true
+	})
+
+	return found
+}
+
+// cronForInterval converts a revalidation interval into a cron schedule
+// Intervals are rounded to at least 15 minutes to avoid constant rebuilds
+func cronForInterval(seconds int) string {
+	minutes := (seconds + 59) / 60
+	switch {
+	case minutes <= 15:
+		return "*/15 * * * *"
+	case minutes < 60:
+		return fmt.Sprintf("*/%d * * * *", minutes)
+	case minutes < 24*60:
+		hours := minutes / 60
+		if hours == 1 {
+			return "0 * * * *"
+		}
+		return fmt.Sprintf("0 */%d * * *", hours)
+	default:
+		return "0 0 * * *"
+	}
+}
@@ -0,0 +1,183 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// NpmRegistry is a package registry configured in .npmrc or .yarnrc.yml
+type NpmRegistry struct {
+	// Scope is the package scope served by the registry (e.g., "@company"); empty for the default registry
+	Scope string
+	// URL is the registry URL
+	URL string
+	// AuthEnv is the variable holding the registry token, if auth is configured with ${VAR}
+	AuthEnv string
+	// File is the config file the registry was read from
+	File string
+}
+
+// publicRegistryHosts are registries that don't need credentials
+var publicRegistryHosts = []string{"registry.npmjs.org", "registry.yarnpkg.com"}
+
+// DetectNpmRegistries reads custom registries and their ${VAR} auth placeholders
+// from .npmrc and .yarnrc.yml. Public npm/yarn registries without auth are skipped.
+func DetectNpmRegistries(ctx *app.Context) []NpmRegistry {
+	var registries []NpmRegistry
+
+	if data, err := ctx.ReadFile(".npmrc"); err == nil {
+		registries = append(registries, parseNpmrcRegistries(data)...)
+	}
+	for _, file := range []string{".yarnrc.yml", ".yarnrc.yaml"} {
+		if data, err := ctx.ReadFile(file); err == nil {
+			registries = append(registries, parseYarnrcRegistries(data, file)...)
+			break
+		}
+	}
+
+	return registries
+}
+
+// parseNpmrcRegistries parses registry=, @scope:registry= and //host/:_authToken= lines
+func parseNpmrcRegistries(data []byte) []NpmRegistry {
+	var registries []NpmRegistry
+	authEnv := make(map[string]string) // registry host+path -> auth variable
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		switch {
+		case key == "registry":
+			registries = append(registries, NpmRegistry{URL: value, File: ".npmrc"})
+		case strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry"):
+			registries = append(registries, NpmRegistry{Scope: strings.TrimSuffix(key, ":registry"), URL: value, File: ".npmrc"})
+		case strings.HasPrefix(key, "//") && (strings.HasSuffix(key, ":_authToken") || strings.HasSuffix(key, ":_auth") || strings.HasSuffix(key, ":_password")):
+			if match := npmrcEnvPattern.FindStringSubmatch(value); match != nil {
+				host := key[2:strings.LastIndex(key, ":")]
+				authEnv[strings.TrimSuffix(host, "/")] = match[1]
+			}
+		}
+	}
+
+	// Registries without a registry= line but with auth (e.g., GitHub Packages via @scope)
+	for i := range registries {
+		host := registryHost(registries[i].URL)
+		if host == "" {
+			continue
+		}
+		for prefix, env := range authEnv {
+			if host == prefix || strings.HasPrefix(host, prefix+"/") || strings.HasPrefix(prefix, host) {
+				registries[i].AuthEnv = env
+			}
+		}
+	}
+
+	return filterPublicRegistries(registries)
+}
+
+// parseYarnrcRegistries parses npmRegistryServer/npmAuthToken at the top level and
+// under npmScopes.<scope> in .yarnrc.yml (indentation based, no full YAML support)
+func parseYarnrcRegistries(data []byte, file string) []NpmRegistry {
+	var registries []NpmRegistry
+	var current *NpmRegistry
+	inScopes := false
+	scopeIndent := -1
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		key, value, _ := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		if indent == 0 {
+			inScopes = key == "npmScopes"
+			current = nil
+			scopeIndent = -1
+			switch key {
+			case "npmRegistryServer":
+				registries = append(registries, NpmRegistry{URL: value, File: file})
+			case "npmAuthToken":
+				if match := npmrcEnvPattern.FindStringSubmatch(value); match != nil {
+					registries = append(registries, NpmRegistry{URL: "https://registry.yarnpkg.com", AuthEnv: match[1], File: file})
+				}
+			}
+			continue
+		}
+		if !inScopes {
+			continue
+		}
+
+		// Scope entries are the first level below npmScopes
+		if scopeIndent == -1 || indent <= scopeIndent {
+			scopeIndent = indent
+			registries = append(registries, NpmRegistry{Scope: "@" + strings.TrimPrefix(key, "@"), File: file})
+			current = &registries[len(registries)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch key {
+		case "npmRegistryServer":
+			current.URL = value
+		case "npmAuthToken":
+			if match := npmrcEnvPattern.FindStringSubmatch(value); match != nil {
+				current.AuthEnv = match[1]
+			}
+		}
+	}
+
+	return filterPublicRegistries(registries)
+}
+
+// filterPublicRegistries drops public registries that don't use credentials
+func filterPublicRegistries(registries []NpmRegistry) []NpmRegistry {
+	result := registries[:0]
+	for _, r := range registries {
+		if r.AuthEnv == "" && isPublicRegistry(r.URL) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// isPublicRegistry checks if a registry URL is the public npm or yarn registry
+func isPublicRegistry(url string) bool {
+	host := registryHost(url)
+	if host == "" {
+		return true
+	}
+	for _, public := range publicRegistryHosts {
+		if host == public || strings.HasPrefix(host, public+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// registryHost strips the scheme and trailing slash from a registry URL
+func registryHost(url string) string {
+	url = strings.TrimPrefix(url, "https://")
+	url = strings.TrimPrefix(url, "http://")
+	return strings.TrimSuffix(url, "/")
+}
@@ -0,0 +1,558 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// netlifyRedirectsFiles are the locations of Netlify's _redirects file that end up in the
+// publish directory (public/ and static/ are copied to the output by most frameworks)
+var netlifyRedirectsFiles = []string{"_redirects", "public/_redirects", "static/_redirects"}
+
+// netlifyHeadersFiles are the locations of Netlify's _headers file
+var netlifyHeadersFiles = []string{"_headers", "public/_headers", "static/_headers"}
+
+var placeholderPattern = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// vercelPlaceholderPattern also consumes path-to-regexp modifiers (:path*, :id?)
+var vercelPlaceholderPattern = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)[*+?]?`)
+
+// RoutingInfo contains the routing rules found in hosting config files
+type RoutingInfo struct {
+	// Routing holds the translated rules
+	Routing app.Routing
+	// Files are the config files the rules were read from
+	Files []string
+	// SPAFallback is true when a catch-all rewrite to /index.html was found
+	SPAFallback bool
+	// Unsupported lists rules that can't be served by a static file server
+	Unsupported []UnsupportedRoute
+}
+
+// UnsupportedRoute is a rule that could not be translated
+type UnsupportedRoute struct {
+	// File is the config file containing the rule
+	File string
+	// Source is the rule's source pattern
+	Source string
+	// Reason explains why the rule was skipped
+	Reason string
+}
+
+// spaAssetExtensions never fall back to index.html, so missing bundles return 404
+// instead of HTML ("Unexpected token '<'" errors after deploys)
+var spaAssetExtensions = []string{".js", ".mjs", ".css", ".map"}
+
+// GetHashedAssetPrefixes returns the directories a framework's bundler emits
+// content-hashed (immutable) assets to
+func GetHashedAssetPrefixes(fw FrameworkInfo) []string {
+	switch fw.Name {
+	case FrameworkCRA, FrameworkGatsby:
+		return []string{"/static/"}
+	case FrameworkAngular:
+		// Angular emits hashed bundles to the output root and hashed url() assets to media/
+		return []string{"/media/"}
+	case FrameworkNuxt:
+		return []string{"/_nuxt/"}
+	case FrameworkSvelteKit:
+		return []string{"/_app/immutable/"}
+	case FrameworkAstro:
+		return []string{"/_astro/"}
+	case FrameworkNextJS:
+		return []string{"/_next/static/"}
+	case FrameworkExpo:
+		return []string{"/_expo/static/", "/assets/"}
+	case FrameworkSolidStart:
+		return []string{"/_build/assets/"}
+	case FrameworkEleventy:
+		// Eleventy doesn't fingerprint assets
+		return nil
+	default:
+		// Vite and Vite-based frameworks (React Router, TanStack) use assets/
+		return []string{"/assets/"}
+	}
+}
+
+// GetSPAFallback returns the history fallback rule for a framework's SPA build,
+// excluding the directories its bundler emits hashed assets to
+func GetSPAFallback(fw FrameworkInfo) *app.Fallback {
+	return &app.Fallback{
+		Document:          "/index.html",
+		ExcludePrefixes:   GetHashedAssetPrefixes(fw),
+		ExcludeExtensions: spaAssetExtensions,
+	}
+}
+
+// GetCachePolicy returns the cache policy for a framework's static output
+func GetCachePolicy(fw FrameworkInfo) *app.CachePolicy {
+	return &app.CachePolicy{
+		ImmutablePrefixes: GetHashedAssetPrefixes(fw),
+		Revalidate:        true,
+	}
+}
+
+// DetectRouting reads redirects, rewrites and headers from Netlify (_redirects, _headers,
+// netlify.toml) and Vercel (vercel.json) config files
+// Returns nil when no routing rules are found
+func DetectRouting(ctx *app.Context) *RoutingInfo {
+	info := &RoutingInfo{}
+
+	// Netlify processes _redirects before netlify.toml
+	for _, file := range netlifyRedirectsFiles {
+		if data, err := ctx.ReadFile(file); err == nil {
+			info.Files = append(info.Files, file)
+			info.parseNetlifyRedirects(file, data)
+			break
+		}
+	}
+	for _, file := range netlifyHeadersFiles {
+		if data, err := ctx.ReadFile(file); err == nil {
+			info.Files = append(info.Files, file)
+			info.parseNetlifyHeaders(data)
+			break
+		}
+	}
+	if data, err := ctx.ReadFile("netlify.toml"); err == nil {
+		info.Files = append(info.Files, "netlify.toml")
+		if err := info.parseNetlifyToml(data); err != nil {
+			logSkipped(ctx, "netlify.toml", err)
+		}
+	}
+	if data, err := ctx.ReadFile("vercel.json"); err == nil {
+		info.Files = append(info.Files, "vercel.json")
+		if err := info.parseVercelJSON(data); err != nil {
+			logSkipped(ctx, "vercel.json", err)
+		}
+	}
+
+	r := info.Routing
+	if len(r.Redirects) == 0 && len(r.Rewrites) == 0 && len(r.Headers) == 0 && !info.SPAFallback && len(info.Unsupported) == 0 {
+		return nil
+	}
+
+	return info
+}
+
+// parseNetlifyRedirects parses _redirects lines: "/from /to [status[!]] [conditions]"
+func (info *RoutingInfo) parseNetlifyRedirects(file string, data []byte) {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		status := 301
+		conditions := false
+		for _, field := range fields[2:] {
+			if code, err := strconv.Atoi(strings.TrimSuffix(field, "!")); err == nil {
+				status = code
+			} else {
+				conditions = true
+			}
+		}
+		if conditions {
+			info.unsupported(file, fields[0], "query, country, language or role conditions")
+			continue
+		}
+
+		info.addNetlifyRule(file, fields[0], fields[1], status)
+	}
+}
+
+// parseNetlifyHeaders parses _headers blocks: a path line followed by indented "Name: value" lines
+func (info *RoutingInfo) parseNetlifyHeaders(data []byte) {
+	var current *app.HeaderRule
+
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line == trimmed {
+			// Unindented line starts a new path block
+			info.addHeaderRule(current)
+			current = &app.HeaderRule{Source: trimmed, Match: netlifyPatternToRegex(trimmed, nil)}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if name, value, ok := strings.Cut(trimmed, ":"); ok {
+			current.Headers = append(current.Headers, app.Header{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+		}
+	}
+	info.addHeaderRule(current)
+}
+
+// netlifyConfig is the subset of netlify.toml used for routing
+type netlifyConfig struct {
+	Redirects []struct {
+		From       string                 `toml:"from"`
+		To         string                 `toml:"to"`
+		Status     int                    `toml:"status"`
+		Conditions map[string]interface{} `toml:"conditions"`
+		Query      map[string]interface{} `toml:"query"`
+	} `toml:"redirects"`
+	Headers []struct {
+		For    string            `toml:"for"`
+		Values map[string]string `toml:"values"`
+	} `toml:"headers"`
+}
+
+// parseNetlifyToml parses [[redirects]] and [[headers]] tables from netlify.toml
+func (info *RoutingInfo) parseNetlifyToml(data []byte) error {
+	var config netlifyConfig
+	if err := app.DecodeTOML("netlify.toml", data, &config); err != nil {
+		return err
+	}
+
+	for _, r := range config.Redirects {
+		if r.From == "" || r.To == "" {
+			continue
+		}
+		if len(r.Conditions) > 0 || len(r.Query) > 0 {
+			info.unsupported("netlify.toml", r.From, "query, country, language or role conditions")
+			continue
+		}
+		status := r.Status
+		if status == 0 {
+			status = 301
+		}
+		info.addNetlifyRule("netlify.toml", r.From, r.To, status)
+	}
+
+	for _, h := range config.Headers {
+		rule := &app.HeaderRule{Source: h.For, Match: netlifyPatternToRegex(h.For, nil)}
+		names := make([]string, 0, len(h.Values))
+		for name := range h.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			rule.Headers = append(rule.Headers, app.Header{Name: name, Value: h.Values[name]})
+		}
+		info.addHeaderRule(rule)
+	}
+	return nil
+}
+
+// addNetlifyRule translates a Netlify redirect/rewrite into a route rule
+func (info *RoutingInfo) addNetlifyRule(file, from, to string, status int) {
+	var names []string
+	rule := app.RouteRule{
+		Source: from,
+		Match:  netlifyPatternToRegex(from, &names),
+		Status: status,
+	}
+
+	// Replace :splat and :placeholders with the matching group references
+	rule.Destination = placeholderPattern.ReplaceAllStringFunc(to, func(m string) string {
+		for i, name := range names {
+			if name == m[1:] {
+				return fmt.Sprintf("$%d", i+1)
+			}
+		}
+		return m
+	})
+
+	info.addRule(file, rule)
+}
+
+// vercelConfig is the subset of vercel.json used for routing
+type vercelConfig struct {
+	Rewrites []struct {
+		Source      string          `json:"source"`
+		Destination string          `json:"destination"`
+		Has         json.RawMessage `json:"has"`
+		Missing     json.RawMessage `json:"missing"`
+	} `json:"rewrites"`
+	Redirects []struct {
+		Source      string          `json:"source"`
+		Destination string          `json:"destination"`
+		Permanent   *bool           `json:"permanent"`
+		StatusCode  int             `json:"statusCode"`
+		Has         json.RawMessage `json:"has"`
+		Missing     json.RawMessage `json:"missing"`
+	} `json:"redirects"`
+	Headers []struct {
+		Source  string `json:"source"`
+		Headers []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"headers"`
+		Has     json.RawMessage `json:"has"`
+		Missing json.RawMessage `json:"missing"`
+	} `json:"headers"`
+}
+
+// parseVercelJSON parses redirects, rewrites and headers from vercel.json
+func (info *RoutingInfo) parseVercelJSON(data []byte) error {
+	var config vercelConfig
+	if err := app.DecodeJSON("vercel.json", data, &config); err != nil {
+		return err
+	}
+
+	for _, r := range config.Redirects {
+		if hasConditions(r.Has, r.Missing) {
+			info.unsupported("vercel.json", r.Source, "has/missing conditions")
+			continue
+		}
+		// Vercel redirects are permanent (308) unless permanent is false (307)
+		status := 308
+		if r.Permanent != nil && !*r.Permanent {
+			status = 307
+		}
+		if r.StatusCode != 0 {
+			status = r.StatusCode
+		}
+		info.addVercelRule(r.Source, r.Destination, status)
+	}
+
+	for _, r := range config.Rewrites {
+		if hasConditions(r.Has, r.Missing) {
+			info.unsupported("vercel.json", r.Source, "has/missing conditions")
+			continue
+		}
+		info.addVercelRule(r.Source, r.Destination, 200)
+	}
+
+	for _, h := range config.Headers {
+		if hasConditions(h.Has, h.Missing) {
+			info.unsupported("vercel.json", h.Source, "has/missing conditions")
+			continue
+		}
+		match, _ := vercelPatternToRegex(h.Source)
+		rule := &app.HeaderRule{Source: h.Source, Match: match}
+		for _, header := range h.Headers {
+			rule.Headers = append(rule.Headers, app.Header{Name: header.Key, Value: header.Value})
+		}
+		info.addHeaderRule(rule)
+	}
+	return nil
+}
+
+// addVercelRule translates a Vercel redirect/rewrite into a route rule
+func (info *RoutingInfo) addVercelRule(source, destination string, status int) {
+	if source == "" || destination == "" {
+		return
+	}
+	match, names := vercelPatternToRegex(source)
+	rule := app.RouteRule{Source: source, Match: match, Status: status}
+
+	rule.Destination = vercelPlaceholderPattern.ReplaceAllStringFunc(destination, func(m string) string {
+		for i, name := range names {
+			if name == strings.TrimRight(m[1:], "*+?") {
+				return fmt.Sprintf("$%d", i+1)
+			}
+		}
+		return m
+	})
+
+	info.addRule("vercel.json", rule)
+}
+
+// addRule adds a redirect (3xx) or rewrite (200) rule, skipping rules a static server can't serve
+func (info *RoutingInfo) addRule(file string, rule app.RouteRule) {
+	external := strings.HasPrefix(rule.Destination, "http://") || strings.HasPrefix(rule.Destination, "https://")
+
+	switch {
+	case rule.Status == 200 && external:
+		info.unsupported(file, rule.Source, "proxying to an external URL")
+	case rule.Status == 200:
+		if rule.Destination == "/index.html" && isCatchAll(rule.Match) {
+			// Served by the SPA fallback
+			info.SPAFallback = true
+			return
+		}
+		rule.Status = 0
+		info.Routing.Rewrites = append(info.Routing.Rewrites, rule)
+	case rule.Status >= 300 && rule.Status < 400:
+		info.Routing.Redirects = append(info.Routing.Redirects, rule)
+	default:
+		info.unsupported(file, rule.Source, fmt.Sprintf("status %d", rule.Status))
+	}
+}
+
+// addHeaderRule adds a header rule if it sets at least one header
+func (info *RoutingInfo) addHeaderRule(rule *app.HeaderRule) {
+	if rule != nil && rule.Match != "" && len(rule.Headers) > 0 {
+		info.Routing.Headers = append(info.Routing.Headers, *rule)
+	}
+}
+
+// unsupported records a rule that was skipped
+func (info *RoutingInfo) unsupported(file, source, reason string) {
+	info.Unsupported = append(info.Unsupported, UnsupportedRoute{File: file, Source: source, Reason: reason})
+}
+
+// netlifyPatternToRegex converts a Netlify path (/blog/:slug, /docs/*) into an anchored
+// regular expression. Placeholder names are appended to names in group order.
+// Like Netlify, a trailing slash is optional.
+func netlifyPatternToRegex(pattern string, names *[]string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*':
+			sb.WriteString("(.*)")
+			if names != nil {
+				*names = append(*names, "splat")
+			}
+		case c == ':' && i+1 < len(pattern) && isIdentStart(pattern[i+1]):
+			j := i + 1
+			for j < len(pattern) && isIdentChar(pattern[j]) {
+				j++
+			}
+			sb.WriteString("([^/]+)")
+			if names != nil {
+				*names = append(*names, pattern[i+1:j])
+			}
+			i = j - 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if !strings.HasSuffix(pattern, "/") && !strings.HasSuffix(pattern, "*") {
+		sb.WriteString("/?")
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// vercelPatternToRegex converts a Vercel path-to-regexp source (/blog/:slug, /docs/:path*,
+// /api/(.*), /post/:id(\d+)) into an anchored regular expression and its group names
+func vercelPatternToRegex(pattern string) (string, []string) {
+	var sb strings.Builder
+	var names []string
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == ':' && i+1 < len(pattern) && isIdentStart(pattern[i+1]):
+			j := i + 1
+			for j < len(pattern) && isIdentChar(pattern[j]) {
+				j++
+			}
+			names = append(names, pattern[i+1:j])
+
+			group := "[^/]+"
+			if j < len(pattern) && pattern[j] == '(' {
+				if end := matchingParen(pattern, j); end > j {
+					group = pattern[j+1 : end]
+					j = end + 1
+				}
+			}
+
+			modifier := byte(0)
+			if j < len(pattern) && strings.IndexByte("*+?", pattern[j]) >= 0 {
+				modifier = pattern[j]
+				j++
+			}
+
+			// A leading slash belongs to the (possibly repeated) segment
+			prefix := strings.HasSuffix(sb.String(), "/")
+			switch modifier {
+			case '*':
+				if prefix {
+					trimLastSlash(&sb)
+					sb.WriteString("(?:/(.*))?")
+				} else {
+					sb.WriteString("(.*)")
+				}
+			case '+':
+				sb.WriteString("(.+)")
+			case '?':
+				if prefix {
+					trimLastSlash(&sb)
+					sb.WriteString("(?:/(" + group + "))?")
+				} else {
+					sb.WriteString("(" + group + ")?")
+				}
+			default:
+				sb.WriteString("(" + group + ")")
+			}
+			i = j - 1
+		case c == '(':
+			// Unnamed group, e.g. /(.*)
+			end := matchingParen(pattern, i)
+			if end < 0 {
+				sb.WriteString(regexp.QuoteMeta(pattern[i:]))
+				i = len(pattern)
+				continue
+			}
+			names = append(names, strconv.Itoa(len(names)+1))
+			sb.WriteString(pattern[i : end+1])
+			i = end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+	return sb.String(), names
+}
+
+// isCatchAll checks if a rule regex matches every path
+func isCatchAll(match string) bool {
+	re, err := regexp.Compile(match)
+	if err != nil {
+		return false
+	}
+	return re.MatchString("/") && re.MatchString("/a") && re.MatchString("/a/b/c.html")
+}
+
+// hasConditions checks if Vercel has/missing conditions are set
+func hasConditions(has, missing json.RawMessage) bool {
+	notEmpty := func(raw json.RawMessage) bool {
+		s := strings.TrimSpace(string(raw))
+		return s != "" && s != "null" && s != "[]"
+	}
+	return notEmpty(has) || notEmpty(missing)
+}
+
+// matchingParen returns the index of the parenthesis closing the one at start, or -1
+func matchingParen(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// trimLastSlash removes a trailing "/" (written as a literal) from the builder
+func trimLastSlash(sb *strings.Builder) {
+	s := strings.TrimSuffix(sb.String(), "/")
+	sb.Reset()
+	sb.WriteString(s)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,129 @@
+package node
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// SecretFile represents a kind of file that commonly contains secrets
+type SecretFile struct {
+	// Patterns are file name globs (path.Match syntax) the kind applies to
+	Patterns []string
+	// Description names the kind of secret for warning messages
+	Description string
+	// Contains are markers, at least one of which must be present in the file;
+	// nil flags every match
+	Contains [][]byte
+}
+
+// SecretFiles is a list of file kinds that should not be baked into an image
+var SecretFiles = []SecretFile{
+	{
+		Patterns:    []string{"serviceAccount*.json", "service-account*.json", "*-firebase-adminsdk-*.json", "credentials.json"},
+		Description: "service account key",
+		Contains:    [][]byte{[]byte(`"private_key"`)},
+	},
+	{
+		Patterns:    []string{"*.pem", "*.key"},
+		Description: "private key",
+		Contains:    [][]byte{[]byte("PRIVATE KEY")},
+	},
+	{
+		Patterns:    []string{"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519"},
+		Description: "SSH private key",
+	},
+	{
+		Patterns:    []string{"*.p12", "*.pfx", "*.keystore", "*.jks"},
+		Description: "certificate keystore",
+	},
+}
+
+// npmAuthTokenPattern matches literal (non-${VAR}) registry tokens in .npmrc
+var npmAuthTokenPattern = regexp.MustCompile(`(?m)_(?:authToken|auth|password)\s*=\s*[^\s$]`)
+
+// DetectedSecretFile is a file in the project that likely contains secrets
+type DetectedSecretFile struct {
+	// File is the path relative to the app root
+	File string
+	// Description names the kind of secret
+	Description string
+}
+
+// DetectSecretFiles finds files that would bake secrets into the image when the
+// source is copied: dotenv files with values, key files and registry tokens
+func DetectSecretFiles(ctx *app.Context) []DetectedSecretFile {
+	var detected []DetectedSecretFile
+
+	for _, file := range envFiles {
+		data, err := ctx.ReadFile(file)
+		if err != nil {
+			logSkipped(ctx, file, err)
+			continue
+		}
+		if envFileHasValues(data) {
+			detected = append(detected, DetectedSecretFile{File: file, Description: "environment file with values"})
+		}
+	}
+
+	if data, err := ctx.ReadFile(".npmrc"); err == nil && npmAuthTokenPattern.Match(data) {
+		detected = append(detected, DetectedSecretFile{File: ".npmrc", Description: "registry auth token"})
+	}
+
+	scanFiles(ctx, []string{"."}, func(name string) bool {
+		return matchSecretFile(name) != nil
+	}, func(rel string, data []byte) bool {
+		kind := matchSecretFile(path.Base(rel))
+		if len(kind.Contains) > 0 && !containsAny(data, kind.Contains) {
+			return true
+		}
+		detected = append(detected, DetectedSecretFile{File: rel, Description: kind.Description})
+		return true
+	})
+
+	return detected
+}
+
+// matchSecretFile returns the secret file kind matching a file name, or nil
+func matchSecretFile(name string) *SecretFile {
+	for i := range SecretFiles {
+		for _, pattern := range SecretFiles[i].Patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return &SecretFiles[i]
+			}
+		}
+	}
+	return nil
+}
+
+// envFileHasValues checks if a dotenv file assigns at least one non-empty value
+func envFileHasValues(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		_, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny checks if data contains at least one of the markers
+func containsAny(data []byte, markers [][]byte) bool {
+	for _, marker := range markers {
+		if bytes.Contains(data, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package node
+
+import (
+	"regexp"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// ServiceClient represents a client library that requires a backing service
+type ServiceClient struct {
+	// Package is the npm package name
+	Package string
+	// Service is the backing service type (e.g., "redis")
+	Service string
+	// Env are the variables conventionally used to connect to the service
+	Env []string
+	// Optional is true when the library falls back to working without the service
+	Optional bool
+}
+
+// ServiceClients is a list of known client libraries and the services they need
+var ServiceClients = []ServiceClient{
+	// Databases
+	{Package: "pg", Service: "postgres", Env: []string{"DATABASE_URL"}},
+	{Package: "postgres", Service: "postgres", Env: []string{"DATABASE_URL"}},
+	{Package: "pg-promise", Service: "postgres", Env: []string{"DATABASE_URL"}},
+	{Package: "mysql", Service: "mysql", Env: []string{"DATABASE_URL"}},
+	{Package: "mysql2", Service: "mysql", Env: []string{"DATABASE_URL"}},
+	{Package: "mariadb", Service: "mariadb", Env: []string{"DATABASE_URL"}},
+	{Package: "mongodb", Service: "mongodb", Env: []string{"MONGODB_URI"}},
+	{Package: "mongoose", Service: "mongodb", Env: []string{"MONGODB_URI"}},
+
+	// Key-value stores
+	{Package: "redis", Service: "redis", Env: []string{"REDIS_URL"}},
+	{Package: "ioredis", Service: "redis", Env: []string{"REDIS_URL"}},
+
+	// Session stores
+	{Package: "connect-redis", Service: "redis", Env: []string{"REDIS_URL"}},
+	{Package: "connect-mongo", Service: "mongodb", Env: []string{"MONGODB_URI"}},
+	{Package: "connect-pg-simple", Service: "postgres", Env: []string{"DATABASE_URL"}},
+	{Package: "express-mysql-session", Service: "mysql", Env: []string{"DATABASE_URL"}},
+	{Package: "connect-memcached", Service: "memcached", Env: []string{"MEMCACHED_SERVERS"}},
+
+	// Caches (in-memory fallback when the store is unavailable)
+	{Package: "memcached", Service: "memcached", Env: []string{"MEMCACHED_SERVERS"}, Optional: true},
+	{Package: "memjs", Service: "memcached", Env: []string{"MEMCACHIER_SERVERS"}, Optional: true},
+	{Package: "cache-manager-redis-store", Service: "redis", Env: []string{"REDIS_URL"}, Optional: true},
+	{Package: "cache-manager-ioredis-yet", Service: "redis", Env: []string{"REDIS_URL"}, Optional: true},
+	{Package: "@keyv/redis", Service: "redis", Env: []string{"REDIS_URL"}, Optional: true},
+
+	// Job queues and message brokers
+	{Package: "bullmq", Service: "redis", Env: []string{"REDIS_URL"}},
+	{Package: "bull", Service: "redis", Env: []string{"REDIS_URL"}},
+	{Package: "bee-queue", Service: "redis", Env: []string{"REDIS_URL"}},
+	{Package: "amqplib", Service: "rabbitmq", Env: []string{"AMQP_URL"}},
+	{Package: "amqp-connection-manager", Service: "rabbitmq", Env: []string{"AMQP_URL"}},
+	{Package: "@golevelup/nestjs-rabbitmq", Service: "rabbitmq", Env: []string{"AMQP_URL"}},
+	{Package: "kafkajs", Service: "kafka", Env: []string{"KAFKA_BROKERS"}},
+	{Package: "@confluentinc/kafka-javascript", Service: "kafka", Env: []string{"KAFKA_BROKERS"}},
+	{Package: "nats", Service: "nats", Env: []string{"NATS_URL"}},
+}
+
+// DetectServiceClients checks which backing-service client libraries are used by the project
+func DetectServiceClients(pkg *PackageJSON) []ServiceClient {
+	var detected []ServiceClient
+
+	for _, client := range ServiceClients {
+		if pkg.HasDependency(client.Package) {
+			detected = append(detected, client)
+		}
+	}
+
+	return detected
+}
+
+// prismaSchemaFiles are the default locations of the Prisma schema
+var prismaSchemaFiles = []string{"prisma/schema.prisma", "schema.prisma"}
+
+var (
+	prismaProviderPattern = regexp.MustCompile(`(?s)datasource\s+\w+\s*\{[^}]*provider\s*=\s*"([^"]+)"`)
+	prismaURLEnvPattern   = regexp.MustCompile(`(?s)datasource\s+\w+\s*\{[^}]*url\s*=\s*env\("([^"]+)"\)`)
+	prismaURLPattern      = regexp.MustCompile(`(?s)datasource\s+\w+\s*\{[^}]*url\s*=\s*"([^"]+)"`)
+)
+
+// prismaServices maps Prisma datasource providers to backing services
+var prismaServices = map[string]string{
+	"postgresql":  "postgres",
+	"postgres":    "postgres",
+	"cockroachdb": "cockroachdb",
+	"mysql":       "mysql",
+	"mongodb":     "mongodb",
+	"sqlserver":   "sqlserver",
+}
+
+// PrismaDatasource describes the datasource block of a Prisma schema
+type PrismaDatasource struct {
+	// Provider is the datasource provider (e.g., "postgresql", "sqlite")
+	Provider string
+	// URLEnv is the variable read by url = env("..."), if any
+	URLEnv string
+	// URL is the literal url = "...", if any
+	URL string
+	// File is the schema file the datasource was read from
+	File string
+}
+
+// DetectPrismaDatasource reads the datasource block from the Prisma schema
+// Returns nil when the project doesn't use Prisma or the schema can't be read
+func DetectPrismaDatasource(ctx *app.Context, pkg *PackageJSON) *PrismaDatasource {
+	if !pkg.HasDependency("prisma") && !pkg.HasDependency("@prisma/client") {
+		return nil
+	}
+
+	for _, file := range prismaSchemaFiles {
+		data, err := ctx.ReadFile(file)
+		if err != nil {
+			logSkipped(ctx, file, err)
+			continue
+		}
+		m := prismaProviderPattern.FindSubmatch(data)
+		if m == nil {
+			continue
+		}
+		ds := &PrismaDatasource{Provider: string(m[1]), File: file}
+		if m := prismaURLEnvPattern.FindSubmatch(data); m != nil {
+			ds.URLEnv = string(m[1])
+		} else if m := prismaURLPattern.FindSubmatch(data); m != nil {
+			ds.URL = string(m[1])
+		}
+		return ds
+	}
+
+	return nil
+}
+
+// Service returns the backing service for the datasource, empty for embedded databases
+func (ds *PrismaDatasource) Service() string {
+	return prismaServices[ds.Provider]
+}
@@ -0,0 +1,158 @@
+package node
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// SitemapGenerator represents a package that generates sitemap.xml/robots.txt during build
+// and embeds the public site URL into the generated files
+type SitemapGenerator struct {
+	// Package is the npm package name
+	Package string
+	// EnvVar is the conventional variable holding the public site URL
+	EnvVar string
+	// ConfigFiles are checked for the site URL setting
+	ConfigFiles []string
+	// ConfigKey is the property holding the site URL in ConfigFiles (dot-separated for nested keys)
+	ConfigKey string
+	// PluginOption is true when ConfigKey is an option of the plugin in the
+	// config's plugins list rather than a key of the config
+	PluginOption bool
+}
+
+var (
+	nextSitemapConfigs = []string{"next-sitemap.config.js", "next-sitemap.config.cjs", "next-sitemap.config.mjs"}
+	astroConfigs       = []string{"astro.config.ts", "astro.config.mjs", "astro.config.js"}
+	nuxtConfigs        = []string{"nuxt.config.ts", "nuxt.config.js", "nuxt.config.mjs"}
+	gatsbyConfigs      = []string{"gatsby-config.ts", "gatsby-config.js", "gatsby-config.mjs"}
+	viteConfigs        = []string{"vite.config.ts", "vite.config.js", "vite.config.mjs"}
+)
+
+// SitemapGenerators is a list of known sitemap/robots generators that run at build time
+var SitemapGenerators = []SitemapGenerator{
+	{
+		Package:     "next-sitemap",
+		EnvVar:      "SITE_URL",
+		ConfigFiles: nextSitemapConfigs,
+		ConfigKey:   "siteUrl",
+	},
+	{
+		Package:     "@astrojs/sitemap",
+		EnvVar:      "SITE_URL",
+		ConfigFiles: astroConfigs,
+		ConfigKey:   "site",
+	},
+	{
+		Package:     "@nuxtjs/sitemap",
+		EnvVar:      "NUXT_PUBLIC_SITE_URL",
+		ConfigFiles: nuxtConfigs,
+		ConfigKey:   "site.url",
+	},
+	{
+		Package:     "nuxt-simple-sitemap",
+		EnvVar:      "NUXT_PUBLIC_SITE_URL",
+		ConfigFiles: nuxtConfigs,
+		ConfigKey:   "site.url",
+	},
+	{
+		Package:     "@nuxtjs/robots",
+		EnvVar:      "NUXT_PUBLIC_SITE_URL",
+		ConfigFiles: nuxtConfigs,
+		ConfigKey:   "site.url",
+	},
+	{
+		Package:     "gatsby-plugin-sitemap",
+		EnvVar:      "SITE_URL",
+		ConfigFiles: gatsbyConfigs,
+		ConfigKey:   "siteMetadata.siteUrl",
+	},
+	{
+		Package:      "vite-plugin-sitemap",
+		EnvVar:       "SITE_URL",
+		ConfigFiles:  viteConfigs,
+		ConfigKey:    "hostname",
+		PluginOption: true,
+	},
+}
+
+// DetectedSitemapGenerator is a sitemap generator found in the project
+type DetectedSitemapGenerator struct {
+	SitemapGenerator
+	// RequiredEnv is the variable that must be set at build time, empty if the
+	// config hardcodes a public URL
+	RequiredEnv string
+	// ConfigFile is the config file the site URL was read from, if any
+	ConfigFile string
+	// LocalhostURL is true when the config hardcodes a localhost URL
+	LocalhostURL bool
+}
+
+var envRefPattern = regexp.MustCompile(`(?:process\.env|import\.meta\.env)\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// DetectSitemapGenerators checks which sitemap generators are used by the project
+// and resolves the build-time variable each one needs for the public site URL
+func DetectSitemapGenerators(ctx *app.Context, pkg *PackageJSON) []DetectedSitemapGenerator {
+	var detected []DetectedSitemapGenerator
+	parser := acquireConfigParser()
+	defer parser.Release()
+
+	for _, gen := range SitemapGenerators {
+		if !pkg.HasDependency(gen.Package) {
+			continue
+		}
+
+		result := DetectedSitemapGenerator{
+			SitemapGenerator: gen,
+			RequiredEnv:      gen.EnvVar,
+		}
+
+		for _, configFile := range gen.ConfigFiles {
+			if !ctx.HasFile(configFile) {
+				continue
+			}
+			root, data, ok := parseConfig(ctx, parser, configFile)
+			if !ok {
+				continue
+			}
+
+			var value string
+			if gen.PluginOption {
+				value = findPluginOption(root, data, gen.ConfigKey)
+			} else {
+				value = FindNestedPropertyValue(root, data, strings.Split(gen.ConfigKey, ".")...)
+			}
+			if value == "" {
+				continue
+			}
+			result.ConfigFile = configFile
+
+			if m := envRefPattern.FindStringSubmatch(value); m != nil {
+				// URL comes from the environment, e.g. process.env.SITE_URL
+				result.RequiredEnv = m[1]
+			} else if isLocalhostURL(value) {
+				result.LocalhostURL = true
+			} else if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+				// Public URL is hardcoded, nothing to provide at build time
+				result.RequiredEnv = ""
+			}
+			break
+		}
+
+		detected = append(detected, result)
+	}
+
+	return detected
+}
+
+// isLocalhostURL checks if a URL points to the local machine
+func isLocalhostURL(url string) bool {
+	for _, host := range []string{"localhost", "127.0.0.1", "0.0.0.0"} {
+		if strings.Contains(url, "://"+host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package node
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// maxScannedFiles limits how many files a single source scan reads
+const maxScannedFiles = 2000
+
+// maxScannedFileSize skips files larger than this (bundles, generated code)
+const maxScannedFileSize = 512 * 1024
+
+// skippedDirs are never descended into when scanning source files
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	".git":         true,
+	".next":        true,
+	".nuxt":        true,
+	".output":      true,
+	".svelte-kit":  true,
+	".coolpack":    true,
+	"dist":         true,
+	"build":        true,
+	"out":          true,
+	"coverage":     true,
+}
+
+// jsSourceExtensions are the file extensions of JavaScript/TypeScript sources
+var jsSourceExtensions = []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts", ".vue", ".svelte", ".astro"}
+
+// scanSourceFiles walks the given directories (relative to the app root) and calls fn
+// for every file with one of the given extensions. Returning false from fn stops the scan.
+func scanSourceFiles(ctx *app.Context, dirs []string, extensions []string, fn func(rel string, data []byte) bool) {
+	scanFiles(ctx, dirs, func(name string) bool {
+		return hasExtension(name, extensions)
+	}, fn)
+}
+
+// scanFiles walks the given directories (relative to the app root) and calls fn
+// for every file whose name is accepted by match. Returning false from fn stops the scan.
+func scanFiles(ctx *app.Context, dirs []string, match func(name string) bool, fn func(rel string, data []byte) bool) {
+	scanned := 0
+
+	for _, dir := range dirs {
+		// Symlinked directories could lead out of the application
+		if !filepath.IsLocal(dir) || (dir != "." && ctx.IsLink(dir)) {
+			continue
+		}
+		root := filepath.Join(ctx.Path, dir)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			ctx.RecordInput(app.InputDir, dir)
+			continue
+		}
+
+		stop := false
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				stop = true
+				return filepath.SkipAll
+			}
+			if err != nil {
+				logSkipped(ctx, path, err)
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && skippedDirs[d.Name()] {
+					return filepath.SkipDir
+				}
+				// A file added to the directory can change the result
+				if rel, err := filepath.Rel(ctx.Path, path); err == nil {
+					ctx.RecordInput(app.InputDir, rel)
+				}
+				return nil
+			}
+			if !match(d.Name()) {
+				return nil
+			}
+			if info, err := d.Info(); err != nil || info.Size() > maxScannedFileSize {
+				return nil
+			}
+
+			scanned++
+			if scanned > maxScannedFiles {
+				ctx.Log().Debug("source scan stopped at the file limit", "limit", maxScannedFiles)
+				stop = true
+				return filepath.SkipAll
+			}
+
+			rel, err := filepath.Rel(ctx.Path, path)
+			if err != nil {
+				return nil
+			}
+			var data []byte
+			if d.Type()&fs.ModeSymlink != 0 {
+				// The context only follows links that stay in the application
+				data, err = ctx.ReadFile(rel)
+			} else {
+				data, err = os.ReadFile(path)
+				ctx.RecordInput(app.InputFile, rel)
+				data = app.NormalizeText(data)
+			}
+			if err != nil {
+				logSkipped(ctx, rel, err)
+				return nil
+			}
+			if !fn(filepath.ToSlash(rel), data) {
+				stop = true
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if stop {
+			return
+		}
+	}
+}
+
+// hasExtension checks if a file name ends with one of the given extensions
+func hasExtension(name string, extensions []string) bool {
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
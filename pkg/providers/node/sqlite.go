@@ -0,0 +1,132 @@
+package node
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// defaultSQLiteDataDir is used when the database path can't be determined
+const defaultSQLiteDataDir = "data"
+
+// SQLiteClients are packages that open SQLite databases on local disk
+var SQLiteClients = []string{
+	"better-sqlite3",
+	"sqlite3",
+	"sqlite",
+	"libsql",
+	"@libsql/client",
+}
+
+// sqliteFilePattern matches literal database paths such as new Database('data/app.db')
+// or createClient({ url: 'file:local.db' })
+var sqliteFilePattern = regexp.MustCompile("['\"`](?:file:)?([^'\"`\\s:]+\\.(?:db|sqlite|sqlite3))['\"`]")
+
+// SQLiteHints are recommendations for running SQLite in production
+var SQLiteHints = []string{
+	"Enable WAL mode (PRAGMA journal_mode = WAL) for concurrent reads during writes",
+	"Set a busy timeout (PRAGMA busy_timeout = 5000) to avoid SQLITE_BUSY errors under load",
+	"Keep the -wal and -shm files on the same volume as the database",
+}
+
+// SQLiteInfo contains the detected on-disk SQLite usage of the application
+type SQLiteInfo struct {
+	// Package is the client (or "prisma") that opens the database
+	Package string
+	// Files are the database files found, relative to the app root
+	Files []string
+	// DataDirs are the directories holding the databases, relative to the app root
+	DataDirs []string
+	// Relocate is true when a database shares a directory with source files
+	// (app root or the Prisma schema directory) and can't be mounted as-is
+	Relocate bool
+}
+
+// DetectSQLite checks if the application stores a SQLite database on local disk
+// Returns nil when no on-disk SQLite database is used
+func DetectSQLite(ctx *app.Context, pkg *PackageJSON, prisma *PrismaDatasource) *SQLiteInfo {
+	info := &SQLiteInfo{}
+
+	if prisma != nil && prisma.Provider == "sqlite" {
+		info.Package = "prisma"
+		if file, ok := strings.CutPrefix(prisma.URL, "file:"); ok && file != "" {
+			// Prisma resolves relative paths against the schema directory
+			schemaDir := path.Dir(prisma.File)
+			db := path.Join(schemaDir, file)
+			info.addFile(db)
+			if path.Dir(db) == schemaDir {
+				// Mounting the schema directory would hide the schema and migrations
+				info.Relocate = true
+				info.DataDirs = nil
+			}
+		}
+	}
+
+	if info.Package == "" {
+		for _, client := range SQLiteClients {
+			if pkg.HasDependency(client) {
+				info.Package = client
+				break
+			}
+		}
+	}
+	if info.Package == "" {
+		return nil
+	}
+
+	if info.Package != "prisma" {
+		scanSourceFiles(ctx, []string{"."}, jsSourceExtensions, func(rel string, data []byte) bool {
+			for _, m := range sqliteFilePattern.FindAllSubmatch(data, -1) {
+				info.addFile(string(m[1]))
+			}
+			return true
+		})
+
+		// libsql is usually a remote (Turso) client; only a file path keeps data on disk
+		if strings.Contains(info.Package, "libsql") && len(info.Files) == 0 {
+			return nil
+		}
+	}
+
+	if len(info.DataDirs) == 0 {
+		info.DataDirs = []string{defaultSQLiteDataDir}
+	}
+
+	return info
+}
+
+// addFile records a database file and the directory holding it
+func (info *SQLiteInfo) addFile(file string) {
+	if strings.HasPrefix(file, "/") {
+		if !strings.HasPrefix(file, AppDir+"/") {
+			// Absolute path outside the app dir (e.g., /data/app.db) is used as-is
+			info.Files = appendUnique(info.Files, file)
+			info.DataDirs = appendUnique(info.DataDirs, path.Dir(file))
+			return
+		}
+		file = strings.TrimPrefix(file, AppDir+"/")
+	}
+
+	file = path.Clean(file)
+	if strings.HasPrefix(file, "..") {
+		return
+	}
+	info.Files = appendUnique(info.Files, file)
+
+	dir := path.Dir(file)
+	if dir == "." {
+		info.Relocate = true
+		return
+	}
+	info.DataDirs = appendUnique(info.DataDirs, dir)
+}
+
+// containerPath returns the container path of a directory relative to the app root
+func containerPath(dir string) string {
+	if strings.HasPrefix(dir, "/") {
+		return dir
+	}
+	return AppDir + "/" + dir
+}
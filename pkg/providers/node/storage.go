@@ -0,0 +1,141 @@
+package node
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// AppDir is the application directory inside the generated image
+const AppDir = "/app"
+
+// defaultUploadDir is assumed when disk storage is used without a literal destination
+const defaultUploadDir = "uploads"
+
+// UploadHandler represents a multipart upload package that can write files to local disk
+type UploadHandler struct {
+	// Package is the npm package name
+	Package string
+	// DefaultsToDisk is true when the package writes to disk without extra configuration
+	DefaultsToDisk bool
+}
+
+// UploadHandlers is a list of known upload handling packages
+var UploadHandlers = []UploadHandler{
+	{Package: "multer"},
+	{Package: "formidable"},
+	{Package: "express-fileupload"},
+	{Package: "@fastify/multipart"},
+	{Package: "multiparty", DefaultsToDisk: true},
+}
+
+// ObjectStorageSDK represents an object storage client package
+type ObjectStorageSDK struct {
+	// Package is the npm package name
+	Package string
+	// Provider is the storage provider (e.g., "s3")
+	Provider string
+	// Env are the non-secret configuration variables the SDK needs
+	Env []string
+	// Secrets are the credential variables the SDK needs
+	Secrets []string
+}
+
+var (
+	s3Env     = []string{"S3_BUCKET", "AWS_REGION"}
+	s3Secrets = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}
+)
+
+// ObjectStorageSDKs is a list of known object storage client packages
+var ObjectStorageSDKs = []ObjectStorageSDK{
+	{Package: "@aws-sdk/client-s3", Provider: "s3", Env: s3Env, Secrets: s3Secrets},
+	{Package: "@aws-sdk/lib-storage", Provider: "s3", Env: s3Env, Secrets: s3Secrets},
+	{Package: "aws-sdk", Provider: "s3", Env: s3Env, Secrets: s3Secrets},
+	{Package: "multer-s3", Provider: "s3", Env: s3Env, Secrets: s3Secrets},
+	{Package: "minio", Provider: "s3", Env: []string{"S3_BUCKET", "S3_ENDPOINT"}, Secrets: []string{"S3_ACCESS_KEY", "S3_SECRET_KEY"}},
+	{Package: "@google-cloud/storage", Provider: "gcs", Env: []string{"GCS_BUCKET"}, Secrets: []string{"GOOGLE_APPLICATION_CREDENTIALS"}},
+	{Package: "@azure/storage-blob", Provider: "azure-blob", Env: []string{"AZURE_STORAGE_CONTAINER"}, Secrets: []string{"AZURE_STORAGE_CONNECTION_STRING"}},
+	{Package: "@vercel/blob", Provider: "vercel-blob", Secrets: []string{"BLOB_READ_WRITE_TOKEN"}},
+	{Package: "cloudinary", Provider: "cloudinary", Secrets: []string{"CLOUDINARY_URL"}},
+	{Package: "uploadthing", Provider: "uploadthing", Secrets: []string{"UPLOADTHING_TOKEN"}},
+}
+
+// uploadDirPattern matches literal upload destinations such as multer({ dest: 'uploads/' }),
+// diskStorage({ destination: './uploads' }) or formidable({ uploadDir: 'tmp/uploads' })
+var uploadDirPattern = regexp.MustCompile(`\b(?:dest|destination|uploadDir)\s*:\s*['"]([^'"]+)['"]`)
+
+// diskStoragePattern matches multer disk storage and express-fileupload's file.mv()
+var diskStoragePattern = regexp.MustCompile(`\bdiskStorage\s*\(|\.mv\s*\(`)
+
+// StorageInfo contains the detected file storage of the application
+type StorageInfo struct {
+	// ObjectStorage lists the object storage SDKs used
+	ObjectStorage []ObjectStorageSDK
+	// UploadPackage is the upload handler writing to local disk, empty if none
+	UploadPackage string
+	// UploadDirs are the local upload directories, relative to the app root
+	UploadDirs []string
+}
+
+// DetectStorage checks whether uploads are written to local disk or to object storage
+func DetectStorage(ctx *app.Context, pkg *PackageJSON) StorageInfo {
+	var info StorageInfo
+
+	for _, sdk := range ObjectStorageSDKs {
+		if pkg.HasDependency(sdk.Package) {
+			info.ObjectStorage = append(info.ObjectStorage, sdk)
+		}
+	}
+
+	var handler *UploadHandler
+	for i := range UploadHandlers {
+		if pkg.HasDependency(UploadHandlers[i].Package) {
+			handler = &UploadHandlers[i]
+			break
+		}
+	}
+	// multer-s3 streams multer uploads straight to S3
+	if handler == nil || pkg.HasDependency("multer-s3") {
+		return info
+	}
+
+	diskStorage := handler.DefaultsToDisk
+	scanSourceFiles(ctx, []string{"."}, jsSourceExtensions, func(rel string, data []byte) bool {
+		for _, m := range uploadDirPattern.FindAllSubmatch(data, -1) {
+			if dir := normalizeUploadDir(string(m[1])); dir != "" {
+				info.UploadDirs = appendUnique(info.UploadDirs, dir)
+			}
+		}
+		if diskStoragePattern.Match(data) {
+			diskStorage = true
+		}
+		return true
+	})
+
+	if len(info.UploadDirs) == 0 && diskStorage {
+		info.UploadDirs = []string{defaultUploadDir}
+	}
+	if len(info.UploadDirs) > 0 {
+		info.UploadPackage = handler.Package
+	}
+
+	return info
+}
+
+// normalizeUploadDir cleans an upload directory relative to the app root.
+// Absolute paths outside the app and temp directories are not persistent uploads.
+func normalizeUploadDir(dir string) string {
+	if strings.HasPrefix(dir, "/") {
+		if !strings.HasPrefix(dir, AppDir+"/") {
+			return ""
+		}
+		dir = strings.TrimPrefix(dir, AppDir+"/")
+	}
+	dir = path.Clean(dir)
+	if dir == "." || strings.HasPrefix(dir, "..") || dir == "tmp" || strings.HasPrefix(dir, "tmp/") {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"strings"
+)
+
+// TestInfo describes the project's test phase
+type TestInfo struct {
+	// Command runs the test script (empty without one)
+	Command string
+	// Runner is the test framework the script runs, empty when unknown
+	Runner string
+}
+
+// testRunners are test frameworks by the binary their scripts run, in the
+// order they are checked. "node" (node --test) and "bun" (bun test) only
+// count with their test argument.
+var testRunners = []string{"vitest", "jest", "mocha", "ava", "tap", "jasmine", "uvu", "playwright", "cypress", "node", "bun"}
+
+// npmTestPlaceholder is the test script npm init writes, which always fails
+const npmTestPlaceholder = "no test specified"
+
+// DetectTestCommand detects the test script and the runner it uses. The
+// command is optional: builds only run it when asked to.
+func DetectTestCommand(pkg *PackageJSON, pm PackageManagerInfo) TestInfo {
+	script := pkg.GetScript("test")
+	if strings.TrimSpace(script) == "" || strings.Contains(script, npmTestPlaceholder) {
+		return TestInfo{}
+	}
+	return TestInfo{
+		Command: pm.GetRunCommand() + " test",
+		Runner:  testRunner(pkg, script),
+	}
+}
+
+// testRunner returns the test framework a script runs, following one level
+// of scripts it runs (e.g., "npm run test:unit")
+func testRunner(pkg *PackageJSON, script string) string {
+	if runner := scriptTestRunner(script); runner != "" {
+		return runner
+	}
+	fields := strings.Fields(script)
+	for i, field := range fields {
+		if i == 0 || (fields[i-1] != "run" && !commandRunsBinary(fields[i-1], "pnpm") && !commandRunsBinary(fields[i-1], "yarn")) {
+			continue
+		}
+		if nested := pkg.GetScript(field); nested != "" {
+			if runner := scriptTestRunner(nested); runner != "" {
+				return runner
+			}
+		}
+	}
+	return ""
+}
+
+// scriptTestRunner returns the test framework a script invokes
+func scriptTestRunner(script string) string {
+	fields := strings.Fields(script)
+	for _, runner := range testRunners {
+		for i, field := range fields {
+			if !commandRunsBinary(field, runner) {
+				continue
+			}
+			switch runner {
+			case "node":
+				if i+1 < len(fields) && hasFlag(fields[i+1:], "--test") {
+					return runner
+				}
+			case "bun", "playwright", "cypress":
+				if i+1 < len(fields) && (fields[i+1] == "test" || fields[i+1] == "run") {
+					return runner
+				}
+			default:
+				return runner
+			}
+		}
+	}
+	return ""
+}
+
+// hasFlag checks if a flag is among the arguments up to the next command
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "&&", "||", ";", "|":
+			return false
+		case flag:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package node
+
+import (
+	"path"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// TypeScriptBackend describes how a TypeScript server without build or start
+// scripts is compiled and started
+type TypeScriptBackend struct {
+	// Entry is the TypeScript entry file (e.g., "src/index.ts")
+	Entry string
+	// Tool compiles or runs the entry: "esbuild", "tsc", "tsx", "ts-node" or "bun"
+	Tool string
+	// BuildCommand compiles the entry (empty when Tool runs TypeScript directly)
+	BuildCommand string
+	// StartCommand runs the compiled output, or the entry with Tool
+	StartCommand string
+}
+
+// typeScriptEntries are the entry files checked, in order, when "main" isn't a
+// TypeScript file
+var typeScriptEntries = []string{
+	"src/index.ts", "src/server.ts", "src/main.ts", "src/app.ts",
+	"index.ts", "server.ts", "main.ts", "app.ts",
+}
+
+// tsconfig holds the compiler options of tsconfig.json that decide where tsc
+// writes its output
+type tsconfig struct {
+	CompilerOptions struct {
+		OutDir  string `json:"outDir"`
+		RootDir string `json:"rootDir"`
+		NoEmit  bool   `json:"noEmit"`
+	} `json:"compilerOptions"`
+}
+
+// DetectTypeScriptBackend derives build and start commands for a TypeScript
+// server (tsconfig.json and an entry such as src/index.ts) without scripts for
+// them: plain Node.js, Express or Fastify, whose defaults only run "start". The
+// entry is bundled with esbuild or compiled with tsc when either is a
+// dependency; otherwise it runs with tsx or ts-node. Bun runs TypeScript itself.
+func DetectTypeScriptBackend(ctx *app.Context, pkg *PackageJSON, pm PackageManagerInfo, fw FrameworkInfo) (TypeScriptBackend, bool) {
+	switch fw.Name {
+	case FrameworkNone, FrameworkExpress, FrameworkFastify:
+	default:
+		return TypeScriptBackend{}, false
+	}
+	if !ctx.HasFile("tsconfig.json") {
+		return TypeScriptBackend{}, false
+	}
+
+	ts := TypeScriptBackend{}
+	if isTypeScriptFile(pkg.Main) && ctx.HasFile(pkg.Main) {
+		ts.Entry = path.Clean(pkg.Main)
+	} else {
+		for _, entry := range typeScriptEntries {
+			if ctx.HasFile(entry) {
+				ts.Entry = entry
+				break
+			}
+		}
+	}
+	if ts.Entry == "" {
+		return TypeScriptBackend{}, false
+	}
+
+	exec := pm.GetExecCommand()
+	var config tsconfig
+	// A tsconfig.json that doesn't parse uses tsc's defaults
+	_ = ctx.ReadJSONC("tsconfig.json", &config)
+
+	switch {
+	case pm.Name == PackageManagerBun:
+		ts.Tool = "bun"
+		ts.StartCommand = "bun " + ts.Entry
+	case pkg.HasDependency("esbuild"):
+		ts.Tool = "esbuild"
+		out := "dist/" + compiledName(path.Base(ts.Entry))
+		ts.BuildCommand = exec + " esbuild " + ts.Entry + " --bundle --platform=node --packages=external --outfile=" + out
+		if pkg.Type == "module" {
+			ts.BuildCommand += " --format=esm"
+		}
+		ts.StartCommand = "node " + out
+	case pkg.HasDependency("typescript") && !config.CompilerOptions.NoEmit:
+		ts.Tool = "tsc"
+		ts.BuildCommand = exec + " tsc"
+		ts.StartCommand = "node " + tscOutput(ts.Entry, config)
+	case pkg.HasDependency("tsx"):
+		ts.Tool = "tsx"
+		ts.StartCommand = exec + " tsx " + ts.Entry
+	case pkg.HasDependency("ts-node"):
+		ts.Tool = "ts-node"
+		ts.StartCommand = exec + " ts-node " + ts.Entry
+	default:
+		return TypeScriptBackend{}, false
+	}
+	return ts, true
+}
+
+// tscOutput returns the file tsc compiles the entry to. Without rootDir, tsc
+// uses the common directory of all sources, which is assumed to be the
+// entry's (src/ in a typical project).
+func tscOutput(entry string, config tsconfig) string {
+	outDir := config.CompilerOptions.OutDir
+	if outDir == "" {
+		// Without outDir, the output is written next to the source
+		return compiledName(entry)
+	}
+	rootDir := config.CompilerOptions.RootDir
+	if rootDir == "" {
+		rootDir = path.Dir(entry)
+	}
+	rel := strings.TrimPrefix(entry, path.Clean(rootDir)+"/")
+	if path.Clean(rootDir) == "." {
+		rel = entry
+	}
+	return path.Join(outDir, compiledName(rel))
+}
+
+// isTypeScriptFile checks if a file is TypeScript source (not a declaration file)
+func isTypeScriptFile(file string) bool {
+	if strings.HasSuffix(file, ".d.ts") {
+		return false
+	}
+	switch path.Ext(file) {
+	case ".ts", ".mts", ".cts":
+		return true
+	}
+	return false
+}
+
+// compiledName returns the JavaScript file a TypeScript file compiles to
+func compiledName(file string) string {
+	switch path.Ext(file) {
+	case ".mts":
+		return strings.TrimSuffix(file, ".mts") + ".mjs"
+	case ".cts":
+		return strings.TrimSuffix(file, ".cts") + ".cjs"
+	}
+	return strings.TrimSuffix(file, path.Ext(file)) + ".js"
+}
@@ -1,82 +1,169 @@
 package node
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coollabsio/coolpack/pkg/app"
+	"github.com/coollabsio/coolpack/pkg/data"
+	"github.com/coollabsio/coolpack/pkg/semver"
 )
 
+// DefaultNodeVersion is the fallback when the release table has no matching line
 const DefaultNodeVersion = "24"
 
+// Default Node version policies, selected with COOLPACK_NODE_DEFAULT. A number
+// (e.g., "22") pins the default to that version instead.
+const (
+	// NodePolicyLTS uses the newest active LTS line (default)
+	NodePolicyLTS = "lts"
+
+	// NodePolicyEcosystem uses the newest LTS line that has been LTS for at least
+	// EcosystemLag, so native modules and frameworks have caught up with it
+	NodePolicyEcosystem = "ecosystem"
+
+	// NodePolicyCurrent uses the newest release line that isn't end-of-life, LTS or not
+	NodePolicyCurrent = "current"
+)
+
+// EcosystemLag is how long an LTS line must have been LTS for NodePolicyEcosystem
+const EcosystemLag = 180 * 24 * time.Hour
+
 // DetectNodeVersion detects the Node.js version to use
 // Priority:
 // 1. COOLPACK_NODE_VERSION environment variable
 // 2. NODE_VERSION environment variable
 // 3. engines.node in package.json
-// 4. .nvmrc file
-// 5. .node-version file
-// 6. .tool-versions file (asdf)
-// 7. mise.toml file
-// 8. Default to 22
+// 4. devEngines.runtime (node) in package.json
+// 5. .nvmrc file
+// 6. .node-version file
+// 7. .tool-versions file (asdf)
+// 8. mise.toml file
+// 9. Default from the COOLPACK_NODE_DEFAULT policy (latest LTS if unset)
 func DetectNodeVersion(ctx *app.Context, pkg *PackageJSON) string {
+	v, _ := detectNodeVersion(ctx, pkg)
+	return v
+}
+
+// detectNodeVersion is DetectNodeVersion, also returning the decision for --explain
+func detectNodeVersion(ctx *app.Context, pkg *PackageJSON) (string, app.Decision) {
+	decision := func(v string, d app.Decision) (string, app.Decision) {
+		d.Field = "language_version"
+		d.Value = v
+		return v, d
+	}
+
 	// 1. Check COOLPACK_NODE_VERSION env var
 	if v := ctx.Env["COOLPACK_NODE_VERSION"]; v != "" {
-		return normalizeVersion(v)
+		return decision(normalizeVersion(v), envDecision("COOLPACK_NODE_VERSION"))
 	}
 
 	// 2. Check NODE_VERSION env var
 	if v := ctx.Env["NODE_VERSION"]; v != "" {
-		return normalizeVersion(v)
+		return decision(normalizeVersion(v), envDecision("NODE_VERSION"))
 	}
 
 	// 3. Check engines.node in package.json
 	if pkg != nil && pkg.Engines.Node != "" {
 		if v := parseEngineVersion(pkg.Engines.Node); v != "" {
-			return v
+			return decision(v, packageJSONDecision(ctx, fmt.Sprintf("engines.node %q in package.json", pkg.Engines.Node), `"engines"`, `"node"`))
 		}
 	}
 
-	// 4. Check .nvmrc file
-	if ctx.HasFile(".nvmrc") {
-		if data, err := ctx.ReadFile(".nvmrc"); err == nil {
-			if v := parseVersionFile(string(data)); v != "" {
-				return v
+	// 4. Check devEngines.runtime in package.json. engines.node comes first: it
+	// is where the application runs, devEngines where it is developed.
+	if pkg != nil {
+		if runtime, ok := pkg.DevEngines.Runtime.Find("node"); ok {
+			if v := parseEngineVersion(runtime.Version); v != "" {
+				return decision(v, packageJSONDecision(ctx, fmt.Sprintf("devEngines.runtime node %q in package.json", runtime.Version), `"devEngines"`, `"runtime"`))
 			}
 		}
 	}
 
-	// 5. Check .node-version file
-	if ctx.HasFile(".node-version") {
-		if data, err := ctx.ReadFile(".node-version"); err == nil {
-			if v := parseVersionFile(string(data)); v != "" {
-				return v
+	// 5-8. Check version files (.nvmrc, .node-version, asdf, mise)
+	versionFiles := []struct {
+		name  string
+		parse func(string) string
+	}{
+		{".nvmrc", parseVersionFile},
+		{".node-version", parseVersionFile},
+		{".tool-versions", func(content string) string { return parseToolVersions(content, "nodejs") }},
+		{"mise.toml", parseMiseToml},
+	}
+	for _, file := range versionFiles {
+		if !ctx.HasFile(file.name) {
+			continue
+		}
+		if data, err := ctx.ReadFile(file.name); err == nil {
+			if v := file.parse(string(data)); v != "" {
+				line := app.LineOf(data, "node")
+				if line == 0 {
+					line = 1
+				}
+				return decision(v, app.Decision{Source: app.SourceFile, File: file.name, Line: line, Reason: file.name})
 			}
 		}
 	}
 
-	// 6. Check .tool-versions file (asdf format)
-	if ctx.HasFile(".tool-versions") {
-		if data, err := ctx.ReadFile(".tool-versions"); err == nil {
-			if v := parseToolVersions(string(data), "nodejs"); v != "" {
-				return v
-			}
+	// 9. Default
+	policy := ctx.Env["COOLPACK_NODE_DEFAULT"]
+	v := DefaultNodeVersionFor(policy, time.Now())
+	if policy != "" {
+		d := envDecision("COOLPACK_NODE_DEFAULT")
+		d.Reason = fmt.Sprintf("default version for the %q policy", policy)
+		return decision(v, d)
+	}
+	return decision(v, app.Decision{Source: app.SourceDefault, Reason: "latest Node.js LTS (no engines.node or version file)"})
+}
+
+// DefaultNodeVersionFor resolves a default version policy ("lts", "ecosystem",
+// "current" or a version number) to a major version with the release table
+func DefaultNodeVersionFor(policy string, now time.Time) string {
+	db := data.Load()
+	today := now.Format("2006-01-02")
+
+	var best data.NodeRelease
+	var found bool
+	consider := func(r data.NodeRelease) {
+		if !r.IsEOL(now) && r.Released <= today && (!found || r.Major > best.Major) {
+			best, found = r, true
 		}
 	}
 
-	// 7. Check mise.toml file
-	if ctx.HasFile("mise.toml") {
-		if data, err := ctx.ReadFile("mise.toml"); err == nil {
-			if v := parseMiseToml(string(data)); v != "" {
-				return v
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case "", NodePolicyLTS:
+		if r, ok := db.LatestLTS(now); ok {
+			return strconv.Itoa(r.Major)
+		}
+	case NodePolicyEcosystem:
+		cutoff := now.Add(-EcosystemLag).Format("2006-01-02")
+		for _, r := range db.NodeReleases {
+			if r.IsLTS() && r.LTS <= cutoff {
+				consider(r)
 			}
 		}
+	case NodePolicyCurrent:
+		for _, r := range db.NodeReleases {
+			consider(r)
+		}
+	default:
+		if v := normalizeVersion(policy); versionNumberPattern.MatchString(v) {
+			return v
+		}
 	}
 
-	// 8. Default
+	if found {
+		return strconv.Itoa(best.Major)
+	}
 	return DefaultNodeVersion
 }
 
+// versionNumberPattern matches version numbers like "22" or "22.11.0"
+var versionNumberPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
 // normalizeVersion cleans up version strings
 func normalizeVersion(v string) string {
 	v = strings.TrimSpace(v)
@@ -84,39 +171,142 @@ func normalizeVersion(v string) string {
 	return v
 }
 
-// parseVersionFile parses a simple version file (.nvmrc, .node-version)
+// parseVersionFile parses a simple version file (.nvmrc, .node-version): the
+// first line that isn't empty or a # comment. A range on it (">=18 <21") is
+// resolved to a major version; otherwise its first word is the version. The
+// version ends up in the Dockerfile's FROM line, so nothing after it is kept.
 func parseVersionFile(content string) string {
-	v := strings.TrimSpace(content)
-	v = strings.TrimPrefix(v, "v")
+	line := ""
+	for _, l := range strings.Split(content, "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "#") {
+			line = l
+			break
+		}
+	}
 
 	// Handle lts/* or lts/iron type versions
-	if strings.HasPrefix(strings.ToLower(v), "lts") {
-		return DefaultNodeVersion
+	if strings.HasPrefix(strings.ToLower(line), "lts") {
+		return resolveLTSAlias(strings.Fields(line)[0])
 	}
 
-	// Extract just the major version or full version
-	if v != "" {
+	return resolveVersionSpec(line)
+}
+
+// resolveVersionSpec keeps a version number from a version file as written
+// ("20", "v20.10.0" is "20.10.0") and resolves a range like engines.node.
+// Anything else is cut to its first word (e.g., "node").
+func resolveVersionSpec(spec string) string {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return ""
+	}
+	if v := normalizeVersion(fields[0]); len(fields) == 1 && versionNumberPattern.MatchString(v) {
 		return v
 	}
-	return ""
+	if major, ok := resolveNodeRange(data.Load().NodeReleases, spec, time.Now()); ok {
+		return major
+	}
+	if major, ok := semver.Major(spec); ok {
+		return strconv.Itoa(major)
+	}
+	return normalizeVersion(fields[0])
 }
 
-// parseEngineVersion parses a semver range from engines.node
+// resolveLTSAlias resolves nvm LTS aliases ("lts/*", "lts/iron") with the release table
+func resolveLTSAlias(alias string) string {
+	db := data.Load()
+	_, codename, _ := strings.Cut(alias, "/")
+
+	if codename != "" && codename != "*" {
+		if r, ok := db.NodeReleaseByCodename(codename); ok {
+			return strconv.Itoa(r.Major)
+		}
+	}
+
+	return DefaultNodeVersionFor(NodePolicyLTS, time.Now())
+}
+
+// parseEngineVersion resolves a semver range from engines.node to a major
+// version with resolveNodeRange (">=18" is the oldest supported LTS, not 18). Ranges outside
+// the release table (e.g., a line newer than the data) use their first number.
 // Examples: ">=18", "^20.0.0", "18.x", ">=18 <21"
 func parseEngineVersion(constraint string) string {
 	constraint = strings.TrimSpace(constraint)
+	if major, ok := resolveNodeRange(data.Load().NodeReleases, constraint, time.Now()); ok {
+		return major
+	}
 
-	// Try to extract a version number
-	re := regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
-	matches := re.FindStringSubmatch(constraint)
-	if len(matches) > 1 {
-		// Return just the major version for broad compatibility
+	if matches := engineVersionPattern.FindStringSubmatch(constraint); len(matches) > 1 {
 		return matches[1]
 	}
-
 	return ""
 }
 
+// engineVersionPattern matches the first version number of a range
+var engineVersionPattern = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// resolveNodeRange returns the major version of the oldest release line in a
+// range among its best kind: supported LTS lines (released and not
+// end-of-life), then current lines, then end-of-life ones. ">=18" is the
+// oldest supported LTS, "^20 || ^22" is 20 while 20 is supported and "^16"
+// stays 16. ok is false when the range doesn't parse or no line of releases
+// is in it.
+func resolveNodeRange(releases []data.NodeRelease, constraint string, now time.Time) (string, bool) {
+	today := now.Format("2006-01-02")
+	rank := func(r data.NodeRelease) int {
+		switch {
+		case r.IsEOL(now) || r.Released > today:
+			return 0
+		case r.IsLTS() && r.LTS <= today:
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	var best data.NodeRelease
+	found := false
+	for _, r := range releases {
+		if !lineInRange(constraint, r.Major) {
+			continue
+		}
+		if !found || rank(r) > rank(best) || rank(r) == rank(best) && r.Major < best.Major {
+			best, found = r, true
+		}
+	}
+	if !found {
+		return "", false
+	}
+	return strconv.Itoa(best.Major), true
+}
+
+// rangeVersionPattern matches the versions a range is bounded by
+var rangeVersionPattern = regexp.MustCompile(`\d+(?:\.\d+){0,2}`)
+
+// lineInRange checks if a range includes a version of a release line. The
+// line's first and last versions and the range's own bounds in the line (and
+// the patch after them, for exclusive bounds) are checked, which finds a
+// version in the line for npm-style ranges ("~20.10.0", ">20.10.0 <20.11").
+func lineInRange(constraint string, major int) bool {
+	candidates := []string{fmt.Sprintf("%d.0.0", major), fmt.Sprintf("%d.999.999", major)}
+	for _, v := range rangeVersionPattern.FindAllString(constraint, -1) {
+		if m, ok := semver.Major(v); !ok || m != major {
+			continue
+		}
+		if version, ok := semver.Parse(v); ok {
+			candidates = append(candidates, version.String(), version.IncPatch().String())
+		}
+	}
+	for _, v := range candidates {
+		if ok, valid := semver.Satisfies(v, constraint); !valid {
+			return false
+		} else if ok {
+			return true
+		}
+	}
+	return false
+}
+
 // parseToolVersions parses .tool-versions file (asdf format)
 // Format: tool-name version
 func parseToolVersions(content string, tool string) string {
@@ -125,7 +315,7 @@ func parseToolVersions(content string, tool string) string {
 		line = strings.TrimSpace(line)
 		parts := strings.Fields(line)
 		if len(parts) >= 2 && parts[0] == tool {
-			return normalizeVersion(parts[1])
+			return resolveVersionSpec(normalizeVersion(parts[1]))
 		}
 	}
 	return ""
@@ -139,10 +329,10 @@ func parseMiseToml(content string) string {
 	// nodejs = "20.10.0"
 	// [tools]
 	// node = "20"
-	re := regexp.MustCompile(`(?:node|nodejs)\s*=\s*"([^"]+)"`)
+	re := regexp.MustCompile(`(?:node|nodejs)\s*=\s*"([^"\s]+)"`)
 	matches := re.FindStringSubmatch(content)
 	if len(matches) > 1 {
-		return normalizeVersion(matches[1])
+		return resolveVersionSpec(normalizeVersion(matches[1]))
 	}
 	return ""
 }
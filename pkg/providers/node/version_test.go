@@ -0,0 +1,84 @@
+package node
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/coollabsio/coolpack/pkg/data"
+)
+
+// TestResolveNodeRange checks ranges resolve to the oldest supported line in
+// them, not an end-of-life first number. The release table is the embedded
+// one, not refreshed data, and the date is fixed: 20 is end-of-life, 22 and
+// 24 are LTS and 26 is current.
+func TestResolveNodeRange(t *testing.T) {
+	releases := data.Embedded().NodeReleases
+	now := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{">=18", "22"},
+		{"^20 || ^22", "22"},
+		{"^22 || ^24", "22"},
+		{"^18 || ^20", "18"},
+		{">=18 <21", "18"},
+		{">= 22.12", "22"},
+		{">=23", "24"},
+		{"^20.0.0", "20"},
+		{"~20.10.0", "20"},
+		{">20.10.0 <20.11", "20"},
+		{"18.x", "18"},
+		{"20.10.0", "20"},
+		{"^16", "16"},
+		{">=25", "26"},
+		{"*", "22"},
+		{">=30", ""},
+		{"latest", ""},
+	}
+	for _, tt := range tests {
+		got, ok := resolveNodeRange(releases, tt.constraint, now)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("resolveNodeRange(%q) = %q, %v; want %q", tt.constraint, got, ok, tt.want)
+		}
+	}
+}
+
+// FuzzParseVersionFile parses arbitrary .nvmrc, .node-version, .tool-versions
+// and mise.toml files and engines.node ranges. The version ends up in the
+// Dockerfile's FROM line, so it must be a single word.
+func FuzzParseVersionFile(f *testing.F) {
+	seeds := []string{
+		"20\n",
+		"v22.11.0",
+		"lts/*",
+		"lts/iron\n",
+		"lts/unknown",
+		"# pinned for the CI\n\n  20.18.1  # comment\n",
+		"nodejs 22.11.0\npython 3.12.0\n",
+		"[tools]\nnode = \"20\"\n",
+		"[tools]\nnodejs = \"lts\"\n",
+		">=18 <21",
+		"^20 || ^22",
+		"\xef\xbb\xbf20\r\n",
+		"",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		for name, parse := range map[string]func(string) string{
+			".nvmrc":         parseVersionFile,
+			".tool-versions": func(content string) string { return parseToolVersions(content, "nodejs") },
+			"mise.toml":      parseMiseToml,
+			"engines.node":   parseEngineVersion,
+		} {
+			if v := parse(content); strings.ContainsFunc(v, unicode.IsSpace) {
+				t.Errorf("%s %q parsed to %q, which isn't a single word", name, content, v)
+			}
+		}
+	})
+}
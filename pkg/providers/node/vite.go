@@ -0,0 +1,111 @@
+package node
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// ViteConfig is the part of vite.config.* that decides where the build is
+// written and the URL path it is served from
+type ViteConfig struct {
+	// File is the config file the values were read from
+	File string
+	// OutDir is the output directory relative to the application root
+	// (build.outDir, under root when that is set), empty for Vite's "dist"
+	OutDir string
+	// Base is the public path the app is served under (e.g., "/app/"),
+	// empty when it is served from "/" or with relative URLs ("./")
+	Base string
+}
+
+// staticPath matches config values that are plain paths rather than
+// expressions such as resolve(__dirname, 'build') or process.env.BASE
+var staticPath = regexp.MustCompile(`^[A-Za-z0-9._~@+/-]*$`)
+
+// DetectViteConfig reads the output directory and base path from the Vite
+// config, or returns nil when there is no config or it keeps the defaults.
+// Values computed when the config runs are reported and ignored.
+func DetectViteConfig(ctx *app.Context) *ViteConfig {
+	parser := acquireConfigParser()
+	defer parser.Release()
+
+	for _, file := range viteConfigs {
+		if !ctx.HasFile(file) {
+			continue
+		}
+		root, data, ok := parseConfig(ctx, parser, file)
+		if !ok {
+			continue
+		}
+
+		setting := func(path ...string) string {
+			value, _ := configSettingOf(ctx, file, root, data, path...)
+			return value
+		}
+		cfg := &ViteConfig{File: file}
+		outDir := viteConfigPath(setting("build", "outDir"))
+		if dir := viteConfigPath(setting("root")); dir != "" || outDir != "" {
+			if outDir == "" {
+				outDir = "dist"
+			}
+			cfg.OutDir = path.Join(dir, outDir)
+		}
+		cfg.Base = viteBasePath(setting("base"))
+		if cfg.OutDir == "" && cfg.Base == "" {
+			return nil
+		}
+		return cfg
+	}
+	return nil
+}
+
+// viteConfigPath returns a directory value as a clean path inside the
+// application, or "" for the default, expressions and paths outside it
+func viteConfigPath(value string) string {
+	if value == "" || !staticPath.MatchString(value) {
+		return ""
+	}
+	dir := path.Clean(value)
+	if dir == "." || strings.HasPrefix(dir, "/") || dir == ".." || strings.HasPrefix(dir, "../") {
+		return ""
+	}
+	return dir
+}
+
+// viteBasePath returns base as "/path/", or "" when the app is served from
+// the root: "/", relative URLs ("./", "") and full URLs, which only move the
+// assets to another host
+func viteBasePath(value string) string {
+	if !strings.HasPrefix(value, "/") || !staticPath.MatchString(value) {
+		return ""
+	}
+	base := path.Clean(value)
+	if base == "/" {
+		return ""
+	}
+	return base + "/"
+}
+
+// applyBasePath moves the framework's fallback, cache and error page paths
+// under the base path the output is served from. Hosting rules
+// (_redirects, vercel.json) already name full paths and are kept.
+func applyBasePath(r *app.Routing, base string) {
+	prefix := strings.TrimSuffix(base, "/")
+	if f := r.Fallback; f != nil {
+		f.Document = prefix + f.Document
+		for i, p := range f.ExcludePrefixes {
+			f.ExcludePrefixes[i] = prefix + p
+		}
+	}
+	if c := r.Cache; c != nil {
+		for i, p := range c.ImmutablePrefixes {
+			c.ImmutablePrefixes[i] = prefix + p
+		}
+	}
+	for i := range r.ErrorPages {
+		r.ErrorPages[i].Document = prefix + r.ErrorPages[i].Document
+	}
+}
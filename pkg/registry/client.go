@@ -0,0 +1,287 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Manifest is a manifest or index fetched from a registry
+type Manifest struct {
+	MediaType string
+	Digest    string
+	Body      []byte
+}
+
+// Client pulls and pushes manifests and blobs with the OCI distribution API,
+// without a container daemon. The target registry gets EnvCredentials when
+// they are set; every registry otherwise gets its LookupCredentials, or is
+// accessed anonymously.
+type Client struct {
+	client *http.Client
+	target string
+
+	mu   sync.Mutex
+	auth map[string]string // "<registry>/<repository>" -> Authorization header
+}
+
+// NewClient creates a registry client for pushing to the target registry host
+// (e.g., "ghcr.io"). Requests are bounded by their context, not a client
+// timeout, since layer uploads can take a while.
+func NewClient(target string) *Client {
+	return &Client{client: &http.Client{}, target: target, auth: make(map[string]string)}
+}
+
+// credentials returns the credentials for a registry. COOLPACK_REGISTRY_*
+// only go to the target: sending them to the registry of a public base image
+// (e.g., Docker Hub for caddy) would leak them, and fail the pull.
+func (c *Client) credentials(registry string) Credentials {
+	if registry == c.target {
+		if creds := EnvCredentials(); !creds.Empty() {
+			return creds
+		}
+	}
+	return LookupCredentials(registry)
+}
+
+// GetManifest fetches a manifest or index by tag or digest
+func (c *Client) GetManifest(ctx context.Context, ref Reference, reference string) (*Manifest, error) {
+	resp, err := c.do(ctx, ref, false, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint(ref, "manifests", reference), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, "manifest "+ref.Repository+":"+reference)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = Digest(body)
+	}
+	return &Manifest{MediaType: strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0]), Digest: digest, Body: body}, nil
+}
+
+// GetBlob opens a blob for reading
+func (c *Client) GetBlob(ctx context.Context, ref Reference, digest string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, ref, false, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, endpoint(ref, "blobs", digest), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, statusError(resp, "blob "+digest)
+	}
+	return resp.Body, nil
+}
+
+// BlobExists checks if a repository already has a blob
+func (c *Client) BlobExists(ctx context.Context, ref Reference, digest string) (bool, error) {
+	resp, err := c.do(ctx, ref, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodHead, endpoint(ref, "blobs", digest), nil)
+	})
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, statusError(resp, "blob "+digest)
+}
+
+// MountBlob links a blob from another repository of the same registry, so it
+// doesn't have to be uploaded. It returns false if the registry didn't mount it.
+func (c *Client) MountBlob(ctx context.Context, ref Reference, digest, from string) (bool, error) {
+	resp, err := c.do(ctx, ref, true, func() (*http.Request, error) {
+		u := endpoint(ref, "blobs", "uploads/") + "?" + url.Values{"mount": {digest}, "from": {from}}.Encode()
+		return http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	})
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusCreated, nil
+}
+
+// PushBlob uploads a blob in a single request. open is called for each attempt,
+// since the upload is retried after authenticating.
+func (c *Client) PushBlob(ctx context.Context, ref Reference, digest string, size int64, open func() (io.ReadCloser, error)) error {
+	resp, err := c.do(ctx, ref, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodPost, endpoint(ref, "blobs", "uploads/"), nil)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return statusError(resp, "upload of "+digest)
+	}
+
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location: %w", err)
+	}
+	q := location.Query()
+	q.Set("digest", digest)
+	location.RawQuery = q.Encode()
+
+	resp, err = c.do(ctx, ref, true, func() (*http.Request, error) {
+		body, err := open()
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, location.String(), body)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/octet-stream")
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return statusError(resp, "upload of "+digest)
+	}
+	return nil
+}
+
+// PutManifest pushes a manifest or index under a tag or digest and returns its digest
+func (c *Client) PutManifest(ctx context.Context, ref Reference, reference, mediaType string, body []byte) (string, error) {
+	resp, err := c.do(ctx, ref, true, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint(ref, "manifests", reference), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", mediaType)
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", statusError(resp, "manifest "+ref.Repository+":"+reference)
+	}
+	return Digest(body), nil
+}
+
+// do sends a request, authenticating and retrying once if the registry asks for it.
+// Push requests ask for pull and push access to the repository.
+func (c *Client) do(ctx context.Context, ref Reference, push bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	key := ref.Registry + "/" + ref.Repository
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	auth := c.auth[key]
+	c.mu.Unlock()
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	auth, err = c.authorize(ctx, ref, push, resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate to %s: %w", ref.Registry, err)
+	}
+	c.mu.Lock()
+	c.auth[key] = auth
+	c.mu.Unlock()
+
+	req, err = newRequest()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", auth)
+	return c.client.Do(req)
+}
+
+// authorize answers a WWW-Authenticate challenge with an Authorization header value
+func (c *Client) authorize(ctx context.Context, ref Reference, push bool, challenge string) (string, error) {
+	creds := c.credentials(ref.Registry)
+
+	scheme, _, _ := strings.Cut(challenge, " ")
+	if strings.EqualFold(scheme, "Basic") {
+		if creds.Empty() && ref.Registry == c.target {
+			return "", fmt.Errorf("no credentials (run docker login or set COOLPACK_REGISTRY_USERNAME and COOLPACK_REGISTRY_PASSWORD)")
+		}
+		if creds.Empty() {
+			return "", fmt.Errorf("no credentials (run docker login %s)", ref.Registry)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(creds.Username, creds.Password)
+		return req.Header.Get("Authorization"), nil
+	}
+
+	actions := "pull"
+	if push {
+		actions = "pull,push"
+	}
+	token, err := fetchToken(ctx, c.client, challenge, fmt.Sprintf("repository:%s:%s", ref.Repository, actions), creds)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
+// endpoint returns the URL of a repository's manifests or blobs endpoint.
+// localhost registries are reached over plain HTTP, like Docker does.
+func endpoint(ref Reference, kind, reference string) string {
+	scheme := "https"
+	host := ref.Registry
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		host = h
+	}
+	if host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", scheme, ref.Registry, ref.Repository, kind, reference)
+}
+
+// statusError describes an unexpected registry response
+func statusError(resp *http.Response, what string) error {
+	return fmt.Errorf("registry returned %s for %s", resp.Status, what)
+}
+
+// Digest returns the sha256 digest of content ("sha256:...")
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
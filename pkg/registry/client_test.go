@@ -0,0 +1,238 @@
+package registry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coollabsio/coolpack/pkg/registry"
+	"github.com/coollabsio/coolpack/pkg/registry/registrytest"
+)
+
+const manifestType = "application/vnd.oci.image.manifest.v1+json"
+
+// isolate keeps the tests from the user's Docker config and registry variables
+func isolate(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv("COOLPACK_REGISTRY_USERNAME", "")
+	t.Setenv("COOLPACK_REGISTRY_PASSWORD", "")
+}
+
+func ref(t *testing.T, image string) registry.Reference {
+	t.Helper()
+	r, err := registry.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func open(content []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+}
+
+func TestPushAndPull(t *testing.T) {
+	for name, auth := range map[string]registrytest.Auth{"bearer": registrytest.Bearer, "basic": registrytest.Basic} {
+		t.Run(name, func(t *testing.T) {
+			isolate(t)
+			t.Setenv("COOLPACK_REGISTRY_USERNAME", "ci")
+			t.Setenv("COOLPACK_REGISTRY_PASSWORD", "secret")
+			reg := registrytest.New(auth)
+			defer reg.Close()
+			reg.AddUser("ci", "secret")
+
+			ctx := context.Background()
+			target := ref(t, reg.Host+"/acme/site:latest")
+			client := registry.NewClient(reg.Host)
+
+			blob := []byte("layer content")
+			digest := registry.Digest(blob)
+			if exists, err := client.BlobExists(ctx, target, digest); err != nil || exists {
+				t.Fatalf("BlobExists before the push = %v, %v; want false", exists, err)
+			}
+			if err := client.PushBlob(ctx, target, digest, int64(len(blob)), open(blob)); err != nil {
+				t.Fatalf("PushBlob: %v", err)
+			}
+			if got, ok := reg.Blob("acme/site", digest); !ok || !bytes.Equal(got, blob) {
+				t.Fatalf("registry has blob %q, want %q", got, blob)
+			}
+			if exists, err := client.BlobExists(ctx, target, digest); err != nil || !exists {
+				t.Errorf("BlobExists after the push = %v, %v; want true", exists, err)
+			}
+
+			body := []byte(`{"schemaVersion": 2}`)
+			pushed, err := client.PutManifest(ctx, target, "latest", manifestType, body)
+			if err != nil {
+				t.Fatalf("PutManifest: %v", err)
+			}
+			if pushed != registry.Digest(body) {
+				t.Errorf("PutManifest digest = %s, want %s", pushed, registry.Digest(body))
+			}
+
+			m, err := client.GetManifest(ctx, target, "latest")
+			if err != nil {
+				t.Fatalf("GetManifest: %v", err)
+			}
+			if m.MediaType != manifestType || m.Digest != pushed || !bytes.Equal(m.Body, body) {
+				t.Errorf("GetManifest = %+v, want the pushed manifest", m)
+			}
+
+			r, err := client.GetBlob(ctx, target, digest)
+			if err != nil {
+				t.Fatalf("GetBlob: %v", err)
+			}
+			got, _ := io.ReadAll(r)
+			r.Close()
+			if !bytes.Equal(got, blob) {
+				t.Errorf("GetBlob = %q, want %q", got, blob)
+			}
+		})
+	}
+}
+
+func TestPushWithoutCredentials(t *testing.T) {
+	isolate(t)
+	reg := registrytest.New(registrytest.Bearer)
+	defer reg.Close()
+	reg.AddUser("ci", "secret")
+
+	target := ref(t, reg.Host+"/acme/site:latest")
+	_, err := registry.NewClient(reg.Host).PutManifest(context.Background(), target, "latest", manifestType, []byte(`{}`))
+	if err == nil {
+		t.Fatal("anonymous push succeeded")
+	}
+}
+
+// TestEnvCredentialsOnlyForTarget pulls a public base image from one registry
+// and pushes to another: COOLPACK_REGISTRY_* must only reach the target
+func TestEnvCredentialsOnlyForTarget(t *testing.T) {
+	isolate(t)
+	t.Setenv("COOLPACK_REGISTRY_USERNAME", "ci")
+	t.Setenv("COOLPACK_REGISTRY_PASSWORD", "secret")
+
+	base := registrytest.New(registrytest.Bearer)
+	defer base.Close()
+	base.PutManifest("library/caddy", "2", manifestType, []byte(`{"schemaVersion": 2}`))
+	target := registrytest.New(registrytest.Bearer)
+	defer target.Close()
+	target.AddUser("ci", "secret")
+
+	ctx := context.Background()
+	client := registry.NewClient(target.Host)
+	if _, err := client.GetManifest(ctx, ref(t, base.Host+"/library/caddy:2"), "2"); err != nil {
+		t.Fatalf("pull from the base registry: %v", err)
+	}
+	if _, err := client.PutManifest(ctx, ref(t, target.Host+"/acme/site:latest"), "latest", manifestType, []byte(`{}`)); err != nil {
+		t.Fatalf("push to the target registry: %v", err)
+	}
+
+	for _, auth := range base.TokenAuthorizations() {
+		if auth != "" {
+			t.Errorf("base registry received credentials %q", auth)
+		}
+	}
+	authenticated := false
+	for _, auth := range target.TokenAuthorizations() {
+		authenticated = authenticated || auth != ""
+	}
+	if !authenticated {
+		t.Error("target registry received no credentials")
+	}
+}
+
+// TestDockerConfigCredentials uses `docker login` credentials for a registry
+// that isn't the target
+func TestDockerConfigCredentials(t *testing.T) {
+	isolate(t)
+	base := registrytest.New(registrytest.Basic)
+	defer base.Close()
+	base.AddUser("reader", "token")
+	base.PutManifest("private/base", "1", manifestType, []byte(`{"schemaVersion": 2}`))
+
+	auth := base64.StdEncoding.EncodeToString([]byte("reader:token"))
+	config := `{"auths": {"` + base.Host + `": {"auth": "` + auth + `"}}}`
+	if err := os.WriteFile(filepath.Join(os.Getenv("DOCKER_CONFIG"), "config.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := registry.NewClient("ghcr.io")
+	if _, err := client.GetManifest(context.Background(), ref(t, base.Host+"/private/base:1"), "1"); err != nil {
+		t.Fatalf("pull with Docker config credentials: %v", err)
+	}
+}
+
+func TestMountBlob(t *testing.T) {
+	isolate(t)
+	t.Setenv("COOLPACK_REGISTRY_USERNAME", "ci")
+	t.Setenv("COOLPACK_REGISTRY_PASSWORD", "secret")
+	reg := registrytest.New(registrytest.Bearer)
+	defer reg.Close()
+	reg.AddUser("ci", "secret")
+	digest := reg.PutBlob("library/caddy", []byte("base layer"))
+
+	ctx := context.Background()
+	client := registry.NewClient(reg.Host)
+	target := ref(t, reg.Host+"/acme/site:latest")
+
+	mounted, err := client.MountBlob(ctx, target, digest, "library/caddy")
+	if err != nil || !mounted {
+		t.Fatalf("MountBlob = %v, %v; want mounted", mounted, err)
+	}
+	if _, ok := reg.Blob("acme/site", digest); !ok {
+		t.Error("mounted blob is missing from the target repository")
+	}
+
+	mounted, err = client.MountBlob(ctx, target, registry.Digest([]byte("unknown")), "library/caddy")
+	if err != nil || mounted {
+		t.Errorf("MountBlob of a missing blob = %v, %v; want not mounted", mounted, err)
+	}
+	for _, req := range reg.Requests() {
+		if req.Method == "PUT" && strings.Contains(req.Path, "/blobs/uploads/") {
+			t.Errorf("blob was uploaded instead of mounted: %s %s", req.Method, req.Path)
+		}
+	}
+}
+
+// TestResolveDigestCredentials resolves a private base image with its
+// registry's Docker config credentials; COOLPACK_REGISTRY_* are for a push
+// target and must not be sent
+func TestResolveDigestCredentials(t *testing.T) {
+	isolate(t)
+	t.Setenv("COOLPACK_REGISTRY_USERNAME", "ci")
+	t.Setenv("COOLPACK_REGISTRY_PASSWORD", "secret")
+
+	private := registrytest.New(registrytest.Basic)
+	defer private.Close()
+	private.AddUser("reader", "token")
+	want := private.PutManifest("private/base", "1", manifestType, []byte(`{"schemaVersion": 2}`))
+	public := registrytest.New(registrytest.Bearer)
+	defer public.Close()
+	public.PutManifest("library/node", "24-slim", manifestType, []byte(`{"schemaVersion": 2}`))
+
+	auth := base64.StdEncoding.EncodeToString([]byte("reader:token"))
+	config := `{"auths": {"` + private.Host + `": {"auth": "` + auth + `"}}}`
+	if err := os.WriteFile(filepath.Join(os.Getenv("DOCKER_CONFIG"), "config.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	resolver := registry.NewResolver()
+	if got, err := resolver.ResolveDigest(ctx, private.Host+"/private/base:1"); err != nil || got != want {
+		t.Errorf("ResolveDigest of the private image = %q, %v; want %q", got, err, want)
+	}
+	if _, err := resolver.ResolveDigest(ctx, public.Host+"/library/node:24-slim"); err != nil {
+		t.Errorf("ResolveDigest of the public image: %v", err)
+	}
+	for _, auth := range public.TokenAuthorizations() {
+		if auth != "" {
+			t.Errorf("public registry received credentials %q", auth)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials are a registry username and password (or token)
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Empty returns true if no credentials are set
+func (c Credentials) Empty() bool {
+	return c.Username == "" && c.Password == ""
+}
+
+// dockerConfig is the part of ~/.docker/config.json that holds credentials
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// EnvCredentials returns the credentials in COOLPACK_REGISTRY_USERNAME and
+// COOLPACK_REGISTRY_PASSWORD. They are meant for the registry an image is
+// pushed to, so the Client only sends them there.
+func EnvCredentials() Credentials {
+	return Credentials{Username: os.Getenv("COOLPACK_REGISTRY_USERNAME"), Password: os.Getenv("COOLPACK_REGISTRY_PASSWORD")}
+}
+
+// LookupCredentials returns the credentials for a registry host from the
+// Docker config (DOCKER_CONFIG or ~/.docker/config.json) and its credential
+// helpers, so `docker login` credentials work without a daemon. It returns
+// empty credentials (anonymous access) when there are none.
+func LookupCredentials(registry string) Credentials {
+	config, ok := readDockerConfig()
+	if !ok {
+		return Credentials{}
+	}
+
+	keys := configKeys(registry)
+
+	for _, key := range keys {
+		if helper := config.CredHelpers[key]; helper != "" {
+			return credentialHelper(helper, key)
+		}
+	}
+
+	for _, key := range keys {
+		auth, ok := config.Auths[key]
+		if !ok {
+			continue
+		}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err == nil {
+				user, pass, _ := strings.Cut(string(decoded), ":")
+				return Credentials{Username: user, Password: pass}
+			}
+		}
+		if auth.Username != "" || auth.Password != "" {
+			return Credentials{Username: auth.Username, Password: auth.Password}
+		}
+	}
+
+	if config.CredsStore != "" {
+		return credentialHelper(config.CredsStore, keys[0])
+	}
+	return Credentials{}
+}
+
+// configKeys returns the keys a registry can have in the Docker config.
+// Docker Hub credentials are stored under its legacy index URL.
+func configKeys(registry string) []string {
+	if registry == dockerHubRegistry || registry == "docker.io" || registry == "index.docker.io" {
+		return []string{"https://index.docker.io/v1/", "index.docker.io", "docker.io", dockerHubRegistry}
+	}
+	return []string{registry, "https://" + registry, "http://" + registry}
+}
+
+func readDockerConfig() (*dockerConfig, bool) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, false
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return nil, false
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, false
+	}
+	return &config, true
+}
+
+// credentialHelper runs docker-credential-<helper> get for a registry
+func credentialHelper(helper, registry string) Credentials {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return Credentials{}
+	}
+
+	var result struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return Credentials{}
+	}
+	return Credentials{Username: result.Username, Password: result.Secret}
+}
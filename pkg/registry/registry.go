@@ -0,0 +1,207 @@
+// Package registry resolves container image tags to content digests using the
+// OCI distribution API, so plans can pin the exact base images they were made with.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+// manifestMediaTypes are accepted when resolving a digest. Index types come
+// first so multi-arch images resolve to the index digest, not one platform.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Reference is a parsed image reference
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference like "node:24-slim",
+// "oven/bun:1-slim" or "ghcr.io/org/app:v1@sha256:..."
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{Registry: dockerHubRegistry, Tag: defaultTag}
+	if image == "" {
+		return ref, fmt.Errorf("empty image reference")
+	}
+
+	name := image
+	if i := strings.Index(name, "@"); i != -1 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+	}
+
+	// The first component is a registry if it looks like a host
+	if i := strings.Index(name, "/"); i != -1 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.Registry = first
+			name = name[i+1:]
+		}
+	}
+
+	// A tag follows the last colon after the last slash (a colon before it is a port)
+	if i := strings.LastIndex(name, ":"); i != -1 && i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	if name == "" {
+		return ref, fmt.Errorf("invalid image reference %q", image)
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+
+	return ref, nil
+}
+
+// resolveTimeout bounds resolving one image, authentication included
+const resolveTimeout = 15 * time.Second
+
+// Resolver resolves image tags to digests. Registries are accessed with the
+// Docker config credentials for their host (LookupCredentials), or
+// anonymously; COOLPACK_REGISTRY_* belong to a push target and are never sent.
+type Resolver struct {
+	client *Client
+}
+
+// NewResolver creates a resolver
+func NewResolver() *Resolver {
+	return &Resolver{client: NewClient("")}
+}
+
+// ResolveDigest returns the manifest digest (e.g., "sha256:...") the image's tag points to.
+// References that already include a digest are returned as-is.
+func (r *Resolver) ResolveDigest(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	resp, err := r.client.do(ctx, ref, false, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint(ref, "manifests", ref.Tag), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %d for %s", ref.Registry, resp.StatusCode, image)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("%s did not return a digest for %s", ref.Registry, image)
+	}
+	return digest, nil
+}
+
+// fetchToken requests a token from the realm in a Bearer challenge. The scope
+// overrides the challenge's scope (e.g., to ask for push access); credentials
+// are sent with basic auth when set.
+func fetchToken(ctx context.Context, client *http.Client, challenge, scope string, creds Credentials) (string, error) {
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("unsupported auth challenge %q", challenge)
+	}
+	if scope != "" {
+		params["scope"] = scope
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm, nil)
+	if err != nil {
+		return "", err
+	}
+	q := req.URL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			q.Set(key, params[key])
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+	if !creds.Empty() {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallenge parses `Bearer realm="...",service="...",scope="..."`
+func parseChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+
+	scheme, rest, ok := strings.Cut(challenge, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return params
+	}
+
+	for rest != "" {
+		key, value, ok := strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end == -1 {
+				break
+			}
+			params[strings.ToLower(key)] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[strings.ToLower(key)] = value
+		}
+	}
+
+	return params
+}
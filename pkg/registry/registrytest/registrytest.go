@@ -0,0 +1,310 @@
+// Package registrytest provides an in-memory OCI distribution registry for
+// testing registry clients, like net/http/httptest does for HTTP clients.
+package registrytest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/coollabsio/coolpack/pkg/registry"
+)
+
+// Auth is how a Registry authenticates requests
+type Auth int
+
+const (
+	// Bearer hands out tokens from a token endpoint, like Docker Hub and
+	// ghcr.io: anyone may pull, pushing needs a user's credentials, and
+	// credentials the registry doesn't know are rejected with 401 even for pulls
+	Bearer Auth = iota
+	// Basic needs a user's credentials on every request
+	Basic
+)
+
+// Request is a request the registry received
+type Request struct {
+	Method string
+	// Path is the URL path, Query the raw query
+	Path  string
+	Query string
+	// Authorization is the request's Authorization header
+	Authorization string
+}
+
+type manifest struct {
+	mediaType string
+	body      []byte
+}
+
+// Registry is an in-memory registry served over HTTP on 127.0.0.1, which
+// registry clients reach over plain HTTP
+type Registry struct {
+	*httptest.Server
+
+	// Host is the registry's host and port, as used in image references
+	Host string
+
+	auth Auth
+
+	mu        sync.Mutex
+	users     map[string]string
+	tokens    map[string]string // token -> "<repository>:<actions>"
+	blobs     map[string]map[string][]byte
+	manifests map[string]map[string]manifest
+	uploads   map[string]string // upload ID -> repository
+	requests  []Request
+	tokenAuth []string
+}
+
+// New starts a registry; the caller closes it
+func New(auth Auth) *Registry {
+	r := &Registry{
+		auth:      auth,
+		users:     make(map[string]string),
+		tokens:    make(map[string]string),
+		blobs:     make(map[string]map[string][]byte),
+		manifests: make(map[string]map[string]manifest),
+		uploads:   make(map[string]string),
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	r.Host = strings.TrimPrefix(r.URL, "http://")
+	return r
+}
+
+// AddUser adds a user who may push
+func (r *Registry) AddUser(username, password string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[username] = password
+}
+
+// PutBlob stores a blob in a repository and returns its digest
+func (r *Registry) PutBlob(repository string, content []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	digest := registry.Digest(content)
+	r.putBlob(repository, digest, content)
+	return digest
+}
+
+// PutManifest stores a manifest in a repository under a tag and its digest,
+// and returns the digest
+func (r *Registry) PutManifest(repository, tag, mediaType string, body []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.putManifest(repository, tag, mediaType, body)
+}
+
+// Blob returns a blob of a repository
+func (r *Registry) Blob(repository, digest string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	content, ok := r.blobs[repository][digest]
+	return content, ok
+}
+
+// Manifest returns the media type and body of a manifest by tag or digest
+func (r *Registry) Manifest(repository, reference string) (string, []byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.manifests[repository][reference]
+	return m.mediaType, m.body, ok
+}
+
+// Requests returns the registry API requests received so far
+func (r *Registry) Requests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Request(nil), r.requests...)
+}
+
+// TokenAuthorizations returns the Authorization headers of the token
+// requests received so far ("" for anonymous ones)
+func (r *Registry) TokenAuthorizations() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.tokenAuth...)
+}
+
+func (r *Registry) putBlob(repository, digest string, content []byte) {
+	if r.blobs[repository] == nil {
+		r.blobs[repository] = make(map[string][]byte)
+	}
+	r.blobs[repository][digest] = content
+}
+
+func (r *Registry) putManifest(repository, reference, mediaType string, body []byte) string {
+	if r.manifests[repository] == nil {
+		r.manifests[repository] = make(map[string]manifest)
+	}
+	digest := registry.Digest(body)
+	r.manifests[repository][reference] = manifest{mediaType: mediaType, body: body}
+	r.manifests[repository][digest] = manifest{mediaType: mediaType, body: body}
+	return digest
+}
+
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		r.serveToken(w, req)
+		return
+	}
+
+	r.mu.Lock()
+	r.requests = append(r.requests, Request{Method: req.Method, Path: req.URL.Path, Query: req.URL.RawQuery, Authorization: req.Header.Get("Authorization")})
+	r.mu.Unlock()
+
+	repository, kind, reference, ok := parsePath(req.URL.Path)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	action := "pull"
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		action = "push"
+	}
+	if !r.authorized(w, req, repository, action) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case kind == "manifests" && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+		m, ok := r.manifests[repository][reference]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Docker-Content-Digest", registry.Digest(m.body))
+		w.Write(m.body)
+	case kind == "manifests" && req.Method == http.MethodPut:
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", r.putManifest(repository, reference, req.Header.Get("Content-Type"), body))
+		w.WriteHeader(http.StatusCreated)
+	case kind == "blobs" && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+		content, ok := r.blobs[repository][reference]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", reference)
+		w.Write(content)
+	case kind == "uploads" && req.Method == http.MethodPost:
+		q := req.URL.Query()
+		if content, ok := r.blobs[q.Get("from")][q.Get("mount")]; ok {
+			r.putBlob(repository, q.Get("mount"), content)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		id := fmt.Sprintf("upload-%d", len(r.uploads)+1)
+		r.uploads[id] = repository
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s?state=1", repository, id))
+		w.WriteHeader(http.StatusAccepted)
+	case kind == "uploads" && req.Method == http.MethodPut:
+		if r.uploads[reference] != repository {
+			http.NotFound(w, req)
+			return
+		}
+		delete(r.uploads, reference)
+		content, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		digest := req.URL.Query().Get("digest")
+		if registry.Digest(content) != digest {
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+			return
+		}
+		r.putBlob(repository, digest, content)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+	}
+}
+
+// parsePath splits /v2/<repository>/<manifests|blobs>/<reference> and
+// /v2/<repository>/blobs/uploads/[<id>] (kind "uploads")
+func parsePath(path string) (repository, kind, reference string, ok bool) {
+	rest, ok := strings.CutPrefix(path, "/v2/")
+	if !ok {
+		return "", "", "", false
+	}
+	if repository, reference, ok := strings.Cut(rest, "/blobs/uploads/"); ok {
+		return repository, "uploads", reference, true
+	}
+	for _, kind := range []string{"manifests", "blobs"} {
+		if i := strings.LastIndex(rest, "/"+kind+"/"); i > 0 {
+			return rest[:i], kind, rest[i+len(kind)+2:], true
+		}
+	}
+	return "", "", "", false
+}
+
+// authorized checks a request's credentials or token, answering 401 with a
+// challenge when they don't allow the action
+func (r *Registry) authorized(w http.ResponseWriter, req *http.Request, repository, action string) bool {
+	auth := req.Header.Get("Authorization")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch r.auth {
+	case Basic:
+		if user, pass, ok := req.BasicAuth(); ok && r.users[user] == pass && pass != "" {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="registrytest"`)
+	default:
+		if grant, ok := r.tokens[strings.TrimPrefix(auth, "Bearer ")]; ok && strings.HasPrefix(auth, "Bearer ") {
+			name, actions, _ := strings.Cut(grant, ":")
+			if name == repository && strings.Contains(actions, action) {
+				return true
+			}
+		}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registrytest",scope="repository:%s:%s"`, r.URL, repository, action))
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+	return false
+}
+
+// serveToken grants pull access to anyone and push access to users, for the
+// requested repository scope
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokenAuth = append(r.tokenAuth, req.Header.Get("Authorization"))
+
+	authenticated := false
+	if user, pass, ok := req.BasicAuth(); ok {
+		if r.users[user] != pass || pass == "" {
+			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+			return
+		}
+		authenticated = true
+	}
+
+	scope := strings.Split(req.URL.Query().Get("scope"), ":")
+	if len(scope) != 3 || scope[0] != "repository" {
+		http.Error(w, "invalid scope", http.StatusBadRequest)
+		return
+	}
+	actions := "pull"
+	if authenticated && strings.Contains(scope[2], "push") {
+		actions = "pull,push"
+	}
+	token := base64.RawURLEncoding.EncodeToString([]byte(url.PathEscape(scope[1]) + fmt.Sprintf("#%d", len(r.tokens))))
+	r.tokens[token] = scope[1] + ":" + actions
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"token": %q}`, token)
+}
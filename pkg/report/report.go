@@ -0,0 +1,260 @@
+// Package report builds machine-readable build reports (stage durations, cache
+// hits, image and layer sizes) from BuildKit's plain progress output.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/analyze"
+)
+
+// Step is a BuildKit build step (vertex)
+type Step struct {
+	// Name is the step as BuildKit prints it (e.g., "[builder 3/7] RUN npm ci")
+	Name string `json:"name"`
+
+	// Stage is the Dockerfile stage (e.g., "builder", "runner"), or "internal"
+	// for BuildKit's own steps (loading the Dockerfile, exporting the image)
+	Stage string `json:"stage"`
+
+	// Duration is how long the step ran, in seconds
+	Duration float64 `json:"duration"`
+
+	// Cached is true if the step was a cache hit
+	Cached bool `json:"cached"`
+
+	// Error is set if the step failed
+	Error string `json:"error,omitempty"`
+}
+
+// Stage summarizes the steps of one Dockerfile stage
+type Stage struct {
+	Name      string  `json:"name"`
+	Duration  float64 `json:"duration"`
+	Steps     int     `json:"steps"`
+	CacheHits int     `json:"cache_hits"`
+}
+
+// Report is the result of a build
+type Report struct {
+	Image      string    `json:"image"`
+	Success    bool      `json:"success"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Duration is the wall-clock build time in seconds
+	Duration float64 `json:"duration"`
+
+	Stages []Stage `json:"stages"`
+	Steps  []Step  `json:"steps"`
+
+	// CacheHits and CacheMisses count the Dockerfile steps (internal steps excluded)
+	CacheHits   int `json:"cache_hits"`
+	CacheMisses int `json:"cache_misses"`
+
+	// ImageSize and Layers are only set for images loaded into the local image store
+	ImageSize int64           `json:"image_size,omitempty"`
+	Layers    []analyze.Layer `json:"layers,omitempty"`
+}
+
+var (
+	// progressLine matches "#12 <text>" lines of --progress=plain output
+	progressLine = regexp.MustCompile(`^#(\d+) (.*)$`)
+
+	// stepStage extracts the stage from "[builder 3/7] RUN ..." or "[internal] load ..."
+	stepStage = regexp.MustCompile(`^\[([^\] ]+)(?: \d+/\d+)?\]`)
+
+	// doneLine matches "DONE 1.2s"
+	doneLine = regexp.MustCompile(`^DONE (\d+(?:\.\d+)?)s$`)
+)
+
+// Parser collects steps from BuildKit --progress=plain output. It is an
+// io.Writer, so it can be attached to the build's stderr next to the terminal.
+type Parser struct {
+	mu      sync.Mutex
+	partial []byte
+	order   []int
+	steps   map[int]*Step
+}
+
+// NewParser creates a progress parser
+func NewParser() *Parser {
+	return &Parser{steps: make(map[int]*Step)}
+}
+
+// Write parses complete lines of progress output
+func (p *Parser) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i == -1 {
+			break
+		}
+		p.parseLine(strings.TrimRight(string(p.partial[:i]), "\r"))
+		p.partial = p.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+func (p *Parser) parseLine(line string) {
+	m := progressLine.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	id, _ := strconv.Atoi(m[1])
+	text := m[2]
+	if id == 0 {
+		// "#0 building with ... instance" names the builder, not a step
+		return
+	}
+
+	step, ok := p.steps[id]
+	if !ok {
+		// The first line of a step is its name
+		step = &Step{Name: text, Stage: "internal"}
+		if sm := stepStage.FindStringSubmatch(text); sm != nil {
+			step.Stage = sm[1]
+		}
+		p.steps[id] = step
+		p.order = append(p.order, id)
+		return
+	}
+
+	switch {
+	case text == "CACHED":
+		step.Cached = true
+	case strings.HasPrefix(text, "ERROR"):
+		step.Error = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(text, "ERROR"), ":"))
+	default:
+		if dm := doneLine.FindStringSubmatch(text); dm != nil {
+			step.Duration, _ = strconv.ParseFloat(dm[1], 64)
+		}
+	}
+}
+
+// Steps returns the parsed steps in the order they started
+func (p *Parser) Steps() []Step {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	steps := make([]Step, 0, len(p.order))
+	for _, id := range p.order {
+		steps = append(steps, *p.steps[id])
+	}
+	return steps
+}
+
+// New builds a report from the parsed steps
+func New(image string, steps []Step, startedAt, finishedAt time.Time, success bool) *Report {
+	r := &Report{
+		Image:      image,
+		Success:    success,
+		StartedAt:  startedAt.UTC(),
+		FinishedAt: finishedAt.UTC(),
+		Duration:   roundSeconds(finishedAt.Sub(startedAt).Seconds()),
+		Steps:      steps,
+	}
+
+	stages := make(map[string]*Stage)
+	var stageOrder []string
+	for _, step := range steps {
+		s, ok := stages[step.Stage]
+		if !ok {
+			s = &Stage{Name: step.Stage}
+			stages[step.Stage] = s
+			stageOrder = append(stageOrder, step.Stage)
+		}
+		s.Steps++
+		s.Duration = roundSeconds(s.Duration + step.Duration)
+		if step.Cached {
+			s.CacheHits++
+		}
+
+		if step.Stage == "internal" {
+			continue
+		}
+		// FROM steps resolve images and are never reported as cached
+		if strings.Contains(step.Name, "] FROM ") {
+			continue
+		}
+		if step.Cached {
+			r.CacheHits++
+		} else {
+			r.CacheMisses++
+		}
+	}
+	for _, name := range stageOrder {
+		r.Stages = append(r.Stages, *stages[name])
+	}
+
+	return r
+}
+
+// Summary returns a human-readable summary: duration, cache hit rate, the
+// slowest steps and the largest layers
+func (r *Report) Summary() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Build report for %s\n", r.Image))
+	sb.WriteString(fmt.Sprintf("  Duration:   %.1fs\n", r.Duration))
+	if total := r.CacheHits + r.CacheMisses; total > 0 {
+		sb.WriteString(fmt.Sprintf("  Cache:      %d/%d steps cached (%d%%)\n", r.CacheHits, total, r.CacheHits*100/total))
+	}
+	if r.ImageSize > 0 {
+		sb.WriteString(fmt.Sprintf("  Image size: %s\n", analyze.FormatSize(r.ImageSize)))
+	}
+
+	if len(r.Stages) > 0 {
+		sb.WriteString("\n  Stages:\n")
+		for _, s := range r.Stages {
+			sb.WriteString(fmt.Sprintf("    %-12s %7.1fs  %d steps, %d cached\n", s.Name, s.Duration, s.Steps, s.CacheHits))
+		}
+	}
+
+	slowest := append([]Step(nil), r.Steps...)
+	sort.SliceStable(slowest, func(i, j int) bool {
+		return slowest[i].Duration > slowest[j].Duration
+	})
+	if len(slowest) > 5 {
+		slowest = slowest[:5]
+	}
+	if len(slowest) > 0 && slowest[0].Duration > 0 {
+		sb.WriteString("\n  Slowest steps:\n")
+		for _, s := range slowest {
+			if s.Duration == 0 {
+				break
+			}
+			sb.WriteString(fmt.Sprintf("    %7.1fs  %s\n", s.Duration, truncate(s.Name, 70)))
+		}
+	}
+
+	if len(r.Layers) > 0 {
+		sb.WriteString("\n  Largest layers:\n")
+		for _, l := range analyze.LargestLayers(r.Layers, 5) {
+			sb.WriteString(fmt.Sprintf("    %10s  %s\n", analyze.FormatSize(l.Size), truncate(l.CreatedBy, 70)))
+		}
+	}
+
+	return sb.String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+func roundSeconds(s float64) float64 {
+	return float64(int64(s*10+0.5)) / 10
+}
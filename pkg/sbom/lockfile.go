@@ -0,0 +1,218 @@
+package sbom
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/app"
+)
+
+// ErrNoLockfile is returned by ReadLockfile when the app has no supported lockfile
+var ErrNoLockfile = errors.New("no supported lockfile found (package-lock.json, npm-shrinkwrap.json, yarn.lock, pnpm-lock.yaml or bun.lock)")
+
+// lockfileParsers are tried in order; the first lockfile that exists is used
+var lockfileParsers = []struct {
+	file  string
+	parse func([]byte) ([]Component, error)
+}{
+	{"package-lock.json", parsePackageLock},
+	{"npm-shrinkwrap.json", parsePackageLock},
+	{"pnpm-lock.yaml", parsePnpmLock},
+	{"yarn.lock", parseYarnLock},
+	{"bun.lock", parseBunLock},
+}
+
+// ReadLockfile returns the npm packages locked in the app's lockfile and the
+// lockfile's name. Dev dependencies are skipped when the lockfile marks them.
+func ReadLockfile(root string) ([]Component, string, error) {
+	for _, lf := range lockfileParsers {
+		data, err := os.ReadFile(filepath.Join(root, lf.file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, lf.file, err
+		}
+		components, err := lf.parse(app.NormalizeText(data))
+		return components, lf.file, err
+	}
+	return nil, "", ErrNoLockfile
+}
+
+// parsePackageLock parses npm lockfiles: v2/v3 "packages" or v1 "dependencies"
+func parsePackageLock(data []byte) ([]Component, error) {
+	type lockDep struct {
+		Version      string              `json:"version"`
+		Dev          bool                `json:"dev"`
+		Link         bool                `json:"link"`
+		Dependencies map[string]*lockDep `json:"dependencies"`
+	}
+	var lock struct {
+		Packages     map[string]*lockDep `json:"packages"`
+		Dependencies map[string]*lockDep `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	var components []Component
+	if len(lock.Packages) > 0 {
+		for path, dep := range lock.Packages {
+			i := strings.LastIndex(path, "node_modules/")
+			if i == -1 || dep.Link || dep.Dev || dep.Version == "" {
+				continue
+			}
+			components = append(components, Component{Kind: KindNpm, Name: path[i+len("node_modules/"):], Version: dep.Version})
+		}
+		return components, nil
+	}
+
+	var walk func(deps map[string]*lockDep)
+	walk = func(deps map[string]*lockDep) {
+		for name, dep := range deps {
+			if dep.Dev {
+				continue
+			}
+			components = append(components, Component{Kind: KindNpm, Name: name, Version: dep.Version})
+			walk(dep.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+	return components, nil
+}
+
+// parseYarnLock parses yarn classic and berry lockfiles
+func parseYarnLock(data []byte) ([]Component, error) {
+	var components []Component
+	var name string
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Entry header: `"@babel/core@^7.0.0", "@babel/core@^7.1.0":`
+		if !strings.HasPrefix(line, " ") {
+			name = ""
+			spec, _, _ := strings.Cut(strings.TrimSuffix(line, ":"), ",")
+			spec = strings.Trim(strings.TrimSpace(spec), `"`)
+			if i := strings.LastIndex(spec, "@"); i > 0 {
+				name = spec[:i]
+			}
+			// Berry workspace and patch entries aren't packages from a registry
+			if strings.Contains(spec, "@workspace:") || strings.Contains(spec, "@patch:") {
+				name = ""
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if name == "" || !strings.HasPrefix(trimmed, "version") {
+			continue
+		}
+		value := strings.TrimPrefix(trimmed, "version")
+		value = strings.Trim(strings.TrimSpace(strings.TrimPrefix(value, ":")), `"`)
+		if value != "" && value != "0.0.0-use.local" {
+			components = append(components, Component{Kind: KindNpm, Name: name, Version: value})
+		}
+		name = ""
+	}
+
+	return components, scanner.Err()
+}
+
+// parsePnpmLock parses the "packages" section of pnpm lockfiles (v5 to v9)
+func parsePnpmLock(data []byte) ([]Component, error) {
+	var components []Component
+	var inPackages bool
+	var current *Component
+
+	flush := func() {
+		if current != nil {
+			components = append(components, *current)
+			current = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == 0 {
+			flush()
+			inPackages = line == "packages:"
+			continue
+		}
+		if !inPackages {
+			continue
+		}
+
+		if indent == 2 && strings.HasSuffix(line, ":") {
+			flush()
+			if name, ver := parsePnpmKey(strings.TrimSuffix(strings.TrimSpace(line), ":")); name != "" {
+				current = &Component{Kind: KindNpm, Name: name, Version: ver}
+			}
+			continue
+		}
+
+		// pnpm v5-v8 mark dev-only packages
+		if current != nil && strings.TrimSpace(line) == "dev: true" {
+			current = nil
+		}
+	}
+	flush()
+
+	return components, scanner.Err()
+}
+
+// parsePnpmKey splits "/@scope/name@1.0.0(peer@2.0.0)", "/name/1.0.0" or "name@1.0.0"
+func parsePnpmKey(key string) (string, string) {
+	key = strings.Trim(key, `'"`)
+	key = strings.TrimPrefix(key, "/")
+	if i := strings.Index(key, "("); i != -1 {
+		key = key[:i]
+	}
+
+	if i := strings.LastIndex(key, "@"); i > 0 {
+		name, ver := key[:i], key[i+1:]
+		if strings.Contains(ver, ":") {
+			return "", ""
+		}
+		return name, ver
+	}
+
+	// pnpm v5: /name/version or /@scope/name/version
+	if i := strings.LastIndex(key, "/"); i > 0 {
+		return key[:i], strings.Split(key[i+1:], "_")[0]
+	}
+	return "", ""
+}
+
+// bunPackagePattern matches `"key": ["name@version"` entries in bun.lock
+var bunPackagePattern = regexp.MustCompile(`(?m)^\s+"[^"]+":\s*\["((?:@[^@"/]+/)?[^@"]+)@([^"]+)"`)
+
+// parseBunLock parses the text lockfile of bun 1.2+ (bun.lockb is binary and unsupported)
+func parseBunLock(data []byte) ([]Component, error) {
+	var components []Component
+	for _, m := range bunPackagePattern.FindAllStringSubmatch(string(data), -1) {
+		// Skip workspace, git and file entries
+		if strings.Contains(m[2], ":") {
+			continue
+		}
+		components = append(components, Component{Kind: KindNpm, Name: m[1], Version: m[2]})
+	}
+	return components, nil
+}
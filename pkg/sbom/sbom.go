@@ -0,0 +1,251 @@
+// Package sbom generates software bills of materials for built images from the
+// application's lockfile and the system packages installed in the image.
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coollabsio/coolpack/pkg/version"
+)
+
+// Supported SBOM formats
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+// Component kinds, used as the package URL type
+const (
+	KindNpm = "npm"
+	KindDeb = "deb"
+)
+
+// Component is a package contained in the image
+type Component struct {
+	// Kind is the package ecosystem: "npm" or "deb"
+	Kind string
+
+	// Name is the package name (e.g., "@babel/core", "libvips42")
+	Name string
+
+	// Version is empty when it isn't known (e.g., APT packages from the plan)
+	Version string
+
+	// Arch is the architecture of deb packages (e.g., "amd64")
+	Arch string
+}
+
+// PURL returns the package URL of the component (e.g., "pkg:npm/%40babel/core@7.24.0")
+func (c Component) PURL() string {
+	var sb strings.Builder
+	sb.WriteString("pkg:")
+	sb.WriteString(c.Kind)
+	sb.WriteString("/")
+
+	switch c.Kind {
+	case KindNpm:
+		if scope, name, ok := strings.Cut(c.Name, "/"); ok && strings.HasPrefix(scope, "@") {
+			sb.WriteString("%40")
+			sb.WriteString(url.PathEscape(strings.TrimPrefix(scope, "@")))
+			sb.WriteString("/")
+			sb.WriteString(url.PathEscape(name))
+		} else {
+			sb.WriteString(url.PathEscape(c.Name))
+		}
+	case KindDeb:
+		sb.WriteString("debian/")
+		sb.WriteString(url.PathEscape(c.Name))
+	default:
+		sb.WriteString(url.PathEscape(c.Name))
+	}
+
+	if c.Version != "" {
+		sb.WriteString("@")
+		sb.WriteString(url.PathEscape(c.Version))
+	}
+	if c.Arch != "" {
+		sb.WriteString("?arch=")
+		sb.WriteString(url.QueryEscape(c.Arch))
+	}
+	return sb.String()
+}
+
+// Document is an SBOM for one image
+type Document struct {
+	// Name and Version identify the image (e.g., "my-app", "latest")
+	Name    string
+	Version string
+
+	// Created is the SBOM timestamp
+	Created time.Time
+
+	// Components are the packages in the image, deduplicated
+	Components []Component
+}
+
+// New creates an empty SBOM for an image
+func New(name, version string) *Document {
+	return &Document{Name: name, Version: version, Created: time.Now().UTC()}
+}
+
+// Add adds components, skipping ones already in the document
+func (d *Document) Add(components ...Component) {
+	seen := make(map[string]bool, len(d.Components))
+	for _, c := range d.Components {
+		seen[c.PURL()] = true
+	}
+	for _, c := range components {
+		if c.Name == "" || seen[c.PURL()] {
+			continue
+		}
+		seen[c.PURL()] = true
+		d.Components = append(d.Components, c)
+	}
+}
+
+// Encode returns the SBOM as CycloneDX 1.5 or SPDX 2.3 JSON
+func (d *Document) Encode(format string) ([]byte, error) {
+	components := append([]Component(nil), d.Components...)
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].PURL() < components[j].PURL()
+	})
+
+	var doc interface{}
+	switch format {
+	case FormatCycloneDX, "":
+		doc = d.cycloneDX(components)
+	case FormatSPDX:
+		doc = d.spdx(components)
+	default:
+		return nil, fmt.Errorf("unknown SBOM format %q (use %s or %s)", format, FormatCycloneDX, FormatSPDX)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func (d *Document) cycloneDX(components []Component) map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(components))
+	for _, c := range components {
+		entry := map[string]interface{}{
+			"type":    "library",
+			"bom-ref": c.PURL(),
+			"purl":    c.PURL(),
+		}
+		if scope, name, ok := strings.Cut(c.Name, "/"); ok && c.Kind == KindNpm && strings.HasPrefix(scope, "@") {
+			entry["group"] = scope
+			entry["name"] = name
+		} else {
+			entry["name"] = c.Name
+		}
+		if c.Version != "" {
+			entry["version"] = c.Version
+		}
+		list = append(list, entry)
+	}
+
+	return map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + newUUID(),
+		"version":      1,
+		"metadata": map[string]interface{}{
+			"timestamp": d.Created.Format(time.RFC3339),
+			"tools": map[string]interface{}{
+				"components": []map[string]interface{}{
+					{"type": "application", "name": "coolpack", "version": version.Version},
+				},
+			},
+			"component": map[string]interface{}{
+				"type":    "container",
+				"bom-ref": "image",
+				"name":    d.Name,
+				"version": d.Version,
+			},
+		},
+		"components": list,
+	}
+}
+
+func (d *Document) spdx(components []Component) map[string]interface{} {
+	const imageID = "SPDXRef-Image"
+
+	packages := []map[string]interface{}{
+		{
+			"SPDXID":                imageID,
+			"name":                  d.Name,
+			"versionInfo":           d.Version,
+			"downloadLocation":      "NOASSERTION",
+			"filesAnalyzed":         false,
+			"primaryPackagePurpose": "CONTAINER",
+		},
+	}
+	relationships := []map[string]interface{}{
+		{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": imageID},
+	}
+
+	for i, c := range components {
+		id := fmt.Sprintf("SPDXRef-Package-%s-%d", c.Kind, i+1)
+		pkg := map[string]interface{}{
+			"SPDXID":           id,
+			"name":             c.Name,
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"externalRefs": []map[string]interface{}{
+				{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": c.PURL()},
+			},
+		}
+		if c.Version != "" {
+			pkg["versionInfo"] = c.Version
+		}
+		packages = append(packages, pkg)
+		relationships = append(relationships, map[string]interface{}{
+			"spdxElementId": imageID, "relationshipType": "CONTAINS", "relatedSpdxElement": id,
+		})
+	}
+
+	return map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              d.Name,
+		"documentNamespace": fmt.Sprintf("https://coollabs.io/coolpack/spdx/%s-%s", url.PathEscape(d.Name), newUUID()),
+		"creationInfo": map[string]interface{}{
+			"created":  d.Created.Format(time.RFC3339),
+			"creators": []string{"Tool: coolpack-" + version.Version},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
+
+// ParseDpkgQuery parses `dpkg-query -W -f '${Package}\t${Version}\t${Architecture}\n'` output
+func ParseDpkgQuery(output string) []Component {
+	var components []Component
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		c := Component{Kind: KindDeb, Name: fields[0], Version: fields[1]}
+		if len(fields) > 2 {
+			c.Arch = fields[2]
+		}
+		components = append(components, c)
+	}
+	return components
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,78 @@
+// Package semver compares versions the way npm packages, runtimes and GitHub
+// release tags write them. It is the one comparator for coolpack's own
+// releases, package manager versions and dependency versions.
+package semver
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Parse parses a version such as "1.2.3", "v1.2.0-rc.1", "22.11" or "18"
+// (missing minor and patch numbers are 0). Pre-release tags sort before the
+// release (1.2.0-rc.1 < 1.2.0); build metadata (+build.5) is kept but ignored
+// when comparing.
+func Parse(v string) (*semver.Version, bool) {
+	version, err := semver.NewVersion(v)
+	if err != nil {
+		return nil, false
+	}
+	return version, true
+}
+
+// Compare returns -1, 0 or 1 as a is older than, the same as or newer than b.
+// A version that doesn't parse sorts before every valid one.
+func Compare(a, b string) int {
+	va, okA := Parse(a)
+	vb, okB := Parse(b)
+	switch {
+	case okA && okB:
+		return va.Compare(vb)
+	case okA:
+		return 1
+	case okB:
+		return -1
+	}
+	return 0
+}
+
+// IsNewer checks if version a is newer than b; false when a doesn't parse
+func IsNewer(a, b string) bool {
+	if _, ok := Parse(a); !ok {
+		return false
+	}
+	return Compare(a, b) > 0
+}
+
+// majorPattern matches the major version at the start of a version or a
+// dependency range (e.g., "^5.0.0-beta.4", ">= 18", "v20", "1.x")
+var majorPattern = regexp.MustCompile(`^\s*(?:[\^~<>=v]\s*)*(\d+)`)
+
+// Major returns the major version of a version or dependency range, or false
+// when it starts with none (e.g., "latest", "*", "workspace:*")
+func Major(v string) (int, bool) {
+	m := majorPattern.FindStringSubmatch(v)
+	if m == nil {
+		return 0, false
+	}
+	major, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return major, true
+}
+
+// Satisfies checks if a version is in an npm-style range (e.g., ">=9",
+// "^9 || ^10", "8.x", ">=18 <21"). Pre-releases only satisfy ranges that name
+// a pre-release. A range that doesn't parse (e.g., "latest") is satisfied by
+// nothing, with ok false.
+func Satisfies(v, constraint string) (satisfied, ok bool) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, false
+	}
+	version, valid := Parse(v)
+	return valid && c.Check(version), true
+}
@@ -0,0 +1,181 @@
+// Package starter scaffolds minimal applications that coolpack detects and
+// deploys without overrides: a package.json with the scripts the framework
+// expects, a .nvmrc, a coolpack.toml and a small amount of source code.
+package starter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coollabsio/coolpack/pkg/detector"
+	"github.com/coollabsio/coolpack/pkg/providers/node"
+)
+
+// Starter is a template for a framework
+type Starter struct {
+	Framework   node.Framework
+	Description string
+
+	// OutputDir is the build output served as static files; empty for server apps.
+	// Coolpack detects it, so coolpack.toml only mentions it in a comment.
+	OutputDir string
+
+	// PackageType is the package.json "type" field (e.g., "module")
+	PackageType string
+
+	Scripts         map[string]string
+	Dependencies    map[string]string
+	DevDependencies map[string]string
+
+	// Ignore lists build outputs for .gitignore, next to node_modules and .coolpack
+	Ignore []string
+
+	// Files are the source files, keyed by slash-separated path
+	Files map[string]string
+}
+
+// Options configure the generated files
+type Options struct {
+	// Name is the package name
+	Name string
+
+	// NodeVersion is written to .nvmrc (e.g., "24")
+	NodeVersion string
+
+	// Force overwrites existing files
+	Force bool
+}
+
+// Get returns the starter of a framework
+func Get(framework string) (*Starter, bool) {
+	for i := range starters {
+		if string(starters[i].Framework) == framework {
+			return &starters[i], true
+		}
+	}
+	return nil, false
+}
+
+// List returns every starter, in the order they are documented
+func List() []Starter {
+	return append([]Starter(nil), starters...)
+}
+
+// Names returns the frameworks that have a starter
+func Names() []string {
+	names := make([]string, len(starters))
+	for i, s := range starters {
+		names[i] = string(s.Framework)
+	}
+	return names
+}
+
+// Render returns the starter's files, keyed by slash-separated path
+func (s *Starter) Render(opts Options) (map[string]string, error) {
+	pkg, err := s.packageJSON(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{
+		"package.json":      pkg,
+		".gitignore":        s.gitignore(),
+		detector.ConfigFile: s.config(),
+	}
+	if opts.NodeVersion != "" {
+		files[".nvmrc"] = opts.NodeVersion + "\n"
+	}
+	for name, content := range s.Files {
+		files[name] = content
+	}
+	return files, nil
+}
+
+// Write renders the starter into dir and returns the paths written, relative
+// to dir. Existing files are left untouched and reported as an error unless
+// opts.Force is set.
+func (s *Starter) Write(dir string, opts Options) ([]string, error) {
+	files, err := s.Render(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if !opts.Force {
+		var existing []string
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+				existing = append(existing, name)
+			}
+		}
+		if len(existing) > 0 {
+			return nil, fmt.Errorf("files already exist in %s: %s (use --force to overwrite)", dir, strings.Join(existing, ", "))
+		}
+	}
+
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return names, nil
+}
+
+func (s *Starter) packageJSON(name string) (string, error) {
+	pkg := struct {
+		Name            string            `json:"name"`
+		Version         string            `json:"version"`
+		Private         bool              `json:"private"`
+		Type            string            `json:"type,omitempty"`
+		Scripts         map[string]string `json:"scripts"`
+		Dependencies    map[string]string `json:"dependencies,omitempty"`
+		DevDependencies map[string]string `json:"devDependencies,omitempty"`
+	}{
+		Name:            name,
+		Version:         "0.1.0",
+		Private:         true,
+		Type:            s.PackageType,
+		Scripts:         s.Scripts,
+		Dependencies:    s.Dependencies,
+		DevDependencies: s.DevDependencies,
+	}
+
+	raw, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to render package.json: %w", err)
+	}
+	return string(raw) + "\n", nil
+}
+
+func (s *Starter) gitignore() string {
+	lines := append([]string{"node_modules/", ".coolpack/"}, s.Ignore...)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (s *Starter) config() string {
+	var sb strings.Builder
+	sb.WriteString("# Coolpack project settings. COOLPACK_* environment variables and CLI flags\n")
+	sb.WriteString("# take precedence over this file.\n\n")
+	sb.WriteString("[build]\n")
+	sb.WriteString("# start = \"npm start\"\n")
+	sb.WriteString("# packages = [\"curl\"]\n")
+	if s.OutputDir != "" {
+		sb.WriteString("\n[static]\n")
+		sb.WriteString(fmt.Sprintf("# output_dir = %q\n", s.OutputDir))
+		sb.WriteString("# server = \"caddy\"\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,264 @@
+package starter
+
+import "github.com/coollabsio/coolpack/pkg/providers/node"
+
+var starters = []Starter{
+	{
+		Framework:   node.FrameworkExpress,
+		Description: "Express HTTP server",
+		PackageType: "module",
+		Scripts: map[string]string{
+			"dev":   "node --watch server.js",
+			"start": "node server.js",
+		},
+		Dependencies: map[string]string{
+			"express": "^5.1.0",
+		},
+		Files: map[string]string{
+			"server.js": expressServer,
+		},
+	},
+	{
+		Framework:   node.FrameworkFastify,
+		Description: "Fastify HTTP server",
+		PackageType: "module",
+		Scripts: map[string]string{
+			"dev":   "node --watch server.js",
+			"start": "node server.js",
+		},
+		Dependencies: map[string]string{
+			"fastify": "^5.4.0",
+		},
+		Files: map[string]string{
+			"server.js": fastifyServer,
+		},
+	},
+	{
+		Framework:   node.FrameworkNextJS,
+		Description: "Next.js app router (server)",
+		Scripts: map[string]string{
+			"dev":   "next dev",
+			"build": "next build",
+			"start": "next start",
+		},
+		Dependencies: map[string]string{
+			"next":      "^15.5.0",
+			"react":     "^19.1.0",
+			"react-dom": "^19.1.0",
+		},
+		Ignore: []string{".next/"},
+		Files: map[string]string{
+			"app/layout.js": nextLayout,
+			"app/page.js":   nextPage,
+		},
+	},
+	{
+		Framework:   node.FrameworkNuxt,
+		Description: "Nuxt (server)",
+		PackageType: "module",
+		Scripts: map[string]string{
+			"dev":   "nuxt dev",
+			"build": "nuxt build",
+			"start": "node .output/server/index.mjs",
+		},
+		Dependencies: map[string]string{
+			"nuxt": "^4.1.0",
+			"vue":  "^3.5.0",
+		},
+		Ignore: []string{".nuxt/", ".output/"},
+		Files: map[string]string{
+			"nuxt.config.ts": nuxtConfig,
+			"app/app.vue":    nuxtApp,
+		},
+	},
+	{
+		Framework:   node.FrameworkSvelteKit,
+		Description: "SvelteKit with adapter-node (server)",
+		PackageType: "module",
+		Scripts: map[string]string{
+			"dev":   "vite dev",
+			"build": "vite build",
+			"start": "node build",
+		},
+		DevDependencies: map[string]string{
+			"@sveltejs/adapter-node":       "^5.2.0",
+			"@sveltejs/kit":                "^2.27.0",
+			"@sveltejs/vite-plugin-svelte": "^6.1.0",
+			"svelte":                       "^5.38.0",
+			"vite":                         "^7.1.0",
+		},
+		Ignore: []string{".svelte-kit/", "build/"},
+		Files: map[string]string{
+			"svelte.config.js":        svelteConfig,
+			"vite.config.js":          svelteViteConfig,
+			"src/app.html":            svelteAppHTML,
+			"src/routes/+page.svelte": sveltePage,
+		},
+	},
+	{
+		Framework:   node.FrameworkAstro,
+		Description: "Astro static site",
+		OutputDir:   "dist",
+		PackageType: "module",
+		Scripts: map[string]string{
+			"dev":   "astro dev",
+			"build": "astro build",
+		},
+		Dependencies: map[string]string{
+			"astro": "^5.13.0",
+		},
+		Ignore: []string{"dist/", ".astro/"},
+		Files: map[string]string{
+			"astro.config.mjs":      astroConfig,
+			"src/pages/index.astro": astroPage,
+		},
+	},
+	{
+		Framework:   node.FrameworkVite,
+		Description: "Vite static site",
+		OutputDir:   "dist",
+		PackageType: "module",
+		Scripts: map[string]string{
+			"dev":     "vite",
+			"build":   "vite build",
+			"preview": "vite preview",
+		},
+		DevDependencies: map[string]string{
+			"vite": "^7.1.0",
+		},
+		Ignore: []string{"dist/"},
+		Files: map[string]string{
+			"index.html":  viteIndex,
+			"src/main.js": viteMain,
+		},
+	},
+}
+
+const expressServer = `import express from "express";
+
+const app = express();
+const port = process.env.PORT || 3000;
+
+app.get("/", (req, res) => {
+  res.send("Hello from Express!");
+});
+
+app.get("/health", (req, res) => {
+  res.json({ status: "ok" });
+});
+
+app.listen(port, () => {
+  console.log(` + "`Listening on port ${port}`" + `);
+});
+`
+
+const fastifyServer = `import Fastify from "fastify";
+
+const app = Fastify({ logger: true });
+const port = Number(process.env.PORT) || 3000;
+
+app.get("/", async () => "Hello from Fastify!");
+
+app.get("/health", async () => ({ status: "ok" }));
+
+// Listen on all interfaces, so the server is reachable in a container
+await app.listen({ host: "0.0.0.0", port });
+`
+
+const nextLayout = `export const metadata = {
+  title: "Next.js on Coolpack",
+};
+
+export default function RootLayout({ children }) {
+  return (
+    <html lang="en">
+      <body>{children}</body>
+    </html>
+  );
+}
+`
+
+const nextPage = `export default function Home() {
+  return <h1>Hello from Next.js!</h1>;
+}
+`
+
+const nuxtConfig = `export default defineNuxtConfig({
+  compatibilityDate: "2025-07-15",
+});
+`
+
+const nuxtApp = `<template>
+  <h1>Hello from Nuxt!</h1>
+</template>
+`
+
+const svelteConfig = `import adapter from "@sveltejs/adapter-node";
+
+export default {
+  kit: {
+    adapter: adapter(),
+  },
+};
+`
+
+const svelteViteConfig = `import { sveltekit } from "@sveltejs/kit/vite";
+import { defineConfig } from "vite";
+
+export default defineConfig({
+  plugins: [sveltekit()],
+});
+`
+
+const svelteAppHTML = `<!doctype html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    %sveltekit.head%
+  </head>
+  <body>
+    <div style="display: contents">%sveltekit.body%</div>
+  </body>
+</html>
+`
+
+const sveltePage = `<h1>Hello from SvelteKit!</h1>
+`
+
+const astroConfig = `import { defineConfig } from "astro/config";
+
+export default defineConfig({});
+`
+
+const astroPage = `---
+const title = "Astro on Coolpack";
+---
+
+<html lang="en">
+  <head>
+    <meta charset="utf-8" />
+    <title>{title}</title>
+  </head>
+  <body>
+    <h1>Hello from Astro!</h1>
+  </body>
+</html>
+`
+
+const viteIndex = `<!doctype html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <title>Vite on Coolpack</title>
+  </head>
+  <body>
+    <div id="app"></div>
+    <script type="module" src="/src/main.js"></script>
+  </body>
+</html>
+`
+
+const viteMain = `document.querySelector("#app").innerHTML = "<h1>Hello from Vite!</h1>";
+`
@@ -3,9 +3,16 @@ package version
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/coollabsio/coolpack/pkg/cache"
+	"github.com/coollabsio/coolpack/pkg/semver"
 )
 
 // Version is set by goreleaser or build script via ldflags
@@ -15,80 +22,311 @@ var (
 	Date    = "unknown"
 )
 
-// GitHubTag represents a tag from GitHub API
-type GitHubTag struct {
-	Name string `json:"name"`
+// GitHubRelease represents a release from GitHub API
+type GitHubRelease struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	HTMLURL    string `json:"html_url"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// Update describes a newer coolpack version
+type Update struct {
+	// Version is the latest version tag (e.g., "v0.1.0")
+	Version string
+
+	// URL is the release page of the latest version
+	URL string
+
+	// Security is true if the latest or any skipped release is a security release
+	Security bool
+
+	// SecurityReleases lists the security releases newer than the current version
+	SecurityReleases []string
+
+	// Notes is the release notes summary of the latest version
+	Notes string
+
+	// Prerelease is true if the latest version is a pre-release (beta channel)
+	Prerelease bool
+
+	// Changelog is the release notes summary of every release newer than the
+	// current version up to the latest, newest first. It is empty when the
+	// release list can't be fetched (Notes still has the latest version's).
+	Changelog []ReleaseNotes
+
+	// ChangelogTruncated is true when older releases newer than the current
+	// version are missing from Changelog (only recent releases are fetched)
+	ChangelogTruncated bool
 }
 
-// CheckForUpdate checks GitHub for a newer version and prints a message if available.
+// ReleaseNotes is the release notes summary of one release
+type ReleaseNotes struct {
+	Version string
+	Notes   string
+}
+
+// Release channels the update check can track
+const (
+	// ChannelStable only offers full releases (GitHub's latest release)
+	ChannelStable = "stable"
+	// ChannelBeta also offers pre-releases, which ship framework detection
+	// fixes before the next full release
+	ChannelBeta = "beta"
+)
+
+// Channels lists the release channels
+var Channels = []string{ChannelStable, ChannelBeta}
+
+// DefaultReleasesURL is the GitHub API of coolpack's repository, which the
+// update check reads releases from unless COOLPACK_RELEASES_URL names a mirror
+const DefaultReleasesURL = "https://api.github.com/repos/coollabsio/coolpack"
+
+// maxNotesLines limits the release notes summary printed by CheckForUpdate
+const maxNotesLines = 10
+
+// releasesPerPage is the number of recent releases fetched for the security
+// scan and changelog
+const releasesPerPage = 30
+
+// securityPattern marks a release as a security release when found in its name or notes
+var securityPattern = regexp.MustCompile(`(?i)\bsecurity\b|\bCVE-\d{4}-\d+|\bGHSA(-[a-z0-9]{4}){3}\b`)
+
+// CheckForUpdate checks GitHub for a newer version on the release channel and
+// prints a message if available. With showNotes, the release notes summaries
+// of the releases since the current version are printed too.
 // Errors are handled silently - returns without printing if check fails.
-func CheckForUpdate() {
-	latest, err := getLatestVersion()
-	if err != nil {
+func CheckForUpdate(channel string, showNotes bool) {
+	update, err := GetUpdate(channel)
+	if err != nil || update == nil {
 		return
 	}
 
-	if latest != "" && latest != Version && isNewer(latest, Version) {
-		fmt.Printf("\nA new version of coolpack is available: %s (current: %s)\n", latest, Version)
-		fmt.Println("Download: https://github.com/coollabsio/coolpack/releases/latest")
+	if update.Security {
+		fmt.Printf("\nA security release of coolpack is available: %s (current: %s)\n", update.Version, Version)
+		fmt.Printf("Upgrade immediately. Security fixes in: %s\n", strings.Join(update.SecurityReleases, ", "))
+	} else if update.Prerelease {
+		fmt.Printf("\nA new pre-release of coolpack is available: %s (current: %s)\n", update.Version, Version)
+	} else {
+		fmt.Printf("\nA new version of coolpack is available: %s (current: %s)\n", update.Version, Version)
 	}
-}
+	fmt.Printf("Download: %s\n", update.URL)
 
-// getLatestVersion fetches the latest release tag from GitHub
-func getLatestVersion() (string, error) {
-	client := &http.Client{Timeout: 5 * time.Second}
-
-	resp, err := client.Get("https://api.github.com/repos/coollabsio/coolpack/tags?per_page=10")
-	if err != nil {
-		return "", err
+	if !showNotes {
+		return
 	}
-	defer resp.Body.Close()
+	changelog := update.Changelog
+	if len(changelog) == 0 && update.Notes != "" {
+		changelog = []ReleaseNotes{{Version: update.Version, Notes: update.Notes}}
+	}
+	if len(changelog) > 0 {
+		fmt.Printf("\nChanges since %s:\n", Version)
+	}
+	for _, release := range changelog {
+		fmt.Printf("\n  %s\n", release.Version)
+		if release.Notes == "" {
+			fmt.Println("    (no release notes)")
+			continue
+		}
+		for _, line := range strings.Split(release.Notes, "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	if update.ChangelogTruncated {
+		fmt.Println("\n  Older releases: https://github.com/coollabsio/coolpack/releases")
+	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("github api returned %d", resp.StatusCode)
+// GetUpdate returns the newer version of coolpack on the release channel, or
+// nil if the current version is the latest
+func GetUpdate(channel string) (*Update, error) {
+	var latest GitHubRelease
+	releases, releasesErr := getReleases()
+	if channel == ChannelBeta {
+		// releases/latest never returns a pre-release; pick the newest release
+		// from the list (most recent first) instead
+		if releasesErr != nil {
+			return nil, releasesErr
+		}
+		for _, release := range releases {
+			if !release.Draft && (latest.TagName == "" || semver.IsNewer(release.TagName, latest.TagName)) {
+				latest = release
+			}
+		}
+	} else {
+		var err error
+		if latest, err = getLatestRelease(); err != nil {
+			return nil, err
+		}
+	}
+	if latest.TagName == "" || latest.TagName == Version || !semver.IsNewer(latest.TagName, Version) {
+		return nil, nil
 	}
 
-	var tags []GitHubTag
-	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
-		return "", err
+	update := &Update{
+		Version:    latest.TagName,
+		URL:        latest.HTMLURL,
+		Notes:      summarizeNotes(latest.Body, maxNotesLines),
+		Prerelease: latest.Prerelease,
+	}
+	if update.URL == "" {
+		update.URL = "https://github.com/coollabsio/coolpack/releases/latest"
 	}
 
-	// Find the latest version tag (starts with 'v')
-	for _, tag := range tags {
-		if strings.HasPrefix(tag.Name, "v") {
-			return tag.Name, nil
+	// Security releases may have been skipped; the list is best-effort
+	if releasesErr != nil {
+		releases = []GitHubRelease{latest}
+	}
+	for _, release := range releases {
+		if release.Draft || !semver.IsNewer(release.TagName, Version) || semver.IsNewer(release.TagName, latest.TagName) {
+			continue
+		}
+		if release.Prerelease && channel != ChannelBeta {
+			continue
+		}
+		if releasesErr == nil {
+			update.Changelog = append(update.Changelog, ReleaseNotes{
+				Version: release.TagName,
+				Notes:   summarizeNotes(release.Body, maxNotesLines),
+			})
+		}
+		if !release.Prerelease && isSecurityRelease(release) {
+			update.Security = true
+			update.SecurityReleases = append(update.SecurityReleases, release.TagName)
 		}
 	}
+	// The list is most recent first; a full page whose oldest release is
+	// still newer than the current version doesn't reach back to it
+	if n := len(releases); releasesErr == nil && n == releasesPerPage && semver.IsNewer(releases[n-1].TagName, Version) {
+		update.ChangelogTruncated = true
+	}
 
-	return "", nil
+	return update, nil
 }
 
-// isNewer compares two semver strings and returns true if latest > current
-func isNewer(latest, current string) bool {
-	// Strip 'v' prefix
-	latest = strings.TrimPrefix(latest, "v")
-	current = strings.TrimPrefix(current, "v")
+// getLatestRelease fetches the latest release from GitHub (drafts and
+// pre-releases are never the latest)
+func getLatestRelease() (GitHubRelease, error) {
+	var release GitHubRelease
+	err := getGitHubJSON(releasesURL()+"/releases/latest", "latest.json", &release)
+	return release, err
+}
 
-	latestParts := strings.Split(latest, ".")
-	currentParts := strings.Split(current, ".")
+// getReleases fetches the most recent releases from GitHub
+func getReleases() ([]GitHubRelease, error) {
+	var releases []GitHubRelease
+	err := getGitHubJSON(fmt.Sprintf("%s/releases?per_page=%d", releasesURL(), releasesPerPage), "releases.json", &releases)
+	return releases, err
+}
 
-	// Compare major.minor.patch
-	for i := 0; i < 3; i++ {
-		var latestNum, currentNum int
-		if i < len(latestParts) {
-			fmt.Sscanf(latestParts[i], "%d", &latestNum)
-		}
-		if i < len(currentParts) {
-			fmt.Sscanf(currentParts[i], "%d", &currentNum)
+// releasesURL returns the API the update check reads releases from:
+// COOLPACK_RELEASES_URL (a GitHub Enterprise repository or an internal mirror
+// serving the same paths) or DefaultReleasesURL
+func releasesURL() string {
+	if url := os.Getenv("COOLPACK_RELEASES_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return DefaultReleasesURL
+}
+
+// cachedResponse is a GitHub API response kept for conditional requests
+type cachedResponse struct {
+	URL  string          `json:"url"`
+	ETag string          `json:"etag"`
+	Body json.RawMessage `json:"body"`
+}
+
+// getGitHubJSON fetches a GitHub API URL and decodes the response into v.
+// GITHUB_TOKEN is sent when set: unauthenticated requests share a limit of 60
+// an hour per IP address, which CI runners behind a NAT quickly use up. It is
+// only sent to api.github.com, never to a mirror from COOLPACK_RELEASES_URL.
+// Responses are kept with their ETag in the releases cache under name, and
+// sent back as If-None-Match, so a check where nothing changed gets 304 Not
+// Modified, which GitHub doesn't count against the limit.
+func getGitHubJSON(url, name string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "coolpack/"+Version)
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && req.URL.Host == "api.github.com" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	cachePath := ""
+	if dir := cache.Path("releases"); dir != "" {
+		cachePath = filepath.Join(dir, name)
+	}
+	var cached cachedResponse
+	if cachePath != "" {
+		if raw, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(raw, &cached) == nil && cached.URL == url && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
 		}
+	}
 
-		if latestNum > currentNum {
-			return true
+	// The default transport honors HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if len(cached.Body) > 0 {
+			// Touch the entry so cache pruning sees it in use
+			now := time.Now()
+			_ = os.Chtimes(cachePath, now, now)
+			return json.Unmarshal(cached.Body, v)
 		}
-		if latestNum < currentNum {
-			return false
+		return fmt.Errorf("github api returned %d without a cached response", resp.StatusCode)
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("github api returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return err
+	}
+
+	// Caching is best-effort: a read-only cache directory only costs the ETag
+	if etag := resp.Header.Get("ETag"); etag != "" && cachePath != "" {
+		if raw, err := json.Marshal(cachedResponse{URL: url, ETag: etag, Body: body}); err == nil {
+			if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+				_ = os.WriteFile(cachePath, raw, 0644)
+			}
 		}
 	}
+	return nil
+}
 
-	return false
+// isSecurityRelease checks if a release is marked as a security release in its name or notes
+func isSecurityRelease(release GitHubRelease) bool {
+	return securityPattern.MatchString(release.Name) || securityPattern.MatchString(release.Body)
+}
+
+// summarizeNotes returns the first maxLines non-empty lines of release notes,
+// skipping the auto-generated "Full Changelog" footer
+func summarizeNotes(body string, maxLines int) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "**Full Changelog**") {
+			continue
+		}
+		if len(lines) == maxLines {
+			lines = append(lines, "...")
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
 }